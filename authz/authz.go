@@ -0,0 +1,91 @@
+// Package authz declares who may do what in one place. Routes and handlers
+// ask it whether the authenticated user may perform an action, rather than
+// comparing roles and owners themselves, so a permission is changed by
+// editing its rule below.
+package authz
+
+import (
+	"gotodolist/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Action is something a user may be allowed to do
+type Action string
+
+// Actions on a user's own resources, checked by the handlers
+const (
+	TaskRead      Action = "tasks:read"
+	TaskUpdate    Action = "tasks:update"
+	TaskDelete    Action = "tasks:delete"
+	TaskDelegate  Action = "tasks:delegate"
+	GoalManage    Action = "goals:manage"
+	ContextManage Action = "contexts:manage"
+	ProjectManage Action = "projects:manage"
+	HabitManage   Action = "habits:manage"
+)
+
+// Actions on the system, checked by the routes
+const (
+	AdminAccess        Action = "admin:access"
+	AnnouncementManage Action = "announcements:manage"
+	IncidentManage     Action = "incidents:manage"
+	PolicyPublish      Action = "policies:publish"
+	HealthHistory      Action = "health:history"
+	MaintenanceRun     Action = "maintenance:run"
+	Profiling          Action = "debug:pprof"
+	QueryDebug         Action = "debug:query"
+)
+
+// Rule grants an action to the users with one of its roles and, when Owner
+// is set, to the owner of the resource acted on
+type Rule struct {
+	Roles []string
+	Owner bool
+}
+
+// rules are the permissions of the API. An action without a rule is
+// denied to everyone.
+var rules = map[Action]Rule{
+	TaskRead:      {Owner: true},
+	TaskUpdate:    {Owner: true},
+	TaskDelete:    {Owner: true},
+	TaskDelegate:  {Owner: true},
+	GoalManage:    {Owner: true},
+	ContextManage: {Owner: true},
+	ProjectManage: {Owner: true},
+	HabitManage:   {Owner: true},
+
+	AdminAccess:        {Roles: []string{models.RoleAdmin}},
+	AnnouncementManage: {Roles: []string{models.RoleAdmin}},
+	IncidentManage:     {Roles: []string{models.RoleAdmin}},
+	PolicyPublish:      {Roles: []string{models.RoleAdmin}},
+	HealthHistory:      {Roles: []string{models.RoleAdmin}},
+	MaintenanceRun:     {Roles: []string{models.RoleAdmin}},
+	Profiling:          {Roles: []string{models.RoleAdmin}},
+	QueryDebug:         {Roles: []string{models.RoleAdmin}},
+}
+
+// Can reports whether a user may perform an action on a resource owned by
+// owner. Actions that are not on a resource pass primitive.NilObjectID.
+func Can(user models.User, action Action, owner primitive.ObjectID) bool {
+	rule, ok := rules[action]
+	if !ok {
+		return false
+	}
+
+	if rule.Owner && !owner.IsZero() && owner == user.ID {
+		return true
+	}
+
+	role := user.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+	for _, allowed := range rule.Roles {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
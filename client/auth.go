@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Session is the answer to a login or token refresh
+type Session struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	User         User   `json:"user"`
+}
+
+// Login authenticates with an email and password and keeps the tokens for
+// the following requests
+func (c *Client) Login(ctx context.Context, email, password string) (*Session, error) {
+	var session Session
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/login",
+		body:   map[string]string{"email": email, "password": password},
+	}, &session)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setTokens(session.Token, session.RefreshToken)
+	return &session, nil
+}
+
+// Refresh exchanges the refresh token for a new pair of tokens. Requests
+// answered with 401 call it automatically.
+func (c *Client) Refresh(ctx context.Context) (*Session, error) {
+	_, refreshToken := c.Tokens()
+	if refreshToken == "" {
+		return nil, errors.New("gotodolist: no refresh token, log in first")
+	}
+
+	var session Session
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/auth/refresh-token",
+		body:   map[string]string{"refreshToken": refreshToken},
+	}, &session)
+	if err != nil {
+		return nil, err
+	}
+
+	c.setTokens(session.Token, session.RefreshToken)
+	return &session, nil
+}
+
+// setTokens stores the tokens of a new session
+func (c *Client) setTokens(accessToken, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken = accessToken
+	c.refreshToken = refreshToken
+}
+
+// refreshAfter refreshes the tokens after staleToken was rejected. Refresh
+// tokens are single use, so concurrent requests share one refresh and a
+// request whose token was already replaced simply retries with the new one.
+func (c *Client) refreshAfter(ctx context.Context, staleToken string) error {
+	c.mu.Lock()
+	if c.accessToken != staleToken {
+		c.mu.Unlock()
+		return nil
+	}
+	if waiting := c.refreshing; waiting != nil {
+		c.mu.Unlock()
+		select {
+		case <-waiting:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if accessToken, _ := c.Tokens(); accessToken == staleToken {
+			return errors.New("token refresh failed")
+		}
+		return nil
+	}
+	done := make(chan struct{})
+	c.refreshing = done
+	c.mu.Unlock()
+
+	_, err := c.Refresh(ctx)
+
+	c.mu.Lock()
+	c.refreshing = nil
+	c.mu.Unlock()
+	close(done)
+	return err
+}
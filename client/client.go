@@ -0,0 +1,210 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client calls the Todo List API. It keeps the tokens of the last login,
+// refreshes them once when a request is answered with 401, and retries
+// requests that failed for transient reasons. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	maxRetries int
+	backoff    time.Duration
+
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+	refreshing   chan struct{} // Closed when the refresh in progress completes
+
+	// Tasks groups the task endpoints
+	Tasks *TasksService
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithRetries sets how many times a failed request is retried, 2 by
+// default, and the delay before the first retry, which doubles every time
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// WithTokens starts the client with tokens of an earlier session
+func WithTokens(accessToken, refreshToken string) Option {
+	return func(c *Client) {
+		c.accessToken = accessToken
+		c.refreshToken = refreshToken
+	}
+}
+
+// New creates a client for the API at baseURL, such as http://localhost:8080
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		maxRetries: 2,
+		backoff:    200 * time.Millisecond,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	c.Tasks = &TasksService{client: c}
+	return c
+}
+
+// Tokens returns the current access and refresh tokens, to be stored and
+// given back with WithTokens
+func (c *Client) Tokens() (accessToken, refreshToken string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.accessToken, c.refreshToken
+}
+
+// APIError is returned when the API answers with an error status
+type APIError struct {
+	StatusCode int
+	Message    string // The "error" field of the response
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("gotodolist: %d %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 answer
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// request describes a call to the API
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	auth   bool // Send the access token and refresh it on 401
+}
+
+// do sends a request, decoding the response into out unless it is nil
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && req.auth {
+		accessToken, _ := c.Tokens()
+		resp.Body.Close()
+		if err := c.refreshAfter(ctx, accessToken); err != nil {
+			return &APIError{StatusCode: http.StatusUnauthorized, Message: "Session expired: " + err.Error()}
+		}
+		if resp, err = c.send(ctx, req); err != nil {
+			return err
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var envelope struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		if envelope.Error == "" {
+			envelope.Error = http.StatusText(resp.StatusCode)
+		}
+		return &APIError{StatusCode: resp.StatusCode, Message: envelope.Error}
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send performs a request, retrying network errors and transient statuses.
+// Only 429 is retried for POST, which may otherwise create duplicates.
+func (c *Client) send(ctx context.Context, req request) (*http.Response, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		if body, err = json.Marshal(req.body); err != nil {
+			return nil, err
+		}
+	}
+
+	target := c.baseURL + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+	idempotent := req.method != http.MethodPost
+
+	delay := c.backoff
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, target, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Accept", "application/json")
+		if body != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+		if req.auth {
+			if accessToken, _ := c.Tokens(); accessToken != "" {
+				httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+			}
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		retry := false
+		switch {
+		case err != nil:
+			retry = idempotent && ctx.Err() == nil
+		case resp.StatusCode == http.StatusTooManyRequests:
+			retry = true
+		case resp.StatusCode == http.StatusBadGateway, resp.StatusCode == http.StatusServiceUnavailable, resp.StatusCode == http.StatusGatewayTimeout:
+			retry = idempotent
+		}
+		if !retry || attempt >= c.maxRetries {
+			return resp, err
+		}
+
+		wait := delay
+		if resp != nil {
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(seconds) * time.Second
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,14 @@
+// Package client is a Go client of the Todo List API.
+//
+//	c := client.New("http://localhost:8080")
+//	if _, err := c.Login(ctx, email, password); err != nil {
+//		return err
+//	}
+//	tasks, err := c.Tasks.List(ctx, &client.TaskListOptions{Limit: 20})
+//
+// The types in types.gen.go are generated from the OpenAPI specification;
+// run "make gen" after changing swagger.yaml. The rest of the package is
+// maintained by hand.
+package client
+
+//go:generate go run ../cmd/clientgen -spec ../swagger.yaml -out types.gen.go
@@ -0,0 +1,237 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// TasksService calls the task endpoints of the authenticated user
+type TasksService struct {
+	client *Client
+}
+
+// TaskListOptions filters, sorts and paginates a task list. Zero values
+// are left to the API defaults.
+type TaskListOptions struct {
+	Completed *bool
+	Priority  string
+	Goal      string
+	Context   string // Context name such as "@home", or "none"
+	Project   string // Project ID, or "none"
+	Snoozed   string // "true" for snoozed tasks only, "all" for both
+	Deferred  string // "true" for tasks not started yet, "all" for both
+	Sort      string // Field to sort by, such as "dueDate"
+	SortDir   string // "asc" or "desc"
+	Page      int
+	Limit     int
+}
+
+// Pagination describes the page of a list
+type Pagination struct {
+	Total      int `json:"total"`
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	TotalPages int `json:"totalPages"`
+}
+
+// TaskList is a page of tasks
+type TaskList struct {
+	Tasks      []Task     `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// TaskGroup is a group of a grouped task list. Count covers every page,
+// Tasks only the listed one.
+type TaskGroup struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+	Tasks []Task `json:"tasks"`
+}
+
+// TaskGroupList is a page of tasks bucketed into groups
+type TaskGroupList struct {
+	Groups     []TaskGroup `json:"data"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+// TaskInput is the body of a task creation or update. An update replaces
+// Completed, so set it to the task's current state to keep it.
+type TaskInput struct {
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Completed   bool       `json:"completed"`
+	StartDate   *time.Time `json:"startDate,omitempty"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	DependsOn   []string   `json:"dependsOn,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+	Estimate    int        `json:"estimate,omitempty"`
+	Goal        string     `json:"goal,omitempty"`
+	Context     string     `json:"context,omitempty"`
+	Project     string     `json:"project,omitempty"`
+	Color       string     `json:"color,omitempty"`
+	Icon        string     `json:"icon,omitempty"`
+}
+
+// List returns a page of tasks
+func (s *TasksService) List(ctx context.Context, options *TaskListOptions) (*TaskList, error) {
+	var list TaskList
+	err := s.client.do(ctx, request{method: http.MethodGet, path: "/tasks/", query: listQuery(options), auth: true}, &list)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// ListGroups returns a page of tasks grouped by "priority", "dueDate",
+// "goal", "section", "context" or "project"
+func (s *TasksService) ListGroups(ctx context.Context, groupBy string, options *TaskListOptions) (*TaskGroupList, error) {
+	query := listQuery(options)
+	query.Set("groupBy", groupBy)
+
+	var list TaskGroupList
+	err := s.client.do(ctx, request{method: http.MethodGet, path: "/tasks/", query: query, auth: true}, &list)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// listQuery encodes the options of a task list
+func listQuery(options *TaskListOptions) url.Values {
+	query := url.Values{}
+	if options != nil {
+		if options.Completed != nil {
+			query.Set("completed", strconv.FormatBool(*options.Completed))
+		}
+		for key, value := range map[string]string{
+			"priority": options.Priority,
+			"goal":     options.Goal,
+			"context":  options.Context,
+			"project":  options.Project,
+			"snoozed":  options.Snoozed,
+			"deferred": options.Deferred,
+			"sort":     options.Sort,
+			"sortDir":  options.SortDir,
+		} {
+			if value != "" {
+				query.Set(key, value)
+			}
+		}
+		if options.Page > 0 {
+			query.Set("page", strconv.Itoa(options.Page))
+		}
+		if options.Limit > 0 {
+			query.Set("limit", strconv.Itoa(options.Limit))
+		}
+	}
+	return query
+}
+
+// Get returns a task by ID
+func (s *TasksService) Get(ctx context.Context, id string) (*Task, error) {
+	return s.task(ctx, request{method: http.MethodGet, path: "/tasks/" + url.PathEscape(id), auth: true})
+}
+
+// Create creates a task, Title is required
+func (s *TasksService) Create(ctx context.Context, input TaskInput) (*Task, error) {
+	return s.task(ctx, request{method: http.MethodPost, path: "/tasks/", body: input, auth: true})
+}
+
+// Update changes a task and returns it updated
+func (s *TasksService) Update(ctx context.Context, id string, input TaskInput) (*Task, error) {
+	return s.task(ctx, request{method: http.MethodPut, path: "/tasks/" + url.PathEscape(id), body: input, auth: true})
+}
+
+// Delete deletes a task
+func (s *TasksService) Delete(ctx context.Context, id string) error {
+	return s.client.do(ctx, request{method: http.MethodDelete, path: "/tasks/" + url.PathEscape(id), auth: true}, nil)
+}
+
+// Capture files a piece of text in the inbox as an untriaged task, the
+// first line becoming its title
+func (s *TasksService) Capture(ctx context.Context, text string) (*Task, error) {
+	body := map[string]string{"text": text}
+	return s.task(ctx, request{method: http.MethodPost, path: "/inbox/", body: body, auth: true})
+}
+
+// Snooze hides an open task from the default views until the given time
+func (s *TasksService) Snooze(ctx context.Context, id string, until time.Time) (*Task, error) {
+	body := map[string]time.Time{"until": until}
+	return s.task(ctx, request{method: http.MethodPost, path: "/tasks/" + url.PathEscape(id) + "/snooze", body: body, auth: true})
+}
+
+// Unsnooze brings a snoozed task back to the default views
+func (s *TasksService) Unsnooze(ctx context.Context, id string) (*Task, error) {
+	return s.task(ctx, request{method: http.MethodDelete, path: "/tasks/" + url.PathEscape(id) + "/snooze", auth: true})
+}
+
+// ReminderInput is a reminder of a task, either at a time or a number of
+// minutes before its due date
+type ReminderInput struct {
+	At     *time.Time `json:"at,omitempty"`
+	Before int        `json:"before,omitempty"`
+}
+
+// SetReminders replaces the reminders of a task, an empty list removing them
+func (s *TasksService) SetReminders(ctx context.Context, id string, reminders []ReminderInput) (*Task, error) {
+	body := map[string][]ReminderInput{"reminders": reminders}
+	return s.task(ctx, request{method: http.MethodPut, path: "/tasks/" + url.PathEscape(id) + "/reminders", body: body, auth: true})
+}
+
+// Notes returns the notes of a task, oldest first
+func (s *TasksService) Notes(ctx context.Context, id string) ([]TaskNote, error) {
+	var envelope struct {
+		Data []TaskNote `json:"data"`
+	}
+	err := s.client.do(ctx, request{method: http.MethodGet, path: "/tasks/" + url.PathEscape(id) + "/notes", auth: true}, &envelope)
+	if err != nil {
+		return nil, err
+	}
+	return envelope.Data, nil
+}
+
+// AddNote appends a note to a task
+func (s *TasksService) AddNote(ctx context.Context, id string, text string) (*TaskNote, error) {
+	var envelope struct {
+		Data TaskNote `json:"data"`
+	}
+	body := map[string]string{"text": text}
+	err := s.client.do(ctx, request{method: http.MethodPost, path: "/tasks/" + url.PathEscape(id) + "/notes", body: body, auth: true}, &envelope)
+	if err != nil {
+		return nil, err
+	}
+	return &envelope.Data, nil
+}
+
+// Versions returns the saved versions of a task, newest first
+func (s *TasksService) Versions(ctx context.Context, id string) ([]TaskVersion, error) {
+	var envelope struct {
+		Data []TaskVersion `json:"data"`
+	}
+	err := s.client.do(ctx, request{method: http.MethodGet, path: "/tasks/" + url.PathEscape(id) + "/versions", auth: true}, &envelope)
+	if err != nil {
+		return nil, err
+	}
+	return envelope.Data, nil
+}
+
+// RestoreVersion brings a task back to a saved version, the current state
+// being saved as a new version first
+func (s *TasksService) RestoreVersion(ctx context.Context, id string, version int) (*Task, error) {
+	path := "/tasks/" + url.PathEscape(id) + "/versions/" + strconv.Itoa(version) + "/restore"
+	return s.task(ctx, request{method: http.MethodPost, path: path, auth: true})
+}
+
+// task sends a request answered with a single task
+func (s *TasksService) task(ctx context.Context, req request) (*Task, error) {
+	var envelope struct {
+		Data Task `json:"data"`
+	}
+	if err := s.client.do(ctx, req, &envelope); err != nil {
+		return nil, err
+	}
+	return &envelope.Data, nil
+}
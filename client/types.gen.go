@@ -0,0 +1,731 @@
+// Code generated by cmd/clientgen from the OpenAPI specification. DO NOT EDIT.
+
+package client
+
+import "time"
+
+// APIVersion is the version of the Todo List API specification the types were generated from
+const APIVersion = "1.0.0"
+
+// ActivationReport describes what deactivating or reactivating an account would change, returned on a dry run
+type ActivationReport struct {
+	// False when the account is already in that state
+	Changed bool `json:"changed"`
+	DryRun  bool `json:"dryRun"`
+	// Sessions a deactivation would end
+	Sessions int  `json:"sessions"`
+	User     User `json:"user"`
+}
+
+// ActivityChange is the ActivityChange schema of the API
+type ActivityChange struct {
+	Count int    `json:"count"`
+	Field string `json:"field"`
+	// Value before the first change of the day
+	From map[string]interface{} `json:"from,omitempty"`
+	// Value after the last change of the day
+	To map[string]interface{} `json:"to,omitempty"`
+}
+
+// Announcement is the Announcement schema of the API
+type Announcement struct {
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// ID of the admin who published it
+	CreatedBy string `json:"createdBy"`
+	// Shown until then, forever when unset
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	ID        string     `json:"id"`
+	Message   string     `json:"message"`
+	// One of: info, warning, critical
+	Severity  string     `json:"severity"`
+	Title     string     `json:"title"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// AnnouncementList is the AnnouncementList schema of the API
+type AnnouncementList struct {
+	Count   int            `json:"count"`
+	Data    []Announcement `json:"data,omitempty"`
+	Success bool           `json:"success"`
+}
+
+// Automation is the Automation schema of the API
+type Automation struct {
+	Actions    []AutomationAction `json:"actions,omitempty"`
+	Conditions struct {
+		Goal          string   `json:"goal"`
+		HasDueDate    bool     `json:"hasDueDate"`
+		Priority      string   `json:"priority"`
+		Tags          []string `json:"tags,omitempty"`
+		TitleContains string   `json:"titleContains"`
+	} `json:"conditions"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	Enabled   bool       `json:"enabled"`
+	ID        string     `json:"id"`
+	LastRunAt *time.Time `json:"lastRunAt,omitempty"`
+	Name      string     `json:"name"`
+	RunCount  int        `json:"runCount"`
+	Trigger   struct {
+		Event       string `json:"event"`
+		WithinHours int    `json:"withinHours"`
+	} `json:"trigger"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	User      string     `json:"user"`
+}
+
+// AutomationAction is the AutomationAction schema of the API
+type AutomationAction struct {
+	// Due date of set_due_date and create_task, in hours from the run
+	DueInHours int `json:"dueInHours"`
+	// Message of notify
+	Message string `json:"message"`
+	// Priority set by set_priority or given to the task of create_task. One of: low, medium, high
+	Priority string `json:"priority"`
+	// Title of create_task and notify, {{title}} is replaced by the task title
+	Title string `json:"title"`
+	// One of: set_priority, set_due_date, create_task, notify
+	Type string `json:"type"`
+}
+
+// AutomationInput is the AutomationInput schema of the API
+type AutomationInput struct {
+	// Between 1 and 10 actions, applied in order
+	Actions []AutomationAction `json:"actions,omitempty"`
+	// All set conditions must match the task
+	Conditions struct {
+		Goal       string `json:"goal"`
+		HasDueDate bool   `json:"hasDueDate"`
+		// One of: low, medium, high
+		Priority string `json:"priority"`
+		// Tags the task must all carry, e.g. ["work"]
+		Tags []string `json:"tags,omitempty"`
+		// Case insensitive, e.g. "#work"
+		TitleContains string `json:"titleContains"`
+	} `json:"conditions"`
+	Enabled bool   `json:"enabled"`
+	Name    string `json:"name"`
+	Trigger struct {
+		// One of: task_created, task_updated, task_completed, task_due_soon
+		Event string `json:"event"`
+		// Window of task_due_soon, which runs once per task and due date
+		WithinHours int `json:"withinHours"`
+	} `json:"trigger"`
+}
+
+// AutomationRun is the AutomationRun schema of the API
+type AutomationRun struct {
+	// Actions applied, in order
+	Actions    []string   `json:"actions,omitempty"`
+	Automation string     `json:"automation"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	// Set when an action failed, the following ones were skipped
+	Error string `json:"error"`
+	Event string `json:"event"`
+	ID    string `json:"id"`
+	Task  string `json:"task"`
+	User  string `json:"user"`
+}
+
+// BoardColumn is the BoardColumn schema of the API
+type BoardColumn struct {
+	// Column key, derived from the name when omitted
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	// Maximum open tasks in the column, 0 means unlimited
+	WipLimit int `json:"wipLimit"`
+}
+
+// CollectionSize is the CollectionSize schema of the API
+type CollectionSize struct {
+	DataBytes    int    `json:"dataBytes"`
+	Documents    int    `json:"documents"`
+	IndexBytes   int    `json:"indexBytes"`
+	Name         string `json:"name"`
+	StorageBytes int    `json:"storageBytes"`
+}
+
+// ConsistencyCheck is the ConsistencyCheck schema of the API
+type ConsistencyCheck struct {
+	Description string `json:"description"`
+	// Documents breaking the check
+	Found int `json:"found"`
+	// One of: taskGoal, goalCounts, taskProject, taskSection, projectCounts, taskContext, contextCounts, tagCounts, taskDependencies, delegationRecipient, checkInHabit
+	Name string `json:"name"`
+	// IDs of the first documents found, up to 10
+	Samples []string `json:"samples,omitempty"`
+}
+
+// ConsistencyReport is the ConsistencyReport schema of the API
+type ConsistencyReport struct {
+	Checks []ConsistencyCheck `json:"checks,omitempty"`
+	// Whether the documents found were repaired
+	Repaired bool `json:"repaired"`
+}
+
+// Context is the Context schema of the API
+type Context struct {
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// Context ID
+	ID string `json:"id"`
+	// Context name, always starting with @
+	Name string `json:"name"`
+	// Number of open tasks in the context, only in lists
+	OpenTasks int        `json:"openTasks"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	User      string     `json:"user"`
+}
+
+// DataExport is the DataExport schema of the API
+type DataExport struct {
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	// Set once the export is ready
+	DownloadURL string `json:"downloadUrl"`
+	Error       string `json:"error"`
+	// The archive is deleted after this date
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	ID        string     `json:"id"`
+	// X-Request-ID of the request that asked for the export
+	RequestID   string     `json:"requestId"`
+	RequestedAt *time.Time `json:"requestedAt,omitempty"`
+	// Archive size in bytes, once ready
+	Size int `json:"size"`
+	// One of: pending, ready, failed
+	Status string `json:"status"`
+}
+
+// DeletionReport counts the documents an admin deletion removed, or would remove on a dry run
+type DeletionReport struct {
+	Deleted int  `json:"deleted"`
+	DryRun  bool `json:"dryRun"`
+}
+
+// Error is the Error schema of the API
+type Error struct {
+	// Error message
+	Error   string `json:"error"`
+	Success bool   `json:"success"`
+}
+
+// EscalationSettings configures the automatic priority escalation of overdue tasks
+type EscalationSettings struct {
+	Enabled bool `json:"enabled"`
+	// Raise an open task to the priority of the largest threshold it is overdue by. The defaults are 24h to medium and 72h to high.
+	Rules []struct {
+		OverdueHours int `json:"overdueHours"`
+		// One of: low, medium, high
+		Priority string `json:"priority"`
+	} `json:"rules,omitempty"`
+}
+
+// Goal is the Goal schema of the API
+type Goal struct {
+	CreatedAt   *time.Time `json:"createdAt,omitempty"`
+	Description string     `json:"description"`
+	// Goal ID
+	ID string `json:"id"`
+	// Goal name
+	Name     string `json:"name"`
+	Progress struct {
+		CompletedTasks int     `json:"completedTasks"`
+		Overdue        bool    `json:"overdue"`
+		Percent        float64 `json:"percent"`
+		TotalTasks     int     `json:"totalTasks"`
+	} `json:"progress"`
+	// Date by which the goal should be reached
+	TargetDate *time.Time `json:"targetDate,omitempty"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+	User       string     `json:"user"`
+}
+
+// Habit is the Habit schema of the API
+type Habit struct {
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// Habit description
+	Description string `json:"description"`
+	// Period over which the target is counted. One of: daily, weekly
+	Frequency string `json:"frequency"`
+	// Habit ID
+	ID string `json:"id"`
+	// Habit name
+	Name string `json:"name"`
+	// Check-ins required per period
+	TargetCount int        `json:"targetCount"`
+	UpdatedAt   *time.Time `json:"updatedAt,omitempty"`
+	// User ID who owns the habit
+	User string `json:"user"`
+}
+
+// HabitCheckIn is the HabitCheckIn schema of the API
+type HabitCheckIn struct {
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// Day of the check-in
+	Date *time.Time `json:"date,omitempty"`
+	// Habit ID
+	Habit string `json:"habit"`
+	ID    string `json:"id"`
+	User  string `json:"user"`
+}
+
+// HabitStats is the HabitStats schema of the API
+type HabitStats struct {
+	CompletedToday bool   `json:"completedToday"`
+	CurrentStreak  int    `json:"currentStreak"`
+	Frequency      string `json:"frequency"`
+	Habit          string `json:"habit"`
+	LongestStreak  int    `json:"longestStreak"`
+	Name           string `json:"name"`
+	TotalCheckIns  int    `json:"totalCheckIns"`
+}
+
+// HealthSnapshot is the HealthSnapshot schema of the API
+type HealthSnapshot struct {
+	ClientErrors int        `json:"clientErrors"`
+	CreatedAt    *time.Time `json:"createdAt,omitempty"`
+	// Round trip of a ping, 0 when the database is down
+	DbLatencyMs float64 `json:"dbLatencyMs"`
+	DbUp        bool    `json:"dbUp"`
+	// Share of requests answered with a 5xx
+	ErrorRate   float64 `json:"errorRate"`
+	ID          string  `json:"id"`
+	Instance    string  `json:"instance"`
+	JobsHealthy bool    `json:"jobsHealthy"`
+	// Requests served since the previous snapshot
+	Requests     int `json:"requests"`
+	ServerErrors int `json:"serverErrors"`
+	SlowQueries  int `json:"slowQueries"`
+	SlowRequests int `json:"slowRequests"`
+}
+
+// Holiday is the Holiday schema of the API
+type Holiday struct {
+	Date *time.Time `json:"date,omitempty"`
+	Name string     `json:"name"`
+}
+
+// HolidaySettings the days a user does not work, and whether due dates move off them
+type HolidaySettings struct {
+	Custom []struct {
+		Date *time.Time `json:"date,omitempty"`
+		Name string     `json:"name"`
+		// Repeat on the same month and day every year
+		Yearly bool `json:"yearly"`
+	} `json:"custom,omitempty"`
+	// Built-in national holiday calendar (US, GB, DE or FR), empty for none
+	Locale string `json:"locale"`
+	// Move due dates set on a day off to the next working day
+	ShiftDueDates bool `json:"shiftDueDates"`
+	// Count the days outside the work schedule as days off
+	SkipWeekends bool `json:"skipWeekends"`
+}
+
+// Incident is the Incident schema of the API
+type Incident struct {
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	ID        string     `json:"id"`
+	// One of: minor, major, notice
+	Impact string `json:"impact"`
+	// Latest update
+	Message    string     `json:"message"`
+	ResolvedAt *time.Time `json:"resolvedAt,omitempty"`
+	// One of: investigating, identified, monitoring, resolved
+	Status    string     `json:"status"`
+	Title     string     `json:"title"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// JobStatus is the JobStatus schema of the API
+type JobStatus struct {
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+	// False when stopped or after 3 failed runs in a row
+	Healthy bool `json:"healthy"`
+	// Whether this instance held the job's lease at the last attempt
+	Holder    bool       `json:"holder"`
+	Interval  string     `json:"interval"`
+	LastError string     `json:"lastError"`
+	LastRunAt *time.Time `json:"lastRunAt,omitempty"`
+	// ID of the last run, found in its logs and the notifications it sent
+	LastRunID     string     `json:"lastRunId"`
+	LastSuccessAt *time.Time `json:"lastSuccessAt,omitempty"`
+	Name          string     `json:"name"`
+	NextRunAt     *time.Time `json:"nextRunAt,omitempty"`
+	Panics        int        `json:"panics"`
+	Restarts      int        `json:"restarts"`
+	// Set while the job's loop waits to be restarted after a crash
+	Stopped bool `json:"stopped"`
+}
+
+// LogLevel is the LogLevel schema of the API
+type LogLevel struct {
+	// ID of the API instance the level applies to
+	Instance string `json:"instance"`
+	// One of: DEBUG, INFO, WARNING, ERROR
+	Level string `json:"level"`
+}
+
+// Notification is the Notification schema of the API
+type Notification struct {
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	Event     string     `json:"event"`
+	ID        string     `json:"id"`
+	Message   string     `json:"message"`
+	ReadAt    *time.Time `json:"readAt,omitempty"`
+	// X-Request-ID of the API call, or ID of the job run, that sent the notification
+	RequestID string `json:"requestId"`
+	// Task the notification is about
+	Task  string `json:"task"`
+	Title string `json:"title"`
+	User  string `json:"user"`
+}
+
+// NotificationPreferences map of event type to the channels it is enabled on
+type NotificationPreferences map[string]map[string]bool
+
+// Policy is the Policy schema of the API
+type Policy struct {
+	Content     string     `json:"content"`
+	ID          string     `json:"id"`
+	PublishedAt *time.Time `json:"publishedAt,omitempty"`
+	PublishedBy string     `json:"publishedBy"`
+	Title       string     `json:"title"`
+	// One of: terms, privacy
+	Type string `json:"type"`
+	// Numbered from 1, users must accept the latest one
+	Version int `json:"version"`
+}
+
+// PolicyAcceptanceRequired returned with status 451 by protected routes until the latest policies are accepted
+type PolicyAcceptanceRequired struct {
+	Error    string `json:"error"`
+	Policies []struct {
+		Type    string `json:"type"`
+		Version int    `json:"version"`
+	} `json:"policies,omitempty"`
+	Success bool `json:"success"`
+}
+
+// Project is the Project schema of the API
+type Project struct {
+	// Hex color such as
+	Color     string          `json:"color"`
+	CreatedAt *time.Time      `json:"createdAt,omitempty"`
+	Defaults  ProjectDefaults `json:"defaults"`
+	// Emoji or icon name (at most 32 characters)
+	Icon string `json:"icon"`
+	// Project ID
+	ID string `json:"id"`
+	// Project name, unique among the user's projects
+	Name string `json:"name"`
+	// Sections of the project in display order
+	Sections []ProjectSection `json:"sections,omitempty"`
+	// Open and completed tasks of the project, kept up to date as its tasks are written
+	TaskCounts struct {
+		Completed int `json:"completed"`
+		Open      int `json:"open"`
+	} `json:"taskCounts"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	// ID of the owner
+	User string `json:"user"`
+}
+
+// ProjectDefaults holds the settings the tasks created in a project start with, unless they set them
+type ProjectDefaults struct {
+	// User the new tasks are offered to
+	Assignee struct {
+		Email string `json:"email"`
+		User  string `json:"user"`
+	} `json:"assignee"`
+	// One of: low, medium, high
+	Priority string `json:"priority"`
+	// Reminders in minutes before the due date, for tasks with one
+	Reminders []int    `json:"reminders,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// ProjectSection is the ProjectSection schema of the API
+type ProjectSection struct {
+	// Section ID
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ProjectTemplate is the ProjectTemplate schema of the API
+type ProjectTemplate struct {
+	Color       string     `json:"color"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty"`
+	Description string     `json:"description"`
+	Icon        string     `json:"icon"`
+	// Template ID, for saved templates
+	ID string `json:"id"`
+	// Template key, for starter templates
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	// Section names in display order
+	Sections []string `json:"sections,omitempty"`
+	Tasks    []struct {
+		Color       string `json:"color"`
+		Context     string `json:"context"`
+		Description string `json:"description"`
+		// Due at the end of this day after the project is created
+		DueInDays int `json:"dueInDays"`
+		// Estimated minutes
+		Estimate int    `json:"estimate"`
+		Icon     string `json:"icon"`
+		// One of: low, medium, high
+		Priority string `json:"priority"`
+		// Name of one of the template's sections
+		Section string   `json:"section"`
+		Tags    []string `json:"tags,omitempty"`
+		Title   string   `json:"title"`
+	} `json:"tasks,omitempty"`
+}
+
+// QuotaWarning is the QuotaWarning schema of the API
+type QuotaWarning struct {
+	Limit   int    `json:"limit"`
+	Message string `json:"message"`
+	// tasks, contexts, automations or notes:<task ID>
+	Quota string `json:"quota"`
+	Used  int    `json:"used"`
+}
+
+// Recurrence repeating series the task is an occurrence of. The next occurrence is created once this one is completed or its due date passes.
+type Recurrence struct {
+	// Holds the occurrences skipped or moved without changing the rule
+	Exceptions []RecurrenceException `json:"exceptions,omitempty"`
+	// Whether the series has no occurrence after this one, by its rule or ended
+	Last bool `json:"last"`
+	// ID of the occurrence created after this one
+	Next string `json:"next"`
+	// Number of this occurrence, from 1
+	Occurrence int `json:"occurrence"`
+	// Whether the series creates no occurrence until it resumes
+	Paused bool `json:"paused"`
+	// Canonical recurrence rule
+	Rule string `json:"rule"`
+	// ID of the first occurrence, shared by the whole series
+	Series string `json:"series"`
+	// Due date the series is anchored on, monthly and yearly rules keeping its day
+	Start *time.Time `json:"start,omitempty"`
+}
+
+// RecurrenceException describes a single occurrence skipped, or moved to movedTo, found by the due date the rule gives it
+type RecurrenceException struct {
+	Date    *time.Time `json:"date,omitempty"`
+	MovedTo *time.Time `json:"movedTo,omitempty"`
+}
+
+// Reminder notifies the owner of a task at a set time, or a number of minutes before it is due
+type Reminder struct {
+	At *time.Time `json:"at,omitempty"`
+	// Minutes before the due date
+	Before int    `json:"before"`
+	ID     string `json:"id"`
+	// Time the reminder was last sent for
+	SentFor *time.Time `json:"sentFor,omitempty"`
+}
+
+// SkippedOccurrence describes a skipped occurrence and the one created in its place, if the series goes on
+type SkippedOccurrence struct {
+	Next    Task       `json:"next"`
+	Skipped *time.Time `json:"skipped,omitempty"`
+}
+
+// StatusReport is the StatusReport schema of the API
+type StatusReport struct {
+	CheckedAt *time.Time `json:"checkedAt,omitempty"`
+	// One of: up, down
+	Database string `json:"database"`
+	// Open incidents, then those resolved in the last 7 days
+	Incidents []Incident `json:"incidents,omitempty"`
+	// One of: ok, degraded
+	Jobs string `json:"jobs"`
+	// One of: operational, degraded, outage
+	Status string `json:"status"`
+	// Share of health snapshots that reached the database, in percent, null without snapshots
+	Uptime struct {
+		// Last 24 hours
+		Day float64 `json:"day"`
+		// Last 7 days
+		Week float64 `json:"week"`
+	} `json:"uptime"`
+	Version string `json:"version"`
+}
+
+// Subtask is the Subtask schema of the API
+type Subtask struct {
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+}
+
+// TagCount is the TagCount schema of the API
+type TagCount struct {
+	// Hex color such as
+	Color string `json:"color"`
+	// Tasks with the tag
+	Count int `json:"count"`
+	// Emoji or icon name (at most 32 characters)
+	Icon string `json:"icon"`
+	// Tasks with the tag not completed yet
+	Open int    `json:"open"`
+	Tag  string `json:"tag"`
+}
+
+// Task is the Task schema of the API
+type Task struct {
+	// Whether the task is completed once all of its subtasks are
+	AutoComplete bool `json:"autoComplete"`
+	// Hex color such as
+	Color string `json:"color"`
+	// Key of the kanban board column holding the task
+	Column string `json:"column"`
+	// Task completion status
+	Completed bool `json:"completed"`
+	// When the task was completed, cleared when it is reopened
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	// Counts the completed subtasks, kept with the checklist so lists can show progress
+	CompletedSubtasks int `json:"completedSubtasks"`
+	// GTD context such as @home
+	Context string `json:"context"`
+	// Task creation date
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// Pending offer of the task to another user
+	Delegation struct {
+		Email     string     `json:"email"`
+		OfferedAt *time.Time `json:"offeredAt,omitempty"`
+		// ID of the user the task is offered to
+		To string `json:"to"`
+	} `json:"delegation"`
+	// IDs of tasks that must finish first
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// Task description
+	Description string `json:"description"`
+	// Task due date
+	DueDate *time.Time `json:"dueDate,omitempty"`
+	// Estimated effort in minutes
+	Estimate int `json:"estimate"`
+	// ID of the goal the task contributes to
+	Goal string `json:"goal"`
+	// Emoji or icon name (at most 32 characters)
+	Icon string `json:"icon"`
+	// Task ID
+	ID string `json:"id"`
+	// Captured in the inbox and not triaged yet
+	Inbox bool `json:"inbox"`
+	// Order of the task within its board column
+	Position int `json:"position"`
+	// Task priority. One of: low, medium, high
+	Priority string `json:"priority"`
+	// ID of the project the task is listed in
+	Project    string     `json:"project"`
+	Recurrence Recurrence `json:"recurrence"`
+	Reminders  []Reminder `json:"reminders,omitempty"`
+	// ID of the project section the task is filed under
+	Section string `json:"section"`
+	// Time until which the task is hidden from the default views
+	SnoozedUntil *time.Time `json:"snoozedUntil,omitempty"`
+	// Date the task is planned to start. Until then it is hidden from the default views
+	StartDate *time.Time `json:"startDate,omitempty"`
+	// Checklist of the task, in display order
+	Subtasks []Subtask `json:"subtasks,omitempty"`
+	// Lowercase labels, at most 20
+	Tags []string `json:"tags,omitempty"`
+	// Task title
+	Title string `json:"title"`
+	// Counts the subtasks of the task
+	TotalSubtasks int `json:"totalSubtasks"`
+	// Task last update date
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	// User ID who owns the task
+	User string `json:"user"`
+}
+
+// TaskActivity is the TaskActivity schema of the API
+type TaskActivity struct {
+	// User who made the change, unset for automatic changes
+	Actor string `json:"actor"`
+	// Field changes of the day, only set on daily_summary entries
+	Changes   []ActivityChange       `json:"changes,omitempty"`
+	CreatedAt *time.Time             `json:"createdAt,omitempty"`
+	Field     string                 `json:"field"`
+	From      map[string]interface{} `json:"from,omitempty"`
+	ID        string                 `json:"id"`
+	Reason    string                 `json:"reason"`
+	Task      string                 `json:"task"`
+	To        map[string]interface{} `json:"to,omitempty"`
+	Type      string                 `json:"type"`
+	User      string                 `json:"user"`
+}
+
+// TaskNote is the TaskNote schema of the API
+type TaskNote struct {
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// Whether the text changed after the note was added
+	Edited    bool       `json:"edited"`
+	ID        string     `json:"id"`
+	Task      string     `json:"task"`
+	Text      string     `json:"text"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	User      string     `json:"user"`
+}
+
+// TaskVersion is the TaskVersion schema of the API
+type TaskVersion struct {
+	// When the snapshot was replaced
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	ID        string     `json:"id"`
+	Snapshot  Task       `json:"snapshot"`
+	Task      string     `json:"task"`
+	User      string     `json:"user"`
+	// Increases with each edit of the task
+	Version int `json:"version"`
+}
+
+// TimelineEntry is the TimelineEntry schema of the API
+type TimelineEntry struct {
+	Completed bool `json:"completed"`
+	// Whether the task is on the critical path
+	Critical  bool       `json:"critical"`
+	DependsOn []string   `json:"dependsOn,omitempty"`
+	DueDate   *time.Time `json:"dueDate,omitempty"`
+	// Planned length in days
+	Duration       float64 `json:"duration"`
+	EarliestFinish float64 `json:"earliestFinish"`
+	// Earliest start in days from the start of the timeline
+	EarliestStart float64 `json:"earliestStart"`
+	ID            string  `json:"id"`
+	// Days the task can slip without delaying the timeline
+	Slack     float64    `json:"slack"`
+	StartDate *time.Time `json:"startDate,omitempty"`
+	Title     string     `json:"title"`
+}
+
+// User is the User schema of the API
+type User struct {
+	// Account creation date
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// Set while the account is deactivated by an admin
+	DeactivatedAt *time.Time `json:"deactivatedAt,omitempty"`
+	// User email
+	Email string `json:"email"`
+	// Whether the email address was verified, accounts registered before verification existed count as verified
+	EmailVerified bool `json:"emailVerified"`
+	// User ID
+	ID string `json:"id"`
+	// Language of the emails sent to the user, the default one when missing. One of: en, de
+	Language    string     `json:"language"`
+	LastLoginAt *time.Time `json:"lastLoginAt,omitempty"`
+	// User role. One of: user, admin
+	Role string `json:"role"`
+	// Username
+	Username string `json:"username"`
+}
+
+// WorkSchedule is the WorkSchedule schema of the API
+type WorkSchedule struct {
+	// Working weekdays, 0 for Sunday
+	Days  []int  `json:"days,omitempty"`
+	End   string `json:"end"`
+	Start string `json:"start"`
+}
@@ -2,9 +2,12 @@ package configs
 
 import (
 	"context"
-	"log"
+	"os"
+	"strings"
 	"time"
 
+	"gotodolist/utils"
+
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -14,24 +17,52 @@ func ConnectDB(mongoURI string) *mongo.Client {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	logger := utils.GetLogger().Named("db")
+
+	// Refuse a prefix that would make collections fail, rather than
+	// sharing unprefixed collections with another environment
+	if prefix := CollectionPrefix(); !ValidCollectionPrefix(prefix) {
+		logger.Error("Invalid COLLECTION_PREFIX: " + prefix)
+		os.Exit(1)
+	}
+
 	clientOptions := options.Client().ApplyURI(mongoURI)
+
+	// Log slow commands unless disabled with MONGO_SLOW_QUERY_MS=0
+	if threshold := SlowQueryThreshold(); threshold > 0 {
+		clientOptions.SetMonitor(NewCommandMonitor(threshold))
+	}
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
-		log.Fatal("Failed to connect to MongoDB:", err)
+		logger.Error("Failed to connect to MongoDB: " + err.Error())
+		os.Exit(1)
 	}
 
 	// Ping the database to check if the connection is established
 	err = client.Ping(ctx, nil)
 	if err != nil {
-		log.Fatal("Failed to ping MongoDB:", err)
+		logger.Error("Failed to ping MongoDB: " + err.Error())
+		os.Exit(1)
 	}
 
-	log.Println("Connected to MongoDB")
+	logger.Debug("Connected to MongoDB")
 	return client
 }
 
-// GetCollection returns a MongoDB collection
+// CollectionPrefix returns the prefix put before every collection name, read
+// from COLLECTION_PREFIX so that several environments can share a database
+func CollectionPrefix() string {
+	return utils.GetEnv("COLLECTION_PREFIX", "")
+}
+
+// ValidCollectionPrefix reports whether MongoDB accepts collection names
+// starting with the prefix
+func ValidCollectionPrefix(prefix string) bool {
+	return !strings.ContainsAny(prefix, "$\x00") && !strings.HasPrefix(prefix, "system.")
+}
+
+// GetCollection returns a MongoDB collection, named with the COLLECTION_PREFIX
 func GetCollection(client *mongo.Client, collectionName string, databaseName string) *mongo.Collection {
-	collection := client.Database(databaseName).Collection(collectionName)
+	collection := client.Database(databaseName).Collection(CollectionPrefix() + collectionName)
 	return collection
 }
@@ -0,0 +1,100 @@
+package configs
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"gotodolist/utils"
+)
+
+// systemdFirstFD is the first file descriptor passed by systemd socket
+// activation, after stdin, stdout and stderr
+const systemdFirstFD = 3
+
+// Listen opens the listener the server accepts connections on, returning
+// it with a description for the logs. In order of precedence it is the
+// socket passed by systemd socket activation (LISTEN_FDS), the Unix domain
+// socket at LISTEN_SOCKET, or TCP on PORT (8080 by default).
+func Listen() (net.Listener, string, error) {
+	if listener, err := systemdListener(); listener != nil || err != nil {
+		return listener, "systemd socket", err
+	}
+
+	if path := utils.GetEnv("LISTEN_SOCKET", ""); path != "" {
+		listener, err := unixListener(path)
+		return listener, "unix socket " + path, err
+	}
+
+	port := utils.GetEnv("PORT", "8080")
+	listener, err := net.Listen("tcp", ":"+port)
+	return listener, "port " + port, err
+}
+
+// systemdListener returns the first socket passed by systemd, nil when the
+// process was not socket activated. The variables are cleared so that
+// child processes do not take the socket for theirs.
+func systemdListener() (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count < 1 {
+		return nil, nil
+	}
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	file := os.NewFile(uintptr(systemdFirstFD), "systemd-socket")
+	defer file.Close()
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid systemd socket: %w", err)
+	}
+	return listener, nil
+}
+
+// unixListener listens on a Unix domain socket, replacing the socket file
+// left behind by a previous run, with the permissions of LISTEN_SOCKET_MODE
+// (0660 by default) so that a reverse proxy in the same group can connect
+func unixListener(path string) (net.Listener, error) {
+	mode, err := strconv.ParseUint(utils.GetEnv("LISTEN_SOCKET_MODE", "0660"), 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LISTEN_SOCKET_MODE: %w", err)
+	}
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, os.FileMode(mode)); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// LocalPeers gives the requests of a Unix domain socket, which have no
+// remote address, the loopback address. Trusting 127.0.0.1 in
+// TRUSTED_PROXIES then trusts the reverse proxy on the socket.
+func LocalPeers(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.RemoteAddr == "" || r.RemoteAddr == "@" {
+			r.RemoteAddr = "127.0.0.1:0"
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,149 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gotodolist/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// slowQueries counts the commands that exceeded the slow query threshold
+var slowQueries atomic.Int64
+
+// SlowQueryCount returns the number of slow commands since startup
+func SlowQueryCount() int64 {
+	return slowQueries.Load()
+}
+
+// SlowQueryThreshold returns the duration above which commands are logged,
+// read from MONGO_SLOW_QUERY_MS. Zero disables slow query logging.
+func SlowQueryThreshold() time.Duration {
+	ms, err := strconv.Atoi(utils.GetEnv("MONGO_SLOW_QUERY_MS", "100"))
+	if err != nil || ms < 0 {
+		ms = 100
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// startedCommand is what is kept of a command until it finishes
+type startedCommand struct {
+	name       string
+	collection string
+	command    bson.Raw
+}
+
+// NewCommandMonitor logs commands slower than the threshold with their
+// collection and filter shape, which helps finding missing indexes
+func NewCommandMonitor(threshold time.Duration) *event.CommandMonitor {
+	logger := utils.GetLogger().Named("db")
+	var started sync.Map // Keyed by request ID
+
+	finished := func(requestID int64, duration time.Duration, failure string) {
+		value, ok := started.LoadAndDelete(requestID)
+		if !ok || duration < threshold {
+			return
+		}
+		cmd := value.(startedCommand)
+		slowQueries.Add(1)
+
+		fields := logger.With(
+			"command", cmd.name,
+			"collection", cmd.collection,
+			"duration", duration.Round(time.Millisecond),
+			"filter", commandFilterShape(cmd.command),
+		)
+		if failure != "" {
+			fields = fields.With("error", failure)
+		}
+		fields.Warning("Slow query")
+	}
+
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, e *event.CommandStartedEvent) {
+			// Only commands addressed to a collection, such as find or update
+			first, err := e.Command.IndexErr(0)
+			if err != nil || first.Value().Type != bsontype.String {
+				return
+			}
+			started.Store(e.RequestID, startedCommand{
+				name:       e.CommandName,
+				collection: first.Value().StringValue(),
+				command:    append(bson.Raw{}, e.Command...),
+			})
+		},
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			finished(e.RequestID, e.Duration, "")
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			finished(e.RequestID, e.Duration, e.Failure)
+		},
+	}
+}
+
+// commandFilterShape returns the filter of a command with its values
+// replaced by "?", so queries can be grouped without leaking user data
+func commandFilterShape(command bson.Raw) string {
+	for _, key := range []string{"filter", "query", "pipeline", "updates", "deletes"} {
+		value, err := command.LookupErr(key)
+		if err != nil {
+			continue
+		}
+		if key == "updates" || key == "deletes" {
+			// Bulk writes carry one statement per element, its filter is "q"
+			statements, ok := value.ArrayOK()
+			if !ok {
+				return "?"
+			}
+			first, err := statements.IndexErr(0)
+			if err != nil {
+				return "{}"
+			}
+			value, err = first.Value().Document().LookupErr("q")
+			if err != nil {
+				return "{}"
+			}
+		}
+		return valueShape(value)
+	}
+	return "{}"
+}
+
+// valueShape renders the structure of a BSON value
+func valueShape(value bson.RawValue) string {
+	switch value.Type {
+	case bsontype.EmbeddedDocument:
+		elements, _ := value.Document().Elements()
+		keys := make([]string, 0, len(elements))
+		for _, element := range elements {
+			keys = append(keys, fmt.Sprintf("%s: %s", element.Key(), valueShape(element.Value())))
+		}
+		sort.Strings(keys)
+		return "{" + strings.Join(keys, ", ") + "}"
+	case bsontype.Array:
+		values, _ := value.Array().Values()
+		if len(values) == 0 {
+			return "[]"
+		}
+		// Operators like $in and $and keep their structure, lists of values collapse
+		if values[0].Type == bsontype.EmbeddedDocument || values[0].Type == bsontype.Array {
+			shapes := make([]string, len(values))
+			for i, item := range values {
+				shapes[i] = valueShape(item)
+			}
+			return "[" + strings.Join(shapes, ", ") + "]"
+		}
+		return "[?]"
+	default:
+		return "?"
+	}
+}
@@ -0,0 +1,54 @@
+package configs
+
+import (
+	"fmt"
+	"strings"
+
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfigureProxies sets which reverse proxies the router trusts, so that
+// ClientIP returns the real client address instead of the proxy's.
+//
+// TRUSTED_PROXIES is a comma-separated list of IPs or CIDRs; when empty no
+// proxy is trusted and the connection address is used. Forwarding headers
+// (REMOTE_IP_HEADERS, X-Forwarded-For and X-Real-IP by default) are read
+// right to left, skipping trusted proxies, so clients cannot spoof their
+// address by prepending entries. TRUSTED_PLATFORM trusts the header of a
+// platform instead: cloudflare, google, or any header name.
+func ConfigureProxies(router *gin.Engine) error {
+	proxies := splitList(utils.GetEnv("TRUSTED_PROXIES", ""))
+	if len(proxies) == 0 {
+		proxies = nil
+	}
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		return fmt.Errorf("invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	router.RemoteIPHeaders = splitList(utils.GetEnv("REMOTE_IP_HEADERS", "X-Forwarded-For,X-Real-IP"))
+
+	switch platform := utils.GetEnv("TRUSTED_PLATFORM", ""); strings.ToLower(platform) {
+	case "":
+	case "cloudflare":
+		router.TrustedPlatform = gin.PlatformCloudflare
+	case "google":
+		router.TrustedPlatform = gin.PlatformGoogleAppEngine
+	default:
+		router.TrustedPlatform = platform
+	}
+
+	return nil
+}
+
+// splitList splits a comma-separated setting, dropping empty entries
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,53 @@
+package configs
+
+import (
+	"sync"
+
+	"gotodolist/utils"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+var (
+	heavyReadsOnce sync.Once
+	heavyReadsPref *readpref.ReadPref
+)
+
+// HeavyReadPreference returns the read preference of heavy reads such as
+// reports and exports, read once from READ_PREFERENCE_HEAVY (primary,
+// primaryPreferred, secondary, secondaryPreferred or nearest). It defaults
+// to primary, which is also used when the mode is not recognized.
+func HeavyReadPreference() *readpref.ReadPref {
+	heavyReadsOnce.Do(func() {
+		heavyReadsPref = readpref.Primary()
+
+		value := utils.GetEnv("READ_PREFERENCE_HEAVY", "primary")
+		mode, err := readpref.ModeFromString(value)
+		if err == nil {
+			heavyReadsPref, err = readpref.New(mode)
+		}
+		if err != nil {
+			heavyReadsPref = readpref.Primary()
+			utils.GetLogger().Named("db").Warning("Invalid READ_PREFERENCE_HEAVY, using primary: " + value)
+		}
+	})
+	return heavyReadsPref
+}
+
+// HeavyReads returns the collection reading with the heavy read preference.
+// Only reads that can tolerate replication lag should go through it; writes
+// and the reads behind authentication keep using the collection itself.
+func HeavyReads(collection *mongo.Collection) *mongo.Collection {
+	pref := HeavyReadPreference()
+	if pref.Mode() == readpref.PrimaryMode {
+		return collection
+	}
+
+	clone, err := collection.Clone(options.Collection().SetReadPreference(pref))
+	if err != nil {
+		return collection
+	}
+	return clone
+}
@@ -0,0 +1,77 @@
+package configs
+
+import (
+	"context"
+	"errors"
+
+	"gotodolist/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Error codes of collMod on a missing collection and of create on an
+// existing one
+const (
+	namespaceNotFound = 26
+	namespaceExists   = 48
+)
+
+// SchemaValidationAction returns what MongoDB does with a write breaking a
+// collection validator, read from SCHEMA_VALIDATION: "error" (the default)
+// rejects it, "warn" only logs it on the server and "off" removes the
+// validators
+func SchemaValidationAction() string {
+	switch action := utils.GetEnv("SCHEMA_VALIDATION", "error"); action {
+	case "error", "warn", "off":
+		return action
+	default:
+		utils.GetLogger().Named("db").Warning("Invalid SCHEMA_VALIDATION, using error: " + action)
+		return "error"
+	}
+}
+
+// ApplySchema sets the $jsonSchema validator of a collection, creating the
+// collection if needed. The validation level is moderate: documents that
+// were already invalid can still be updated, so a new rule does not lock
+// out older data, while inserts and updates of valid documents are checked.
+func ApplySchema(ctx context.Context, collection *mongo.Collection, schema bson.M) error {
+	validator := bson.M{"$jsonSchema": schema}
+	level, action := "moderate", SchemaValidationAction()
+	if action == "off" {
+		validator, level, action = bson.M{}, "off", "error"
+	}
+
+	db := collection.Database()
+	collMod := func() error {
+		return db.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: collection.Name()},
+			{Key: "validator", Value: validator},
+			{Key: "validationLevel", Value: level},
+			{Key: "validationAction", Value: action},
+		}).Err()
+	}
+
+	err := collMod()
+	if commandErrorCode(err) == namespaceNotFound {
+		err = db.CreateCollection(ctx, collection.Name(), options.CreateCollection().
+			SetValidator(validator).
+			SetValidationLevel(level).
+			SetValidationAction(action))
+		// Another instance created it in the meantime
+		if commandErrorCode(err) == namespaceExists {
+			err = collMod()
+		}
+	}
+	return err
+}
+
+// commandErrorCode returns the code of a failed command, 0 for other errors
+func commandErrorCode(err error) int32 {
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) {
+		return commandErr.Code
+	}
+	return 0
+}
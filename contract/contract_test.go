@@ -0,0 +1,109 @@
+// Package contract checks that the API keeps to its contract, swagger.yaml:
+//
+//	go test ./contract
+//
+// TestRoutesDocumented compares the routes registered in routes/ and
+// main.go with the documented operations. TestResponsesMatchSpec calls
+// every documented operation through the router, over the database of
+// TEST_MONGO_URI, and checks each JSON response: it must have the
+// {"success", "data"/"error"} envelope and, when its status is documented,
+// match the schema without undocumented fields. It is skipped without
+// TEST_MONGO_URI.
+package contract
+
+import (
+	"os"
+	"sort"
+	"testing"
+
+	"gotodolist/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.ReleaseMode)
+	os.Exit(m.Run())
+}
+
+// TestRoutesDocumented fails for the routes missing from the specification
+// and the documented operations that no route serves
+func TestRoutesDocumented(t *testing.T) {
+	document, err := loadSpec("../swagger.yaml")
+	if err != nil {
+		t.Fatalf("load the specification: %v", err)
+	}
+	routes, err := scanRoutes("..")
+	if err != nil {
+		t.Fatalf("read the routes: %v", err)
+	}
+
+	served := map[string]bool{}
+	for _, r := range routes {
+		key := r.Method + " " + r.SpecPath()
+		served[key] = true
+		if _, ok := document.operations[key]; !ok && !isUndocumented(r) {
+			t.Errorf("route is not documented: %s", key)
+		}
+	}
+
+	var missing []string
+	for key := range document.operations {
+		if !served[key] {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	for _, key := range missing {
+		t.Errorf("documented operation has no route: %s", key)
+	}
+	t.Logf("%d routes, %d documented operations", len(routes), len(document.operations))
+}
+
+// TestResponsesMatchSpec calls every documented operation served by the
+// router as a new user, and the admin operations as a new admin, and checks
+// the responses against the specification.
+// A success status missing from the specification fails the operation, an
+// undocumented error status is only logged.
+func TestResponsesMatchSpec(t *testing.T) {
+	db := testutil.Database(t)
+
+	document, err := loadSpec("../swagger.yaml")
+	if err != nil {
+		t.Fatalf("load the specification: %v", err)
+	}
+
+	user := testutil.NewUser()
+	admin := testutil.NewAdmin()
+	member := testutil.NewUser()
+	testutil.Insert(t, db.Collection("users"), user, admin, member)
+	router := newRouter(db)
+	l := newLive(t, document, testutil.NewClient(t, router, user), testutil.NewClient(t, router, admin), member.ID)
+
+	var routes []route
+	for _, info := range router.Routes() {
+		routes = append(routes, route{Method: info.Method, Path: info.Path})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	order(routes)
+
+	for _, r := range routes {
+		key := r.Method + " " + r.SpecPath()
+		op, documented := document.operations[key]
+		if !documented || sessionEnding[key] {
+			continue
+		}
+		res := l.check(r, key, op)
+		for _, failure := range res.Failures {
+			t.Errorf("%s (%d): %s", key, res.Status, failure)
+		}
+		for _, warning := range res.Warnings {
+			t.Logf("%s (%d): warning: %s", key, res.Status, warning)
+		}
+	}
+}
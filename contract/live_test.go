@@ -0,0 +1,294 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gotodolist/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// sessionEnding are the operations not called, since they would log the
+// check out or lock it out of its account. A published policy locks every
+// user out until they accept it.
+var sessionEnding = map[string]bool{
+	"POST /auth/logout":     true,
+	"PUT /auth/me/password": true,
+	"POST /admin/policies":  true,
+}
+
+// fixture is a resource created before the checks, whose ID fills the
+// path parameters naming it
+type fixture struct {
+	Name string
+	Path string // Gin path, with :task and the like replaced by fixtures created before
+	Body map[string]interface{}
+	ID   string // Location of the ID in the response, such as data.id
+}
+
+// fixtures are created in order, so a path can use the ones before it
+var fixtures = []fixture{
+	{Name: "task", Path: "/tasks/", Body: map[string]interface{}{"title": "Contract check"}, ID: "data.id"},
+	{Name: "goal", Path: "/goals/", Body: map[string]interface{}{"name": "Contract check"}, ID: "data.id"},
+	{Name: "habit", Path: "/habits/", Body: map[string]interface{}{"name": "Contract check"}, ID: "data.id"},
+	{Name: "context", Path: "/contexts/", Body: map[string]interface{}{"name": "@contract"}, ID: "data.id"},
+	{Name: "project", Path: "/projects/", Body: map[string]interface{}{"name": "Contract check"}, ID: "data.id"},
+	{Name: "note", Path: "/tasks/:task/notes", Body: map[string]interface{}{"text": "Contract check"}, ID: "data.id"},
+	{Name: "subtask", Path: "/tasks/:task/subtasks", Body: map[string]interface{}{"title": "Contract check"}, ID: "data.subtasks.-1.id"},
+	{Name: "section", Path: "/projects/:project/sections", Body: map[string]interface{}{"name": "Contract check"}, ID: "data.sections.-1.id"},
+	{Name: "announcement", Path: "/admin/announcements/", Body: map[string]interface{}{"title": "Contract check", "message": "Contract check"}, ID: "data.id"},
+	{Name: "incident", Path: "/admin/incidents/", Body: map[string]interface{}{"title": "Contract check", "message": "Contract check"}, ID: "data.id"},
+}
+
+// idFixtures name the fixture of an :id parameter by the segment before it,
+// other parameters are named after their fixture, such as :noteId. The
+// users of the admin routes are a member created for them.
+var idFixtures = map[string]string{
+	"tasks":         "task",
+	"goals":         "goal",
+	"habits":        "habit",
+	"contexts":      "context",
+	"projects":      "project",
+	"announcements": "announcement",
+	"incidents":     "incident",
+	"users":         "member",
+}
+
+// result is the outcome of calling one operation
+type result struct {
+	Operation string
+	Status    int
+	Failures  []string
+	Warnings  []string
+}
+
+// live calls the operations of a router as a user of the test database,
+// and the operations under /admin as an admin
+type live struct {
+	t      testing.TB
+	spec   *spec
+	client *testutil.Client
+	admin  *testutil.Client
+	ids    map[string]string // Fixture IDs by name
+}
+
+// newLive creates the fixtures of a user through the router. The admin
+// routes acting on a user act on member, so the checks keep their own
+// account.
+func newLive(t testing.TB, document *spec, client, admin *testutil.Client, member primitive.ObjectID) *live {
+	t.Helper()
+
+	l := &live{t: t, spec: document, client: client, admin: admin, ids: map[string]string{"member": member.Hex()}}
+	for _, f := range fixtures {
+		status, _, body, err := l.do(http.MethodPost, l.fill(f.Path), f.Body)
+		if err != nil || status >= 300 {
+			t.Fatalf("create the %s fixture: status %d: %v", f.Name, status, err)
+		}
+		id, _ := lookup(body, f.ID).(string)
+		if id == "" {
+			t.Fatalf("create the %s fixture: no ID at %s", f.Name, f.ID)
+		}
+		l.ids[f.Name] = id
+	}
+	return l
+}
+
+// fill replaces the :name segments of a fixture path by fixture IDs
+func (l *live) fill(path string) string {
+	for name, id := range l.ids {
+		path = strings.ReplaceAll(path, ":"+name, id)
+	}
+	return path
+}
+
+// order sorts the routes in the order they are called: reads first and
+// deletions last, the nested resources before their parent
+func order(routes []route) {
+	methods := map[string]int{"GET": 0, "POST": 1, "PUT": 2, "PATCH": 3, "DELETE": 4}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if methods[routes[i].Method] != methods[routes[j].Method] {
+			return methods[routes[i].Method] < methods[routes[j].Method]
+		}
+		if routes[i].Method == http.MethodDelete {
+			return len(routes[i].Path) > len(routes[j].Path)
+		}
+		return false
+	})
+}
+
+// check calls one operation and checks its response against the contract
+func (l *live) check(r route, key string, op *operation) result {
+	res := result{Operation: key}
+
+	path, query := l.path(r, op)
+	var body interface{}
+	if op.RequestBody != nil {
+		if content, ok := op.RequestBody.Content["application/json"]; ok {
+			body = l.spec.sample(content.Schema)
+		}
+	}
+
+	status, contentType, decoded, err := l.do(r.Method, path+query, body)
+	res.Status = status
+	if err != nil {
+		res.Failures = append(res.Failures, err.Error())
+		return res
+	}
+
+	// Gin answers unknown routes in plain text, API errors are JSON
+	if status == http.StatusNotFound && contentType != "application/json" {
+		res.Failures = append(res.Failures, "route is not served")
+		return res
+	}
+	if contentType != "application/json" {
+		// Files, HTML and profiles are not enveloped
+		return res
+	}
+
+	res.Failures = append(res.Failures, envelope(status, decoded)...)
+
+	response, documented := op.Responses[strconv.Itoa(status)]
+	if !documented {
+		response, documented = op.Responses["default"]
+	}
+	switch {
+	case !documented && status < 400:
+		res.Failures = append(res.Failures, fmt.Sprintf("status %d is not documented", status))
+	case !documented:
+		res.Warnings = append(res.Warnings, fmt.Sprintf("error status %d is not documented", status))
+	default:
+		if content, ok := response.Content["application/json"]; ok {
+			res.Failures = append(res.Failures, l.spec.validate("", decoded, content.Schema)...)
+		}
+	}
+	return res
+}
+
+// envelope checks the {"success", "data"/"error"} envelope of a response
+func envelope(status int, body interface{}) []string {
+	object, ok := body.(map[string]interface{})
+	if !ok {
+		return []string{"body is not a JSON object"}
+	}
+	success, ok := object["success"].(bool)
+	if !ok {
+		return []string{"success: missing from the envelope"}
+	}
+	if status < 400 && !success {
+		return []string{fmt.Sprintf("success: false on status %d", status)}
+	}
+	if status >= 400 {
+		if success {
+			return []string{fmt.Sprintf("success: true on status %d", status)}
+		}
+		if message, _ := object["error"].(string); message == "" {
+			return []string{"error: missing from the error envelope"}
+		}
+	}
+	return nil
+}
+
+// path fills the parameters of a route with fixture IDs, the examples of
+// the specification or values of their type, and adds the required query
+// parameters
+func (l *live) path(r route, op *operation) (string, string) {
+	params := map[string]parameter{}
+	query := []string{}
+	for _, p := range op.Parameters {
+		switch p.In {
+		case "path":
+			params[p.Name] = p
+		case "query":
+			if p.Required {
+				query = append(query, p.Name+"="+fmt.Sprint(l.parameterValue(p)))
+			}
+		}
+	}
+
+	segments := strings.Split(r.Path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		name := segment[1:]
+		fixtureName := strings.TrimSuffix(name, "Id")
+		if name == "id" {
+			fixtureName = idFixtures[segments[i-1]]
+		}
+		if id, ok := l.ids[fixtureName]; ok {
+			segments[i] = id
+		} else {
+			segments[i] = fmt.Sprint(l.parameterValue(params[name]))
+		}
+	}
+
+	if len(query) == 0 {
+		return strings.Join(segments, "/"), ""
+	}
+	return strings.Join(segments, "/"), "?" + strings.Join(query, "&")
+}
+
+// parameterValue returns a value for a parameter without fixture, an
+// ObjectID when nothing else is known since most are IDs
+func (l *live) parameterValue(p parameter) interface{} {
+	if p.Example != nil {
+		return p.Example
+	}
+	sc := l.spec.resolve(p.Schema)
+	if sc == nil || (sc.Type == "string" && sc.Format == "" && sc.Example == nil && len(sc.Enum) == 0) {
+		return primitive.NewObjectID().Hex()
+	}
+	return l.spec.sample(sc)
+}
+
+// do sends a request through the router and decodes its JSON response
+func (l *live) do(method, path string, body interface{}) (int, string, interface{}, error) {
+	client := l.client
+	if strings.HasPrefix(path, "/admin/") {
+		client = l.admin
+	}
+	w := client.Do(l.t, method, path, body)
+
+	contentType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if contentType != "application/json" {
+		return w.Code, contentType, nil, nil
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+		return w.Code, contentType, nil, fmt.Errorf("invalid JSON body: %v", err)
+	}
+	return w.Code, contentType, decoded, nil
+}
+
+// lookup reads a value from a decoded document by a dotted location, where
+// -1 is the last element of an array
+func lookup(value interface{}, location string) interface{} {
+	for _, key := range strings.Split(location, ".") {
+		switch current := value.(type) {
+		case map[string]interface{}:
+			value = current[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || len(current) == 0 {
+				return nil
+			}
+			if index < 0 {
+				index += len(current)
+			}
+			if index < 0 || index >= len(current) {
+				return nil
+			}
+			value = current[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
@@ -0,0 +1,22 @@
+package contract
+
+import (
+	"gotodolist/middleware"
+	"gotodolist/routes"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// newRouter serves the routes of the specification with the wiring of
+// main.go, over the collections of a test database. The profiling and email
+// preview routes are left out, being out of the specification, as are the
+// documentation and welcome routes main.go registers itself, which only the
+// route comparison covers.
+func newRouter(db *mongo.Database) *gin.Engine {
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.Use(middleware.Recovery())
+	routes.NewApp(db.Client(), db.Name()).Register(router)
+	return router
+}
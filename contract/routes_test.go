@@ -0,0 +1,156 @@
+package contract
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// route is an operation registered on the router
+type route struct {
+	Method string
+	Path   string // Gin path such as /tasks/:id
+}
+
+// SpecPath returns the path of the route in the specification, such as
+// /tasks/{id}
+func (r route) SpecPath() string {
+	segments := strings.Split(strings.TrimSuffix(r.Path, "/"), "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	path := strings.Join(segments, "/")
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// routeMethods are the router methods registering an operation
+var routeMethods = map[string]bool{"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+
+// undocumentedRoutes are served on purpose without being in the
+// specification, by gin path
+var undocumentedRoutes = map[string]string{
+	"/":              "Welcome message",
+	"/api-docs/*any": "The specification itself",
+}
+
+// undocumentedPrefixes are groups of routes served without being in the
+// specification
+var undocumentedPrefixes = map[string]string{
+	"/debug/pprof":  "Runtime profiles of net/http/pprof",
+	"/debug/emails": "Email previews of debug mode",
+}
+
+// scanRoutes finds the routes registered by the Setup functions of routes/
+// and by main.go. It reads the source rather than building the router, so
+// it needs no database and no controller. Routes must be registered as
+// they are now: with a literal path on the router or on a group created
+// from it with a literal prefix.
+func scanRoutes(root string) ([]route, error) {
+	files, err := filepath.Glob(filepath.Join(root, "routes", "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, filepath.Join(root, "main.go"))
+
+	fset := token.NewFileSet()
+	var routes []route
+	for _, file := range files {
+		parsed, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, decl := range parsed.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				routes = append(routes, functionRoutes(fn.Body)...)
+			}
+		}
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes, nil
+}
+
+// functionRoutes returns the routes registered in the body of a function,
+// following the groups it creates
+func functionRoutes(body *ast.BlockStmt) []route {
+	prefixes := map[string]string{"router": ""}
+	var routes []route
+
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			// group := router.Group("/prefix")
+			if len(node.Lhs) != 1 || len(node.Rhs) != 1 {
+				return true
+			}
+			name, ok := node.Lhs[0].(*ast.Ident)
+			if !ok {
+				return true
+			}
+			if receiver, method, path, ok := literalCall(node.Rhs[0]); ok && method == "Group" {
+				if prefix, known := prefixes[receiver]; known {
+					prefixes[name.Name] = prefix + path
+				}
+			}
+		case *ast.CallExpr:
+			// group.GET("/path", handlers...)
+			if receiver, method, path, ok := literalCall(node); ok && routeMethods[method] {
+				if prefix, known := prefixes[receiver]; known {
+					routes = append(routes, route{Method: method, Path: prefix + path})
+				}
+			}
+		}
+		return true
+	})
+	return routes
+}
+
+// literalCall matches a call such as receiver.Method("literal", ...)
+func literalCall(expr ast.Expr) (receiver, method, arg string, ok bool) {
+	call, isCall := expr.(*ast.CallExpr)
+	if !isCall || len(call.Args) == 0 {
+		return "", "", "", false
+	}
+	selector, isSelector := call.Fun.(*ast.SelectorExpr)
+	if !isSelector {
+		return "", "", "", false
+	}
+	ident, isIdent := selector.X.(*ast.Ident)
+	literal, isLiteral := call.Args[0].(*ast.BasicLit)
+	if !isIdent || !isLiteral || literal.Kind != token.STRING {
+		return "", "", "", false
+	}
+	value, err := strconv.Unquote(literal.Value)
+	if err != nil {
+		return "", "", "", false
+	}
+	return ident.Name, selector.Sel.Name, value, true
+}
+
+// isUndocumented reports whether a route is served on purpose without
+// being in the specification
+func isUndocumented(r route) bool {
+	if _, ok := undocumentedRoutes[r.Path]; ok {
+		return true
+	}
+	for prefix := range undocumentedPrefixes {
+		if strings.HasPrefix(r.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
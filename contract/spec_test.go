@@ -0,0 +1,284 @@
+package contract
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// schema is the subset of an OpenAPI schema object used by the checks
+type schema struct {
+	Ref                  string             `yaml:"$ref"`
+	Type                 string             `yaml:"type"`
+	Format               string             `yaml:"format"`
+	Enum                 []interface{}      `yaml:"enum"`
+	Example              interface{}        `yaml:"example"`
+	Required             []string           `yaml:"required"`
+	Properties           map[string]*schema `yaml:"properties"`
+	Items                *schema            `yaml:"items"`
+	AdditionalProperties *schema            `yaml:"additionalProperties"`
+	Minimum              *float64           `yaml:"minimum"`
+	AllOf                []*schema          `yaml:"allOf"`
+	OneOf                []*schema          `yaml:"oneOf"`
+}
+
+// media is the content of a request or response body
+type media map[string]struct {
+	Schema *schema `yaml:"schema"`
+}
+
+// parameter is an operation or path parameter
+type parameter struct {
+	In       string      `yaml:"in"`
+	Name     string      `yaml:"name"`
+	Required bool        `yaml:"required"`
+	Example  interface{} `yaml:"example"`
+	Schema   *schema     `yaml:"schema"`
+}
+
+// operation is a single method of a path
+type operation struct {
+	Parameters  []parameter `yaml:"parameters"`
+	RequestBody *struct {
+		Content media `yaml:"content"`
+	} `yaml:"requestBody"`
+	Responses map[string]struct {
+		Content media `yaml:"content"`
+	} `yaml:"responses"`
+}
+
+// spec is the subset of an OpenAPI document used by the checks, with its
+// operations keyed by "METHOD /path/{param}"
+type spec struct {
+	Paths      map[string]map[string]yaml.Node `yaml:"paths"`
+	Components struct {
+		Schemas map[string]*schema `yaml:"schemas"`
+	} `yaml:"components"`
+
+	operations map[string]*operation
+}
+
+// loadSpec reads the specification and indexes its operations, the path
+// parameters being added to each operation of the path
+func loadSpec(path string) (*spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var document spec
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	document.operations = map[string]*operation{}
+	for path, items := range document.Paths {
+		var shared []parameter
+		if node, ok := items["parameters"]; ok {
+			if err := node.Decode(&shared); err != nil {
+				return nil, fmt.Errorf("invalid parameters of %s: %v", path, err)
+			}
+		}
+		for method, node := range items {
+			if method == "parameters" {
+				continue
+			}
+			var op operation
+			if err := node.Decode(&op); err != nil {
+				return nil, fmt.Errorf("invalid operation %s %s: %v", method, path, err)
+			}
+			op.Parameters = append(append([]parameter{}, shared...), op.Parameters...)
+			document.operations[strings.ToUpper(method)+" "+path] = &op
+		}
+	}
+	return &document, nil
+}
+
+// resolve follows the $ref of a schema
+func (s *spec) resolve(sc *schema) *schema {
+	for sc != nil && sc.Ref != "" {
+		sc = s.Components.Schemas[strings.TrimPrefix(sc.Ref, "#/components/schemas/")]
+	}
+	return sc
+}
+
+// validate checks a decoded JSON value against a schema and returns the
+// mismatches, located by their path in the document. Fields missing from
+// an object's properties are reported as undocumented unless the object
+// allows additional properties.
+func (s *spec) validate(at string, value interface{}, sc *schema) []string {
+	sc = s.resolve(sc)
+	if sc == nil {
+		return nil
+	}
+	if value == nil {
+		// Go encodes nil slices, maps and pointers without omitempty as null
+		return nil
+	}
+
+	var problems []string
+	for _, part := range sc.AllOf {
+		problems = append(problems, s.validate(at, value, part)...)
+	}
+	if len(sc.OneOf) > 0 {
+		matched := false
+		for _, alternative := range sc.OneOf {
+			if len(s.validate(at, value, alternative)) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			problems = append(problems, where(at)+": matches none of the oneOf schemas")
+		}
+	}
+
+	kind := sc.Type
+	if kind == "" && (len(sc.Properties) > 0 || sc.AdditionalProperties != nil) {
+		kind = "object"
+	}
+
+	switch kind {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return append(problems, where(at)+": want an object")
+		}
+		for _, name := range sc.Required {
+			if _, present := object[name]; !present {
+				problems = append(problems, join(at, name)+": required field is missing")
+			}
+		}
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			switch property, documented := sc.Properties[name]; {
+			case documented:
+				problems = append(problems, s.validate(join(at, name), object[name], property)...)
+			case sc.AdditionalProperties != nil:
+				problems = append(problems, s.validate(join(at, name), object[name], sc.AdditionalProperties)...)
+			case len(sc.Properties) > 0:
+				problems = append(problems, join(at, name)+": undocumented field")
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return append(problems, where(at)+": want an array")
+		}
+		for i, item := range items {
+			problems = append(problems, s.validate(fmt.Sprintf("%s[%d]", at, i), item, sc.Items)...)
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return append(problems, where(at)+": want a string")
+		}
+		if sc.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, text); err != nil {
+				problems = append(problems, where(at)+": want an RFC 3339 date-time")
+			}
+		}
+		if len(sc.Enum) > 0 && !inEnum(text, sc.Enum) {
+			problems = append(problems, fmt.Sprintf("%s: %q is not one of %v", where(at), text, sc.Enum))
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			return append(problems, where(at)+": want an integer")
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return append(problems, where(at)+": want a number")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return append(problems, where(at)+": want a boolean")
+		}
+	}
+	return problems
+}
+
+// where names a location in messages, the body itself being the empty one
+func where(at string) string {
+	if at == "" {
+		return "body"
+	}
+	return at
+}
+
+// join appends a field name to a location
+func join(at, name string) string {
+	if at == "" {
+		return name
+	}
+	return at + "." + name
+}
+
+// inEnum reports whether a string is one of the values of an enum
+func inEnum(text string, enum []interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == text {
+			return true
+		}
+	}
+	return false
+}
+
+// sample returns a value of a schema for a request: the example or first
+// enum value when there is one, a value of the type otherwise. Objects only
+// get their required fields.
+func (s *spec) sample(sc *schema) interface{} {
+	sc = s.resolve(sc)
+	if sc == nil {
+		return nil
+	}
+	if sc.Example != nil {
+		return sc.Example
+	}
+	if len(sc.Enum) > 0 {
+		return sc.Enum[0]
+	}
+
+	switch sc.Type {
+	case "object", "":
+		object := map[string]interface{}{}
+		for _, name := range sc.Required {
+			object[name] = s.sample(sc.Properties[name])
+		}
+		for _, part := range sc.AllOf {
+			if values, ok := s.sample(part).(map[string]interface{}); ok {
+				for name, value := range values {
+					object[name] = value
+				}
+			}
+		}
+		return object
+	case "array":
+		return []interface{}{}
+	case "integer", "number":
+		if sc.Minimum != nil {
+			return *sc.Minimum
+		}
+		return 1
+	case "boolean":
+		return false
+	}
+
+	switch sc.Format {
+	case "date-time":
+		return time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	case "date":
+		return time.Now().Format("2006-01-02")
+	case "email":
+		return "contract@example.com"
+	}
+	return "Contract check"
+}
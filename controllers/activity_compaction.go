@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"gotodolist/configs"
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// activityCompactionBatch caps the task days compacted per run, so a large
+// backlog is worked off over several runs
+const activityCompactionBatch = 1000
+
+// CompactionReport counts the activity entries rolled into daily summaries
+// and removed past their retention by a compaction, or that would be on a
+// dry run
+type CompactionReport struct {
+	DryRun    bool           `json:"dryRun"`
+	Summaries int64          `json:"summaries"` // Daily summaries written
+	Compacted int64          `json:"compacted"` // Entries rolled into them
+	Expired   int64          `json:"expired"`   // Entries older than ACTIVITY_RETENTION
+	Activity  CollectionSize `json:"activity"`  // Size of the activity collection afterwards, or as it is on a dry run
+}
+
+// CollectionSize is the storage used by a collection as reported by MongoDB
+type CollectionSize struct {
+	Name         string `json:"name"`
+	Documents    int64  `json:"documents"`
+	DataBytes    int64  `json:"dataBytes"`
+	StorageBytes int64  `json:"storageBytes"`
+	IndexBytes   int64  `json:"indexBytes"`
+}
+
+// RunCompaction compacts the activity history on demand and reports what
+// was compacted and removed, ?dryRun=true only reports what would be
+func (mc *MaintenanceController) RunCompaction(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	report, err := mc.Compact(ctx, c.Query("dryRun") == "true")
+	if err != nil {
+		mc.logger.Error("Failed to compact task activity: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to compact task activity",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// GetCollectionSizes returns the size of the collections holding user and
+// task data, largest first
+func (mc *MaintenanceController) GetCollectionSizes(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collections := map[string]*mongo.Collection{
+		"users": mc.userCollection,
+		"tasks": mc.taskCollection,
+	}
+	for name, collection := range mc.userData {
+		collections[name] = collection
+	}
+	for name, collection := range mc.taskData {
+		collections[name] = collection
+	}
+
+	sizes := []CollectionSize{}
+	for name, collection := range collections {
+		size, err := collectionSize(ctx, collection)
+		if err != nil {
+			mc.logger.Error("Failed to measure " + name + ": " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to measure collections",
+			})
+			return
+		}
+		size.Name = name
+		sizes = append(sizes, size)
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].StorageBytes != sizes[j].StorageBytes {
+			return sizes[i].StorageBytes > sizes[j].StorageBytes
+		}
+		return sizes[i].Name < sizes[j].Name
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sizes,
+	})
+}
+
+// CompactActivity is the activity-compaction background job
+func (mc *MaintenanceController) CompactActivity(ctx context.Context) error {
+	report, err := mc.Compact(ctx, false)
+	if err != nil {
+		return err
+	}
+	if report.Compacted > 0 || report.Expired > 0 {
+		mc.logger.Info(fmt.Sprintf("Compacted %d activity entries into %d daily summaries and removed %d expired ones, %d entries (%d bytes) left",
+			report.Compacted, report.Summaries, report.Expired, report.Activity.Documents, report.Activity.StorageBytes))
+	}
+	return nil
+}
+
+// Compact rolls the field changes of each task older than
+// ACTIVITY_COMPACT_AFTER into one summary per UTC day, then removes the
+// entries older than ACTIVITY_RETENTION. A summary is written over the
+// earliest entry it replaces before the others are removed, so an
+// interrupted run never counts a change twice. A dry run only counts the
+// entries, within the same batch limit as a run.
+func (mc *MaintenanceController) Compact(ctx context.Context, dryRun bool) (CompactionReport, error) {
+	report := CompactionReport{DryRun: dryRun}
+	now := time.Now()
+
+	if mc.compactAfter > 0 {
+		cursor, err := mc.activityCollection.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{
+				"field":     bson.M{"$exists": true, "$ne": ""},
+				"type":      bson.M{"$ne": models.ActivityDailySummary},
+				"createdAt": bson.M{"$lt": now.Add(-mc.compactAfter)},
+			}}},
+			{{Key: "$sort", Value: bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}}},
+			{{Key: "$group", Value: bson.M{
+				"_id": bson.M{
+					"task": "$task",
+					"user": "$user",
+					"day":  bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$createdAt"}},
+				},
+				"entries": bson.M{"$push": "$$ROOT"},
+				"count":   bson.M{"$sum": 1},
+			}}},
+			// A single change takes no less room as a summary
+			{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+			{{Key: "$limit", Value: activityCompactionBatch}},
+		}, options.Aggregate().SetAllowDiskUse(true))
+		if err != nil {
+			return report, err
+		}
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			var group struct {
+				Entries []models.TaskActivity `bson:"entries"`
+			}
+			if err := cursor.Decode(&group); err != nil {
+				return report, err
+			}
+			if !dryRun {
+				if err := mc.compactDay(ctx, group.Entries); err != nil {
+					return report, err
+				}
+			}
+			report.Summaries++
+			report.Compacted += int64(len(group.Entries))
+		}
+		if err := cursor.Err(); err != nil {
+			return report, err
+		}
+	}
+
+	if mc.retention > 0 {
+		expired := bson.M{"createdAt": bson.M{"$lt": now.Add(-mc.retention)}}
+		if dryRun {
+			count, err := mc.activityCollection.CountDocuments(ctx, expired)
+			if err != nil {
+				return report, err
+			}
+			report.Expired = count
+		} else {
+			result, err := mc.activityCollection.DeleteMany(ctx, expired)
+			if err != nil {
+				return report, err
+			}
+			report.Expired = result.DeletedCount
+		}
+	}
+
+	size, err := collectionSize(ctx, mc.activityCollection)
+	if err != nil {
+		return report, err
+	}
+	size.Name = "task_activity"
+	report.Activity = size
+	return report, nil
+}
+
+// compactDay replaces the entries of a task's day with their summary: for
+// each field, the value before its first change and after its last one
+func (mc *MaintenanceController) compactDay(ctx context.Context, entries []models.TaskActivity) error {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+
+	changes := []models.ActivityChange{}
+	fieldIndex := map[string]int{}
+	for _, entry := range entries {
+		i, ok := fieldIndex[entry.Field]
+		if !ok {
+			i = len(changes)
+			fieldIndex[entry.Field] = i
+			changes = append(changes, models.ActivityChange{Field: entry.Field, From: entry.From})
+		}
+		changes[i].To = entry.To
+		changes[i].Count++
+	}
+
+	first, last := entries[0], entries[len(entries)-1]
+	summary := models.TaskActivity{
+		ID:        first.ID,
+		Task:      first.Task,
+		User:      first.User,
+		Type:      models.ActivityDailySummary,
+		Changes:   changes,
+		CreatedAt: last.CreatedAt,
+	}
+	if _, err := mc.activityCollection.ReplaceOne(ctx, bson.M{"_id": first.ID}, summary); err != nil {
+		return err
+	}
+
+	ids := []primitive.ObjectID{}
+	for _, entry := range entries[1:] {
+		ids = append(ids, entry.ID)
+	}
+	_, err := mc.activityCollection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	return err
+}
+
+// EnsureIndexes creates the index used to find old activity entries
+func (mc *MaintenanceController) EnsureIndexes(ctx context.Context) error {
+	_, err := mc.activityCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "createdAt", Value: 1}},
+	})
+	return err
+}
+
+// collectionSize measures a collection with $collStats, as a heavy read.
+// The figures of a sharded collection are summed over its shards.
+func collectionSize(ctx context.Context, collection *mongo.Collection) (CollectionSize, error) {
+	size := CollectionSize{Name: collection.Name()}
+	cursor, err := configs.HeavyReads(collection).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$collStats", Value: bson.M{"storageStats": bson.M{}}}},
+	})
+	if err != nil {
+		return size, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		StorageStats struct {
+			Count          int64   `bson:"count"`
+			Size           float64 `bson:"size"`
+			StorageSize    float64 `bson:"storageSize"`
+			TotalIndexSize float64 `bson:"totalIndexSize"`
+		} `bson:"storageStats"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return size, err
+	}
+	for _, row := range rows {
+		size.Documents += row.StorageStats.Count
+		size.DataBytes += int64(row.StorageStats.Size)
+		size.StorageBytes += int64(row.StorageStats.StorageSize)
+		size.IndexBytes += int64(row.StorageStats.TotalIndexSize)
+	}
+	return size, nil
+}
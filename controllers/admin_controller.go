@@ -0,0 +1,646 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"gotodolist/configs"
+	"gotodolist/jobs"
+	"gotodolist/middleware"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AdminController handles operational and moderation endpoints reserved to admins
+type AdminController struct {
+	userCollection *mongo.Collection
+	taskCollection *mongo.Collection
+	userCache      *middleware.UserCache
+	scheduler      *jobs.Scheduler
+	logger         *utils.Logger
+
+	statsMu      sync.Mutex
+	stats        gin.H
+	statsExpires time.Time
+}
+
+// ActivationReport describes what deactivating or reactivating an account
+// would change, returned instead of the account on a dry run
+type ActivationReport struct {
+	DryRun   bool                `json:"dryRun"`
+	User     models.UserResponse `json:"user"`     // Account as it is now
+	Changed  bool                `json:"changed"`  // False when the account is already in that state
+	Sessions int                 `json:"sessions"` // Sessions a deactivation would end
+}
+
+// NewAdminController creates a new admin controller
+func NewAdminController(userCollection *mongo.Collection, taskCollection *mongo.Collection, userCache *middleware.UserCache, scheduler *jobs.Scheduler) *AdminController {
+	return &AdminController{
+		userCollection: userCollection,
+		taskCollection: taskCollection,
+		userCache:      userCache,
+		scheduler:      scheduler,
+		logger:         utils.GetLogger().Named("admin"),
+	}
+}
+
+// GetLogLevel returns the current log level of this instance
+func (ac *AdminController) GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"level":    utils.GetLogger().Level(),
+			"instance": utils.InstanceID(),
+		},
+	})
+}
+
+// UpdateLogLevel changes the log level of this instance without a restart
+func (ac *AdminController) UpdateLogLevel(c *gin.Context) {
+	var input struct {
+		Level string `json:"level" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	level, err := utils.ParseLogLevel(input.Level)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Level must be one of: debug, info, warning, error",
+		})
+		return
+	}
+
+	logger := utils.GetLogger()
+	previous := logger.Level()
+	logger.SetLevel(level)
+
+	// Logged as a warning so the change is visible at any level
+	user, _ := c.Get("user")
+	ac.logger.Warning("Log level changed from " + string(previous) + " to " + string(level) + " by " + user.(models.User).Username)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"level":    level,
+			"instance": utils.InstanceID(),
+		},
+	})
+}
+
+// GetSlowQueries returns how many MongoDB commands exceeded the slow query
+// threshold on this instance since it started
+func (ac *AdminController) GetSlowQueries(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"count":       configs.SlowQueryCount(),
+			"thresholdMs": configs.SlowQueryThreshold().Milliseconds(),
+			"instance":    utils.InstanceID(),
+		},
+	})
+}
+
+// GetSlowRequests returns how many requests exceeded the slow request
+// threshold on this instance since it started, with the p95 latency of
+// each route over its recent requests
+func (ac *AdminController) GetSlowRequests(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"count":       middleware.SlowRequestCount(),
+			"thresholdMs": middleware.SlowRequestThreshold().Milliseconds(),
+			"routes":      middleware.RouteLatencies(),
+			"instance":    utils.InstanceID(),
+		},
+	})
+}
+
+// GetJobs returns the health of the background jobs on this instance
+func (ac *AdminController) GetJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"healthy":  ac.scheduler.Healthy(),
+			"jobs":     ac.scheduler.Status(),
+			"instance": utils.InstanceID(),
+		},
+	})
+}
+
+// DeactivateUser suspends an account: the user can no longer log in and all
+// of their tokens are revoked, while their data is kept. ?dryRun=true only
+// reports the sessions that would end.
+func (ac *AdminController) DeactivateUser(c *gin.Context) {
+	ac.setUserActive(c, false)
+}
+
+// ReactivateUser lifts the suspension of an account, the user logs in again.
+// ?dryRun=true only reports whether the account is deactivated.
+func (ac *AdminController) ReactivateUser(c *gin.Context) {
+	ac.setUserActive(c, true)
+}
+
+// setUserActive deactivates or reactivates the user referenced by :id, or
+// reports what that would change on a dry run
+func (ac *AdminController) setUserActive(c *gin.Context, active bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid user ID format",
+		})
+		return
+	}
+
+	if !active && objectID == c.MustGet("userId").(primitive.ObjectID) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Admins cannot deactivate their own account",
+		})
+		return
+	}
+
+	if c.Query("dryRun") == "true" {
+		ac.previewUserActive(ctx, c, objectID, active)
+		return
+	}
+
+	update := bson.M{
+		"$unset": bson.M{"deactivatedAt": ""},
+		"$set":   bson.M{"updatedAt": time.Now()},
+	}
+	if !active {
+		// End the sessions and, through the token version, revoke access tokens
+		update = bson.M{
+			"$set": bson.M{
+				"deactivatedAt": time.Now(),
+				"updatedAt":     time.Now(),
+			},
+			"$unset": bson.M{"sessions": "", "refreshToken": "", "refreshTokenExpire": ""},
+			"$inc":   bson.M{"tokenVersion": 1},
+		}
+	}
+
+	var user models.User
+	err = ac.userCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": objectID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update user",
+		})
+		return
+	}
+	ac.userCache.Invalidate(user.ID)
+
+	admin := c.MustGet("user").(models.User)
+	if active {
+		ac.logger.Warning("User " + user.Username + " reactivated by " + admin.Username)
+	} else {
+		ac.logger.Warning("User " + user.Username + " deactivated by " + admin.Username)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    user.ToResponse(),
+	})
+}
+
+// previewUserActive answers a dry run of setUserActive with the account as
+// it is and what the change would do to it
+func (ac *AdminController) previewUserActive(ctx context.Context, c *gin.Context, objectID primitive.ObjectID, active bool) {
+	var user models.User
+	err := ac.userCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch user",
+		})
+		return
+	}
+
+	report := ActivationReport{
+		DryRun:  true,
+		User:    user.ToResponse(),
+		Changed: user.IsActive() != active,
+	}
+	if !active {
+		// Deactivation ends every session, the single refresh token of older
+		// versions included
+		report.Sessions = len(user.Sessions)
+		if user.RefreshToken != "" {
+			report.Sessions++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// ListUsers lists user accounts with filters and pagination, or exports all
+// matching users as CSV with format=csv
+func (ac *AdminController) ListUsers(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := bson.M{}
+
+	// Search by username or email
+	if search := c.Query("search"); search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(search), Options: "i"}
+		query["$or"] = bson.A{
+			bson.M{"username": pattern},
+			bson.M{"email": pattern},
+		}
+	}
+
+	switch role := c.Query("role"); role {
+	case "":
+	case models.RoleAdmin:
+		query["role"] = models.RoleAdmin
+	case models.RoleUser:
+		query["role"] = bson.M{"$ne": models.RoleAdmin}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Role must be one of: user, admin",
+		})
+		return
+	}
+
+	switch status := c.Query("status"); status {
+	case "":
+	case "active":
+		query["deactivatedAt"] = bson.M{"$exists": false}
+	case "deactivated":
+		query["deactivatedAt"] = bson.M{"$exists": true}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Status must be one of: active, deactivated",
+		})
+		return
+	}
+
+	for field, params := range map[string][2]string{
+		"createdAt":   {"registeredFrom", "registeredTo"},
+		"lastLoginAt": {"lastLoginFrom", "lastLoginTo"},
+	} {
+		dayRange, ok := dayRangeFilter(c, params[0], params[1])
+		if !ok {
+			return
+		}
+		if dayRange != nil {
+			query[field] = dayRange
+		}
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"createdAt": -1})
+
+	if c.Query("format") == "csv" {
+		ac.exportUsers(c, query, findOptions)
+		return
+	}
+
+	page, _ := strconv.Atoi(utils.GetQueryDefault(c, "page", "1"))
+	limit, _ := strconv.Atoi(utils.GetQueryDefault(c, "limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+
+	total, err := configs.HeavyReads(ac.userCollection).CountDocuments(ctx, query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count users",
+		})
+		return
+	}
+
+	users, err := ac.findUsers(ctx, query, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch users",
+		})
+		return
+	}
+
+	responses := make([]models.UserResponse, len(users))
+	for i := range users {
+		responses[i] = users[i].ToResponse()
+	}
+
+	totalPages := (int(total) + limit - 1) / limit
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"pagination": gin.H{
+			"total":      total,
+			"page":       page,
+			"limit":      limit,
+			"totalPages": totalPages,
+			"links":      utils.SetPaginationLinks(c, page, limit, totalPages),
+		},
+		"count": len(responses),
+		"data":  responses,
+	})
+}
+
+// exportUsers streams every user matching the query as a CSV attachment
+func (ac *AdminController) exportUsers(c *gin.Context, query bson.M, findOptions *options.FindOptions) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), exportTimeout)
+	defer cancel()
+
+	cursor, err := configs.HeavyReads(ac.userCollection).Find(ctx, query, findOptions.SetBatchSize(exportBatchSize))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch users",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	startExport(c, "text/csv; charset=utf-8", "users.csv")
+	header := []string{"id", "username", "email", "role", "status", "createdAt", "lastLoginAt"}
+	err = streamCSV(ctx, c, cursor, header, func(cursor *mongo.Cursor) ([]string, error) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, err
+		}
+		response := user.ToResponse()
+		status := "active"
+		if !user.IsActive() {
+			status = "deactivated"
+		}
+		return []string{
+			response.ID.Hex(),
+			response.Username,
+			response.Email,
+			response.Role,
+			status,
+			formatTime(&response.CreatedAt),
+			formatTime(response.LastLoginAt),
+		}, nil
+	})
+	if err != nil {
+		ac.logger.Error("Failed to stream user export: " + err.Error())
+	}
+}
+
+// findUsers runs a user query, a heavy read
+func (ac *AdminController) findUsers(ctx context.Context, query bson.M, findOptions *options.FindOptions) ([]models.User, error) {
+	cursor, err := configs.HeavyReads(ac.userCollection).Find(ctx, query, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// dayRangeFilter builds a date filter from two optional YYYY-MM-DD query
+// parameters, both days included. It returns nil when neither is set and
+// writes the error response when one is invalid.
+func dayRangeFilter(c *gin.Context, fromParam, toParam string) (bson.M, bool) {
+	dayRange := bson.M{}
+	for param, operator := range map[string]string{fromParam: "$gte", toParam: "$lt"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		day, err := time.ParseInLocation(utils.DayLayout, value, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   param + " must use the YYYY-MM-DD format",
+			})
+			return nil, false
+		}
+		if operator == "$lt" {
+			day = day.AddDate(0, 0, 1)
+		}
+		dayRange[operator] = day
+	}
+
+	if len(dayRange) == 0 {
+		return nil, true
+	}
+	return dayRange, true
+}
+
+// GetStats returns system-wide statistics. They are computed with a few
+// aggregations and cached per instance for ADMIN_STATS_TTL (1m by default).
+func (ac *AdminController) GetStats(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ac.statsMu.Lock()
+	defer ac.statsMu.Unlock()
+
+	if ac.stats == nil || time.Now().After(ac.statsExpires) {
+		stats, err := ac.computeStats(ctx)
+		if err != nil {
+			ac.logger.Error("Failed to compute stats: " + err.Error())
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to compute stats",
+			})
+			return
+		}
+
+		ttl, err := time.ParseDuration(utils.GetEnv("ADMIN_STATS_TTL", "1m"))
+		if err != nil {
+			ttl = time.Minute
+		}
+		ac.stats = stats
+		ac.statsExpires = time.Now().Add(ttl)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    ac.stats,
+	})
+}
+
+// computeStats runs the aggregations behind GetStats, as heavy reads
+func (ac *AdminController) computeStats(ctx context.Context) (gin.H, error) {
+	now := time.Now()
+	userCollection := configs.HeavyReads(ac.userCollection)
+	taskCollection := configs.HeavyReads(ac.taskCollection)
+
+	// User counts by status and role in a single pass
+	cursor, err := userCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":   nil,
+			"total": bson.M{"$sum": 1},
+			"deactivated": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$gt": bson.A{"$deactivatedAt", nil}}, 1, 0},
+			}},
+			"admins": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$eq": bson.A{"$role", models.RoleAdmin}}, 1, 0},
+			}},
+			"activeDay": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$gte": bson.A{"$lastLoginAt", now.AddDate(0, 0, -1)}}, 1, 0},
+			}},
+			"activeWeek": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$gte": bson.A{"$lastLoginAt", now.AddDate(0, 0, -7)}}, 1, 0},
+			}},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var userRows []struct {
+		Total       int64 `bson:"total"`
+		Deactivated int64 `bson:"deactivated"`
+		Admins      int64 `bson:"admins"`
+		ActiveDay   int64 `bson:"activeDay"`
+		ActiveWeek  int64 `bson:"activeWeek"`
+	}
+	if err := cursor.All(ctx, &userRows); err != nil {
+		return nil, err
+	}
+	users := gin.H{"total": 0, "active": 0, "deactivated": 0, "admins": 0}
+	activity := gin.H{"dailyActiveUsers": 0, "weeklyActiveUsers": 0}
+	if len(userRows) > 0 {
+		row := userRows[0]
+		users = gin.H{
+			"total":       row.Total,
+			"active":      row.Total - row.Deactivated,
+			"deactivated": row.Deactivated,
+			"admins":      row.Admins,
+		}
+		activity = gin.H{
+			"dailyActiveUsers":  row.ActiveDay,
+			"weeklyActiveUsers": row.ActiveWeek,
+		}
+	}
+
+	// Tasks created per day over the last two weeks, days without tasks included
+	from := utils.StartOfDay(now).AddDate(0, 0, -13)
+	cursor, err = taskCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"createdAt": bson.M{"$gte": from}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format":   "%Y-%m-%d",
+				"date":     "$createdAt",
+				"timezone": now.Format("-07:00"),
+			}},
+			"count": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var dayRows []struct {
+		Day   string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &dayRows); err != nil {
+		return nil, err
+	}
+	countByDay := map[string]int64{}
+	for _, row := range dayRows {
+		countByDay[row.Day] = row.Count
+	}
+	tasksPerDay := []gin.H{}
+	for day := from; !day.After(now); day = day.AddDate(0, 0, 1) {
+		key := day.Format(utils.DayLayout)
+		tasksPerDay = append(tasksPerDay, gin.H{"day": key, "count": countByDay[key]})
+	}
+
+	totalTasks, err := taskCollection.EstimatedDocumentCount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Storage usage as reported by MongoDB
+	var storage struct {
+		Collections int64   `bson:"collections"`
+		Objects     int64   `bson:"objects"`
+		DataSize    float64 `bson:"dataSize"`
+		StorageSize float64 `bson:"storageSize"`
+		IndexSize   float64 `bson:"indexSize"`
+	}
+	dbStats := ac.userCollection.Database().RunCommand(ctx, bson.D{{Key: "dbStats", Value: 1}},
+		options.RunCmd().SetReadPreference(configs.HeavyReadPreference()))
+	if err := dbStats.Decode(&storage); err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"users":    users,
+		"activity": activity,
+		"tasks": gin.H{
+			"total":  totalTasks,
+			"perDay": tasksPerDay,
+		},
+		"storage": gin.H{
+			"collections":  storage.Collections,
+			"documents":    storage.Objects,
+			"dataBytes":    int64(storage.DataSize),
+			"storageBytes": int64(storage.StorageSize),
+			"indexBytes":   int64(storage.IndexSize),
+		},
+		"slowQueries": configs.SlowQueryCount(),
+		"computedAt":  now,
+	}, nil
+}
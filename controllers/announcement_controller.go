@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AnnouncementController handles broadcast announcements
+type AnnouncementController struct {
+	collection *mongo.Collection
+}
+
+// NewAnnouncementController creates a new announcement controller
+func NewAnnouncementController(collection *mongo.Collection) *AnnouncementController {
+	return &AnnouncementController{
+		collection: collection,
+	}
+}
+
+// GetAnnouncements returns the announcements that have not expired, most
+// recent first. It is public so clients can show them before login.
+func (ac *AnnouncementController) GetAnnouncements(c *gin.Context) {
+	ac.listAnnouncements(c, bson.M{
+		"$or": bson.A{
+			bson.M{"expiresAt": bson.M{"$exists": false}},
+			bson.M{"expiresAt": bson.M{"$gt": time.Now()}},
+		},
+	})
+}
+
+// GetAllAnnouncements returns every announcement, expired ones included
+func (ac *AnnouncementController) GetAllAnnouncements(c *gin.Context) {
+	ac.listAnnouncements(c, bson.M{})
+}
+
+// listAnnouncements writes the announcements matching a filter
+func (ac *AnnouncementController) listAnnouncements(c *gin.Context, filter bson.M) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := ac.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch announcements",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	announcements := []models.Announcement{}
+	if err := cursor.All(ctx, &announcements); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse announcements",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(announcements),
+		"data":    announcements,
+	})
+}
+
+// CreateAnnouncement publishes a new announcement
+func (ac *AnnouncementController) CreateAnnouncement(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Title     string     `json:"title" binding:"required"`
+		Message   string     `json:"message" binding:"required"`
+		Severity  string     `json:"severity"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	announcement := models.NewAnnouncement(input.Title, input.Message, c.MustGet("userId").(primitive.ObjectID))
+	if input.Severity != "" {
+		if !models.IsAnnouncementSeverity(input.Severity) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Severity must be one of: info, warning, critical",
+			})
+			return
+		}
+		announcement.Severity = input.Severity
+	}
+	if input.ExpiresAt != nil {
+		if !input.ExpiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Expiry must be in the future",
+			})
+			return
+		}
+		announcement.ExpiresAt = input.ExpiresAt
+	}
+
+	result, err := ac.collection.InsertOne(ctx, announcement)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create announcement",
+		})
+		return
+	}
+
+	announcement.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    announcement,
+	})
+}
+
+// DeleteAnnouncement withdraws an announcement. ?dryRun=true only reports
+// whether it exists.
+func (ac *AnnouncementController) DeleteAnnouncement(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid announcement ID format",
+		})
+		return
+	}
+
+	report := DeletionReport{DryRun: c.Query("dryRun") == "true"}
+	if report.DryRun {
+		report.Deleted, err = ac.collection.CountDocuments(ctx, bson.M{"_id": objectID})
+	} else {
+		var result *mongo.DeleteResult
+		result, err = ac.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+		if err == nil {
+			report.Deleted = result.DeletedCount
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete announcement",
+		})
+		return
+	}
+	if report.Deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Announcement not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
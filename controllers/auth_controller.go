@@ -3,8 +3,13 @@ package controllers
 import (
 	"context"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"gotodolist/emails"
+	"gotodolist/middleware"
 	"gotodolist/models"
 	"gotodolist/utils"
 
@@ -12,20 +17,49 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // AuthController handles authentication-related operations
 type AuthController struct {
-	userCollection *mongo.Collection
-	logger         *utils.Logger
+	userCollection   *mongo.Collection
+	userCache        *middleware.UserCache
+	maxSessions      int
+	sessionLimitMode string
+	mailQueue        *MailQueue
+	resetURL         *url.URL // Page of the client where users choose a new password
+	verifyEmailURL   *url.URL // Page of the client confirming a new email address
+	logger           *utils.Logger
 }
 
-// NewAuthController creates a new auth controller
-func NewAuthController(userCollection *mongo.Collection) *AuthController {
+// NewAuthController creates a new auth controller, user changes invalidate
+// the given cache used by the auth middleware, and account emails are sent
+// through mailQueue. MAX_SESSIONS caps the concurrent sessions of each user (0,
+// the default, for no limit) and SESSION_LIMIT_MODE decides whether a login
+// beyond it ends the oldest session (evict, the default) or is rejected
+// (reject). Reset and email confirmation emails link to PASSWORD_RESET_URL
+// and EMAIL_VERIFY_URL when they are set.
+func NewAuthController(userCollection *mongo.Collection, userCache *middleware.UserCache, mailQueue *MailQueue) *AuthController {
+	maxSessions, err := strconv.Atoi(utils.GetEnv("MAX_SESSIONS", "0"))
+	if err != nil || maxSessions < 0 {
+		maxSessions = 0
+	}
+	mode := utils.GetEnv("SESSION_LIMIT_MODE", sessionLimitEvict)
+	if mode != sessionLimitReject {
+		mode = sessionLimitEvict
+	}
+
+	logger := utils.GetLogger().Named("auth")
 	return &AuthController{
-		userCollection: userCollection,
-		logger:         utils.GetLogger(),
+		userCollection:   userCollection,
+		userCache:        userCache,
+		maxSessions:      maxSessions,
+		sessionLimitMode: mode,
+		mailQueue:        mailQueue,
+		resetURL:         parseClientURL(logger, "PASSWORD_RESET_URL"),
+		verifyEmailURL:   parseClientURL(logger, "EMAIL_VERIFY_URL"),
+		logger:           logger,
 	}
 }
 
@@ -86,8 +120,12 @@ func (ac *AuthController) Register(c *gin.Context) {
 		return
 	}
 
-	// Create the user
+	// Create the user, unverified until they follow the link emailed to
+	// them, in the language of their browser when emails are written in it
 	user := models.NewUser(input.Username, input.Email, string(hashedPassword))
+	user.Language = emails.MatchLanguage(c.GetHeader("Accept-Language"))
+	verification, verificationToken := newEmailVerification()
+	user.EmailVerification = verification
 
 	result, err := ac.userCollection.InsertOne(ctx, user)
 	if err != nil {
@@ -101,6 +139,7 @@ func (ac *AuthController) Register(c *gin.Context) {
 
 	// Get the inserted ID
 	user.ID = result.InsertedID.(primitive.ObjectID)
+	ac.sendVerification(c, *user, verificationToken)
 
 	// Generate tokens and send response
 	if err := ac.sendTokenResponse(c, user); err != nil {
@@ -165,8 +204,33 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
+	if !user.IsActive() {
+		ac.logger.Warning("Login failed: Account deactivated for user: " + user.Email)
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Account is deactivated",
+		})
+		return
+	}
+
+	// Checked before the login becomes a session of its own
+	newDevice := ac.newDevice(user, c.Request.UserAgent())
+
+	// Record the login, it is only informational so a failure is not fatal
+	now := time.Now()
+	if _, err := ac.userCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"lastLoginAt": now}}); err != nil {
+		ac.logger.Warning("Failed to record login time: " + err.Error())
+	} else {
+		user.LastLoginAt = &now
+	}
+
 	// Generate tokens and send response
 	if err := ac.sendTokenResponse(c, &user); err != nil {
+		if err == errTooManySessions {
+			ac.logger.Warning("Login failed: Session limit reached for user: " + user.Email)
+			ac.tooManySessions(c)
+			return
+		}
 		ac.logger.Error("Login failed: Error sending token response: " + err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -175,10 +239,35 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
+	if newDevice && user.NotificationPrefs.Enabled(models.NotifyAlerts, models.ChannelEmail) {
+		sendEmail(ac.mailQueue, ac.logger, user, user.Email, emails.NewLogin, emails.Data{
+			"Time":      now,
+			"IPAddress": c.ClientIP(),
+			"UserAgent": c.Request.UserAgent(),
+		})
+	}
+
 	ac.logger.Success("User logged in successfully: " + user.Username + " (" + user.Email + ")")
 }
 
-// Logout handles user logout
+// newDevice reports whether a login of a user comes from a device they are
+// not logged in on, told apart by user agent. A user who never logged in
+// before has no device to compare with.
+func (ac *AuthController) newDevice(user models.User, userAgent string) bool {
+	if user.LastLoginAt == nil {
+		return false
+	}
+	now := time.Now()
+	for _, session := range user.Sessions {
+		if session.ExpiresAt.After(now) && session.UserAgent == truncate(userAgent, 200) {
+			return false
+		}
+	}
+	return true
+}
+
+// Logout handles user logout, ending the session of the access token or,
+// for tokens issued before sessions existed, every session
 func (ac *AuthController) Logout(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -194,15 +283,12 @@ func (ac *AuthController) Logout(c *gin.Context) {
 		return
 	}
 
-	// Clear refresh token in database
-	_, err := ac.userCollection.UpdateOne(
-		ctx,
-		bson.M{"_id": userID},
-		bson.M{"$set": bson.M{
-			"refreshToken":       nil,
-			"refreshTokenExpire": nil,
-		}},
-	)
+	// End the session in the database
+	update := bson.M{"$unset": bson.M{"sessions": "", "refreshToken": "", "refreshTokenExpire": ""}}
+	if sessionID, ok := c.Get("sessionId"); ok {
+		update = bson.M{"$pull": bson.M{"sessions": bson.M{"_id": sessionID}}}
+	}
+	_, err := ac.userCollection.UpdateOne(ctx, bson.M{"_id": userID}, update)
 
 	if err != nil {
 		ac.logger.Error("Logout failed: Error updating user record: " + err.Error())
@@ -212,6 +298,7 @@ func (ac *AuthController) Logout(c *gin.Context) {
 		})
 		return
 	}
+	ac.userCache.Invalidate(userID.(primitive.ObjectID))
 
 	ac.logger.Info("User logged out successfully: " + userID.(primitive.ObjectID).Hex())
 	c.JSON(http.StatusOK, gin.H{
@@ -241,11 +328,16 @@ func (ac *AuthController) RefreshToken(c *gin.Context) {
 	// Hash the provided token to check against database
 	hashedToken := utils.HashString(input.RefreshToken)
 
-	// Find user with matching refresh token that hasn't expired
+	// Find user with a session of the refresh token that hasn't expired,
+	// or the single refresh token of older versions
 	var user models.User
+	now := time.Now()
 	err := ac.userCollection.FindOne(ctx, bson.M{
-		"refreshToken":       hashedToken,
-		"refreshTokenExpire": bson.M{"$gt": time.Now()},
+		"$or": bson.A{
+			bson.M{"sessions": bson.M{"$elemMatch": bson.M{"tokenHash": hashedToken, "expiresAt": bson.M{"$gt": now}}}},
+			bson.M{"refreshToken": hashedToken, "refreshTokenExpire": bson.M{"$gt": now}},
+		},
+		"deactivatedAt": bson.M{"$exists": false},
 	}).Decode(&user)
 
 	if err != nil {
@@ -265,8 +357,29 @@ func (ac *AuthController) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// Generate new tokens and send response
-	if err := ac.sendTokenResponse(c, &user); err != nil {
+	// Rotate the refresh token of the session and send new tokens
+	session, refreshToken, err := ac.rotateSession(ctx, &user, hashedToken, c.Request.UserAgent())
+	switch err {
+	case nil:
+	case errSessionEnded:
+		ac.logger.Warning("Token refresh failed: Refresh token was already used")
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Invalid or expired refresh token",
+		})
+		return
+	case errTooManySessions:
+		ac.tooManySessions(c)
+		return
+	default:
+		ac.logger.Error("Token refresh failed: Error updating session: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to generate authentication tokens",
+		})
+		return
+	}
+	if err := ac.sendTokens(c, &user, session, refreshToken); err != nil {
 		ac.logger.Error("Token refresh failed: Error sending token response: " + err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -278,6 +391,101 @@ func (ac *AuthController) RefreshToken(c *gin.Context) {
 	ac.logger.Info("Tokens refreshed successfully for user: " + user.Username)
 }
 
+// ChangePassword replaces the password of the authenticated user and revokes
+// every access and refresh token issued so far, then returns fresh tokens
+func (ac *AuthController) ChangePassword(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Not authenticated",
+		})
+		return
+	}
+
+	var input struct {
+		CurrentPassword string `json:"currentPassword" binding:"required"`
+		NewPassword     string `json:"newPassword" binding:"required,min=6"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	var user models.User
+	if err := ac.userCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		ac.logger.Error("Password change failed: Database error while finding user")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to change password",
+		})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
+		ac.logger.Warning("Password change failed: Invalid current password for user: " + user.Username)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Current password is incorrect",
+		})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		ac.logger.Error("Password change failed: Password hashing error")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to change password",
+		})
+		return
+	}
+
+	// Bumping the token version invalidates all outstanding access tokens,
+	// and ending the sessions all refresh tokens
+	err = ac.userCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": user.ID},
+		bson.M{
+			"$set": bson.M{
+				"password":  string(hashedPassword),
+				"updatedAt": time.Now(),
+			},
+			"$inc":   bson.M{"tokenVersion": 1},
+			"$unset": bson.M{"sessions": "", "refreshToken": "", "refreshTokenExpire": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		ac.logger.Error("Password change failed: Database error while updating user: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to change password",
+		})
+		return
+	}
+	ac.userCache.Invalidate(user.ID)
+
+	// A new session replaces the ended ones
+	if err := ac.sendTokenResponse(c, &user); err != nil {
+		ac.logger.Error("Password change failed: Error sending token response: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Password changed, but failed to issue new tokens",
+		})
+		return
+	}
+
+	ac.logger.Info("Password changed for user: " + user.Username)
+}
+
 // GetMe retrieves the authenticated user's information
 func (ac *AuthController) GetMe(c *gin.Context) {
 	user, exists := c.Get("user")
@@ -300,6 +508,11 @@ func (ac *AuthController) GetMe(c *gin.Context) {
 		return
 	}
 
+	userObj, ok = ac.fullUser(c, userObj)
+	if !ok {
+		return
+	}
+
 	ac.logger.Debug("User retrieved their profile: " + userObj.Username)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -307,29 +520,226 @@ func (ac *AuthController) GetMe(c *gin.Context) {
 	})
 }
 
-// sendTokenResponse generates access and refresh tokens and sends the response
-func (ac *AuthController) sendTokenResponse(c *gin.Context, user *models.User) error {
+// GetNotificationPreferences returns which notification events are enabled on each channel
+func (ac *AuthController) GetNotificationPreferences(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Not authenticated",
+		})
+		return
+	}
+
+	userObj, ok := user.(models.User)
+	if !ok {
+		ac.logger.Error("GetNotificationPreferences failed: Type assertion error for user object")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get user data",
+		})
+		return
+	}
+
+	userObj, ok = ac.fullUser(c, userObj)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    userObj.NotificationPrefs.Resolved(),
+	})
+}
+
+// UpdateNotificationPreferences enables or disables notification events per channel.
+// Only the events and channels present in the body are changed.
+func (ac *AuthController) UpdateNotificationPreferences(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Generate access token
-	accessToken, err := utils.GenerateAccessToken(user.ID.Hex())
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Not authenticated",
+		})
+		return
+	}
+
+	var input models.NotificationPreferences
+	if err := c.ShouldBindJSON(&input); err != nil || len(input) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	updateSet := bson.M{
+		"updatedAt": time.Now(),
+	}
+	for event, channels := range input {
+		if !models.IsNotificationEvent(event) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Unknown notification event: " + event,
+			})
+			return
+		}
+		for channel, enabled := range channels {
+			if !models.IsNotificationChannel(channel) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"error":   "Unknown notification channel: " + channel,
+				})
+				return
+			}
+			updateSet["notificationPreferences."+event+"."+channel] = enabled
+		}
+	}
+
+	var user models.User
+	err := ac.userCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": updateSet},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
 	if err != nil {
-		return err
+		ac.logger.Error("Notification preferences update failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update notification preferences",
+		})
+		return
 	}
+	ac.userCache.Invalidate(user.ID)
 
-	// Generate refresh token
-	refreshToken, hashedRefreshToken, expireTime := utils.GenerateRefreshToken()
+	ac.logger.Info("Notification preferences updated for user: " + user.Username)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    user.NotificationPrefs.Resolved(),
+	})
+}
 
-	// Update user with new refresh token
-	update := bson.M{
-		"$set": bson.M{
-			"refreshToken":       hashedRefreshToken,
-			"refreshTokenExpire": expireTime,
-		},
+// UpdateLanguage sets the language of the emails sent to the authenticated
+// user. An empty language goes back to the default one.
+func (ac *AuthController) UpdateLanguage(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Not authenticated",
+		})
+		return
 	}
 
-	_, err = ac.userCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, update)
+	var input struct {
+		Language *string `json:"language" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+	if *input.Language != "" && !emails.IsLanguage(*input.Language) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Unsupported language, use one of: " + strings.Join(emails.Languages, ", "),
+		})
+		return
+	}
+
+	update := bson.M{"$set": bson.M{"language": *input.Language, "updatedAt": time.Now()}}
+	if *input.Language == "" {
+		update = bson.M{"$set": bson.M{"updatedAt": time.Now()}, "$unset": bson.M{"language": ""}}
+	}
+
+	var user models.User
+	err := ac.userCollection.FindOneAndUpdate(ctx, bson.M{"_id": userID}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		ac.logger.Error("Language update failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update language",
+		})
+		return
+	}
+	ac.userCache.Invalidate(user.ID)
+
+	ac.logger.Info("Language updated for user: " + user.Username)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    user.ToResponse(),
+	})
+}
+
+// fullUser returns the complete user document. In stateless auth mode the
+// user in the context only holds the token claims, so it is loaded here.
+func (ac *AuthController) fullUser(c *gin.Context, user models.User) (models.User, bool) {
+	if !c.GetBool("userFromToken") {
+		return user, true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var fullUser models.User
+	err := ac.userCollection.FindOne(ctx, bson.M{"_id": user.ID}).Decode(&fullUser)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "User not found",
+			})
+			return user, false
+		}
+		ac.logger.Error("Failed to load user: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get user data",
+		})
+		return user, false
+	}
+
+	return fullUser, true
+}
+
+// sendTokenResponse opens a session for the user and sends its tokens. It
+// returns errTooManySessions, without responding, when the user has
+// MAX_SESSIONS sessions and logins beyond it are rejected.
+func (ac *AuthController) sendTokenResponse(c *gin.Context, user *models.User) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	session, refreshToken, err := ac.openSession(ctx, user.ID, c.Request.UserAgent())
+	if err != nil {
+		return err
+	}
+	return ac.sendTokens(c, user, session, refreshToken)
+}
+
+// sendTokens generates an access token for a session and sends it with
+// the refresh token of the session
+func (ac *AuthController) sendTokens(c *gin.Context, user *models.User, session models.Session, refreshToken string) error {
+	// Generate access token
+	accessToken, err := utils.GenerateAccessToken(utils.AccessClaims{
+		UserID:       user.ID.Hex(),
+		Username:     user.Username,
+		Email:        user.Email,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
+		SessionID:    session.ID.Hex(),
+	})
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/emails"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RequestEmailChange starts changing the email address of the authenticated
+// user, which takes their password. A token confirming the new address is
+// emailed to it, and the account keeps its current address until the token
+// is sent to VerifyEmail. A new request replaces a pending one.
+func (ac *AuthController) RequestEmailChange(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Not authenticated",
+		})
+		return
+	}
+
+	var input struct {
+		Email    string `json:"email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	var user models.User
+	if err := ac.userCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		ac.logger.Error("Email change failed: Database error while finding user")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to change email",
+		})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		ac.logger.Warning("Email change failed: Invalid password for user: " + user.Username)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Password is incorrect",
+		})
+		return
+	}
+
+	if input.Email == user.Email {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "This is already your email address",
+		})
+		return
+	}
+	taken, ok := ac.emailTaken(ctx, c, input.Email)
+	if !ok {
+		return
+	}
+	if taken {
+		ac.logger.Warning("Email change failed: Email already in use: " + input.Email)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Email already in use",
+		})
+		return
+	}
+
+	token, hashedToken, expireTime := utils.GenerateEmailChangeToken()
+	_, err := ac.userCollection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": bson.M{
+		"emailChange": models.EmailChange{Email: input.Email, TokenHash: hashedToken, RequestedAt: time.Now(), ExpiresAt: expireTime},
+	}})
+	if err != nil {
+		ac.logger.Error("Email change failed: Database error while saving token: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to change email",
+		})
+		return
+	}
+
+	sendEmail(ac.mailQueue, ac.logger, user, input.Email, emails.EmailChange, emails.Data{
+		"Email":     input.Email,
+		"Link":      ac.verifyLink(c, token),
+		"Token":     token,
+		"ExpiresAt": expireTime,
+	})
+
+	ac.logger.Info("Email change requested for user: " + user.Username)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "A confirmation link has been sent to the new email address",
+	})
+}
+
+// VerifyEmail confirms an email address with a token emailed to it: the
+// address a user registered with, or a new address given to
+// RequestEmailChange. The account then uses the new address, and the old
+// one is told about the change.
+func (ac *AuthController) VerifyEmail(c *gin.Context) {
+	var input struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+	ac.confirmEmail(c, input.Token)
+}
+
+// confirmEmailChange moves a user to the new email address confirmed by a
+// token, the hash of which is given
+func (ac *AuthController) confirmEmailChange(ctx context.Context, c *gin.Context, hashedToken string) {
+	var user models.User
+	err := ac.userCollection.FindOne(ctx, bson.M{
+		"emailChange.tokenHash": hashedToken,
+		"emailChange.expiresAt": bson.M{"$gt": time.Now()},
+		"deactivatedAt":         bson.M{"$exists": false},
+	}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		ac.logger.Warning("Email verification failed: Invalid or expired confirmation token")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid or expired confirmation token",
+		})
+		return
+	}
+	if err != nil {
+		ac.logger.Error("Email verification failed: Database error while finding token: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to verify email",
+		})
+		return
+	}
+
+	// The address may have been registered since the change was requested
+	newEmail := user.EmailChange.Email
+	taken, ok := ac.emailTaken(ctx, c, newEmail)
+	if !ok {
+		return
+	}
+	if taken {
+		ac.logger.Warning("Email verification failed: Email already in use: " + newEmail)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Email already in use",
+		})
+		return
+	}
+
+	// The token is used up with the change, a second use finds nothing.
+	// Receiving it verified the new address too.
+	result, err := ac.userCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "emailChange.tokenHash": hashedToken},
+		bson.M{
+			"$set":   bson.M{"email": newEmail, "updatedAt": time.Now()},
+			"$unset": bson.M{"emailChange": "", "emailVerification": ""},
+		},
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Email already in use",
+		})
+		return
+	}
+	if err != nil {
+		ac.logger.Error("Email verification failed: Database error while updating user: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to verify email",
+		})
+		return
+	}
+	if result.MatchedCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid or expired confirmation token",
+		})
+		return
+	}
+	ac.userCache.Invalidate(user.ID)
+
+	sendEmail(ac.mailQueue, ac.logger, user, user.Email, emails.EmailChanged, emails.Data{"Email": newEmail})
+
+	ac.logger.Info("Email changed for user: " + user.Username + " (" + user.Email + " to " + newEmail + ")")
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Email address changed to " + newEmail,
+	})
+}
+
+// emailTaken reports whether an account uses an email address. On a
+// database error the response is written and ok is false.
+func (ac *AuthController) emailTaken(ctx context.Context, c *gin.Context, email string) (taken bool, ok bool) {
+	count, err := ac.userCollection.CountDocuments(ctx, bson.M{"email": email})
+	if err != nil {
+		ac.logger.Error("Failed to check existing users: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to check existing users",
+		})
+		return false, false
+	}
+	return count > 0, true
+}
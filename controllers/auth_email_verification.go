@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/emails"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newEmailVerification starts verifying the email address of a user,
+// returning the pending verification with the token to email them
+func newEmailVerification() (*models.EmailVerification, string) {
+	token, hashedToken, expireTime := utils.GenerateEmailVerificationToken()
+	return &models.EmailVerification{
+		TokenHash:   hashedToken,
+		RequestedAt: time.Now(),
+		ExpiresAt:   expireTime,
+	}, token
+}
+
+// sendVerification emails a user the link verifying their email address
+func (ac *AuthController) sendVerification(c *gin.Context, user models.User, token string) {
+	sendEmail(ac.mailQueue, ac.logger, user, user.Email, emails.VerifyEmail, emails.Data{
+		"Email":     user.Email,
+		"Link":      ac.verifyLink(c, token),
+		"ExpiresAt": user.EmailVerification.ExpiresAt,
+	})
+}
+
+// verifyLink returns the link confirming an email address with a token:
+// the EMAIL_VERIFY_URL page of the client when it is set, otherwise
+// GET /auth/verify-email/:token of the API itself
+func (ac *AuthController) verifyLink(c *gin.Context, token string) string {
+	if ac.verifyEmailURL != nil {
+		return tokenLink(ac.verifyEmailURL, token)
+	}
+	link := utils.RequestURL(c)
+	link.Path = "/auth/verify-email/" + token
+	link.RawQuery = ""
+	return link.String()
+}
+
+// VerifyEmailLink confirms an email address with the token of the link
+// emailed to it, like VerifyEmail does with the token in the body
+func (ac *AuthController) VerifyEmailLink(c *gin.Context) {
+	ac.confirmEmail(c, c.Param("token"))
+}
+
+// ResendVerification emails the authenticated user a new link verifying
+// their email address, the previous link no longer working. At most one
+// link is sent per minute.
+func (ac *AuthController) ResendVerification(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Not authenticated",
+		})
+		return
+	}
+
+	// Only a pending verification is replaced, unless it was sent moments ago
+	verification, token := newEmailVerification()
+	var user models.User
+	err := ac.userCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": userID, "emailVerification.requestedAt": bson.M{"$lte": time.Now().Add(-emailTokenInterval)}},
+		bson.M{"$set": bson.M{"emailVerification": verification}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		var current models.User
+		if err := ac.userCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&current); err == nil && current.EmailVerified() {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Email address is already verified",
+			})
+			return
+		}
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"success": false,
+			"error":   "A verification link was sent moments ago, wait a minute before asking for another",
+		})
+		return
+	}
+	if err != nil {
+		ac.logger.Error("Verification resend failed: Database error while saving token: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to send verification link",
+		})
+		return
+	}
+	ac.userCache.Invalidate(user.ID)
+
+	ac.sendVerification(c, user, token)
+
+	ac.logger.Info("Verification link sent again to user: " + user.Username)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "A verification link has been sent to " + user.Email,
+	})
+}
+
+// confirmEmail uses a token emailed to verify an address: the address a
+// user registered with, or the new address of an email change
+func (ac *AuthController) confirmEmail(c *gin.Context, token string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hashedToken := utils.HashString(token)
+	var user models.User
+	err := ac.userCollection.FindOneAndUpdate(ctx,
+		bson.M{"emailVerification.tokenHash": hashedToken, "emailVerification.expiresAt": bson.M{"$gt": time.Now()}},
+		bson.M{
+			"$set":   bson.M{"updatedAt": time.Now()},
+			"$unset": bson.M{"emailVerification": ""},
+		},
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		ac.confirmEmailChange(ctx, c, hashedToken)
+		return
+	}
+	if err != nil {
+		ac.logger.Error("Email verification failed: Database error while finding token: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to verify email",
+		})
+		return
+	}
+	ac.userCache.Invalidate(user.ID)
+
+	ac.logger.Info("Email verified for user: " + user.Username)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Email address " + user.Email + " verified",
+	})
+}
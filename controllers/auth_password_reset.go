@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/emails"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// emailTokenInterval is the least time between two emails carrying a reset
+// or verification token to the same user, so the endpoints cannot be used
+// to flood a mailbox
+const emailTokenInterval = time.Minute
+
+// ForgotPassword emails a password reset token to the active account with
+// the given email. It answers the same whether or not there is one, so it
+// cannot be used to find out which emails are registered.
+func (ac *AuthController) ForgotPassword(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+	requested := gin.H{
+		"success": true,
+		"message": "If an account exists for this email, a password reset link has been sent to it",
+	}
+
+	var user models.User
+	err := ac.userCollection.FindOne(ctx, bson.M{"email": input.Email, "deactivatedAt": bson.M{"$exists": false}}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		ac.logger.Warning("Password reset requested for an unknown email: " + input.Email)
+		c.JSON(http.StatusOK, requested)
+		return
+	}
+	if err != nil {
+		ac.logger.Error("Password reset failed: Database error while finding user: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to request password reset",
+		})
+		return
+	}
+
+	// The new token replaces any pending one, unless that one was sent
+	// moments ago
+	token, hashedToken, expireTime := utils.GeneratePasswordResetToken()
+	now := time.Now()
+	result, err := ac.userCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "passwordReset.requestedAt": bson.M{"$not": bson.M{"$gt": now.Add(-emailTokenInterval)}}},
+		bson.M{"$set": bson.M{"passwordReset": models.PasswordReset{TokenHash: hashedToken, RequestedAt: now, ExpiresAt: expireTime}}},
+	)
+	if err != nil {
+		ac.logger.Error("Password reset failed: Database error while saving token: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to request password reset",
+		})
+		return
+	}
+	if result.ModifiedCount == 0 {
+		ac.logger.Info("Password reset not sent again so soon for user: " + user.Email)
+		c.JSON(http.StatusOK, requested)
+		return
+	}
+
+	// Sent in the background, so the response time does not tell whether
+	// the account exists
+	sendEmail(ac.mailQueue, ac.logger, user, user.Email, emails.PasswordReset, emails.Data{
+		"Link":      tokenLink(ac.resetURL, token),
+		"Token":     token,
+		"ExpiresAt": expireTime,
+	})
+
+	ac.logger.Info("Password reset requested for user: " + user.Email)
+	c.JSON(http.StatusOK, requested)
+}
+
+// ResetPassword sets a new password with a reset token sent by
+// ForgotPassword. Like a password change, it ends every session and revokes
+// every access token; the user then logs in with the new password.
+func (ac *AuthController) ResetPassword(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"newPassword" binding:"required,min=6"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	// Tokens are checked before the costly password hashing
+	pending := bson.M{
+		"passwordReset.tokenHash": utils.HashString(input.Token),
+		"passwordReset.expiresAt": bson.M{"$gt": time.Now()},
+		"deactivatedAt":           bson.M{"$exists": false},
+	}
+	count, err := ac.userCollection.CountDocuments(ctx, pending)
+	if err != nil {
+		ac.logger.Error("Password reset failed: Database error while finding token: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to reset password",
+		})
+		return
+	}
+	if count == 0 {
+		ac.logger.Warning("Password reset failed: Invalid or expired reset token")
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid or expired reset token",
+		})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		ac.logger.Error("Password reset failed: Password hashing error")
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to reset password",
+		})
+		return
+	}
+
+	// The token is used up with the reset, a second use finds nothing
+	var user models.User
+	err = ac.userCollection.FindOneAndUpdate(ctx, pending,
+		bson.M{
+			"$set": bson.M{
+				"password":  string(hashedPassword),
+				"updatedAt": time.Now(),
+			},
+			"$inc":   bson.M{"tokenVersion": 1},
+			"$unset": bson.M{"passwordReset": "", "sessions": "", "refreshToken": "", "refreshTokenExpire": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid or expired reset token",
+		})
+		return
+	}
+	if err != nil {
+		ac.logger.Error("Password reset failed: Database error while updating user: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to reset password",
+		})
+		return
+	}
+	ac.userCache.Invalidate(user.ID)
+
+	sendEmail(ac.mailQueue, ac.logger, user, user.Email, emails.PasswordResetDone, nil)
+
+	ac.logger.Info("Password reset for user: " + user.Username)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password has been reset, log in with the new password",
+	})
+}
+
+// EnsureIndexes creates the indexes finding users by password reset, email
+// change and email verification token
+func (ac *AuthController) EnsureIndexes(ctx context.Context) error {
+	_, err := ac.userCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"passwordReset.tokenHash": 1}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.M{"emailChange.tokenHash": 1}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.M{"emailVerification.tokenHash": 1}, Options: options.Index().SetSparse(true)},
+	})
+	return err
+}
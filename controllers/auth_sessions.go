@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session limit modes, what a login beyond MAX_SESSIONS does
+const (
+	sessionLimitEvict  = "evict"  // End the oldest session
+	sessionLimitReject = "reject" // Refuse the login
+)
+
+// maxStoredSessions bounds the sessions kept per user without MAX_SESSIONS,
+// the oldest ones ending first
+const maxStoredSessions = 100
+
+var (
+	errTooManySessions = errors.New("too many active sessions")
+	errSessionEnded    = errors.New("session has ended")
+)
+
+// sessionLimit returns the most sessions a user keeps
+func (ac *AuthController) sessionLimit() int {
+	if ac.maxSessions > 0 {
+		return ac.maxSessions
+	}
+	return maxStoredSessions
+}
+
+// openSession starts a session for a user and returns it with its refresh
+// token. Expired sessions are dropped first; past the limit, the oldest
+// session ends, or errTooManySessions is returned when extra logins are
+// rejected. The single refresh token of older versions is dropped too.
+func (ac *AuthController) openSession(ctx context.Context, userID primitive.ObjectID, userAgent string) (models.Session, string, error) {
+	now := time.Now()
+	if _, err := ac.userCollection.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$pull": bson.M{"sessions": bson.M{"expiresAt": bson.M{"$lte": now}}}},
+	); err != nil {
+		return models.Session{}, "", err
+	}
+
+	refreshToken, hashedRefreshToken, expireTime := utils.GenerateRefreshToken()
+	session := models.Session{
+		ID:         primitive.NewObjectID(),
+		TokenHash:  hashedRefreshToken,
+		UserAgent:  truncate(userAgent, 200),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  expireTime,
+	}
+
+	// The sessions are kept oldest first, so slicing keeps the newest, and
+	// the check that the slot past the limit is free rejects atomically
+	filter := bson.M{"_id": userID}
+	if ac.sessionLimitMode == sessionLimitReject && ac.maxSessions > 0 {
+		filter["sessions."+strconv.Itoa(ac.maxSessions-1)] = bson.M{"$exists": false}
+	}
+	result, err := ac.userCollection.UpdateOne(ctx, filter, bson.M{
+		"$push":  bson.M{"sessions": bson.M{"$each": bson.A{session}, "$slice": -ac.sessionLimit()}},
+		"$unset": bson.M{"refreshToken": "", "refreshTokenExpire": ""},
+	})
+	if err != nil {
+		return models.Session{}, "", err
+	}
+	if result.MatchedCount == 0 {
+		return models.Session{}, "", errTooManySessions
+	}
+	ac.userCache.Invalidate(userID)
+	return session, refreshToken, nil
+}
+
+// rotateSession replaces the refresh token of the session it belongs to,
+// extending the session, and returns the session with its new token. A
+// refresh token of older versions, which has no session, starts one.
+func (ac *AuthController) rotateSession(ctx context.Context, user *models.User, hashedToken, userAgent string) (models.Session, string, error) {
+	var session models.Session
+	found := false
+	for _, candidate := range user.Sessions {
+		if candidate.TokenHash == hashedToken {
+			session, found = candidate, true
+		}
+	}
+	if !found {
+		return ac.openSession(ctx, user.ID, userAgent)
+	}
+
+	refreshToken, hashedRefreshToken, expireTime := utils.GenerateRefreshToken()
+	session.TokenHash = hashedRefreshToken
+	session.LastUsedAt = time.Now()
+	session.ExpiresAt = expireTime
+
+	// Only the first of concurrent refreshes with the same token wins
+	result, err := ac.userCollection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "sessions": bson.M{"$elemMatch": bson.M{"_id": session.ID, "tokenHash": hashedToken}}},
+		bson.M{"$set": bson.M{
+			"sessions.$.tokenHash":  session.TokenHash,
+			"sessions.$.lastUsedAt": session.LastUsedAt,
+			"sessions.$.expiresAt":  session.ExpiresAt,
+		}},
+	)
+	if err != nil {
+		return models.Session{}, "", err
+	}
+	if result.MatchedCount == 0 {
+		return models.Session{}, "", errSessionEnded
+	}
+	ac.userCache.Invalidate(user.ID)
+	return session, refreshToken, nil
+}
+
+// tooManySessions answers a login rejected by the session limit
+func (ac *AuthController) tooManySessions(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{
+		"success": false,
+		"error": fmt.Sprintf("This account is already logged in on %d devices, the most allowed. "+
+			"Log out on one of them, or wait for its session to expire, then log in again.", ac.maxSessions),
+	})
+}
+
+// truncate shortens a string to at most n bytes
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	return text[:n]
+}
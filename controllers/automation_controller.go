@@ -0,0 +1,681 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxAutomations caps the number of automations of a user
+const maxAutomations = 50
+
+// automationRunRetention is how long automation runs are kept
+const automationRunRetention = 30 * 24 * time.Hour
+
+// AutomationController manages user-defined automations and runs them on task
+// events. Changes made by automations do not trigger other automations, so
+// rules cannot loop.
+type AutomationController struct {
+	collection         *mongo.Collection
+	runCollection      *mongo.Collection
+	taskCollection     *mongo.Collection
+	goalCollection     *mongo.Collection
+	projectCollection  *mongo.Collection
+	tagCollection      *mongo.Collection
+	activityCollection *mongo.Collection
+	notifier           *NotificationController
+	logger             *utils.Logger
+}
+
+// NewAutomationController creates a new automation controller. Follow-up
+// tasks are counted on the goal and project they are created in.
+func NewAutomationController(collection *mongo.Collection, runCollection *mongo.Collection, taskCollection *mongo.Collection, goalCollection *mongo.Collection, projectCollection *mongo.Collection, tagCollection *mongo.Collection, activityCollection *mongo.Collection, notifier *NotificationController) *AutomationController {
+	return &AutomationController{
+		collection:         collection,
+		runCollection:      runCollection,
+		taskCollection:     taskCollection,
+		goalCollection:     goalCollection,
+		projectCollection:  projectCollection,
+		tagCollection:      tagCollection,
+		activityCollection: activityCollection,
+		notifier:           notifier,
+		logger:             utils.GetLogger().Named("automations"),
+	}
+}
+
+// automationInput is the body of an automation creation or replacement
+type automationInput struct {
+	Name       string                   `json:"name" binding:"required"`
+	Enabled    *bool                    `json:"enabled"` // Enabled by default
+	Trigger    models.AutomationTrigger `json:"trigger"`
+	Conditions struct {
+		TitleContains string   `json:"titleContains"`
+		Priority      string   `json:"priority"`
+		Goal          string   `json:"goal"`
+		HasDueDate    *bool    `json:"hasDueDate"`
+		Tags          []string `json:"tags"` // Normalized like task tags
+	} `json:"conditions"`
+	Actions []models.AutomationAction `json:"actions"`
+}
+
+// GetAutomations lists the authenticated user's automations
+func (ac *AutomationController) GetAutomations(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	cursor, err := ac.collection.Find(ctx, bson.M{"user": userID}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch automations",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	automations := []models.Automation{}
+	if err := cursor.All(ctx, &automations); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to decode automations",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(automations),
+		"data":    automations,
+	})
+}
+
+// GetAutomation returns one of the authenticated user's automations
+func (ac *AutomationController) GetAutomation(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	automation, ok := ac.findAutomation(ctx, c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    automation,
+	})
+}
+
+// CreateAutomation creates an automation for the authenticated user
+func (ac *AutomationController) CreateAutomation(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	automation, ok := bindAutomation(c)
+	if !ok {
+		return
+	}
+
+	count, err := ac.collection.CountDocuments(ctx, bson.M{"user": userID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count automations",
+		})
+		return
+	}
+	if count >= maxAutomations {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("An account can have at most %d automations", maxAutomations),
+		})
+		return
+	}
+
+	now := time.Now()
+	automation.User = userID.(primitive.ObjectID)
+	automation.CreatedAt = now
+	automation.UpdatedAt = now
+
+	result, err := ac.collection.InsertOne(ctx, automation)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create automation",
+		})
+		return
+	}
+	automation.ID = result.InsertedID.(primitive.ObjectID)
+	warnings := ac.notifier.QuotaWarnings(ctx, automation.User, "automations", "automations", count+1, maxAutomations)
+
+	c.JSON(http.StatusCreated, withWarnings(gin.H{
+		"success": true,
+		"data":    automation,
+	}, warnings))
+}
+
+// UpdateAutomation replaces the definition of an automation, keeping its run statistics
+func (ac *AutomationController) UpdateAutomation(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	existing, ok := ac.findAutomation(ctx, c)
+	if !ok {
+		return
+	}
+
+	automation, ok := bindAutomation(c)
+	if !ok {
+		return
+	}
+
+	var updated models.Automation
+	err := ac.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": existing.ID},
+		bson.M{"$set": bson.M{
+			"name":       automation.Name,
+			"enabled":    automation.Enabled,
+			"trigger":    automation.Trigger,
+			"conditions": automation.Conditions,
+			"actions":    automation.Actions,
+			"updatedAt":  time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update automation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+	})
+}
+
+// DeleteAutomation deletes an automation and its runs
+func (ac *AutomationController) DeleteAutomation(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	automation, ok := ac.findAutomation(ctx, c)
+	if !ok {
+		return
+	}
+
+	if _, err := ac.collection.DeleteOne(ctx, bson.M{"_id": automation.ID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete automation",
+		})
+		return
+	}
+	if _, err := ac.runCollection.DeleteMany(ctx, bson.M{"automation": automation.ID}); err != nil {
+		ac.logger.Warning("Failed to delete runs of automation " + automation.ID.Hex() + ": " + err.Error())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Automation deleted successfully",
+	})
+}
+
+// GetAutomationRuns lists the latest runs of an automation, newest first
+func (ac *AutomationController) GetAutomationRuns(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	automation, ok := ac.findAutomation(ctx, c)
+	if !ok {
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(100)
+	cursor, err := ac.runCollection.Find(ctx, bson.M{"automation": automation.ID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch automation runs",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	runs := []models.AutomationRun{}
+	if err := cursor.All(ctx, &runs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to decode automation runs",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(runs),
+		"data":    runs,
+	})
+}
+
+// EnsureIndexes creates the indexes used to find automations and to expire
+// and deduplicate their runs
+func (ac *AutomationController) EnsureIndexes(ctx context.Context) error {
+	_, err := ac.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user", Value: 1}, {Key: "trigger.event", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = ac.runCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "automation", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{
+			Keys:    bson.M{"createdAt": 1},
+			Options: options.Index().SetExpireAfterSeconds(int32(automationRunRetention.Seconds())),
+		},
+		{
+			Keys:    bson.M{"key": 1},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"key": bson.M{"$exists": true}}),
+		},
+	})
+	return err
+}
+
+// Dispatch runs the user's enabled automations matching a task event and
+// returns the task as left by their actions. Automation failures are logged
+// and recorded on the run, they never fail the request that caused the event.
+func (ac *AutomationController) Dispatch(ctx context.Context, event string, task models.Task) models.Task {
+	if ac == nil {
+		return task
+	}
+
+	cursor, err := ac.collection.Find(ctx, bson.M{"user": task.User, "enabled": true, "trigger.event": event})
+	if err != nil {
+		ac.logger.Error("Failed to fetch automations: " + err.Error())
+		return task
+	}
+	var automations []models.Automation
+	if err := cursor.All(ctx, &automations); err != nil {
+		ac.logger.Error("Failed to decode automations: " + err.Error())
+		return task
+	}
+
+	changed := false
+	for _, automation := range automations {
+		if !automation.Conditions.Matches(&task) {
+			continue
+		}
+		run := ac.run(ctx, automation, event, &task)
+		changed = changed || len(run.Actions) > 0
+		if _, err := ac.runCollection.InsertOne(ctx, run); err != nil {
+			ac.logger.Error("Failed to record automation run: " + err.Error())
+		}
+	}
+	if !changed {
+		return task
+	}
+
+	var updated models.Task
+	if err := ac.taskCollection.FindOne(ctx, bson.M{"_id": task.ID}).Decode(&updated); err != nil {
+		return task
+	}
+	return updated
+}
+
+// RunDueSoon runs the task_due_soon automations on the open tasks entering
+// their window. Each automation runs once per task and due date.
+func (ac *AutomationController) RunDueSoon(ctx context.Context) error {
+	cursor, err := ac.collection.Find(ctx, bson.M{"enabled": true, "trigger.event": models.TriggerTaskDueSoon})
+	if err != nil {
+		return err
+	}
+	var automations []models.Automation
+	if err := cursor.All(ctx, &automations); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, automation := range automations {
+		window := time.Duration(automation.Trigger.WithinHours) * time.Hour
+		cursor, err := ac.taskCollection.Find(ctx, bson.M{
+			"user":      automation.User,
+			"completed": false,
+			"dueDate":   bson.M{"$gte": now, "$lte": now.Add(window)},
+		})
+		if err != nil {
+			return err
+		}
+		var tasks []models.Task
+		if err := cursor.All(ctx, &tasks); err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			if !automation.Conditions.Matches(&task) {
+				continue
+			}
+
+			// The run is claimed before acting so another instance or a later
+			// tick does not run the automation on the same due date again
+			claim := models.AutomationRun{
+				Automation: automation.ID,
+				User:       automation.User,
+				Task:       task.ID,
+				Event:      models.TriggerTaskDueSoon,
+				Key:        fmt.Sprintf("%s:%s:%d", automation.ID.Hex(), task.ID.Hex(), task.DueDate.Unix()),
+				Actions:    []string{},
+				CreatedAt:  now,
+			}
+			result, err := ac.runCollection.InsertOne(ctx, claim)
+			if mongo.IsDuplicateKeyError(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			run := ac.run(ctx, automation, models.TriggerTaskDueSoon, &task)
+			_, err = ac.runCollection.UpdateOne(ctx, bson.M{"_id": result.InsertedID}, bson.M{"$set": bson.M{
+				"actions": run.Actions,
+				"error":   run.Error,
+			}})
+			if err != nil {
+				ac.logger.Error("Failed to record automation run: " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// run applies an automation's actions to a task, updating the task in place,
+// and returns the run to record. Actions stop at the first failure.
+func (ac *AutomationController) run(ctx context.Context, automation models.Automation, event string, task *models.Task) models.AutomationRun {
+	run := models.AutomationRun{
+		Automation: automation.ID,
+		User:       automation.User,
+		Task:       task.ID,
+		Event:      event,
+		Actions:    []string{},
+		CreatedAt:  time.Now(),
+	}
+	logger := ac.logger.With("automation", automation.ID.Hex()).With("task", task.ID.Hex())
+
+	for _, action := range automation.Actions {
+		if err := ac.apply(ctx, automation, action, task); err != nil {
+			logger.Error("Automation action " + action.Type + " failed: " + err.Error())
+			run.Error = action.Type + " failed"
+			break
+		}
+		run.Actions = append(run.Actions, action.Type)
+	}
+
+	_, err := ac.collection.UpdateOne(ctx, bson.M{"_id": automation.ID}, bson.M{
+		"$inc": bson.M{"runCount": 1},
+		"$set": bson.M{"lastRunAt": run.CreatedAt},
+	})
+	if err != nil {
+		logger.Warning("Failed to update automation statistics: " + err.Error())
+	}
+	return run
+}
+
+// apply performs one action on a task
+func (ac *AutomationController) apply(ctx context.Context, automation models.Automation, action models.AutomationAction, task *models.Task) error {
+	reason := "Automation: " + automation.Name
+
+	switch action.Type {
+	case models.ActionSetPriority:
+		if task.Priority == action.Priority {
+			return nil
+		}
+		if err := ac.setTaskField(ctx, task, "priority", task.Priority, action.Priority, reason); err != nil {
+			return err
+		}
+		task.Priority = action.Priority
+
+	case models.ActionSetDueDate:
+		dueDate := time.Now().Add(time.Duration(action.DueInHours) * time.Hour)
+		if err := ac.setTaskField(ctx, task, "dueDate", task.DueDate, dueDate, reason); err != nil {
+			return err
+		}
+		task.DueDate = &dueDate
+
+	case models.ActionCreateTask:
+		created := models.NewTask(expandTaskTitle(action.Title, task), task.User)
+		created.ID = primitive.NewObjectID()
+		created.Goal = task.Goal
+		created.Section = task.Section
+		created.Project = task.Project
+		if action.Priority != "" {
+			created.Priority = action.Priority
+		}
+		if action.DueInHours > 0 {
+			dueDate := time.Now().Add(time.Duration(action.DueInHours) * time.Hour)
+			created.DueDate = &dueDate
+		}
+		counters := taskCounters{goals: ac.goalCollection, projects: ac.projectCollection, tags: ac.tagCollection}
+		err := writeWithCounts(ctx, ac.taskCollection, counters, func(ctx context.Context) (countChanges, error) {
+			if _, err := ac.taskCollection.InsertOne(ctx, created); err != nil {
+				return nil, err
+			}
+			return taskChange(nil, created), nil
+		})
+		if err != nil {
+			return err
+		}
+		return recordActivity(ctx, ac.activityCollection, models.TaskActivity{
+			Task:   task.ID,
+			User:   task.User,
+			Type:   models.ActivityAutomationApplied,
+			To:     created.ID,
+			Reason: reason + " created a follow-up task",
+		})
+
+	case models.ActionNotify:
+		notification := models.NewNotification(task.User, models.NotifyReminders, expandTaskTitle(action.Title, task), expandTaskTitle(action.Message, task))
+		notification.Task = &task.ID
+		_, err := ac.notifier.NotifyUser(ctx, task.User, notification)
+		return err
+	}
+	return nil
+}
+
+// setTaskField changes a field of a task and records it in the task's activity
+func (ac *AutomationController) setTaskField(ctx context.Context, task *models.Task, field string, from, to interface{}, reason string) error {
+	_, err := ac.taskCollection.UpdateOne(ctx, bson.M{"_id": task.ID}, bson.M{"$set": bson.M{
+		field:       to,
+		"updatedAt": time.Now(),
+	}})
+	if err != nil {
+		return err
+	}
+	return recordActivity(ctx, ac.activityCollection, models.TaskActivity{
+		Task:   task.ID,
+		User:   task.User,
+		Type:   models.ActivityAutomationApplied,
+		Field:  field,
+		From:   from,
+		To:     to,
+		Reason: reason,
+	})
+}
+
+// expandTaskTitle replaces {{title}} in a template with the task title
+func expandTaskTitle(template string, task *models.Task) string {
+	return strings.ReplaceAll(template, "{{title}}", task.Title)
+}
+
+// findAutomation loads the automation of the :id parameter owned by the
+// authenticated user, answering the request when it cannot
+func (ac *AutomationController) findAutomation(ctx context.Context, c *gin.Context) (models.Automation, bool) {
+	var automation models.Automation
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return automation, false
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid automation ID",
+		})
+		return automation, false
+	}
+
+	err = ac.collection.FindOne(ctx, bson.M{"_id": id, "user": userID}).Decode(&automation)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Automation not found",
+		})
+		return automation, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch automation",
+		})
+		return automation, false
+	}
+	return automation, true
+}
+
+// bindAutomation reads and validates an automation from the request body,
+// answering the request when it is invalid
+func bindAutomation(c *gin.Context) (models.Automation, bool) {
+	var input automationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return models.Automation{}, false
+	}
+
+	if message := validateAutomation(input); message != "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   message,
+		})
+		return models.Automation{}, false
+	}
+
+	automation := models.Automation{
+		Name:    strings.TrimSpace(input.Name),
+		Enabled: input.Enabled == nil || *input.Enabled,
+		Trigger: input.Trigger,
+		Conditions: models.AutomationConditions{
+			TitleContains: input.Conditions.TitleContains,
+			Priority:      input.Conditions.Priority,
+			HasDueDate:    input.Conditions.HasDueDate,
+		},
+		Actions: input.Actions,
+	}
+	if input.Conditions.Goal != "" {
+		goalID, _ := primitive.ObjectIDFromHex(input.Conditions.Goal)
+		automation.Conditions.Goal = &goalID
+	}
+	if len(input.Conditions.Tags) > 0 {
+		automation.Conditions.Tags, _ = utils.NormalizeTags(input.Conditions.Tags)
+	}
+	if automation.Trigger.Event != models.TriggerTaskDueSoon {
+		automation.Trigger.WithinHours = 0
+	}
+	return automation, true
+}
+
+// validateAutomation returns why an automation is invalid, or an empty string
+func validateAutomation(input automationInput) string {
+	if strings.TrimSpace(input.Name) == "" {
+		return "Name is required"
+	}
+	if !models.IsAutomationTrigger(input.Trigger.Event) {
+		return "Trigger event must be one of: " + strings.Join(models.AutomationTriggers, ", ")
+	}
+	if input.Trigger.Event == models.TriggerTaskDueSoon && input.Trigger.WithinHours < 1 {
+		return "task_due_soon triggers need withinHours of at least 1"
+	}
+
+	if p := input.Conditions.Priority; p != "" && priorityRank[p] == 0 {
+		return "Priority must be one of: low, medium, high"
+	}
+	if input.Conditions.Goal != "" {
+		if _, err := primitive.ObjectIDFromHex(input.Conditions.Goal); err != nil {
+			return "Invalid goal ID format"
+		}
+	}
+	if _, ok := utils.NormalizeTags(input.Conditions.Tags); !ok {
+		return fmt.Sprintf("Tags must be at most %d words of letters, digits, - or _ up to 32 characters", utils.MaxTags)
+	}
+
+	if len(input.Actions) == 0 || len(input.Actions) > 10 {
+		return "An automation needs between 1 and 10 actions"
+	}
+	for _, action := range input.Actions {
+		if !models.IsAutomationAction(action.Type) {
+			return "Action type must be one of: " + strings.Join(models.AutomationActions, ", ")
+		}
+		switch action.Type {
+		case models.ActionSetPriority:
+			if priorityRank[action.Priority] == 0 {
+				return "set_priority needs a priority of low, medium or high"
+			}
+		case models.ActionSetDueDate:
+			if action.DueInHours < 0 {
+				return "set_due_date needs a positive dueInHours"
+			}
+		case models.ActionCreateTask:
+			if strings.TrimSpace(action.Title) == "" {
+				return "create_task needs a title"
+			}
+			if action.Priority != "" && priorityRank[action.Priority] == 0 {
+				return "Priority must be one of: low, medium, high"
+			}
+			if action.DueInHours < 0 {
+				return "create_task needs a positive dueInHours"
+			}
+		case models.ActionNotify:
+			if strings.TrimSpace(action.Title) == "" {
+				return "notify needs a title"
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,464 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gotodolist/authz"
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BoardController handles kanban board operations
+type BoardController struct {
+	collection     *mongo.Collection
+	taskCollection *mongo.Collection
+}
+
+// NewBoardController creates a new board controller
+func NewBoardController(collection *mongo.Collection, taskCollection *mongo.Collection) *BoardController {
+	return &BoardController{
+		collection:     collection,
+		taskCollection: taskCollection,
+	}
+}
+
+// GetBoard retrieves the board of the authenticated user with the tasks of each column
+func (bc *BoardController) GetBoard(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	board, err := bc.boardForUser(ctx, userID.(primitive.ObjectID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch board",
+		})
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "createdAt", Value: 1}})
+	cursor, err := bc.taskCollection.Find(ctx, bson.M{"user": userID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch tasks",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse tasks",
+		})
+		return
+	}
+
+	// Tasks without a known column are shown in the first column
+	tasksByColumn := map[string][]models.Task{}
+	for _, task := range tasks {
+		key := board.columnOf(task)
+		tasksByColumn[key] = append(tasksByColumn[key], task)
+	}
+
+	columns := []gin.H{}
+	for _, column := range board.Columns {
+		columnTasks := tasksByColumn[column.Key]
+		if columnTasks == nil {
+			columnTasks = []models.Task{}
+		}
+		open := countOpen(columnTasks)
+		columns = append(columns, gin.H{
+			"key":         column.Key,
+			"name":        column.Name,
+			"wipLimit":    column.WIPLimit,
+			"openCount":   open,
+			"wipExceeded": column.WIPLimit > 0 && open > column.WIPLimit,
+			"tasks":       columnTasks,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"id":      board.ID,
+			"columns": columns,
+		},
+	})
+}
+
+// UpdateColumns replaces the columns of the authenticated user's board
+func (bc *BoardController) UpdateColumns(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	var input struct {
+		Columns []models.BoardColumn `json:"columns" binding:"required,min=1"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "At least one column is required",
+		})
+		return
+	}
+
+	// Validate columns and derive missing keys from names
+	seen := map[string]bool{}
+	for i := range input.Columns {
+		column := &input.Columns[i]
+		column.Name = strings.TrimSpace(column.Name)
+		if column.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Every column needs a name",
+			})
+			return
+		}
+		if column.Key == "" {
+			column.Key = columnKey(column.Name)
+		}
+		if column.Key == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Column key is required for name: " + column.Name,
+			})
+			return
+		}
+		if seen[column.Key] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Duplicate column: " + column.Key,
+			})
+			return
+		}
+		if column.WIPLimit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "WIP limit must be a positive number",
+			})
+			return
+		}
+		seen[column.Key] = true
+	}
+
+	board, err := bc.boardForUser(ctx, userID.(primitive.ObjectID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch board",
+		})
+		return
+	}
+
+	// Refuse to drop columns that still hold tasks
+	for _, column := range board.Columns {
+		if seen[column.Key] {
+			continue
+		}
+		count, err := bc.taskCollection.CountDocuments(ctx, bson.M{"user": userID, "column": column.Key})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to count column tasks",
+			})
+			return
+		}
+		if count > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("Column %q still contains %d task(s), move them before removing it", column.Name, count),
+			})
+			return
+		}
+	}
+
+	var updatedBoard models.Board
+	err = bc.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": board.ID},
+		bson.M{"$set": bson.M{
+			"columns":   input.Columns,
+			"updatedAt": time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updatedBoard)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update board",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedBoard,
+	})
+}
+
+// MoveTask moves a task to a column at the given position
+func (bc *BoardController) MoveTask(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid task ID format",
+		})
+		return
+	}
+
+	var input struct {
+		Column   string `json:"column" binding:"required"`
+		Position *int   `json:"position"` // Defaults to the end of the column
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	if input.Position != nil && *input.Position < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Position must be a positive number",
+		})
+		return
+	}
+
+	var task models.Task
+	err = bc.taskCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Task not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch task",
+		})
+		return
+	}
+
+	// Check that the user may act on the task
+	if !authorize(c, authz.TaskUpdate, task.User, "Task not found", "Not authorized to move this task") {
+		return
+	}
+
+	board, err := bc.boardForUser(ctx, userID.(primitive.ObjectID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch board",
+		})
+		return
+	}
+
+	column, ok := board.Column(input.Column)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Unknown column: " + input.Column,
+		})
+		return
+	}
+
+	// Enforce the WIP limit when an open task enters the column
+	if column.WIPLimit > 0 && !task.Completed && board.columnOf(task) != column.Key {
+		open, err := bc.taskCollection.CountDocuments(ctx, board.columnFilter(userID, column.Key, bson.M{"completed": false}))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to count column tasks",
+			})
+			return
+		}
+		if open >= int64(column.WIPLimit) {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   fmt.Sprintf("WIP limit reached: column %q allows at most %d open task(s)", column.Name, column.WIPLimit),
+			})
+			return
+		}
+	}
+
+	// Place the task at the requested position, defaulting to the end of the column
+	others := board.columnFilter(userID, column.Key, bson.M{"_id": bson.M{"$ne": task.ID}})
+	count, err := bc.taskCollection.CountDocuments(ctx, others)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count column tasks",
+		})
+		return
+	}
+
+	position := int(count)
+	if input.Position != nil && *input.Position < position {
+		position = *input.Position
+	}
+
+	// Shift the tasks at or after the target position down by one
+	shift := board.columnFilter(userID, column.Key, bson.M{
+		"_id":      bson.M{"$ne": task.ID},
+		"position": bson.M{"$gte": position},
+	})
+	if _, err := bc.taskCollection.UpdateMany(ctx, shift, bson.M{"$inc": bson.M{"position": 1}}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to reorder column tasks",
+		})
+		return
+	}
+
+	var movedTask models.Task
+	err = bc.taskCollection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": task.ID},
+		bson.M{"$set": bson.M{
+			"column":    column.Key,
+			"position":  position,
+			"updatedAt": time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&movedTask)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to move task",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    movedTask,
+	})
+}
+
+// boardForUser returns the board of a user, creating the default board on first use
+func (bc *BoardController) boardForUser(ctx context.Context, userID primitive.ObjectID) (*kanbanBoard, error) {
+	board := models.NewBoard(userID)
+	err := bc.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"user": userID},
+		bson.M{"$setOnInsert": board},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(board)
+	if err != nil {
+		return nil, err
+	}
+	return &kanbanBoard{board}, nil
+}
+
+// kanbanBoard adds task placement helpers to a board
+type kanbanBoard struct {
+	*models.Board
+}
+
+// columnOf returns the column a task is displayed in
+func (b *kanbanBoard) columnOf(task models.Task) string {
+	if _, ok := b.Column(task.Column); ok {
+		return task.Column
+	}
+	return b.Columns[0].Key
+}
+
+// columnFilter builds a task query for a column, including tasks without a
+// known column when it is the first column
+func (b *kanbanBoard) columnFilter(userID interface{}, key string, extra bson.M) bson.M {
+	filter := bson.M{"user": userID}
+	for k, v := range extra {
+		filter[k] = v
+	}
+
+	if key != b.Columns[0].Key {
+		filter["column"] = key
+		return filter
+	}
+
+	known := make([]string, 0, len(b.Columns)-1)
+	for _, column := range b.Columns[1:] {
+		known = append(known, column.Key)
+	}
+	filter["column"] = bson.M{"$nin": known}
+	return filter
+}
+
+// countOpen counts the tasks that are not completed
+func countOpen(tasks []models.Task) int {
+	open := 0
+	for _, task := range tasks {
+		if !task.Completed {
+			open++
+		}
+	}
+	return open
+}
+
+// columnKey derives a column key from its name
+func columnKey(name string) string {
+	var b strings.Builder
+	lastDash := true
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastDash = false
+		} else if !lastDash {
+			b.WriteRune('-')
+			lastDash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,625 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// consistencySamples is the number of document IDs reported per check
+const consistencySamples = 10
+
+// ConsistencyReport lists what the consistency checks found and, when
+// Repaired is set, fixed
+type ConsistencyReport struct {
+	Repaired bool               `json:"repaired"`
+	Checks   []ConsistencyCheck `json:"checks"`
+}
+
+// ConsistencyCheck is the result of one consistency check
+type ConsistencyCheck struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Found       int64                `json:"found"`   // Documents breaking the check
+	Samples     []primitive.ObjectID `json:"samples"` // IDs of the first of them
+}
+
+// Total returns the number of documents the checks found
+func (r ConsistencyReport) Total() int64 {
+	var total int64
+	for _, check := range r.Checks {
+		total += check.Found
+	}
+	return total
+}
+
+// consistencyRule finds the documents breaking a rule and repairs them
+type consistencyRule struct {
+	name        string
+	description string
+	find        func(ctx context.Context) ([]primitive.ObjectID, error)
+	repair      func(ctx context.Context, ids []primitive.ObjectID) error
+}
+
+// GetConsistency runs the consistency checks and reports what they found,
+// without changing anything
+func (mc *MaintenanceController) GetConsistency(c *gin.Context) {
+	mc.respondConsistency(c, false)
+}
+
+// RepairConsistency runs the consistency checks and repairs what they found,
+// ?dryRun=true only reports what would be repaired
+func (mc *MaintenanceController) RepairConsistency(c *gin.Context) {
+	mc.respondConsistency(c, c.Query("dryRun") != "true")
+}
+
+// respondConsistency answers a consistency check request
+func (mc *MaintenanceController) respondConsistency(c *gin.Context, repair bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	report, err := mc.CheckConsistency(ctx, repair)
+	if err != nil {
+		mc.logger.Error("Failed to check data consistency: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to check data consistency",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// RunConsistencyCheck is the consistency-check background job. It only
+// reports what it finds unless CONSISTENCY_REPAIR is true.
+func (mc *MaintenanceController) RunConsistencyCheck(ctx context.Context) error {
+	repair := utils.GetEnv("CONSISTENCY_REPAIR", "false") == "true"
+	report, err := mc.CheckConsistency(ctx, repair)
+	if err != nil {
+		return err
+	}
+	for _, check := range report.Checks {
+		if check.Found == 0 {
+			continue
+		}
+		if repair {
+			mc.logger.Info(fmt.Sprintf("Repaired %d documents failing the %s consistency check", check.Found, check.Name))
+		} else {
+			mc.logger.Warning(fmt.Sprintf("%d documents fail the %s consistency check: %s", check.Found, check.Name, check.Description))
+		}
+	}
+	return nil
+}
+
+// CheckConsistency looks for broken references between the collections and
+// task counts that are out of sync, and repairs them when asked to. Orphans
+// of deleted users and tasks are left to Cleanup.
+func (mc *MaintenanceController) CheckConsistency(ctx context.Context, repair bool) (ConsistencyReport, error) {
+	report := ConsistencyReport{Repaired: repair, Checks: []ConsistencyCheck{}}
+
+	for _, rule := range mc.consistencyRules() {
+		ids, err := rule.find(ctx)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", rule.name, err)
+		}
+
+		check := ConsistencyCheck{
+			Name:        rule.name,
+			Description: rule.description,
+			Found:       int64(len(ids)),
+			Samples:     ids,
+		}
+		if len(check.Samples) > consistencySamples {
+			check.Samples = check.Samples[:consistencySamples]
+		}
+		report.Checks = append(report.Checks, check)
+
+		if repair && len(ids) > 0 {
+			if err := rule.repair(ctx, ids); err != nil {
+				return report, fmt.Errorf("%s: %w", rule.name, err)
+			}
+		}
+	}
+	return report, nil
+}
+
+// consistencyRules returns the checks whose collections are known, goals,
+// contexts, projects, tags, habits and habit_checkins being found in userData
+func (mc *MaintenanceController) consistencyRules() []consistencyRule {
+	tasks := mc.taskCollection
+	goals := mc.userData["goals"]
+	contexts := mc.userData["contexts"]
+	projects := mc.userData["projects"]
+	tags := mc.userData["tags"]
+	habits := mc.userData["habits"]
+	checkIns := mc.userData["habit_checkins"]
+
+	rules := []consistencyRule{}
+	if goals != nil {
+		rules = append(rules,
+			consistencyRule{
+				name:        "taskGoal",
+				description: "Tasks whose goal was deleted or belongs to another user",
+				find: func(ctx context.Context) ([]primitive.ObjectID, error) {
+					return aggregateIDs(ctx, tasks, mongo.Pipeline{
+						{{Key: "$match", Value: bson.M{"goal": bson.M{"$exists": true}}}},
+						{{Key: "$lookup", Value: bson.M{"from": goals.Name(), "localField": "goal", "foreignField": "_id", "as": "owner"}}},
+						{{Key: "$match", Value: bson.M{"$expr": bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$user", "$owner.user"}}}}}}},
+					})
+				},
+				repair: func(ctx context.Context, ids []primitive.ObjectID) error {
+					_, err := tasks.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$unset": bson.M{"goal": ""}})
+					return err
+				},
+			},
+			consistencyRule{
+				name:        "goalCounts",
+				description: "Goals whose stored task counts differ from their tasks",
+				find: func(ctx context.Context) ([]primitive.ObjectID, error) {
+					return staleGoalCounts(ctx, tasks, goals)
+				},
+				repair: func(ctx context.Context, ids []primitive.ObjectID) error {
+					for _, id := range ids {
+						goalID := id
+						if err := recountTaskCounts(ctx, tasks, taskCounters{goals: goals}, models.Task{Goal: &goalID}); err != nil {
+							return err
+						}
+					}
+					return nil
+				},
+			},
+		)
+	}
+
+	if projects != nil {
+		rules = append(rules,
+			consistencyRule{
+				name:        "taskProject",
+				description: "Tasks whose project was deleted or belongs to another user",
+				find: func(ctx context.Context) ([]primitive.ObjectID, error) {
+					return aggregateIDs(ctx, tasks, mongo.Pipeline{
+						{{Key: "$match", Value: bson.M{"project": bson.M{"$exists": true}}}},
+						{{Key: "$lookup", Value: bson.M{"from": projects.Name(), "localField": "project", "foreignField": "_id", "as": "owner"}}},
+						{{Key: "$match", Value: bson.M{"$expr": bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$user", "$owner.user"}}}}}}},
+					})
+				},
+				repair: func(ctx context.Context, ids []primitive.ObjectID) error {
+					_, err := tasks.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$unset": bson.M{"project": "", "section": ""}})
+					return err
+				},
+			},
+			consistencyRule{
+				name:        "taskSection",
+				description: "Tasks filed under a section their project no longer has",
+				find: func(ctx context.Context) ([]primitive.ObjectID, error) {
+					return aggregateIDs(ctx, tasks, mongo.Pipeline{
+						{{Key: "$match", Value: bson.M{"section": bson.M{"$exists": true}}}},
+						{{Key: "$lookup", Value: bson.M{"from": projects.Name(), "localField": "project", "foreignField": "_id", "as": "owner"}}},
+						{{Key: "$unwind", Value: bson.M{"path": "$owner", "preserveNullAndEmptyArrays": true}}},
+						{{Key: "$match", Value: bson.M{"$expr": bson.M{"$not": bson.A{bson.M{"$in": bson.A{"$section", bson.M{"$ifNull": bson.A{"$owner.sections._id", bson.A{}}}}}}}}}},
+					})
+				},
+				repair: func(ctx context.Context, ids []primitive.ObjectID) error {
+					_, err := tasks.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$unset": bson.M{"section": ""}})
+					return err
+				},
+			},
+			consistencyRule{
+				name:        "projectCounts",
+				description: "Projects whose stored task counts differ from their tasks",
+				find: func(ctx context.Context) ([]primitive.ObjectID, error) {
+					return staleProjectCounts(ctx, tasks, projects)
+				},
+				repair: func(ctx context.Context, ids []primitive.ObjectID) error {
+					cursor, err := projects.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"user": 1}))
+					if err != nil {
+						return err
+					}
+					var items []models.Project
+					if err := cursor.All(ctx, &items); err != nil {
+						return err
+					}
+					stale := []models.Task{}
+					for _, item := range items {
+						projectID := item.ID
+						stale = append(stale, models.Task{User: item.User, Project: &projectID})
+					}
+					return recountTaskCounts(ctx, tasks, taskCounters{projects: projects}, stale...)
+				},
+			},
+		)
+	}
+	if contexts != nil {
+		rules = append(rules,
+			consistencyRule{
+				name:        "taskContext",
+				description: "Tasks in a context their user no longer has",
+				find: func(ctx context.Context) ([]primitive.ObjectID, error) {
+					return aggregateIDs(ctx, tasks, mongo.Pipeline{
+						{{Key: "$match", Value: bson.M{"context": bson.M{"$exists": true, "$ne": ""}}}},
+						{{Key: "$lookup", Value: bson.M{
+							"from": contexts.Name(),
+							"let":  bson.M{"user": "$user", "name": "$context"},
+							"pipeline": mongo.Pipeline{
+								{{Key: "$match", Value: bson.M{"$expr": bson.M{"$and": bson.A{
+									bson.M{"$eq": bson.A{"$user", "$$user"}},
+									bson.M{"$eq": bson.A{"$name", "$$name"}},
+								}}}}},
+								{{Key: "$project", Value: bson.M{"_id": 1}}},
+							},
+							"as": "owner",
+						}}},
+						{{Key: "$match", Value: bson.M{"owner": bson.M{"$size": 0}}}},
+					})
+				},
+				repair: func(ctx context.Context, ids []primitive.ObjectID) error {
+					_, err := tasks.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$unset": bson.M{"context": ""}})
+					return err
+				},
+			},
+			consistencyRule{
+				name:        "contextCounts",
+				description: "Contexts whose stored task counts differ from their tasks",
+				find: func(ctx context.Context) ([]primitive.ObjectID, error) {
+					return staleContextCounts(ctx, tasks, contexts)
+				},
+				repair: func(ctx context.Context, ids []primitive.ObjectID) error {
+					cursor, err := contexts.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"user": 1, "name": 1}))
+					if err != nil {
+						return err
+					}
+					var items []models.Context
+					if err := cursor.All(ctx, &items); err != nil {
+						return err
+					}
+					stale := []models.Task{}
+					for _, item := range items {
+						stale = append(stale, models.Task{User: item.User, Context: item.Name})
+					}
+					return recountTaskCounts(ctx, tasks, taskCounters{contexts: contexts}, stale...)
+				},
+			},
+		)
+	}
+
+	if tags != nil {
+		rules = append(rules,
+			consistencyRule{
+				name:        "tagCounts",
+				description: "Tags whose stored task counts differ from their tasks, given as the tag or, when it has no document, one of its tasks",
+				find: func(ctx context.Context) ([]primitive.ObjectID, error) {
+					return staleTagCounts(ctx, tasks, tags)
+				},
+				repair: func(ctx context.Context, ids []primitive.ObjectID) error {
+					stale := []models.Task{}
+					cursor, err := tags.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+					if err != nil {
+						return err
+					}
+					var items []models.Tag
+					if err := cursor.All(ctx, &items); err != nil {
+						return err
+					}
+					for _, item := range items {
+						stale = append(stale, models.Task{User: item.User, Tags: []string{item.Name}})
+					}
+					cursor, err = tasks.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"user": 1, "tags": 1}))
+					if err != nil {
+						return err
+					}
+					var carriers []models.Task
+					if err := cursor.All(ctx, &carriers); err != nil {
+						return err
+					}
+					stale = append(stale, carriers...)
+					return recountTaskCounts(ctx, tasks, taskCounters{tags: tags}, stale...)
+				},
+			},
+		)
+	}
+
+	rules = append(rules,
+		consistencyRule{
+			name:        "taskDependencies",
+			description: "Tasks depending on tasks that were deleted",
+			find: func(ctx context.Context) ([]primitive.ObjectID, error) {
+				return aggregateIDs(ctx, tasks, mongo.Pipeline{
+					{{Key: "$match", Value: bson.M{"dependsOn.0": bson.M{"$exists": true}}}},
+					{{Key: "$lookup", Value: bson.M{"from": tasks.Name(), "localField": "dependsOn", "foreignField": "_id", "as": "dependencies"}}},
+					{{Key: "$match", Value: bson.M{"$expr": bson.M{"$lt": bson.A{bson.M{"$size": "$dependencies"}, bson.M{"$size": bson.M{"$setUnion": bson.A{"$dependsOn"}}}}}}}},
+				})
+			},
+			repair: func(ctx context.Context, ids []primitive.ObjectID) error {
+				return removeMissingDependencies(ctx, tasks, ids)
+			},
+		},
+		consistencyRule{
+			name:        "delegationRecipient",
+			description: "Tasks offered to users that were deleted",
+			find: func(ctx context.Context) ([]primitive.ObjectID, error) {
+				return aggregateIDs(ctx, tasks, mongo.Pipeline{
+					{{Key: "$match", Value: bson.M{"delegation.to": bson.M{"$exists": true}}}},
+					{{Key: "$lookup", Value: bson.M{"from": mc.userCollection.Name(), "localField": "delegation.to", "foreignField": "_id", "as": "recipient"}}},
+					{{Key: "$match", Value: bson.M{"recipient": bson.M{"$size": 0}}}},
+				})
+			},
+			repair: func(ctx context.Context, ids []primitive.ObjectID) error {
+				_, err := tasks.UpdateMany(ctx, bson.M{"_id": bson.M{"$in": ids}}, bson.M{"$unset": bson.M{"delegation": ""}})
+				return err
+			},
+		},
+	)
+
+	if habits != nil && checkIns != nil {
+		rules = append(rules, consistencyRule{
+			name:        "checkInHabit",
+			description: "Habit check-ins of habits that were deleted",
+			find: func(ctx context.Context) ([]primitive.ObjectID, error) {
+				return aggregateIDs(ctx, checkIns, mongo.Pipeline{
+					{{Key: "$lookup", Value: bson.M{"from": habits.Name(), "localField": "habit", "foreignField": "_id", "as": "owner"}}},
+					{{Key: "$match", Value: bson.M{"owner": bson.M{"$size": 0}}}},
+				})
+			},
+			repair: func(ctx context.Context, ids []primitive.ObjectID) error {
+				_, err := checkIns.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+				return err
+			},
+		})
+	}
+	return rules
+}
+
+// aggregateIDs runs a pipeline and returns the IDs of the documents it
+// outputs
+func aggregateIDs(ctx context.Context, collection *mongo.Collection, pipeline mongo.Pipeline) ([]primitive.ObjectID, error) {
+	pipeline = append(pipeline, bson.D{{Key: "$project", Value: bson.M{"_id": 1}}})
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	ids := []primitive.ObjectID{}
+	for cursor.Next(ctx) {
+		var row struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		ids = append(ids, row.ID)
+	}
+	return ids, cursor.Err()
+}
+
+// groupedTaskCounts counts the open and completed tasks per value of the
+// given group expression
+func groupedTaskCounts(ctx context.Context, taskCollection *mongo.Collection, match bson.M, group interface{}, each func(key bson.Raw, counts models.TaskCounts)) error {
+	cursor, err := taskCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       group,
+			"open":      bson.M{"$sum": bson.M{"$cond": bson.A{"$completed", 0, 1}}},
+			"completed": bson.M{"$sum": bson.M{"$cond": bson.A{"$completed", 1, 0}}},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var row struct {
+			Key       bson.Raw `bson:"_id"`
+			Open      int64    `bson:"open"`
+			Completed int64    `bson:"completed"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return err
+		}
+		each(row.Key, models.TaskCounts{Open: row.Open, Completed: row.Completed})
+	}
+	return cursor.Err()
+}
+
+// staleGoalCounts returns the goals whose stored task counts are wrong
+func staleGoalCounts(ctx context.Context, taskCollection *mongo.Collection, goalCollection *mongo.Collection) ([]primitive.ObjectID, error) {
+	actual := map[primitive.ObjectID]models.TaskCounts{}
+	err := groupedTaskCounts(ctx, taskCollection, bson.M{"goal": bson.M{"$exists": true}}, bson.M{"goal": "$goal"}, func(key bson.Raw, counts models.TaskCounts) {
+		if goalID, ok := key.Lookup("goal").ObjectIDOK(); ok {
+			actual[goalID] = counts
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := goalCollection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"taskCounts": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var goals []models.Goal
+	if err := cursor.All(ctx, &goals); err != nil {
+		return nil, err
+	}
+
+	stale := []primitive.ObjectID{}
+	for _, goal := range goals {
+		if goal.TaskCounts != actual[goal.ID] {
+			stale = append(stale, goal.ID)
+		}
+	}
+	return stale, nil
+}
+
+// staleContextCounts returns the contexts whose stored task counts are wrong
+func staleContextCounts(ctx context.Context, taskCollection *mongo.Collection, contextCollection *mongo.Collection) ([]primitive.ObjectID, error) {
+	type contextKey struct {
+		user primitive.ObjectID
+		name string
+	}
+	actual := map[contextKey]models.TaskCounts{}
+	err := groupedTaskCounts(ctx, taskCollection, bson.M{"context": bson.M{"$exists": true, "$ne": ""}}, bson.M{"user": "$user", "context": "$context"}, func(key bson.Raw, counts models.TaskCounts) {
+		userID, _ := key.Lookup("user").ObjectIDOK()
+		name, _ := key.Lookup("context").StringValueOK()
+		actual[contextKey{userID, name}] = counts
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := contextCollection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"user": 1, "name": 1, "taskCounts": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var contexts []models.Context
+	if err := cursor.All(ctx, &contexts); err != nil {
+		return nil, err
+	}
+
+	stale := []primitive.ObjectID{}
+	for _, item := range contexts {
+		if item.TaskCounts != actual[contextKey{item.User, item.Name}] {
+			stale = append(stale, item.ID)
+		}
+	}
+	return stale, nil
+}
+
+// staleProjectCounts returns the projects whose stored task counts are wrong
+func staleProjectCounts(ctx context.Context, taskCollection *mongo.Collection, projectCollection *mongo.Collection) ([]primitive.ObjectID, error) {
+	actual := map[primitive.ObjectID]models.TaskCounts{}
+	err := groupedTaskCounts(ctx, taskCollection, bson.M{"project": bson.M{"$exists": true}}, bson.M{"project": "$project"}, func(key bson.Raw, counts models.TaskCounts) {
+		if projectID, ok := key.Lookup("project").ObjectIDOK(); ok {
+			actual[projectID] = counts
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := projectCollection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"taskCounts": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var projects []models.Project
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+
+	stale := []primitive.ObjectID{}
+	for _, project := range projects {
+		if project.TaskCounts != actual[project.ID] {
+			stale = append(stale, project.ID)
+		}
+	}
+	return stale, nil
+}
+
+// staleTagCounts returns the tag documents whose stored task counts are
+// wrong, and a task of each tag that has no document
+func staleTagCounts(ctx context.Context, taskCollection *mongo.Collection, tagCollection *mongo.Collection) ([]primitive.ObjectID, error) {
+	type tagKey struct {
+		user primitive.ObjectID
+		name string
+	}
+	type tagCounts struct {
+		counts models.TaskCounts
+		task   primitive.ObjectID
+	}
+	cursor, err := taskCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"tags": bson.M{"$exists": true}}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       bson.M{"user": "$user", "tag": "$tags"},
+			"open":      bson.M{"$sum": bson.M{"$cond": bson.A{"$completed", 0, 1}}},
+			"completed": bson.M{"$sum": bson.M{"$cond": bson.A{"$completed", 1, 0}}},
+			"task":      bson.M{"$first": "$_id"},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Key struct {
+			User primitive.ObjectID `bson:"user"`
+			Tag  string             `bson:"tag"`
+		} `bson:"_id"`
+		Open      int64              `bson:"open"`
+		Completed int64              `bson:"completed"`
+		Task      primitive.ObjectID `bson:"task"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	actual := map[tagKey]tagCounts{}
+	for _, row := range rows {
+		actual[tagKey{row.Key.User, row.Key.Tag}] = tagCounts{models.TaskCounts{Open: row.Open, Completed: row.Completed}, row.Task}
+	}
+
+	cursor, err = tagCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var tags []models.Tag
+	if err := cursor.All(ctx, &tags); err != nil {
+		return nil, err
+	}
+
+	stale := []primitive.ObjectID{}
+	for _, tag := range tags {
+		key := tagKey{tag.User, tag.Name}
+		if tag.TaskCounts != actual[key].counts {
+			stale = append(stale, tag.ID)
+		}
+		delete(actual, key)
+	}
+	for _, missing := range actual {
+		stale = append(stale, missing.task)
+	}
+	return stale, nil
+}
+
+// removeMissingDependencies drops the dependencies on deleted tasks from the
+// given tasks
+func removeMissingDependencies(ctx context.Context, taskCollection *mongo.Collection, ids []primitive.ObjectID) error {
+	cursor, err := taskCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, options.Find().SetProjection(bson.M{"dependsOn": 1}))
+	if err != nil {
+		return err
+	}
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		values, err := taskCollection.Distinct(ctx, "_id", bson.M{"_id": bson.M{"$in": task.DependsOn}})
+		if err != nil {
+			return err
+		}
+		existing := []primitive.ObjectID{}
+		for _, value := range values {
+			if id, ok := value.(primitive.ObjectID); ok {
+				existing = append(existing, id)
+			}
+		}
+		if _, err := taskCollection.UpdateOne(ctx, bson.M{"_id": task.ID}, bson.M{"$pull": bson.M{"dependsOn": bson.M{"$nin": existing}}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxBulkTasks caps the task IDs of a bulk context or tag change
+const maxBulkTasks = 500
+
+// UpdateContextTasks sets the context on the tasks listed in "add" and
+// clears it from the tasks listed in "remove". A task has a single context,
+// so adding replaces the one it had. Tasks of other users and tasks in
+// "remove" without this context are left untouched.
+func (cc *ContextController) UpdateContextTasks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Add    []string `json:"add"`
+		Remove []string `json:"remove"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+	if len(input.Add)+len(input.Remove) == 0 || len(input.Add)+len(input.Remove) > maxBulkTasks {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("Between 1 and %d task IDs are required", maxBulkTasks),
+		})
+		return
+	}
+
+	add, ok := parseTaskIDs(c, input.Add)
+	if !ok {
+		return
+	}
+	remove, ok := parseTaskIDs(c, input.Remove)
+	if !ok {
+		return
+	}
+
+	item, ok := cc.findContext(ctx, c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	var added, removed int64
+	err := writeWithCounts(ctx, cc.taskCollection, taskCounters{contexts: cc.collection}, func(ctx context.Context) (countChanges, error) {
+		added, removed = 0, 0
+		changes := countChanges{}
+
+		// Added tasks are moved one by one, as each leaves its own context
+		for _, id := range add {
+			filter := bson.M{"_id": id, "user": item.User, "context": bson.M{"$ne": item.Name}}
+			_, changed, err := updateCounted(ctx, cc.taskCollection, filter, bson.M{"$set": bson.M{"context": item.Name, "updatedAt": now}})
+			if err == mongo.ErrNoDocuments {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			changes.merge(changed)
+			added++
+		}
+		if len(remove) > 0 {
+			modified, err := updateByCompletion(
+				ctx,
+				cc.taskCollection,
+				bson.M{"_id": bson.M{"$in": remove}, "user": item.User, "context": item.Name},
+				bson.M{"$unset": bson.M{"context": ""}, "$set": bson.M{"updatedAt": now}},
+			)
+			if err != nil {
+				return nil, err
+			}
+			changes.addCounts(countGroup{kind: contextGroup, user: item.User, name: item.Name}, modified, -1)
+			removed = modified.Open + modified.Completed
+		}
+		return changes, nil
+	})
+	if err != nil {
+		cc.logger.Error("Failed to update context tasks: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update context tasks",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"added":   added,
+			"removed": removed,
+		},
+	})
+}
+
+// MergeContext moves the tasks of a context to the context given as "into"
+// and deletes it. Tasks are moved before the context is deleted, so a merge
+// that fails half way can simply be retried.
+func (cc *ContextController) MergeContext(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Into string `json:"into" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+	intoID, err := primitive.ObjectIDFromHex(input.Into)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid context ID format",
+		})
+		return
+	}
+
+	item, ok := cc.findContext(ctx, c)
+	if !ok {
+		return
+	}
+	if intoID == item.ID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "A context cannot be merged into itself",
+		})
+		return
+	}
+
+	var into models.Context
+	err = cc.collection.FindOne(ctx, bson.M{"_id": intoID, "user": item.User}).Decode(&into)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Target context not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch context",
+		})
+		return
+	}
+
+	var moved models.TaskCounts
+	err = writeWithCounts(ctx, cc.taskCollection, taskCounters{contexts: cc.collection}, func(ctx context.Context) (countChanges, error) {
+		var err error
+		moved, err = updateByCompletion(
+			ctx,
+			cc.taskCollection,
+			bson.M{"user": item.User, "context": item.Name},
+			bson.M{"$set": bson.M{"context": into.Name, "updatedAt": time.Now()}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		changes := countChanges{}
+		changes.addCounts(countGroup{kind: contextGroup, user: item.User, name: item.Name}, moved, -1)
+		changes.addCounts(countGroup{kind: contextGroup, user: into.User, name: into.Name}, moved, 1)
+		return changes, nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to move context tasks",
+		})
+		return
+	}
+
+	if _, err := cc.collection.DeleteOne(ctx, bson.M{"_id": item.ID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete context",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"context": into,
+			"moved":   moved.Open + moved.Completed,
+		},
+	})
+}
+
+// parseTaskIDs parses a list of task IDs, writing the error response when
+// one is invalid
+func parseTaskIDs(c *gin.Context, ids []string) ([]primitive.ObjectID, bool) {
+	parsed := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid task ID format",
+			})
+			return nil, false
+		}
+		parsed = append(parsed, objectID)
+	}
+	return parsed, true
+}
@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gotodolist/authz"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxContexts caps the number of contexts of a user
+const maxContexts = 50
+
+// ContextController manages the GTD contexts of the authenticated user
+type ContextController struct {
+	collection     *mongo.Collection
+	taskCollection *mongo.Collection
+	notifier       *NotificationController
+	logger         *utils.Logger
+}
+
+// NewContextController creates a new context controller
+func NewContextController(collection *mongo.Collection, taskCollection *mongo.Collection, notifier *NotificationController) *ContextController {
+	return &ContextController{
+		collection:     collection,
+		taskCollection: taskCollection,
+		notifier:       notifier,
+		logger:         utils.GetLogger().Named("contexts"),
+	}
+}
+
+// GetContexts lists the authenticated user's contexts by name, each with its
+// number of open tasks
+func (cc *ContextController) GetContexts(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	cursor, err := cc.collection.Find(ctx, bson.M{"user": userID}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch contexts",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var contexts []models.Context
+	if err := cursor.All(ctx, &contexts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse contexts",
+		})
+		return
+	}
+
+	responses := []models.ContextResponse{}
+	for _, item := range contexts {
+		responses = append(responses, models.ContextResponse{
+			Context:   item,
+			OpenTasks: item.TaskCounts.Open,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(responses),
+		"data":    responses,
+	})
+}
+
+// CreateContext creates a context, the leading @ of its name is optional
+func (cc *ContextController) CreateContext(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	var input struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	name, ok := validContextName(c, input.Name)
+	if !ok {
+		return
+	}
+
+	count, err := cc.collection.CountDocuments(ctx, bson.M{"user": userID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count contexts",
+		})
+		return
+	}
+	if count >= maxContexts {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("An account can have at most %d contexts", maxContexts),
+		})
+		return
+	}
+
+	item := models.NewContext(name, userID.(primitive.ObjectID))
+	result, err := cc.collection.InsertOne(ctx, item)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Context already exists",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create context",
+		})
+		return
+	}
+
+	item.ID = result.InsertedID.(primitive.ObjectID)
+	warnings := cc.notifier.QuotaWarnings(ctx, item.User, "contexts", "contexts", count+1, maxContexts)
+
+	c.JSON(http.StatusCreated, withWarnings(gin.H{
+		"success": true,
+		"data":    item,
+	}, warnings))
+}
+
+// UpdateContext renames a context and the tasks using it
+func (cc *ContextController) UpdateContext(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	var input struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	name, ok := validContextName(c, input.Name)
+	if !ok {
+		return
+	}
+
+	item, ok := cc.findContext(ctx, c)
+	if !ok {
+		return
+	}
+
+	// The tasks follow in the same transaction, where the deployment has
+	// them, so no task count lands on the old name meanwhile
+	var updated models.Context
+	err := withTransaction(ctx, cc.collection.Database().Client(), func(ctx context.Context) error {
+		err := cc.collection.FindOneAndUpdate(
+			ctx,
+			bson.M{"_id": item.ID},
+			bson.M{"$set": bson.M{"name": name, "updatedAt": time.Now()}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&updated)
+		if err != nil {
+			return err
+		}
+		_, err = cc.taskCollection.UpdateMany(
+			ctx,
+			bson.M{"user": item.User, "context": item.Name},
+			bson.M{"$set": bson.M{"context": name}},
+		)
+		return err
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Context already exists",
+		})
+		return
+	}
+	if err != nil {
+		cc.logger.With("context", item.ID.Hex()).Error("Failed to rename context: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update context",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+	})
+}
+
+// DeleteContext deletes a context and removes it from its tasks
+func (cc *ContextController) DeleteContext(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	item, ok := cc.findContext(ctx, c)
+	if !ok {
+		return
+	}
+
+	// Tasks are kept, they only lose their context, in the same transaction
+	// where the deployment has them
+	err := withTransaction(ctx, cc.collection.Database().Client(), func(ctx context.Context) error {
+		if _, err := cc.collection.DeleteOne(ctx, bson.M{"_id": item.ID}); err != nil {
+			return err
+		}
+		_, err := cc.taskCollection.UpdateMany(
+			ctx,
+			bson.M{"user": item.User, "context": item.Name},
+			bson.M{"$unset": bson.M{"context": ""}},
+		)
+		return err
+	})
+	if err != nil {
+		cc.logger.With("context", item.ID.Hex()).Error("Failed to delete context: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete context",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{},
+	})
+}
+
+// EnsureIndexes creates the index keeping context names unique per user
+func (cc *ContextController) EnsureIndexes(ctx context.Context) error {
+	_, err := cc.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// findContext loads the context referenced by the :id parameter and checks
+// that it belongs to the authenticated user, writing the error response otherwise
+func (cc *ContextController) findContext(ctx context.Context, c *gin.Context) (*models.Context, bool) {
+	// Get user ID from context
+	_, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return nil, false
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid context ID format",
+		})
+		return nil, false
+	}
+
+	var item models.Context
+	err = cc.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&item)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Context not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch context",
+		})
+		return nil, false
+	}
+
+	// Check that the user may act on the context
+	if !authorize(c, authz.ContextManage, item.User, "Context not found", "Not authorized to access this context") {
+		return nil, false
+	}
+
+	return &item, true
+}
+
+// validContextName normalizes a context name, writing the error response
+// when it is invalid
+func validContextName(c *gin.Context, name string) (string, bool) {
+	name, ok := utils.NormalizeContext(name)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Context must be a name such as @home of at most 32 characters",
+		})
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DashboardController builds the overview shown on a user's dashboard
+type DashboardController struct {
+	taskCollection  *mongo.Collection
+	habitController *HabitController
+}
+
+// NewDashboardController creates a new dashboard controller
+func NewDashboardController(taskCollection *mongo.Collection, habitController *HabitController) *DashboardController {
+	return &DashboardController{
+		taskCollection:  taskCollection,
+		habitController: habitController,
+	}
+}
+
+// GetDashboard returns task counts and habit streak stats for the authenticated user
+func (dc *DashboardController) GetDashboard(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	now := time.Now()
+	endOfToday := utils.StartOfDay(now).AddDate(0, 0, 1)
+
+	// Count tasks by state
+	filters := map[string]bson.M{
+		"open":      {"user": userID, "completed": false},
+		"completed": {"user": userID, "completed": true},
+		"overdue":   {"user": userID, "completed": false, "dueDate": bson.M{"$lt": now}},
+		"dueToday":  {"user": userID, "completed": false, "dueDate": bson.M{"$gte": now, "$lt": endOfToday}, "startDate": notDeferred()},
+		"deferred":  {"user": userID, "completed": false, "startDate": bson.M{"$gt": now}},
+	}
+
+	taskCounts := gin.H{}
+	for name, filter := range filters {
+		count, err := dc.taskCollection.CountDocuments(ctx, filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to count tasks",
+			})
+			return
+		}
+		taskCounts[name] = count
+	}
+
+	habitStats, err := dc.habitController.StatsForUser(ctx, userID.(primitive.ObjectID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to compute habit stats",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"tasks":  taskCounts,
+			"habits": habitStats,
+		},
+	})
+}
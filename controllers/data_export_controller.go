@@ -0,0 +1,400 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"gotodolist/configs"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DataExportController handles data subject access requests: it compiles
+// everything stored about a user into a downloadable zip archive
+type DataExportController struct {
+	collection     *mongo.Collection
+	userCollection *mongo.Collection
+	userData       map[string]*mongo.Collection // Collections holding documents with a "user" field, keyed by file name
+	logger         *utils.Logger
+}
+
+// NewDataExportController creates a new data export controller. userData maps
+// the archive file name of each collection owned by users to the collection.
+func NewDataExportController(collection *mongo.Collection, userCollection *mongo.Collection, userData map[string]*mongo.Collection) *DataExportController {
+	return &DataExportController{
+		collection:     collection,
+		userCollection: userCollection,
+		userData:       userData,
+		logger:         utils.GetLogger().Named("exports"),
+	}
+}
+
+// RequestExport queues an export of the authenticated user's data. A pending
+// export is returned instead of queueing a second one.
+func (dc *DataExportController) RequestExport(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	var export models.DataExport
+	err := dc.collection.FindOne(ctx, bson.M{"user": userID, "status": models.ExportPending}).Decode(&export)
+	if err == mongo.ErrNoDocuments {
+		export = *models.NewDataExport(userID.(primitive.ObjectID))
+		export.RequestID = utils.RequestID(ctx)
+		var result *mongo.InsertOneResult
+		result, err = dc.collection.InsertOne(ctx, export)
+		if err == nil {
+			export.ID = result.InsertedID.(primitive.ObjectID)
+			dc.logger.With("requestId", export.RequestID).Info("Data export requested by user: " + export.User.Hex())
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to request data export",
+		})
+		return
+	}
+
+	c.Header("Location", "/auth/me/exports/"+export.ID.Hex())
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    dc.exportResponse(c, export),
+	})
+}
+
+// GetExports lists the authenticated user's exports, newest first
+func (dc *DataExportController) GetExports(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.M{"requestedAt": -1}).
+		SetProjection(bson.M{"archive": 0})
+	cursor, err := dc.collection.Find(ctx, bson.M{"user": userID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch data exports",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var exports []models.DataExport
+	if err := cursor.All(ctx, &exports); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to decode data exports",
+		})
+		return
+	}
+
+	responses := make([]gin.H, len(exports))
+	for i, export := range exports {
+		responses[i] = dc.exportResponse(c, export)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(responses),
+		"data":    responses,
+	})
+}
+
+// GetExport returns the status of an export, with its download URL once ready
+func (dc *DataExportController) GetExport(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	export, ok := dc.findExport(ctx, c, options.FindOne().SetProjection(bson.M{"archive": 0}))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dc.exportResponse(c, *export),
+	})
+}
+
+// DownloadExport sends the archive of a ready export
+func (dc *DataExportController) DownloadExport(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	export, ok := dc.findExport(ctx, c, options.FindOne())
+	if !ok {
+		return
+	}
+
+	if export.Status != models.ExportReady {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Data export is not ready",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="gotodolist-export-`+export.ID.Hex()+`.zip"`)
+	c.Data(http.StatusOK, "application/zip", export.Archive)
+}
+
+// ProcessExports compiles the archives of pending exports, it runs as a background job
+func (dc *DataExportController) ProcessExports(ctx context.Context) error {
+	cursor, err := dc.collection.Find(ctx, bson.M{"status": models.ExportPending}, options.Find().SetSort(bson.M{"requestedAt": 1}))
+	if err != nil {
+		return err
+	}
+	var exports []models.DataExport
+	if err := cursor.All(ctx, &exports); err != nil {
+		return err
+	}
+
+	ttl, err := time.ParseDuration(utils.GetEnv("DATA_EXPORT_TTL", "168h"))
+	if err != nil || ttl <= 0 {
+		ttl = 7 * 24 * time.Hour
+	}
+
+	for _, export := range exports {
+		// Logged under the ID of the request that asked for the export
+		logger := dc.logger.With("requestId", export.RequestID)
+		now := time.Now()
+		expiresAt := now.Add(ttl)
+		update := bson.M{"completedAt": now, "expiresAt": expiresAt}
+
+		archive, err := dc.buildArchive(ctx, export.User)
+		if err != nil {
+			if ctx.Err() != nil {
+				// Out of time, the export is picked up again by the next run
+				return err
+			}
+			logger.Error("Failed to build data export " + export.ID.Hex() + ": " + err.Error())
+			update["status"] = models.ExportFailed
+			update["error"] = "Failed to compile the export, please request a new one"
+		} else {
+			update["status"] = models.ExportReady
+			update["archive"] = archive
+			update["size"] = len(archive)
+		}
+
+		if _, err := dc.collection.UpdateOne(ctx, bson.M{"_id": export.ID}, bson.M{"$set": update}); err != nil {
+			return err
+		}
+		logger.Info("Data export " + export.ID.Hex() + " is " + update["status"].(string))
+	}
+	return nil
+}
+
+// EnsureIndexes creates the TTL index that removes expired exports
+func (dc *DataExportController) EnsureIndexes(ctx context.Context) error {
+	_, err := dc.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expiresAt": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// buildArchive writes the user's profile, sessions, owned documents and
+// log lines into a zip archive of JSON files
+func (dc *DataExportController) buildArchive(ctx context.Context, userID primitive.ObjectID) ([]byte, error) {
+	var user models.User
+	if err := dc.userCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return nil, err
+	}
+
+	files := map[string]interface{}{
+		"profile.json": gin.H{
+			"user":                    user.ToResponse(),
+			"notificationPreferences": user.NotificationPrefs.Resolved(),
+			"acceptedPolicies":        user.AcceptedPolicies,
+		},
+		"sessions.json": gin.H{
+			"lastLoginAt": user.LastLoginAt,
+			"sessions":    user.Sessions,
+		},
+	}
+
+	buffer := new(bytes.Buffer)
+	archive := zip.NewWriter(buffer)
+
+	// Owned documents are compressed as they are read rather than loaded
+	// collection by collection
+	for name, collection := range dc.userData {
+		if err := writeCollectionFile(ctx, archive, name+".json", collection, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, content := range files {
+		data, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		file, err := archive.Create(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := file.Write(data); err != nil {
+			return nil, err
+		}
+	}
+
+	logs, err := archive.Create("logs.txt")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeUserLogLines(logs, user); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// writeCollectionFile streams the documents of a user in a collection into a
+// JSON file of the archive
+func writeCollectionFile(ctx context.Context, archive *zip.Writer, name string, collection *mongo.Collection, userID primitive.ObjectID) error {
+	cursor, err := configs.HeavyReads(collection).Find(ctx, bson.M{"user": userID}, options.Find().SetBatchSize(exportBatchSize))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	return writeJSONArray(ctx, file, cursor, func(cursor *mongo.Cursor) (interface{}, error) {
+		var document bson.M
+		err := cursor.Decode(&document)
+		return document, err
+	}, func() error { return nil })
+}
+
+// writeUserLogLines copies the lines of this instance's log file that
+// mention the user's ID, username or email
+func writeUserLogLines(w io.Writer, user models.User) error {
+	file, err := os.Open(utils.GetEnv("LOG_FILE", "logs/app.log"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	needles := []string{user.ID.Hex(), user.Username, user.Email}
+	// With hashed logs the ID and email appear as their pseudonyms
+	for _, value := range []string{user.ID.Hex(), user.Email} {
+		if pseudonym, ok := utils.LogPseudonym(value); ok {
+			needles = append(needles, pseudonym)
+		}
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		for _, needle := range needles {
+			if strings.Contains(line, needle) {
+				if _, err := w.Write([]byte(line + "\n")); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// findExport loads an export of the authenticated user from the :id parameter
+func (dc *DataExportController) findExport(ctx context.Context, c *gin.Context, findOptions *options.FindOneOptions) (*models.DataExport, bool) {
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid export ID format",
+		})
+		return nil, false
+	}
+
+	var export models.DataExport
+	err = dc.collection.FindOne(ctx, bson.M{"_id": objectID, "user": c.MustGet("userId")}, findOptions).Decode(&export)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Data export not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch data export",
+		})
+		return nil, false
+	}
+
+	return &export, true
+}
+
+// exportResponse adds the absolute download URL to a ready export
+func (dc *DataExportController) exportResponse(c *gin.Context, export models.DataExport) gin.H {
+	response := gin.H{
+		"id":          export.ID,
+		"status":      export.Status,
+		"requestedAt": export.RequestedAt,
+	}
+	if export.CompletedAt != nil {
+		response["completedAt"] = export.CompletedAt
+		response["expiresAt"] = export.ExpiresAt
+	}
+	if export.Error != "" {
+		response["error"] = export.Error
+	}
+	if export.RequestID != "" {
+		response["requestId"] = export.RequestID
+	}
+	if export.Status == models.ExportReady {
+		download := utils.RequestURL(c)
+		download.Path = "/auth/me/exports/" + export.ID.Hex() + "/download"
+		download.RawQuery = ""
+		response["size"] = export.Size
+		response["downloadUrl"] = download.String()
+	}
+	return response
+}
@@ -0,0 +1,475 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gotodolist/authz"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DelegationController hands tasks over to other users: the owner offers a
+// task, and the recipient becomes its owner once they accept it
+type DelegationController struct {
+	taskCollection     *mongo.Collection
+	goalCollection     *mongo.Collection
+	contextCollection  *mongo.Collection
+	projectCollection  *mongo.Collection
+	tagCollection      *mongo.Collection
+	userCollection     *mongo.Collection
+	activityCollection *mongo.Collection
+	versionCollection  *mongo.Collection
+	notifier           *NotificationController
+	logger             *utils.Logger
+}
+
+// NewDelegationController creates a new delegation controller. An accepted
+// task leaves the goal, context, project and tags of its former owner, whose
+// task counts are refreshed.
+func NewDelegationController(taskCollection *mongo.Collection, goalCollection *mongo.Collection, contextCollection *mongo.Collection, projectCollection *mongo.Collection, tagCollection *mongo.Collection, userCollection *mongo.Collection, activityCollection *mongo.Collection, versionCollection *mongo.Collection, notifier *NotificationController) *DelegationController {
+	return &DelegationController{
+		taskCollection:     taskCollection,
+		goalCollection:     goalCollection,
+		contextCollection:  contextCollection,
+		projectCollection:  projectCollection,
+		tagCollection:      tagCollection,
+		userCollection:     userCollection,
+		activityCollection: activityCollection,
+		versionCollection:  versionCollection,
+		notifier:           notifier,
+		logger:             utils.GetLogger().Named("delegation"),
+	}
+}
+
+// OfferTask offers an open task to the registered user with the given email.
+// The task stays with its owner, showing the pending delegation, until the
+// recipient accepts or declines it.
+func (dc *DelegationController) OfferTask(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	var input struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	task, ok := dc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+	if task.Completed {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Completed tasks cannot be delegated",
+		})
+		return
+	}
+	if task.Delegation != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Task is already offered, withdraw the offer first",
+		})
+		return
+	}
+
+	var recipient models.User
+	err := dc.userCollection.FindOne(ctx, bson.M{
+		"email":         strings.TrimSpace(input.Email),
+		"deactivatedAt": bson.M{"$exists": false},
+	}).Decode(&recipient)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "No user with this email",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to find user",
+		})
+		return
+	}
+	if recipient.ID == task.User {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Tasks cannot be delegated to yourself",
+		})
+		return
+	}
+
+	delegation := models.Delegation{To: recipient.ID, Email: recipient.Email, OfferedAt: time.Now()}
+	var updated models.Task
+	err = dc.taskCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": task.ID, "delegation": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"delegation": delegation, "updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Task is already offered, withdraw the offer first",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delegate task",
+		})
+		return
+	}
+
+	owner := dc.username(ctx, task.User)
+	notification := models.NewNotification(recipient.ID, models.NotifyAssignments,
+		owner+" offered you a task",
+		fmt.Sprintf("%s wants to hand %q over to you. Accept or decline it from your delegations.", owner, task.Title))
+	notification.Task = &task.ID
+	if _, err := dc.notifier.Notify(ctx, recipient, notification); err != nil {
+		dc.logger.With("task", task.ID.Hex()).Error("Failed to notify delegation offer: " + err.Error())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+	})
+}
+
+// WithdrawOffer cancels the pending delegation of a task
+func (dc *DelegationController) WithdrawOffer(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	task, ok := dc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+	if task.Delegation == nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Task is not offered to anyone",
+		})
+		return
+	}
+
+	var updated models.Task
+	err := dc.taskCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": task.ID},
+		bson.M{"$unset": bson.M{"delegation": ""}, "$set": bson.M{"updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to withdraw delegation",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+	})
+}
+
+// GetDelegations lists the tasks offered to the authenticated user, oldest
+// offer first, with the user offering each
+func (dc *DelegationController) GetDelegations(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	cursor, err := dc.taskCollection.Find(ctx, bson.M{"delegation.to": userID},
+		options.Find().SetSort(bson.M{"delegation.offeredAt": 1}).SetLimit(100))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch delegations",
+		})
+		return
+	}
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse delegations",
+		})
+		return
+	}
+
+	offers := []gin.H{}
+	usernames := map[primitive.ObjectID]string{}
+	for _, task := range tasks {
+		if _, ok := usernames[task.User]; !ok {
+			usernames[task.User] = dc.username(ctx, task.User)
+		}
+		offers = append(offers, gin.H{
+			"task": task,
+			"from": gin.H{"id": task.User, "username": usernames[task.User]},
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    offers,
+	})
+}
+
+// AcceptDelegation makes the authenticated user the owner of a task offered
+// to them. The task lands in their inbox without the previous owner's goal,
+// context, dependencies or board placement, and the previous owner's tasks
+// stop depending on it.
+func (dc *DelegationController) AcceptDelegation(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	task, userID, ok := dc.offeredTask(ctx, c)
+	if !ok {
+		return
+	}
+
+	// The task leaves the groups of its owner and its tags move with it
+	counters := taskCounters{goals: dc.goalCollection, contexts: dc.contextCollection, projects: dc.projectCollection, tags: dc.tagCollection}
+	err := writeWithCounts(ctx, dc.taskCollection, counters, func(ctx context.Context) (countChanges, error) {
+		_, changes, err := updateCounted(ctx, dc.taskCollection,
+			bson.M{"_id": task.ID, "user": task.User, "delegation.to": userID},
+			bson.M{
+				"$set":   bson.M{"user": userID, "inbox": true, "position": 0, "updatedAt": time.Now()},
+				"$unset": bson.M{"delegation": "", "goal": "", "section": "", "context": "", "project": "", "dependsOn": "", "column": ""},
+			},
+		)
+		return changes, err
+	})
+	var updated models.Task
+	if err == nil {
+		err = dc.taskCollection.FindOne(ctx, bson.M{"_id": task.ID}).Decode(&updated)
+	}
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Delegation not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to accept delegation",
+		})
+		return
+	}
+
+	// The history follows the task to its new owner
+	for _, collection := range []*mongo.Collection{dc.activityCollection, dc.versionCollection} {
+		if _, err := collection.UpdateMany(ctx, bson.M{"task": task.ID}, bson.M{"$set": bson.M{"user": userID}}); err != nil {
+			dc.logger.With("task", task.ID.Hex()).Error("Failed to move task history: " + err.Error())
+		}
+	}
+	_, err = dc.taskCollection.UpdateMany(ctx, bson.M{"user": task.User, "dependsOn": task.ID}, bson.M{"$pull": bson.M{"dependsOn": task.ID}})
+	if err != nil {
+		dc.logger.With("task", task.ID.Hex()).Error("Failed to remove delegated dependency: " + err.Error())
+	}
+
+	dc.answerOffer(ctx, task, userID, true)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+	})
+}
+
+// DeclineDelegation turns down a task offered to the authenticated user,
+// which stays with its owner
+func (dc *DelegationController) DeclineDelegation(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	task, userID, ok := dc.offeredTask(ctx, c)
+	if !ok {
+		return
+	}
+
+	result, err := dc.taskCollection.UpdateOne(ctx,
+		bson.M{"_id": task.ID, "delegation.to": userID},
+		bson.M{"$unset": bson.M{"delegation": ""}, "$set": bson.M{"updatedAt": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to decline delegation",
+		})
+		return
+	}
+	if result.ModifiedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Delegation not found",
+		})
+		return
+	}
+
+	dc.answerOffer(ctx, task, userID, false)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Delegation declined",
+	})
+}
+
+// answerOffer records the recipient's answer in the task's activity and
+// notifies the user who offered it
+func (dc *DelegationController) answerOffer(ctx context.Context, task models.Task, recipientID primitive.ObjectID, accepted bool) {
+	activity := models.TaskActivity{
+		Task:  task.ID,
+		User:  task.User,
+		Actor: &recipientID,
+		Type:  models.ActivityDelegationDeclined,
+		Field: "user",
+		From:  task.User,
+		To:    recipientID,
+	}
+	answer := "declined"
+	if accepted {
+		activity.User = recipientID
+		activity.Type = models.ActivityDelegated
+		answer = "accepted"
+	}
+	if err := recordActivity(ctx, dc.activityCollection, activity); err != nil {
+		dc.logger.With("task", task.ID.Hex()).Error("Failed to record delegation: " + err.Error())
+	}
+
+	recipient := dc.username(ctx, recipientID)
+	notification := models.NewNotification(task.User, models.NotifyAssignments,
+		recipient+" "+answer+" your task",
+		fmt.Sprintf("%s %s %q.", recipient, answer, task.Title))
+	// A declined task is still the owner's to open
+	if !accepted {
+		notification.Task = &task.ID
+	}
+	if _, err := dc.notifier.NotifyUser(ctx, task.User, notification); err != nil {
+		dc.logger.With("task", task.ID.Hex()).Error("Failed to notify delegation answer: " + err.Error())
+	}
+}
+
+// ownedTask loads the task of the :id parameter owned by the authenticated
+// user, answering the request when it cannot
+func (dc *DelegationController) ownedTask(ctx context.Context, c *gin.Context) (models.Task, bool) {
+	var task models.Task
+
+	_, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return task, false
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid task ID format",
+		})
+		return task, false
+	}
+
+	err = dc.taskCollection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Task not found",
+		})
+		return task, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch task",
+		})
+		return task, false
+	}
+
+	if !authorize(c, authz.TaskDelegate, task.User, "Task not found", "Not authorized to delegate this task") {
+		return task, false
+	}
+	return task, true
+}
+
+// offeredTask loads the task of the :id parameter offered to the
+// authenticated user, answering the request when it cannot
+func (dc *DelegationController) offeredTask(ctx context.Context, c *gin.Context) (models.Task, primitive.ObjectID, bool) {
+	var task models.Task
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return task, primitive.NilObjectID, false
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid task ID format",
+		})
+		return task, primitive.NilObjectID, false
+	}
+
+	// Tasks not offered to the user answer like missing ones
+	err = dc.taskCollection.FindOne(ctx, bson.M{"_id": objectID, "delegation.to": userID}).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Delegation not found",
+		})
+		return task, primitive.NilObjectID, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch delegation",
+		})
+		return task, primitive.NilObjectID, false
+	}
+	return task, userID.(primitive.ObjectID), true
+}
+
+// username returns the username of a user, or "Someone" when it cannot be
+// loaded
+func (dc *DelegationController) username(ctx context.Context, userID primitive.ObjectID) string {
+	var user models.User
+	err := dc.userCollection.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{"username": 1})).Decode(&user)
+	if err != nil {
+		return "Someone"
+	}
+	return user.Username
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"net/http"
+
+	"gotodolist/emails"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailPreviewController renders the emails with example values, so that
+// their templates can be worked on without sending them. It is only
+// served in debug mode.
+type EmailPreviewController struct {
+	logger *utils.Logger
+}
+
+// NewEmailPreviewController creates a new email preview controller
+func NewEmailPreviewController() *EmailPreviewController {
+	return &EmailPreviewController{
+		logger: utils.GetLogger().Named("emails"),
+	}
+}
+
+// ListEmails lists the emails that can be previewed and their languages
+func (ec *EmailPreviewController) ListEmails(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"emails":    emails.Names,
+			"languages": emails.Languages,
+		},
+	})
+}
+
+// PreviewEmail renders an email in ?language (the default language when
+// unset) as the HTML page it shows as, or as plain text with
+// ?format=text. The subject is sent in the X-Email-Subject header.
+func (ec *EmailPreviewController) PreviewEmail(c *gin.Context) {
+	name := c.Param("name")
+	if !emails.IsName(name) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Email not found",
+		})
+		return
+	}
+	language := c.DefaultQuery("language", emails.DefaultLanguage)
+	if !emails.IsLanguage(language) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Unsupported language: " + language,
+		})
+		return
+	}
+
+	message, err := emails.Render(name, language, emails.Sample(name))
+	if err != nil {
+		ec.logger.Error("Failed to render " + name + " email: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to render email: " + err.Error(),
+		})
+		return
+	}
+
+	c.Header("X-Email-Subject", message.Subject)
+	if c.Query("format") == "text" {
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(message.Text))
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(message.HTML))
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/url"
+
+	"gotodolist/emails"
+	"gotodolist/models"
+	"gotodolist/utils"
+)
+
+// sendEmail writes an email to a user in their language, EMAIL_LANGUAGE
+// for users without one, and queues it to be sent to the given address
+func sendEmail(mailQueue *MailQueue, logger *utils.Logger, user models.User, to, name string, data emails.Data) {
+	language := user.Language
+	if language == "" {
+		language = utils.GetEnv("EMAIL_LANGUAGE", emails.DefaultLanguage)
+	}
+	if data == nil {
+		data = emails.Data{}
+	}
+	data["Username"] = user.Username
+
+	message, err := emails.Render(name, language, data)
+	if err != nil {
+		logger.Error("Failed to write " + name + " email: " + err.Error())
+		return
+	}
+	mailQueue.Send(to, name, message)
+}
+
+// tokenLink returns a page of the client with a token as its token query
+// parameter, or "" without a page, the email then carrying the token itself
+func tokenLink(page *url.URL, token string) string {
+	if page == nil {
+		return ""
+	}
+	link := *page
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// parseClientURL reads a setting holding a page of the client, nil when it
+// is not set or not an absolute URL
+func parseClientURL(logger *utils.Logger, setting string) *url.URL {
+	value := utils.GetEnv(setting, "")
+	if value == "" {
+		return nil
+	}
+	parsed, err := url.Parse(value)
+	if err != nil || !parsed.IsAbs() {
+		logger.Warning("Invalid " + setting + ", emails carry the token instead: " + value)
+		return nil
+	}
+	return parsed
+}
@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gotodolist/middleware"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EscalationController manages the automatic priority escalation of overdue
+// tasks: its per-user settings and the job applying them
+type EscalationController struct {
+	taskCollection     *mongo.Collection
+	userCollection     *mongo.Collection
+	activityCollection *mongo.Collection
+	notifier           *NotificationController
+	userCache          *middleware.UserCache
+	logger             *utils.Logger
+}
+
+// NewEscalationController creates a new escalation controller
+func NewEscalationController(taskCollection *mongo.Collection, userCollection *mongo.Collection, activityCollection *mongo.Collection, notifier *NotificationController, userCache *middleware.UserCache) *EscalationController {
+	return &EscalationController{
+		taskCollection:     taskCollection,
+		userCollection:     userCollection,
+		activityCollection: activityCollection,
+		notifier:           notifier,
+		userCache:          userCache,
+		logger:             utils.GetLogger().Named("escalation"),
+	}
+}
+
+// GetSettings returns the authenticated user's escalation settings with the default rules applied
+func (ec *EscalationController) GetSettings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	// Loaded from the database since stateless tokens do not carry the settings
+	var user models.User
+	err := ec.userCollection.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{"escalation": 1})).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get escalation settings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    user.Escalation.Resolved(),
+	})
+}
+
+// UpdateSettings replaces the authenticated user's escalation settings. An
+// empty rule list restores the default rules.
+func (ec *EscalationController) UpdateSettings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	var input models.EscalationSettings
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	thresholds := map[int]bool{}
+	for _, rule := range input.Rules {
+		if _, ok := priorityRank[rule.Priority]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid priority: " + rule.Priority,
+			})
+			return
+		}
+		if rule.OverdueHours < 1 || thresholds[rule.OverdueHours] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Each rule needs a distinct overdueHours of at least 1",
+			})
+			return
+		}
+		thresholds[rule.OverdueHours] = true
+	}
+
+	_, err := ec.userCollection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{
+		"escalation": input,
+		"updatedAt":  time.Now(),
+	}})
+	if err != nil {
+		ec.logger.Error("Escalation settings update failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update escalation settings",
+		})
+		return
+	}
+	ec.userCache.Invalidate(userID.(primitive.ObjectID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    input.Resolved(),
+	})
+}
+
+// Run escalates the overdue tasks of every user who enabled escalation. A task
+// is only ever raised to the priority of the largest threshold it passed, so
+// running again, or after the user lowered the priority back, is harmless
+// until the task passes the next threshold.
+func (ec *EscalationController) Run(ctx context.Context) error {
+	cursor, err := ec.userCollection.Find(ctx, bson.M{"escalation.enabled": true}, options.Find().SetProjection(bson.M{
+		"username":                1,
+		"escalation":              1,
+		"notificationPreferences": 1,
+		"holidays":                1,
+		"workSchedule":            1,
+	}))
+	if err != nil {
+		return err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if err := ec.escalateUser(ctx, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escalateUser applies a user's rules to their open overdue tasks. Users who
+// set a work schedule are left alone outside their working hours and on
+// their holidays; their tasks are escalated once they are back.
+func (ec *EscalationController) escalateUser(ctx context.Context, user models.User) error {
+	settings := user.Escalation.Resolved()
+	if len(settings.Rules) == 0 {
+		return nil
+	}
+	if user.WorkSchedule != nil && !newWorkCalendar(user).InHours(time.Now()) {
+		return nil
+	}
+
+	now := time.Now()
+	first := time.Duration(settings.Rules[0].OverdueHours) * time.Hour
+	cursor, err := ec.taskCollection.Find(ctx, bson.M{
+		"user":      user.ID,
+		"completed": false,
+		"dueDate":   bson.M{"$lte": now.Add(-first)},
+	})
+	if err != nil {
+		return err
+	}
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		rule, ok := settings.Target(now.Sub(*task.DueDate))
+		if !ok || priorityRank[rule.Priority] <= priorityRank[task.Priority] {
+			continue
+		}
+		if err := ec.escalate(ctx, user, task, rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escalate raises a task's priority, records it in the task's activity and
+// notifies its owner
+func (ec *EscalationController) escalate(ctx context.Context, user models.User, task models.Task, rule models.EscalationRule) error {
+	// Matching the priority read earlier leaves concurrent edits untouched
+	result, err := ec.taskCollection.UpdateOne(ctx,
+		bson.M{"_id": task.ID, "priority": task.Priority, "completed": false},
+		bson.M{"$set": bson.M{"priority": rule.Priority, "updatedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return nil
+	}
+
+	reason := fmt.Sprintf("Overdue by more than %d hours", rule.OverdueHours)
+	err = recordActivity(ctx, ec.activityCollection, models.TaskActivity{
+		Task:   task.ID,
+		User:   user.ID,
+		Type:   models.ActivityPriorityEscalated,
+		Field:  "priority",
+		From:   task.Priority,
+		To:     rule.Priority,
+		Reason: reason,
+	})
+	if err != nil {
+		ec.logger.Error("Failed to record escalation of task " + task.ID.Hex() + ": " + err.Error())
+	}
+
+	notification := models.NewNotification(user.ID, models.NotifyReminders,
+		"Task escalated to "+rule.Priority+" priority",
+		fmt.Sprintf("%q is overdue by more than %d hours and was raised from %s to %s priority.", task.Title, rule.OverdueHours, task.Priority, rule.Priority),
+	)
+	notification.Task = &task.ID
+	if _, err := ec.notifier.Notify(ctx, user, notification); err != nil {
+		ec.logger.Error("Failed to notify escalation of task " + task.ID.Hex() + ": " + err.Error())
+	}
+
+	ec.logger.Info("Task " + task.ID.Hex() + " escalated from " + task.Priority + " to " + rule.Priority)
+	return nil
+}
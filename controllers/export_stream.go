@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// exportTimeout bounds a streamed export, which can take far longer than a
+// regular request for large accounts
+const exportTimeout = 10 * time.Minute
+
+// exportBatchSize is the number of documents fetched per cursor batch and
+// written between flushes, which bounds the memory of a streamed export
+const exportBatchSize = 500
+
+// exportRow decodes the current document of a cursor into a CSV row
+type exportRow func(cursor *mongo.Cursor) ([]string, error)
+
+// exportDocument decodes the current document of a cursor into the value
+// written to a JSON export
+type exportDocument func(cursor *mongo.Cursor) (interface{}, error)
+
+// startExport sends the headers of a streamed attachment. Without a
+// Content-Length the body goes out with chunked transfer encoding.
+func startExport(c *gin.Context, contentType string, filename string) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+}
+
+// streamCSV writes a header and a row per document of the cursor. Rows are
+// flushed every batch: a slow client blocks the writes, and the cursor only
+// fetches the next batch once the previous one went out.
+func streamCSV(ctx context.Context, c *gin.Context, cursor *mongo.Cursor, header []string, row exportRow) error {
+	writer := csv.NewWriter(c.Writer)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	rows := 0
+	for cursor.Next(ctx) {
+		record, err := row(cursor)
+		if err != nil {
+			return err
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+		if rows++; rows%exportBatchSize == 0 {
+			if err := flushCSV(c, writer); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	return flushCSV(c, writer)
+}
+
+// streamJSON writes the documents of the cursor as a JSON array, flushed
+// every batch like streamCSV
+func streamJSON(ctx context.Context, c *gin.Context, cursor *mongo.Cursor, document exportDocument) error {
+	if err := writeJSONArray(ctx, c.Writer, cursor, document, func() error {
+		c.Writer.Flush()
+		return nil
+	}); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// writeJSONArray encodes the documents of a cursor as a JSON array, calling
+// flush every batch
+func writeJSONArray(ctx context.Context, w io.Writer, cursor *mongo.Cursor, document exportDocument, flush func() error) error {
+	if _, err := w.Write([]byte("[")); err != nil {
+		return err
+	}
+
+	count := 0
+	for cursor.Next(ctx) {
+		value, err := document(cursor)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			if _, err := w.Write([]byte(",\n")); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		if count++; count%exportBatchSize == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	_, err := w.Write([]byte("]\n"))
+	return err
+}
+
+// flushCSV sends the buffered rows to the client
+func flushCSV(c *gin.Context, writer *csv.Writer) error {
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// completionPoints are the points earned by completing a task of each
+// priority, one more is earned when it is completed by its due date
+var completionPoints = map[string]int{
+	"low":    1,
+	"medium": 2,
+	"high":   3,
+}
+
+// badge is an achievement unlocked when a stat reaches its target
+type badge struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Stat     string `json:"stat"` // "completed" or "longestStreak"
+	Target   int    `json:"target"`
+	Progress int    `json:"progress"`
+	Earned   bool   `json:"earned"`
+}
+
+// badges are the achievements of GET /stats/gamification
+var badges = []badge{
+	{Key: "first-task", Name: "First step", Stat: "completed", Target: 1},
+	{Key: "tasks-10", Name: "Getting things done", Stat: "completed", Target: 10},
+	{Key: "tasks-100", Name: "Centurion", Stat: "completed", Target: 100},
+	{Key: "tasks-1000", Name: "Unstoppable", Stat: "completed", Target: 1000},
+	{Key: "streak-7", Name: "One week streak", Stat: "longestStreak", Target: 7},
+	{Key: "streak-30", Name: "One month streak", Stat: "longestStreak", Target: 30},
+	{Key: "streak-100", Name: "Hundred day streak", Stat: "longestStreak", Target: 100},
+}
+
+// GetGamification returns the completion streaks, points and badges of the
+// authenticated user. A day counts towards the streak when at least one
+// task was completed on it, and the current day does not break the streak
+// while it is still in progress.
+func (sc *StatsController) GetGamification(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	findOptions := options.Find().SetProjection(bson.M{"completedAt": 1, "updatedAt": 1, "dueDate": 1, "priority": 1})
+	cursor, err := sc.taskCollection.Find(ctx, bson.M{"user": userID, "completed": true}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch tasks",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse tasks",
+		})
+		return
+	}
+
+	now := time.Now()
+	today := now.Format(utils.DayLayout)
+	perDay := map[string]int{}
+	points := 0
+	for _, task := range tasks {
+		// Tasks completed before completion times were recorded fall back
+		// to their last update
+		completedAt := task.UpdatedAt
+		if task.CompletedAt != nil {
+			completedAt = *task.CompletedAt
+		}
+		perDay[completedAt.In(now.Location()).Format(utils.DayLayout)]++
+
+		points += completionPoints[task.Priority]
+		if task.DueDate != nil && !completedAt.After(*task.DueDate) {
+			points++
+		}
+	}
+
+	current, longest := utils.CalculateStreaks(perDay, 1, false, now)
+	stats := map[string]int{
+		"completed":     len(tasks),
+		"longestStreak": longest,
+	}
+
+	results := []badge{}
+	for _, item := range badges {
+		item.Progress = stats[item.Stat]
+		if item.Progress > item.Target {
+			item.Progress = item.Target
+		}
+		item.Earned = stats[item.Stat] >= item.Target
+		results = append(results, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"completed":      len(tasks),
+			"completedToday": perDay[today],
+			"points":         points,
+			"streak": gin.H{
+				"current": current,
+				"longest": longest,
+			},
+			"badges": results,
+		},
+	})
+}
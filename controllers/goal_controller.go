@@ -0,0 +1,308 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"gotodolist/authz"
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GoalController handles goal-related operations
+type GoalController struct {
+	collection     *mongo.Collection
+	taskCollection *mongo.Collection
+}
+
+// NewGoalController creates a new goal controller
+func NewGoalController(collection *mongo.Collection, taskCollection *mongo.Collection) *GoalController {
+	return &GoalController{
+		collection:     collection,
+		taskCollection: taskCollection,
+	}
+}
+
+// GetGoals retrieves all goals of the authenticated user with their progress
+func (gc *GoalController) GetGoals(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := gc.collection.Find(ctx, bson.M{"user": userID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch goals",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var goals []models.Goal
+	if err := cursor.All(ctx, &goals); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse goals",
+		})
+		return
+	}
+
+	responses := withProgress(goals)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(responses),
+		"data":    responses,
+	})
+}
+
+// GetGoal retrieves a single goal by ID with its progress
+func (gc *GoalController) GetGoal(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	goal, ok := gc.findGoal(ctx, c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    withProgress([]models.Goal{*goal})[0],
+	})
+}
+
+// CreateGoal creates a new goal
+func (gc *GoalController) CreateGoal(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	var input struct {
+		Name        string     `json:"name" binding:"required"`
+		Description string     `json:"description"`
+		TargetDate  *time.Time `json:"targetDate"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	goal := models.NewGoal(input.Name, userID.(primitive.ObjectID))
+	goal.Description = input.Description
+	goal.TargetDate = input.TargetDate
+
+	result, err := gc.collection.InsertOne(ctx, goal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create goal",
+		})
+		return
+	}
+
+	goal.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    goal,
+	})
+}
+
+// UpdateGoal updates an existing goal
+func (gc *GoalController) UpdateGoal(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Name        string     `json:"name"`
+		Description string     `json:"description"`
+		TargetDate  *time.Time `json:"targetDate"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	goal, ok := gc.findGoal(ctx, c)
+	if !ok {
+		return
+	}
+
+	// Only update fields that were provided
+	updateSet := bson.M{
+		"updatedAt": time.Now(),
+	}
+	if input.Name != "" {
+		updateSet["name"] = input.Name
+	}
+	if input.Description != "" {
+		updateSet["description"] = input.Description
+	}
+	if input.TargetDate != nil {
+		updateSet["targetDate"] = input.TargetDate
+	}
+
+	var updatedGoal models.Goal
+	err := gc.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": goal.ID},
+		bson.M{"$set": updateSet},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updatedGoal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update goal",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedGoal,
+	})
+}
+
+// DeleteGoal deletes a goal and detaches its tasks
+func (gc *GoalController) DeleteGoal(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	goal, ok := gc.findGoal(ctx, c)
+	if !ok {
+		return
+	}
+
+	// Tasks are kept, they only lose their goal reference. Both writes go in
+	// one transaction, where the deployment has them, so no task write lands
+	// between them.
+	err := withTransaction(ctx, gc.collection.Database().Client(), func(ctx context.Context) error {
+		if _, err := gc.collection.DeleteOne(ctx, bson.M{"_id": goal.ID}); err != nil {
+			return err
+		}
+		_, err := gc.taskCollection.UpdateMany(
+			ctx,
+			bson.M{"goal": goal.ID},
+			bson.M{"$unset": bson.M{"goal": ""}},
+		)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete goal",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{},
+	})
+}
+
+// withProgress adds the progress of each goal from its task counts
+func withProgress(goals []models.Goal) []models.GoalResponse {
+	responses := []models.GoalResponse{}
+
+	now := time.Now()
+	for _, goal := range goals {
+		progress := models.GoalProgress{
+			TotalTasks:     goal.TaskCounts.Open + goal.TaskCounts.Completed,
+			CompletedTasks: goal.TaskCounts.Completed,
+		}
+		if progress.TotalTasks > 0 {
+			progress.Percent = math.Round(float64(progress.CompletedTasks)/float64(progress.TotalTasks)*1000) / 10
+		}
+		progress.Overdue = goal.TargetDate != nil && goal.TargetDate.Before(now) && progress.Percent < 100
+		responses = append(responses, models.GoalResponse{
+			Goal:     goal,
+			Progress: progress,
+		})
+	}
+
+	return responses
+}
+
+// findGoal loads the goal referenced by the :id parameter and checks that it
+// belongs to the authenticated user, writing the error response otherwise
+func (gc *GoalController) findGoal(ctx context.Context, c *gin.Context) (*models.Goal, bool) {
+	// Get user ID from context
+	_, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return nil, false
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid goal ID format",
+		})
+		return nil, false
+	}
+
+	var goal models.Goal
+	err = gc.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&goal)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Goal not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch goal",
+		})
+		return nil, false
+	}
+
+	// Check that the user may act on the goal
+	if !authorize(c, authz.GoalManage, goal.User, "Goal not found", "Not authorized to access this goal") {
+		return nil, false
+	}
+
+	return &goal, true
+}
@@ -0,0 +1,583 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/authz"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// HabitController handles habit-related operations
+type HabitController struct {
+	collection        *mongo.Collection
+	checkInCollection *mongo.Collection
+}
+
+// NewHabitController creates a new habit controller
+func NewHabitController(collection *mongo.Collection, checkInCollection *mongo.Collection) *HabitController {
+	return &HabitController{
+		collection:        collection,
+		checkInCollection: checkInCollection,
+	}
+}
+
+// GetHabits retrieves all habits for the authenticated user
+func (hc *HabitController) GetHabits(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"createdAt": -1})
+	cursor, err := hc.collection.Find(ctx, bson.M{"user": userID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch habits",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	habits := []models.Habit{}
+	if err := cursor.All(ctx, &habits); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse habits",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(habits),
+		"data":    habits,
+	})
+}
+
+// GetHabit retrieves a single habit by ID along with its streak stats
+func (hc *HabitController) GetHabit(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	habit, ok := hc.findHabit(ctx, c)
+	if !ok {
+		return
+	}
+
+	stats, err := hc.habitStats(ctx, habit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to compute habit stats",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    habit,
+		"stats":   stats,
+	})
+}
+
+// CreateHabit creates a new habit
+func (hc *HabitController) CreateHabit(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	var input struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+		Frequency   string `json:"frequency"`
+		TargetCount int    `json:"targetCount"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	if !validHabitInput(c, input.Frequency, input.TargetCount) {
+		return
+	}
+
+	// Create a new habit
+	habit := models.NewHabit(input.Name, userID.(primitive.ObjectID))
+	habit.Description = input.Description
+	if input.Frequency != "" {
+		habit.Frequency = input.Frequency
+	}
+	if input.TargetCount > 0 {
+		habit.TargetCount = input.TargetCount
+	}
+
+	result, err := hc.collection.InsertOne(ctx, habit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create habit",
+		})
+		return
+	}
+
+	habit.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    habit,
+	})
+}
+
+// UpdateHabit updates an existing habit
+func (hc *HabitController) UpdateHabit(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Frequency   string `json:"frequency"`
+		TargetCount int    `json:"targetCount"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	if !validHabitInput(c, input.Frequency, input.TargetCount) {
+		return
+	}
+
+	habit, ok := hc.findHabit(ctx, c)
+	if !ok {
+		return
+	}
+
+	// Only update fields that were provided
+	updateSet := bson.M{
+		"updatedAt": time.Now(),
+	}
+	if input.Name != "" {
+		updateSet["name"] = input.Name
+	}
+	if input.Description != "" {
+		updateSet["description"] = input.Description
+	}
+	if input.Frequency != "" {
+		updateSet["frequency"] = input.Frequency
+	}
+	if input.TargetCount > 0 {
+		updateSet["targetCount"] = input.TargetCount
+	}
+
+	var updatedHabit models.Habit
+	err := hc.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": habit.ID},
+		bson.M{"$set": updateSet},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updatedHabit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update habit",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedHabit,
+	})
+}
+
+// DeleteHabit deletes a habit and its check-ins
+func (hc *HabitController) DeleteHabit(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	habit, ok := hc.findHabit(ctx, c)
+	if !ok {
+		return
+	}
+
+	if _, err := hc.collection.DeleteOne(ctx, bson.M{"_id": habit.ID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete habit",
+		})
+		return
+	}
+
+	if _, err := hc.checkInCollection.DeleteMany(ctx, bson.M{"habit": habit.ID}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete habit check-ins",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{},
+	})
+}
+
+// GetCheckIns retrieves the check-ins of a habit
+func (hc *HabitController) GetCheckIns(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	habit, ok := hc.findHabit(ctx, c)
+	if !ok {
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"date": -1})
+	cursor, err := hc.checkInCollection.Find(ctx, bson.M{"habit": habit.ID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch check-ins",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	checkIns := []models.HabitCheckIn{}
+	if err := cursor.All(ctx, &checkIns); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse check-ins",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(checkIns),
+		"data":    checkIns,
+	})
+}
+
+// CheckIn records a check-in for a habit, for today unless a date is given
+func (hc *HabitController) CheckIn(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Date string `json:"date"`
+	}
+
+	// The body is optional, an empty body checks in for today
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid input data",
+			})
+			return
+		}
+	}
+
+	date := time.Now().Format(utils.DayLayout)
+	if input.Date != "" {
+		day, err := time.ParseInLocation(utils.DayLayout, input.Date, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Date must use the YYYY-MM-DD format",
+			})
+			return
+		}
+		if day.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Cannot check in for a future date",
+			})
+			return
+		}
+		date = input.Date
+	}
+
+	habit, ok := hc.findHabit(ctx, c)
+	if !ok {
+		return
+	}
+
+	// A habit can only be checked in once per day
+	err := hc.checkInCollection.FindOne(ctx, bson.M{"habit": habit.ID, "date": date}).Err()
+	if err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Habit already checked in for this date",
+		})
+		return
+	}
+	if err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to check existing check-ins",
+		})
+		return
+	}
+
+	checkIn := models.HabitCheckIn{
+		Habit:     habit.ID,
+		User:      habit.User,
+		Date:      date,
+		CreatedAt: time.Now(),
+	}
+
+	result, err := hc.checkInCollection.InsertOne(ctx, checkIn)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to record check-in",
+		})
+		return
+	}
+	checkIn.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    checkIn,
+	})
+}
+
+// UndoCheckIn removes the check-in of a habit for the given date
+func (hc *HabitController) UndoCheckIn(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	habit, ok := hc.findHabit(ctx, c)
+	if !ok {
+		return
+	}
+
+	result, err := hc.checkInCollection.DeleteOne(ctx, bson.M{"habit": habit.ID, "date": c.Param("date")})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete check-in",
+		})
+		return
+	}
+
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Check-in not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{},
+	})
+}
+
+// GetStats returns the streak stats of every habit of the authenticated user
+func (hc *HabitController) GetStats(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	stats, err := hc.StatsForUser(ctx, userID.(primitive.ObjectID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to compute habit stats",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(stats),
+		"data":    stats,
+	})
+}
+
+// StatsForUser computes the streak stats of every habit owned by a user
+func (hc *HabitController) StatsForUser(ctx context.Context, userID primitive.ObjectID) ([]models.HabitStats, error) {
+	cursor, err := hc.collection.Find(ctx, bson.M{"user": userID}, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var habits []models.Habit
+	if err := cursor.All(ctx, &habits); err != nil {
+		return nil, err
+	}
+
+	stats := []models.HabitStats{}
+	for i := range habits {
+		habitStats, err := hc.habitStats(ctx, &habits[i])
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, habitStats)
+	}
+
+	return stats, nil
+}
+
+// habitStats computes the streak stats of a single habit from its check-ins
+func (hc *HabitController) habitStats(ctx context.Context, habit *models.Habit) (models.HabitStats, error) {
+	stats := models.HabitStats{
+		Habit:     habit.ID,
+		Name:      habit.Name,
+		Frequency: habit.Frequency,
+	}
+
+	cursor, err := hc.checkInCollection.Find(ctx, bson.M{"habit": habit.ID})
+	if err != nil {
+		return stats, err
+	}
+	defer cursor.Close(ctx)
+
+	var checkIns []models.HabitCheckIn
+	if err := cursor.All(ctx, &checkIns); err != nil {
+		return stats, err
+	}
+
+	// Count check-ins per period (day or week)
+	weekly := habit.Frequency == models.HabitWeekly
+	today := time.Now().Format(utils.DayLayout)
+	counts := map[string]int{}
+	for _, checkIn := range checkIns {
+		day, err := time.ParseInLocation(utils.DayLayout, checkIn.Date, time.Local)
+		if err != nil {
+			continue
+		}
+		counts[utils.PeriodStart(day, weekly).Format(utils.DayLayout)]++
+		if checkIn.Date == today {
+			stats.CompletedToday = true
+		}
+	}
+
+	target := habit.TargetCount
+	if target < 1 {
+		target = 1
+	}
+
+	stats.CurrentStreak, stats.LongestStreak = utils.CalculateStreaks(counts, target, weekly, time.Now())
+	stats.TotalCheckIns = len(checkIns)
+
+	return stats, nil
+}
+
+// findHabit loads the habit referenced by the :id parameter and checks that it
+// belongs to the authenticated user, writing the error response otherwise
+func (hc *HabitController) findHabit(ctx context.Context, c *gin.Context) (*models.Habit, bool) {
+	// Get user ID from context
+	_, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return nil, false
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid habit ID format",
+		})
+		return nil, false
+	}
+
+	var habit models.Habit
+	err = hc.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&habit)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Habit not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch habit",
+		})
+		return nil, false
+	}
+
+	// Check that the user may act on the habit
+	if !authorize(c, authz.HabitManage, habit.User, "Habit not found", "Not authorized to access this habit") {
+		return nil, false
+	}
+
+	return &habit, true
+}
+
+// validHabitInput validates the frequency and target count of a habit
+func validHabitInput(c *gin.Context, frequency string, targetCount int) bool {
+	if frequency != "" && frequency != models.HabitDaily && frequency != models.HabitWeekly {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Frequency must be one of: daily, weekly",
+		})
+		return false
+	}
+
+	if targetCount < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Target count must be a positive number",
+		})
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gotodolist/configs"
+	"gotodolist/jobs"
+	"gotodolist/middleware"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// healthHistoryRetention is how long health snapshots are kept, and the
+// longest period GET /admin/health/history covers
+const healthHistoryRetention = 7 * 24 * time.Hour
+
+// minAlertSamples is the number of recent requests a route needs before
+// its p95 can raise an alert
+const minAlertSamples = 20
+
+// maxPendingSnapshots caps the snapshots kept in memory while they cannot
+// be stored, so an outage still shows up in the history once it is over
+const maxPendingSnapshots = 60
+
+// HealthController records the health of the instance at regular intervals,
+// reports its history to admins and alerts them when a route slows down
+type HealthController struct {
+	collection     *mongo.Collection
+	client         *mongo.Client
+	userCollection *mongo.Collection
+	scheduler      *jobs.Scheduler
+	notifier       *NotificationController
+	interval       time.Duration
+	alertP95       time.Duration
+	logger         *utils.Logger
+
+	// Only used by the snapshot loop
+	pending                              []models.HealthSnapshot
+	requests, clientErrors, serverErrors int64
+	slowQueries, slowRequests            int64
+	degraded                             map[string]bool // Routes whose p95 has been alerted on
+}
+
+// NewHealthController creates a health controller taking a snapshot every
+// HEALTH_SNAPSHOT_INTERVAL (5m by default). Admins are notified when the
+// p95 latency of a route exceeds SLOW_REQUEST_ALERT_P95_MS (0, the
+// default, disables alerts).
+func NewHealthController(collection *mongo.Collection, client *mongo.Client, userCollection *mongo.Collection, scheduler *jobs.Scheduler, notifier *NotificationController) *HealthController {
+	interval, err := time.ParseDuration(utils.GetEnv("HEALTH_SNAPSHOT_INTERVAL", "5m"))
+	if err != nil || interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	alertMs, err := strconv.Atoi(utils.GetEnv("SLOW_REQUEST_ALERT_P95_MS", "0"))
+	if err != nil || alertMs < 0 {
+		alertMs = 0
+	}
+
+	return &HealthController{
+		collection:     collection,
+		client:         client,
+		userCollection: userCollection,
+		scheduler:      scheduler,
+		notifier:       notifier,
+		interval:       interval,
+		alertP95:       time.Duration(alertMs) * time.Millisecond,
+		logger:         utils.GetLogger().Named("health"),
+		degraded:       map[string]bool{},
+	}
+}
+
+// Start takes snapshots until ctx is cancelled. Every instance records its
+// own, unlike the scheduler's jobs which run on a single instance.
+func (hc *HealthController) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(hc.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				snapshotCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+				if err := hc.Snapshot(snapshotCtx); err != nil {
+					hc.logger.Warning("Failed to store health snapshot: " + err.Error())
+				}
+				if err := hc.AlertSlowRoutes(snapshotCtx); err != nil {
+					hc.logger.Warning("Failed to send slow route alerts: " + err.Error())
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Snapshot measures the database latency and the requests served since the
+// previous snapshot, and stores them with the snapshots not stored yet
+func (hc *HealthController) Snapshot(ctx context.Context) error {
+	snapshot := models.HealthSnapshot{
+		Instance:    utils.InstanceID(),
+		JobsHealthy: hc.scheduler.Healthy(),
+		CreatedAt:   time.Now(),
+	}
+
+	started := time.Now()
+	if err := hc.client.Ping(ctx, nil); err == nil {
+		snapshot.DBUp = true
+		snapshot.DBLatencyMs = math.Round(float64(time.Since(started).Microseconds())/10) / 100
+	}
+
+	requests, clientErrors, serverErrors := middleware.RequestCounts()
+	slowQueries := configs.SlowQueryCount()
+	slowRequests := middleware.SlowRequestCount()
+	snapshot.Requests = requests - hc.requests
+	snapshot.ClientErrors = clientErrors - hc.clientErrors
+	snapshot.ServerErrors = serverErrors - hc.serverErrors
+	snapshot.SlowQueries = slowQueries - hc.slowQueries
+	snapshot.SlowRequests = slowRequests - hc.slowRequests
+	if snapshot.Requests > 0 {
+		snapshot.ErrorRate = float64(snapshot.ServerErrors) / float64(snapshot.Requests)
+	}
+	hc.requests, hc.clientErrors, hc.serverErrors = requests, clientErrors, serverErrors
+	hc.slowQueries, hc.slowRequests = slowQueries, slowRequests
+
+	hc.pending = append(hc.pending, snapshot)
+	if len(hc.pending) > maxPendingSnapshots {
+		hc.pending = hc.pending[len(hc.pending)-maxPendingSnapshots:]
+	}
+
+	documents := make([]interface{}, len(hc.pending))
+	for i, pending := range hc.pending {
+		documents[i] = pending
+	}
+	if _, err := hc.collection.InsertMany(ctx, documents); err != nil {
+		return err
+	}
+	hc.pending = nil
+	return nil
+}
+
+// AlertSlowRoutes notifies the active admins when the p95 latency of a route
+// over its recent requests goes above the alert threshold. A route is
+// alerted on once, then again only after it recovered.
+func (hc *HealthController) AlertSlowRoutes(ctx context.Context) error {
+	if hc.alertP95 == 0 {
+		return nil
+	}
+
+	threshold := float64(hc.alertP95.Milliseconds())
+	alerts := []*models.Notification{}
+	for _, route := range middleware.RouteLatencies() {
+		if route.Samples < minAlertSamples {
+			continue
+		}
+		if route.P95Ms <= threshold {
+			delete(hc.degraded, route.Route)
+			continue
+		}
+		if hc.degraded[route.Route] {
+			continue
+		}
+		hc.degraded[route.Route] = true
+
+		message := fmt.Sprintf("The p95 latency of %s is %.0fms over its last %d requests on instance %s, above the %.0fms alert threshold.",
+			route.Route, route.P95Ms, route.Samples, utils.InstanceID(), threshold)
+		alerts = append(alerts, models.NewNotification(primitive.NilObjectID, models.NotifyAlerts, "Slow route: "+route.Route, message))
+		hc.logger.With("route", route.Route, "p95Ms", route.P95Ms).Warning("Route p95 latency degraded")
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	cursor, err := hc.userCollection.Find(ctx, bson.M{"role": models.RoleAdmin, "deactivatedAt": bson.M{"$exists": false}},
+		options.Find().SetProjection(recipientFields))
+	if err != nil {
+		return err
+	}
+	var admins []models.User
+	if err := cursor.All(ctx, &admins); err != nil {
+		return err
+	}
+
+	for _, alert := range alerts {
+		for _, admin := range admins {
+			notification := *alert
+			if _, err := hc.notifier.Notify(ctx, admin, &notification); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetHistory returns the health snapshots of the last ?hours (24 by
+// default, at most 168), oldest first, with a summary of the period.
+// ?instance restricts them to one instance.
+func (hc *HealthController) GetHistory(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hours, err := strconv.Atoi(utils.GetQueryDefault(c, "hours", "24"))
+	if err != nil || hours < 1 || time.Duration(hours)*time.Hour > healthHistoryRetention {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "hours must be between 1 and 168",
+		})
+		return
+	}
+
+	now := time.Now()
+	filter := bson.M{"createdAt": bson.M{"$gte": now.Add(-time.Duration(hours) * time.Hour)}}
+	if instance := c.Query("instance"); instance != "" {
+		filter["instance"] = instance
+	}
+
+	cursor, err := hc.collection.Find(ctx, filter, options.Find().SetSort(bson.M{"createdAt": 1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch health history",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	snapshots := []models.HealthSnapshot{}
+	if err := cursor.All(ctx, &snapshots); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse health history",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"intervalSeconds": hc.interval.Seconds(),
+			"summary":         summarizeHealth(snapshots),
+			"snapshots":       snapshots,
+		},
+	})
+}
+
+// EnsureIndexes creates the TTL index that removes old snapshots
+func (hc *HealthController) EnsureIndexes(ctx context.Context) error {
+	_, err := hc.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"createdAt": 1},
+		Options: options.Index().SetExpireAfterSeconds(int32(healthHistoryRetention.Seconds())),
+	})
+	return err
+}
+
+// summarizeHealth aggregates snapshots over their period: the share of
+// snapshots that reached the database, its latency while up and the
+// requests served
+func summarizeHealth(snapshots []models.HealthSnapshot) gin.H {
+	var up, degraded, requests, serverErrors int64
+	var latencyTotal, latencyMax float64
+	instances := map[string]bool{}
+	for _, snapshot := range snapshots {
+		instances[snapshot.Instance] = true
+		requests += snapshot.Requests
+		serverErrors += snapshot.ServerErrors
+		if !snapshot.JobsHealthy {
+			degraded++
+		}
+		if snapshot.DBUp {
+			up++
+			latencyTotal += snapshot.DBLatencyMs
+			latencyMax = math.Max(latencyMax, snapshot.DBLatencyMs)
+		}
+	}
+
+	round := func(value float64) float64 {
+		return math.Round(value*100) / 100
+	}
+	summary := gin.H{
+		"snapshots":      len(snapshots),
+		"instances":      len(instances),
+		"uptimePercent":  0.0,
+		"avgDbLatencyMs": 0.0,
+		"maxDbLatencyMs": latencyMax,
+		"jobsDegraded":   degraded,
+		"requests":       requests,
+		"serverErrors":   serverErrors,
+		"errorRate":      0.0,
+	}
+	if len(snapshots) > 0 {
+		summary["uptimePercent"] = round(float64(up) * 100 / float64(len(snapshots)))
+	}
+	if up > 0 {
+		summary["avgDbLatencyMs"] = round(latencyTotal / float64(up))
+	}
+	if requests > 0 {
+		summary["errorRate"] = float64(serverErrors) / float64(requests)
+	}
+	return summary
+}
@@ -0,0 +1,283 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gotodolist/middleware"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxCustomHolidays caps the custom holidays of a user
+const maxCustomHolidays = 100
+
+// HolidayController manages the holiday calendars and working hours of users
+// and moves due dates off their days off
+type HolidayController struct {
+	userCollection *mongo.Collection
+	userCache      *middleware.UserCache
+	logger         *utils.Logger
+}
+
+// NewHolidayController creates a new holiday controller
+func NewHolidayController(userCollection *mongo.Collection, userCache *middleware.UserCache) *HolidayController {
+	return &HolidayController{
+		userCollection: userCollection,
+		userCache:      userCache,
+		logger:         utils.GetLogger().Named("holidays"),
+	}
+}
+
+// GetSettings returns the authenticated user's holiday settings and the
+// locales with a built-in calendar
+func (hc *HolidayController) GetSettings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	calendar, err := hc.calendar(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get holiday settings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"settings": calendar.holidays,
+			"locales":  utils.HolidayLocales(),
+		},
+	})
+}
+
+// UpdateSettings replaces the authenticated user's holiday settings
+func (hc *HolidayController) UpdateSettings(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	var input models.HolidaySettings
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	input.Locale = strings.ToUpper(strings.TrimSpace(input.Locale))
+	if _, ok := utils.HolidaysOf(input.Locale, time.Now().Year()); input.Locale != "" && !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Unsupported locale, use one of: " + strings.Join(utils.HolidayLocales(), ", "),
+		})
+		return
+	}
+	if len(input.Custom) > maxCustomHolidays {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "At most 100 custom holidays are allowed",
+		})
+		return
+	}
+	for _, holiday := range input.Custom {
+		if _, err := time.Parse(utils.DayLayout, holiday.Date); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid holiday date, use YYYY-MM-DD: " + holiday.Date,
+			})
+			return
+		}
+	}
+
+	_, err := hc.userCollection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{
+		"holidays":  input,
+		"updatedAt": time.Now(),
+	}})
+	if err != nil {
+		hc.logger.Error("Holiday settings update failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update holiday settings",
+		})
+		return
+	}
+	hc.userCache.Invalidate(userID.(primitive.ObjectID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    input,
+	})
+}
+
+// GetCalendar returns the holidays of the authenticated user in ?year (the
+// current year by default): those of their locale and their custom ones.
+// ?locale shows a built-in calendar instead.
+func (hc *HolidayController) GetCalendar(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	year, err := strconv.Atoi(utils.GetQueryDefault(c, "year", strconv.Itoa(time.Now().Year())))
+	if err != nil || year < 1900 || year > 2200 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "year must be between 1900 and 2200",
+		})
+		return
+	}
+
+	if locale := c.Query("locale"); locale != "" {
+		holidays, ok := utils.HolidaysOf(strings.ToUpper(locale), year)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Unsupported locale, use one of: " + strings.Join(utils.HolidayLocales(), ", "),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    holidays,
+		})
+		return
+	}
+
+	calendar, err := hc.calendar(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get holiday settings",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    holidaysIn(calendar.holidays, year),
+	})
+}
+
+// ShiftDueDate moves a due date to the next working day of the user when they
+// enabled it and the date falls on one of their holidays, or on a day outside
+// their work schedule when they skip weekends. The time of day is kept.
+func (hc *HolidayController) ShiftDueDate(ctx context.Context, userID interface{}, dueDate *time.Time) (*time.Time, error) {
+	if dueDate == nil {
+		return nil, nil
+	}
+
+	calendar, err := hc.calendar(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !calendar.holidays.ShiftDueDates {
+		return dueDate, nil
+	}
+
+	isDayOff := func(day time.Time) bool {
+		if calendar.holidays.SkipWeekends && !calendar.schedule.IsWorkingDay(day.Weekday()) {
+			return true
+		}
+		return calendar.IsHoliday(day)
+	}
+
+	day := dueDate.In(time.Local)
+	if !isDayOff(day) {
+		return dueDate, nil
+	}
+	// Bounded so a calendar without working days cannot loop forever
+	for i := 0; i < 366 && isDayOff(day); i++ {
+		day = day.AddDate(0, 0, 1)
+	}
+	return &day, nil
+}
+
+// shiftDueDate moves a due date being set off the user's days off, writing
+// the error response when their settings cannot be loaded
+func (tc *TaskController) shiftDueDate(ctx context.Context, c *gin.Context, userID interface{}, dueDate *time.Time) (*time.Time, bool) {
+	if tc.holidays == nil {
+		return dueDate, true
+	}
+
+	shifted, err := tc.holidays.ShiftDueDate(ctx, userID, dueDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get holiday settings",
+		})
+		return nil, false
+	}
+	return shifted, true
+}
+
+// calendar loads the holiday settings and work schedule of a user, from the
+// database since stateless tokens do not carry them
+func (hc *HolidayController) calendar(ctx context.Context, userID interface{}) (*workCalendar, error) {
+	var user models.User
+	err := hc.userCollection.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{
+		"holidays":     1,
+		"workSchedule": 1,
+	})).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return newWorkCalendar(user), nil
+}
+
+// holidaysIn returns the holidays of the settings' locale and the custom
+// holidays falling in a year, sorted by date
+func holidaysIn(settings models.HolidaySettings, year int) []utils.Holiday {
+	holidays, _ := utils.HolidaysOf(settings.Locale, year)
+	if holidays == nil {
+		holidays = []utils.Holiday{}
+	}
+	for _, custom := range settings.Custom {
+		date := custom.Date
+		if custom.Yearly {
+			date = strconv.Itoa(year) + date[4:]
+		}
+		if strings.HasPrefix(date, strconv.Itoa(year)+"-") {
+			holidays = append(holidays, utils.Holiday{Date: date, Name: custom.Name})
+		}
+	}
+	sort.SliceStable(holidays, func(i, j int) bool { return holidays[i].Date < holidays[j].Date })
+	return holidays
+}
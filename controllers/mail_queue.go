@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"gotodolist/emails"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// mailClaimTimeout is how long an email being sent is left alone by
+	// the other instances, after which it is sent again
+	mailClaimTimeout = 5 * time.Minute
+	// mailRetryDelay is the wait after the first failed attempt, doubled
+	// after each of the following ones up to mailMaxRetryDelay
+	mailRetryDelay    = time.Minute
+	mailMaxRetryDelay = time.Hour
+	// failedEmailTTL is how long failed emails are kept to be looked into
+	failedEmailTTL = 7 * 24 * time.Hour
+)
+
+// MailQueue sends emails through the mail transport, keeping them in the
+// email_outbox collection until they are sent. The first attempt is made
+// at once, and the emails it failed are retried by the "email-delivery"
+// job with an increasing delay, MAIL_MAX_ATTEMPTS times in all (6 by
+// default) unless the transport rejected them.
+type MailQueue struct {
+	collection  *mongo.Collection
+	mailer      utils.Mailer
+	maxAttempts int
+	logger      *utils.Logger
+}
+
+// NewMailQueue creates a new mail queue sending through mailer
+func NewMailQueue(collection *mongo.Collection, mailer utils.Mailer) *MailQueue {
+	maxAttempts, err := strconv.Atoi(utils.GetEnv("MAIL_MAX_ATTEMPTS", "6"))
+	if err != nil || maxAttempts < 1 {
+		maxAttempts = 6
+	}
+	return &MailQueue{
+		collection:  collection,
+		mailer:      mailer,
+		maxAttempts: maxAttempts,
+		logger:      utils.GetLogger().Named("mailer"),
+	}
+}
+
+// Send queues an email written from the named template and makes the first
+// attempt to send it, all in the background, so that neither the database
+// nor a slow transport holds up the response
+func (mq *MailQueue) Send(to, name string, message emails.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		now := time.Now()
+		email := models.OutboundEmail{
+			To:       to,
+			Template: name,
+			Subject:  message.Subject,
+			Text:     message.Text,
+			HTML:     message.HTML,
+			Status:   models.EmailPending,
+			// Claimed for the first attempt, the job only picks it up if
+			// this instance stops before recording the outcome
+			NextAttemptAt: now.Add(mailClaimTimeout),
+			CreatedAt:     now,
+		}
+		result, err := mq.collection.InsertOne(ctx, email)
+		if err != nil {
+			// Without the outbox the email gets a single attempt
+			mq.logger.Error("Failed to queue " + name + " email to " + to + ", sending without retries: " + err.Error())
+			if err := mq.mailer.Send(ctx, to, message); err != nil {
+				mq.logger.Error("Failed to send " + name + " email to " + to + ": " + err.Error())
+			}
+			return
+		}
+		email.ID = result.InsertedID.(primitive.ObjectID)
+		if err := mq.attempt(ctx, email); err != nil {
+			mq.logger.Error("Failed to record the outcome of " + name + " email " + email.ID.Hex() + ": " + err.Error())
+		}
+	}()
+}
+
+// Deliver retries the emails due for another attempt, oldest first. Each
+// email is claimed before it is sent, so that instances sending at the
+// same time do not send it twice.
+func (mq *MailQueue) Deliver(ctx context.Context) error {
+	for ctx.Err() == nil {
+		now := time.Now()
+		var email models.OutboundEmail
+		err := mq.collection.FindOneAndUpdate(ctx,
+			bson.M{"status": models.EmailPending, "nextAttemptAt": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"nextAttemptAt": now.Add(mailClaimTimeout)}},
+			options.FindOneAndUpdate().SetSort(bson.M{"nextAttemptAt": 1}).SetReturnDocument(options.After),
+		).Decode(&email)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := mq.attempt(ctx, email); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// EnsureIndexes creates the index finding the emails due for an attempt and
+// the TTL index removing failed ones
+func (mq *MailQueue) EnsureIndexes(ctx context.Context) error {
+	_, err := mq.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{Keys: bson.M{"expiresAt": 1}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// attempt sends a claimed email and records the outcome: a sent email is
+// removed, a failed one is scheduled for another attempt, or marked failed
+// without its body once it ran out of attempts or was rejected
+func (mq *MailQueue) attempt(ctx context.Context, email models.OutboundEmail) error {
+	sendErr := mq.mailer.Send(ctx, email.To, emails.Message{Subject: email.Subject, Text: email.Text, HTML: email.HTML})
+	if sendErr == nil {
+		_, err := mq.collection.DeleteOne(ctx, bson.M{"_id": email.ID})
+		return err
+	}
+
+	attempts := email.Attempts + 1
+	set := bson.M{"attempts": attempts, "lastError": sendErr.Error()}
+	update := bson.M{"$set": set}
+	if attempts >= mq.maxAttempts || errors.Is(sendErr, utils.ErrMailRejected) {
+		mq.logger.Error("Failed to send " + email.Template + " email to " + email.To + ", giving up after " + strconv.Itoa(attempts) + " attempts: " + sendErr.Error())
+		set["status"] = models.EmailFailed
+		set["expiresAt"] = time.Now().Add(failedEmailTTL)
+		update["$unset"] = bson.M{"text": "", "html": ""}
+	} else {
+		delay := min(mailRetryDelay<<min(attempts-1, 16), mailMaxRetryDelay)
+		mq.logger.Warning("Failed to send " + email.Template + " email to " + email.To + ", retrying in " + delay.String() + ": " + sendErr.Error())
+		set["nextAttemptAt"] = time.Now().Add(delay)
+	}
+	_, err := mq.collection.UpdateOne(ctx, bson.M{"_id": email.ID}, update)
+	return err
+}
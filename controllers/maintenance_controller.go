@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MaintenanceController removes records left behind by deleted users and
+// tasks, and sessions that can no longer be refreshed, compacts the
+// activity history of tasks and checks the references between collections
+type MaintenanceController struct {
+	userCollection     *mongo.Collection
+	taskCollection     *mongo.Collection
+	activityCollection *mongo.Collection
+	userData           map[string]*mongo.Collection // Collections holding documents with a "user" field, keyed by name
+	taskData           map[string]*mongo.Collection // Collections holding documents with a "task" field, keyed by name
+	compactAfter       time.Duration                // Age of the activity entries rolled into daily summaries, 0 to keep them
+	retention          time.Duration                // Age of the activity entries removed, 0 to keep them
+	logger             *utils.Logger
+}
+
+// CleanupReport counts the records removed by a cleanup, or that would be
+// removed on a dry run
+type CleanupReport struct {
+	DryRun          bool             `json:"dryRun"`
+	OrphanedByUser  map[string]int64 `json:"orphanedByUser"` // Documents of users that no longer exist, per collection
+	OrphanedByTask  map[string]int64 `json:"orphanedByTask"` // Documents of tasks that no longer exist, per collection
+	ExpiredSessions int64            `json:"expiredSessions"`
+}
+
+// DeletionReport counts the documents removed by an admin deletion, or
+// that would be removed on a dry run
+type DeletionReport struct {
+	DryRun  bool  `json:"dryRun"`
+	Deleted int64 `json:"deleted"`
+}
+
+// Total returns the number of records the report covers
+func (r CleanupReport) Total() int64 {
+	total := r.ExpiredSessions
+	for _, count := range r.OrphanedByUser {
+		total += count
+	}
+	for _, count := range r.OrphanedByTask {
+		total += count
+	}
+	return total
+}
+
+// NewMaintenanceController creates a new maintenance controller. userData
+// and taskData map a name to each collection owned by users and by tasks;
+// the consistency checks find the goals, contexts, habits and habit_checkins
+// collections there.
+// Activity entries are rolled into daily summaries after
+// ACTIVITY_COMPACT_AFTER (720h by default) and removed after
+// ACTIVITY_RETENTION (0, the default, keeps them).
+func NewMaintenanceController(userCollection *mongo.Collection, taskCollection *mongo.Collection, activityCollection *mongo.Collection, userData map[string]*mongo.Collection, taskData map[string]*mongo.Collection) *MaintenanceController {
+	compactAfter, err := time.ParseDuration(utils.GetEnv("ACTIVITY_COMPACT_AFTER", "720h"))
+	if err != nil || compactAfter < 0 {
+		compactAfter = 720 * time.Hour
+	}
+	retention, err := time.ParseDuration(utils.GetEnv("ACTIVITY_RETENTION", "0"))
+	if err != nil || retention < 0 {
+		retention = 0
+	}
+
+	return &MaintenanceController{
+		userCollection:     userCollection,
+		taskCollection:     taskCollection,
+		activityCollection: activityCollection,
+		userData:           userData,
+		taskData:           taskData,
+		compactAfter:       compactAfter,
+		retention:          retention,
+		logger:             utils.GetLogger().Named("maintenance"),
+	}
+}
+
+// RunCleanup cleans up on demand and reports what was removed,
+// ?dryRun=true only reports what would be
+func (mc *MaintenanceController) RunCleanup(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	report, err := mc.Cleanup(ctx, c.Query("dryRun") == "true")
+	if err != nil {
+		mc.logger.Error("Failed to clean up orphaned data: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to clean up orphaned data",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// Run is the orphan-cleanup background job
+func (mc *MaintenanceController) Run(ctx context.Context) error {
+	report, err := mc.Cleanup(ctx, false)
+	if err != nil {
+		return err
+	}
+	if total := report.Total(); total > 0 {
+		mc.logger.Info(fmt.Sprintf("Cleaned up %d orphaned records and expired sessions", total))
+	}
+	return nil
+}
+
+// Cleanup removes the documents of missing users, then those of missing
+// tasks, including the tasks it just removed, and ends expired sessions. A dry run only counts them, leaving out the documents of tasks
+// that the cleanup would remove with their user.
+func (mc *MaintenanceController) Cleanup(ctx context.Context, dryRun bool) (CleanupReport, error) {
+	report := CleanupReport{
+		DryRun:         dryRun,
+		OrphanedByUser: map[string]int64{},
+		OrphanedByTask: map[string]int64{},
+	}
+
+	for name, collection := range mc.userData {
+		count, err := removeOrphans(ctx, collection, "user", mc.userCollection, dryRun)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", name, err)
+		}
+		report.OrphanedByUser[name] = count
+	}
+
+	for name, collection := range mc.taskData {
+		count, err := removeOrphans(ctx, collection, "task", mc.taskCollection, dryRun)
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", name, err)
+		}
+		report.OrphanedByTask[name] = count
+	}
+
+	count, err := mc.removeExpiredSessions(ctx, dryRun)
+	if err != nil {
+		return report, err
+	}
+	report.ExpiredSessions = count
+
+	return report, nil
+}
+
+// removeExpiredSessions ends, or counts on a dry run, the sessions whose
+// refresh token expired, and the expired single refresh tokens of older
+// versions
+func (mc *MaintenanceController) removeExpiredSessions(ctx context.Context, dryRun bool) (int64, error) {
+	now := time.Now()
+	expired := bson.M{"sessions.expiresAt": bson.M{"$lt": now}}
+	cursor, err := mc.userCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: expired}},
+		{{Key: "$unwind", Value: "$sessions"}},
+		{{Key: "$match", Value: expired}},
+		{{Key: "$count", Value: "count"}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var counts []struct {
+		Count int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return 0, err
+	}
+	var count int64
+	if len(counts) > 0 {
+		count = counts[0].Count
+	}
+
+	legacy := bson.M{"refreshTokenExpire": bson.M{"$lt": now}}
+	if dryRun {
+		legacyCount, err := mc.userCollection.CountDocuments(ctx, legacy)
+		return count + legacyCount, err
+	}
+
+	if _, err := mc.userCollection.UpdateMany(ctx, expired, bson.M{"$pull": bson.M{"sessions": bson.M{"expiresAt": bson.M{"$lt": now}}}}); err != nil {
+		return 0, err
+	}
+	result, err := mc.userCollection.UpdateMany(ctx, legacy, bson.M{"$unset": bson.M{"refreshToken": "", "refreshTokenExpire": ""}})
+	if err != nil {
+		return 0, err
+	}
+	return count + result.ModifiedCount, nil
+}
+
+// removeOrphans deletes, or counts on a dry run, the documents whose field
+// references a missing document of the parent collection
+func removeOrphans(ctx context.Context, collection *mongo.Collection, field string, parents *mongo.Collection, dryRun bool) (int64, error) {
+	values, err := collection.Distinct(ctx, field, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	referenced := []primitive.ObjectID{}
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			referenced = append(referenced, id)
+		}
+	}
+	if len(referenced) == 0 {
+		return 0, nil
+	}
+
+	values, err = parents.Distinct(ctx, "_id", bson.M{"_id": bson.M{"$in": referenced}})
+	if err != nil {
+		return 0, err
+	}
+	existing := map[primitive.ObjectID]bool{}
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			existing[id] = true
+		}
+	}
+
+	missing := []primitive.ObjectID{}
+	for _, id := range referenced {
+		if !existing[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 {
+		return 0, nil
+	}
+
+	filter := bson.M{field: bson.M{"$in": missing}}
+	if dryRun {
+		return collection.CountDocuments(ctx, filter)
+	}
+	result, err := collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
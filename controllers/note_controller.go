@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gotodolist/authz"
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxNoteLength is the longest note text, in characters
+const maxNoteLength = 10000
+
+// maxNotesPerTask caps the number of notes on a task
+const maxNotesPerTask = 500
+
+// NoteController manages the notes appended to tasks, kept apart from the
+// task description so progress notes never overwrite it
+type NoteController struct {
+	collection     *mongo.Collection
+	taskCollection *mongo.Collection
+	notifier       *NotificationController
+}
+
+// NewNoteController creates a new note controller
+func NewNoteController(collection *mongo.Collection, taskCollection *mongo.Collection, notifier *NotificationController) *NoteController {
+	return &NoteController{
+		collection:     collection,
+		taskCollection: taskCollection,
+		notifier:       notifier,
+	}
+}
+
+// GetNotes lists the notes of a task in the order they were added,
+// ?order=desc lists the newest first
+func (nc *NoteController) GetNotes(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	taskID, ok := nc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+
+	order := 1
+	if c.Query("order") == "desc" {
+		order = -1
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: order}, {Key: "_id", Value: order}})
+	cursor, err := nc.collection.Find(ctx, bson.M{"task": taskID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch notes",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	notes := []models.TaskNote{}
+	if err := cursor.All(ctx, &notes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse notes",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(notes),
+		"data":    notes,
+	})
+}
+
+// CreateNote appends a note to a task
+func (nc *NoteController) CreateNote(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	text, ok := bindNoteText(c)
+	if !ok {
+		return
+	}
+
+	taskID, ok := nc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+
+	count, err := nc.collection.CountDocuments(ctx, bson.M{"task": taskID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count notes",
+		})
+		return
+	}
+	if count >= maxNotesPerTask {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("A task can have at most %d notes", maxNotesPerTask),
+		})
+		return
+	}
+
+	userID, _ := c.Get("userId")
+	note := models.NewTaskNote(taskID, userID.(primitive.ObjectID), text)
+	result, err := nc.collection.InsertOne(ctx, note)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create note",
+		})
+		return
+	}
+	note.ID = result.InsertedID.(primitive.ObjectID)
+	warnings := nc.notifier.QuotaWarnings(ctx, note.User, "notes:"+taskID.Hex(), "notes on this task", count+1, maxNotesPerTask)
+
+	c.JSON(http.StatusCreated, withWarnings(gin.H{
+		"success": true,
+		"data":    note,
+	}, warnings))
+}
+
+// UpdateNote replaces the text of a note and marks it as edited
+func (nc *NoteController) UpdateNote(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	text, ok := bindNoteText(c)
+	if !ok {
+		return
+	}
+
+	taskID, noteID, ok := nc.noteIDs(ctx, c)
+	if !ok {
+		return
+	}
+
+	var note models.TaskNote
+	err := nc.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": noteID, "task": taskID},
+		bson.M{"$set": bson.M{"text": text, "edited": true, "updatedAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&note)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Note not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update note",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    note,
+	})
+}
+
+// DeleteNote deletes a note of a task
+func (nc *NoteController) DeleteNote(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	taskID, noteID, ok := nc.noteIDs(ctx, c)
+	if !ok {
+		return
+	}
+
+	result, err := nc.collection.DeleteOne(ctx, bson.M{"_id": noteID, "task": taskID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete note",
+		})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Note not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{},
+	})
+}
+
+// EnsureIndexes creates the index used to list the notes of a task
+func (nc *NoteController) EnsureIndexes(ctx context.Context) error {
+	_, err := nc.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "task", Value: 1}, {Key: "createdAt", Value: 1}},
+	})
+	return err
+}
+
+// ownedTask checks that the task of the :id parameter belongs to the
+// authenticated user, writing the error response otherwise
+func (nc *NoteController) ownedTask(ctx context.Context, c *gin.Context) (primitive.ObjectID, bool) {
+	_, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid task ID format",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	var task models.Task
+	err = nc.taskCollection.FindOne(ctx, bson.M{"_id": taskID}, options.FindOne().SetProjection(bson.M{"user": 1})).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Task not found",
+			})
+			return primitive.NilObjectID, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch task",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	if !authorize(c, authz.TaskRead, task.User, "Task not found", "Not authorized to access this task") {
+		return primitive.NilObjectID, false
+	}
+
+	return taskID, true
+}
+
+// noteIDs checks the owned task of the :id parameter and parses the
+// :noteId parameter, writing the error response when either is invalid
+func (nc *NoteController) noteIDs(ctx context.Context, c *gin.Context) (primitive.ObjectID, primitive.ObjectID, bool) {
+	noteID, err := primitive.ObjectIDFromHex(c.Param("noteId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid note ID format",
+		})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	taskID, ok := nc.ownedTask(ctx, c)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	return taskID, noteID, true
+}
+
+// bindNoteText reads the text of a note body, writing the error response
+// when it is missing or too long
+func bindNoteText(c *gin.Context) (string, bool) {
+	var input struct {
+		Text string `json:"text" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return "", false
+	}
+
+	text := strings.TrimSpace(input.Text)
+	if text == "" || utf8.RuneCountInString(text) > maxNoteLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("Note text must be between 1 and %d characters", maxNoteLength),
+		})
+		return "", false
+	}
+
+	return text, true
+}
@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/emails"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotificationController delivers in-app notifications, emails alerts, and
+// serves the authenticated user's notification inbox
+type NotificationController struct {
+	collection     *mongo.Collection
+	userCollection *mongo.Collection
+	mailQueue      *MailQueue
+	logger         *utils.Logger
+}
+
+// recipientFields are the fields of a user that notifications need
+var recipientFields = bson.M{"username": 1, "email": 1, "language": 1, "notificationPreferences": 1}
+
+// NewNotificationController creates a new notification controller, alerts
+// being emailed through mailQueue
+func NewNotificationController(collection *mongo.Collection, userCollection *mongo.Collection, mailQueue *MailQueue) *NotificationController {
+	return &NotificationController{
+		collection:     collection,
+		userCollection: userCollection,
+		mailQueue:      mailQueue,
+		logger:         utils.GetLogger().Named("notifications"),
+	}
+}
+
+// Notify stores a notification for a user unless they disabled the event on
+// the in-app channel. It reports whether the notification was delivered
+// there. Alerts are emailed too, unless disabled on the email channel. The
+// notification is tagged with the request ID carried by ctx.
+func (nc *NotificationController) Notify(ctx context.Context, user models.User, notification *models.Notification) (bool, error) {
+	if notification.Event == models.NotifyAlerts && user.Email != "" && user.NotificationPrefs.Enabled(models.NotifyAlerts, models.ChannelEmail) {
+		sendEmail(nc.mailQueue, nc.logger, user, user.Email, emails.Alert, emails.Data{
+			"Title":   notification.Title,
+			"Message": notification.Message,
+		})
+	}
+
+	if !user.NotificationPrefs.Enabled(notification.Event, models.ChannelInApp) {
+		return false, nil
+	}
+
+	notification.User = user.ID
+	if notification.RequestID == "" {
+		notification.RequestID = utils.RequestID(ctx)
+	}
+	result, err := nc.collection.InsertOne(ctx, notification)
+	if err != nil {
+		return false, err
+	}
+	notification.ID = result.InsertedID.(primitive.ObjectID)
+	return true, nil
+}
+
+// NotifyUser is Notify for a user known by ID, whose preferences are loaded first
+func (nc *NotificationController) NotifyUser(ctx context.Context, userID primitive.ObjectID, notification *models.Notification) (bool, error) {
+	var user models.User
+	err := nc.userCollection.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(recipientFields)).Decode(&user)
+	if err != nil {
+		return false, err
+	}
+	return nc.Notify(ctx, user, notification)
+}
+
+// GetNotifications lists the authenticated user's notifications, newest first.
+// ?unread=true only returns the ones not read yet.
+func (nc *NotificationController) GetNotifications(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	filter := bson.M{"user": userID}
+	if c.Query("unread") == "true" {
+		filter["readAt"] = bson.M{"$exists": false}
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"createdAt": -1}).SetLimit(100)
+	cursor, err := nc.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch notifications",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to decode notifications",
+		})
+		return
+	}
+
+	unread, err := nc.collection.CountDocuments(ctx, bson.M{"user": userID, "readAt": bson.M{"$exists": false}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count unread notifications",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(notifications),
+		"unread":  unread,
+		"data":    notifications,
+	})
+}
+
+// MarkRead marks one of the authenticated user's notifications as read
+func (nc *NotificationController) MarkRead(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid notification ID",
+		})
+		return
+	}
+
+	// A notification read earlier keeps its first read time
+	var notification models.Notification
+	err = nc.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id, "user": userID},
+		[]bson.M{{"$set": bson.M{"readAt": bson.M{"$ifNull": bson.A{"$readAt", time.Now()}}}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&notification)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Notification not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update notification",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    notification,
+	})
+}
+
+// MarkAllRead marks every unread notification of the authenticated user as read
+func (nc *NotificationController) MarkAllRead(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	result, err := nc.collection.UpdateMany(
+		ctx,
+		bson.M{"user": userID, "readAt": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"readAt": time.Now()}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update notifications",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"updated": result.ModifiedCount,
+	})
+}
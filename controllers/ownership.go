@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"net/http"
+
+	"gotodolist/authz"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// authorize reports whether the authenticated user may perform an action on
+// a resource owned by owner, as decided by the authz rules. When they may
+// not, the request is answered with respondNotOwned.
+func authorize(c *gin.Context, action authz.Action, owner primitive.ObjectID, notFoundMessage, forbiddenMessage string) bool {
+	user, _ := c.Get("user")
+	userObj, _ := user.(models.User)
+	if authz.Can(userObj, action, owner) {
+		return true
+	}
+
+	respondNotOwned(c, notFoundMessage, forbiddenMessage)
+	return false
+}
+
+// respondNotOwned answers a request for a resource owned by another user.
+// By default this is a 403 with the given message; when HIDE_FOREIGN_RESOURCES
+// is enabled it is the same 404 a missing resource gets, so callers cannot
+// probe for the IDs of other users' resources.
+func respondNotOwned(c *gin.Context, notFoundMessage, forbiddenMessage string) {
+	if utils.GetEnv("HIDE_FOREIGN_RESOURCES", "false") == "true" {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   notFoundMessage,
+		})
+		return
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"success": false,
+		"error":   forbiddenMessage,
+	})
+}
@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gotodolist/middleware"
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PolicyController serves versioned policy documents and records their acceptance
+type PolicyController struct {
+	collection     *mongo.Collection
+	userCollection *mongo.Collection
+	userCache      *middleware.UserCache
+	policyGate     *middleware.PolicyGate
+}
+
+// NewPolicyController creates a new policy controller
+func NewPolicyController(collection *mongo.Collection, userCollection *mongo.Collection, userCache *middleware.UserCache, policyGate *middleware.PolicyGate) *PolicyController {
+	return &PolicyController{
+		collection:     collection,
+		userCollection: userCollection,
+		userCache:      userCache,
+		policyGate:     policyGate,
+	}
+}
+
+// GetPolicies returns the latest version of each published policy
+func (pc *PolicyController) GetPolicies(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	policies := []models.Policy{}
+	for _, policyType := range models.PolicyTypes {
+		policy, err := pc.findPolicy(ctx, policyType, 0)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to fetch policies",
+			})
+			return
+		}
+		policies = append(policies, *policy)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(policies),
+		"data":    policies,
+	})
+}
+
+// GetPolicy returns the latest version of a policy, or the one given by ?version=
+func (pc *PolicyController) GetPolicy(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	policyType := c.Param("type")
+	if !models.IsPolicyType(policyType) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Policy not found",
+		})
+		return
+	}
+
+	version := 0
+	if versionParam := c.Query("version"); versionParam != "" {
+		var err error
+		version, err = strconv.Atoi(versionParam)
+		if err != nil || version < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Version must be a positive number",
+			})
+			return
+		}
+	}
+
+	policy, err := pc.findPolicy(ctx, policyType, version)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Policy not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch policy",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// AcceptPolicy records that the authenticated user accepted a policy version,
+// which must be the latest one
+func (pc *PolicyController) AcceptPolicy(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	var input struct {
+		Version int `json:"version" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	policyType := c.Param("type")
+	if !models.IsPolicyType(policyType) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Policy not found",
+		})
+		return
+	}
+
+	latest, err := pc.findPolicy(ctx, policyType, 0)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Policy not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch policy",
+		})
+		return
+	}
+
+	if input.Version != latest.Version {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Only the latest version (" + strconv.Itoa(latest.Version) + ") can be accepted",
+		})
+		return
+	}
+
+	acceptance := models.PolicyAcceptance{
+		Version:    latest.Version,
+		AcceptedAt: time.Now(),
+	}
+	_, err = pc.userCollection.UpdateOne(
+		ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"acceptedPolicies." + policyType: acceptance}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to record acceptance",
+		})
+		return
+	}
+	pc.userCache.Invalidate(userID.(primitive.ObjectID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"type":       policyType,
+			"version":    acceptance.Version,
+			"acceptedAt": acceptance.AcceptedAt,
+		},
+	})
+}
+
+// PublishPolicy publishes a new version of a policy, every user has to
+// accept it before using the API again
+func (pc *PolicyController) PublishPolicy(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Type    string `json:"type" binding:"required"`
+		Title   string `json:"title" binding:"required"`
+		Content string `json:"content" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	if !models.IsPolicyType(input.Type) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Type must be one of: terms, privacy",
+		})
+		return
+	}
+
+	version := 1
+	latest, err := pc.findPolicy(ctx, input.Type, 0)
+	if err == nil {
+		version = latest.Version + 1
+	} else if err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch policy",
+		})
+		return
+	}
+
+	policy := models.Policy{
+		Type:        input.Type,
+		Version:     version,
+		Title:       input.Title,
+		Content:     input.Content,
+		PublishedBy: c.MustGet("userId").(primitive.ObjectID),
+		PublishedAt: time.Now(),
+	}
+
+	result, err := pc.collection.InsertOne(ctx, policy)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to publish policy",
+		})
+		return
+	}
+	policy.ID = result.InsertedID.(primitive.ObjectID)
+	pc.policyGate.Invalidate()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// findPolicy loads a version of a policy type, the latest one when version is 0
+func (pc *PolicyController) findPolicy(ctx context.Context, policyType string, version int) (*models.Policy, error) {
+	filter := bson.M{"type": policyType}
+	if version > 0 {
+		filter["version"] = version
+	}
+
+	var policy models.Policy
+	err := pc.collection.FindOne(ctx, filter, options.FindOne().SetSort(bson.M{"version": -1})).Decode(&policy)
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
@@ -0,0 +1,495 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gotodolist/authz"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// maxProjects caps the number of projects of a user
+	maxProjects = 100
+	// maxProjectName is the longest project name, in characters
+	maxProjectName = 64
+)
+
+// ProjectController manages the projects of the authenticated user, the
+// lists their tasks are grouped in
+type ProjectController struct {
+	collection         *mongo.Collection
+	taskCollection     *mongo.Collection
+	templateCollection *mongo.Collection
+	goalCollection     *mongo.Collection
+	contextCollection  *mongo.Collection
+	tagCollection      *mongo.Collection
+	userCollection     *mongo.Collection
+	notifier           *NotificationController
+	maxTasks           int64
+	logger             *utils.Logger
+}
+
+// NewProjectController creates a new project controller. The goal, context
+// and tag collections are recounted when a project deletes its tasks, the
+// users are those the tasks of a project can be assigned to, and projects
+// created from a template respect the MAX_TASKS limit of the tasks it adds.
+func NewProjectController(collection *mongo.Collection, taskCollection *mongo.Collection, templateCollection *mongo.Collection, goalCollection *mongo.Collection, contextCollection *mongo.Collection, tagCollection *mongo.Collection, userCollection *mongo.Collection, notifier *NotificationController) *ProjectController {
+	maxTasks, err := strconv.ParseInt(utils.GetEnv("MAX_TASKS", "0"), 10, 64)
+	if err != nil || maxTasks < 0 {
+		maxTasks = 0
+	}
+
+	return &ProjectController{
+		collection:         collection,
+		taskCollection:     taskCollection,
+		templateCollection: templateCollection,
+		goalCollection:     goalCollection,
+		contextCollection:  contextCollection,
+		tagCollection:      tagCollection,
+		userCollection:     userCollection,
+		notifier:           notifier,
+		maxTasks:           maxTasks,
+		logger:             utils.GetLogger().Named("projects"),
+	}
+}
+
+// GetProjects lists the authenticated user's projects by name
+func (pc *ProjectController) GetProjects(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	cursor, err := pc.collection.Find(ctx, bson.M{"user": userID}, options.Find().SetSort(bson.M{"name": 1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch projects",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	projects := []models.Project{}
+	if err := cursor.All(ctx, &projects); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse projects",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(projects),
+		"data":    projects,
+	})
+}
+
+// GetProject returns a project
+func (pc *ProjectController) GetProject(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	project, ok := pc.findProject(ctx, c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    project,
+	})
+}
+
+// CreateProject creates a project with a name unique among the user's
+// projects and an optional color and icon. With "template", a starter
+// template key or the ID of a saved template, the project starts with the
+// template's sections and tasks and takes its name, color and icon unless
+// given.
+func (pc *ProjectController) CreateProject(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	var input struct {
+		Name     string `json:"name"`
+		Color    string `json:"color"`
+		Icon     string `json:"icon"`
+		Template string `json:"template"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || (input.Name == "" && input.Template == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	template := models.ProjectTemplate{}
+	if input.Template != "" {
+		var ok bool
+		if template, ok = pc.findTemplate(ctx, c, input.Template, userID); !ok {
+			return
+		}
+		if input.Name == "" {
+			input.Name = template.Name
+		}
+		if input.Color == "" {
+			input.Color = template.Color
+		}
+		if input.Icon == "" {
+			input.Icon = template.Icon
+		}
+	}
+
+	name, ok := validProjectName(c, input.Name)
+	if !ok {
+		return
+	}
+	if !validAppearance(c, input.Color, input.Icon) {
+		return
+	}
+
+	count, err := pc.collection.CountDocuments(ctx, bson.M{"user": userID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count projects",
+		})
+		return
+	}
+	if count >= maxProjects {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("An account can have at most %d projects", maxProjects),
+		})
+		return
+	}
+
+	if !pc.templateQuota(ctx, c, userID, template) {
+		return
+	}
+
+	project := models.NewProject(name, userID.(primitive.ObjectID))
+	project.Color = input.Color
+	project.Icon = input.Icon
+	project.Sections = templateSections(template)
+	result, err := pc.collection.InsertOne(ctx, project)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Project already exists",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create project",
+		})
+		return
+	}
+
+	project.ID = result.InsertedID.(primitive.ObjectID)
+
+	if err := pc.createTemplateTasks(ctx, project, template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create template tasks",
+		})
+		return
+	}
+
+	warnings := pc.notifier.QuotaWarnings(ctx, project.User, "projects", "projects", count+1, maxProjects)
+
+	c.JSON(http.StatusCreated, withWarnings(gin.H{
+		"success": true,
+		"data":    project,
+	}, warnings))
+}
+
+// UpdateProject renames a project or changes its color or icon, an empty
+// color or icon clearing it
+func (pc *ProjectController) UpdateProject(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	var input struct {
+		Name  *string `json:"name"`
+		Color *string `json:"color"`
+		Icon  *string `json:"icon"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	updateSet := bson.M{"updatedAt": time.Now()}
+	updateUnset := bson.M{}
+	if input.Name != nil {
+		name, ok := validProjectName(c, *input.Name)
+		if !ok {
+			return
+		}
+		updateSet["name"] = name
+	}
+	if input.Color != nil {
+		if !validAppearance(c, *input.Color, "") {
+			return
+		}
+		if *input.Color == "" {
+			updateUnset["color"] = ""
+		} else {
+			updateSet["color"] = *input.Color
+		}
+	}
+	if input.Icon != nil {
+		if !validAppearance(c, "", *input.Icon) {
+			return
+		}
+		if *input.Icon == "" {
+			updateUnset["icon"] = ""
+		} else {
+			updateSet["icon"] = *input.Icon
+		}
+	}
+
+	project, ok := pc.findProject(ctx, c)
+	if !ok {
+		return
+	}
+
+	update := bson.M{"$set": updateSet}
+	if len(updateUnset) > 0 {
+		update["$unset"] = updateUnset
+	}
+	var updated models.Project
+	err := pc.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": project.ID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Project already exists",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update project",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+	})
+}
+
+// DeleteProject deletes a project. With ?mode=orphan, the default, its
+// tasks are kept and lose their project; with ?mode=cascade they are
+// deleted too. The number of tasks detached or deleted is returned.
+func (pc *ProjectController) DeleteProject(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	mode := utils.GetQueryDefault(c, "mode", models.ProjectDeleteOrphan)
+	if mode != models.ProjectDeleteOrphan && mode != models.ProjectDeleteCascade {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "mode must be one of: orphan, cascade",
+		})
+		return
+	}
+
+	project, ok := pc.findProject(ctx, c)
+	if !ok {
+		return
+	}
+
+	// The tasks are handled first, so a delete interrupted half way can be
+	// retried, and with the project in one transaction where the deployment
+	// has them
+	var tasks int64
+	var failure string
+	err := withTransaction(ctx, pc.collection.Database().Client(), func(ctx context.Context) error {
+		var err error
+		failure = "Failed to delete project tasks"
+		if mode == models.ProjectDeleteCascade {
+			tasks, err = pc.deleteTasks(ctx, project)
+		} else {
+			var result *mongo.UpdateResult
+			result, err = pc.taskCollection.UpdateMany(
+				ctx,
+				bson.M{"user": project.User, "project": project.ID},
+				bson.M{"$unset": bson.M{"project": "", "section": ""}},
+			)
+			if err == nil {
+				tasks = result.ModifiedCount
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		failure = "Failed to delete project"
+		_, err = pc.collection.DeleteOne(ctx, bson.M{"_id": project.ID})
+		return err
+	})
+	if err != nil {
+		pc.logger.With("project", project.ID.Hex()).Error("Failed to " + mode + " project: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   failure,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"mode": mode, "tasks": tasks},
+	})
+}
+
+// EnsureIndexes creates the index keeping project names unique per user
+func (pc *ProjectController) EnsureIndexes(ctx context.Context) error {
+	_, err := pc.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// deleteTasks deletes the tasks of a project and takes them off the goals,
+// contexts and tags they were in, returning the number of tasks deleted
+func (pc *ProjectController) deleteTasks(ctx context.Context, project *models.Project) (int64, error) {
+	filter := bson.M{"user": project.User, "project": project.ID}
+	var deleted int64
+	err := writeWithCounts(ctx, pc.taskCollection, pc.counters(), func(ctx context.Context) (countChanges, error) {
+		ids, err := pc.taskCollection.Distinct(ctx, "_id", filter)
+		if err != nil {
+			return nil, err
+		}
+
+		// Tasks are deleted one by one so each counts the groups it leaves
+		deleted = 0
+		changes := countChanges{}
+		for _, id := range ids {
+			_, changed, err := deleteCounted(ctx, pc.taskCollection, bson.M{"_id": id, "user": project.User, "project": project.ID})
+			if err == mongo.ErrNoDocuments {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			changes.merge(changed)
+			deleted++
+		}
+		return changes, nil
+	})
+	if err != nil {
+		return deleted, err
+	}
+	pc.logger.With("project", project.ID.Hex()).Info(fmt.Sprintf("Deleted %d tasks with their project", deleted))
+	return deleted, nil
+}
+
+// findProject loads the project referenced by the :id parameter and checks
+// that it belongs to the authenticated user, writing the error response otherwise
+func (pc *ProjectController) findProject(ctx context.Context, c *gin.Context) (*models.Project, bool) {
+	// Get user ID from context
+	_, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return nil, false
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid project ID format",
+		})
+		return nil, false
+	}
+
+	var project models.Project
+	err = pc.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&project)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Project not found",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch project",
+		})
+		return nil, false
+	}
+
+	// Check that the user may act on the project
+	if !authorize(c, authz.ProjectManage, project.User, "Project not found", "Not authorized to access this project") {
+		return nil, false
+	}
+
+	return &project, true
+}
+
+// validProjectName trims a project name, writing the error response when
+// it is empty or too long
+func validProjectName(c *gin.Context, name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxProjectName {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("Project name must be between 1 and %d characters", maxProjectName),
+		})
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,32 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"gotodolist/controllers"
+	"gotodolist/routes"
+	"gotodolist/testutil"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestProjectAppearanceValidation checks that projects are not given an
+// invalid name, color or icon
+func TestProjectAppearanceValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := testutil.NewUser()
+	projectController := controllers.NewProjectController(nil, nil, nil, nil, nil, nil, nil, nil)
+	router := gin.New()
+	routes.SetupProjectRoutes(router, projectController, testutil.NewAuthMiddleware(user))
+	client := testutil.NewClient(t, router, user)
+
+	testutil.Error(t, client.Do(t, http.MethodPost, "/projects/", gin.H{}), http.StatusBadRequest, "Invalid input data")
+	testutil.Error(t, client.Do(t, http.MethodPost, "/projects/", gin.H{"name": "Garden", "color": "green"}), http.StatusBadRequest, "Color must be a hex color such as #ff8800")
+	testutil.Error(t, client.Do(t, http.MethodPost, "/projects/", gin.H{"name": "Garden", "icon": "a watering can for the plants in the garden"}), http.StatusBadRequest, "Icon must be an emoji or a name of at most 32 characters")
+
+	path := "/projects/" + primitive.NewObjectID().Hex()
+	testutil.Error(t, client.Do(t, http.MethodPut, path, gin.H{"icon": "a watering can for the plants in the garden"}), http.StatusBadRequest, "Icon must be an emoji or a name of at most 32 characters")
+}
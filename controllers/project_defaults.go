@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SetDefaults replaces the defaults of a project: the priority, tags,
+// reminders (in minutes before the due date) and assignee, by email, that
+// the tasks created in it start with
+func (pc *ProjectController) SetDefaults(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	var input struct {
+		Priority  string   `json:"priority"`
+		Tags      []string `json:"tags"`
+		Reminders []int    `json:"reminders"`
+		Assignee  string   `json:"assignee"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	if input.Priority != "" && input.Priority != "low" && input.Priority != "medium" && input.Priority != "high" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Priority must be one of: low, medium, high",
+		})
+		return
+	}
+	tags, ok := validTags(c, input.Tags)
+	if !ok {
+		return
+	}
+	if len(input.Reminders) > maxReminders {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("A task can have at most %d reminders", maxReminders),
+		})
+		return
+	}
+	reminders := []int{}
+	seen := map[int]bool{}
+	for _, before := range input.Reminders {
+		if before < 1 || time.Duration(before)*time.Minute > maxReminderLead {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Reminders must be between 1 and 10080 minutes",
+			})
+			return
+		}
+		if !seen[before] {
+			seen[before] = true
+			reminders = append(reminders, before)
+		}
+	}
+
+	project, ok := pc.findProject(ctx, c)
+	if !ok {
+		return
+	}
+
+	defaults := models.ProjectDefaults{Priority: input.Priority, Tags: tags, Reminders: reminders}
+	if input.Assignee != "" {
+		assignee, ok := pc.projectAssignee(ctx, c, project, input.Assignee)
+		if !ok {
+			return
+		}
+		defaults.Assignee = assignee
+	}
+
+	update := bson.M{"$set": bson.M{"defaults": defaults, "updatedAt": time.Now()}}
+	if defaults.Priority == "" && len(defaults.Tags) == 0 && len(defaults.Reminders) == 0 && defaults.Assignee == nil {
+		update = bson.M{"$unset": bson.M{"defaults": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	}
+	pc.updateDefaults(ctx, c, project, update)
+}
+
+// DeleteDefaults clears the defaults of a project
+func (pc *ProjectController) DeleteDefaults(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	project, ok := pc.findProject(ctx, c)
+	if !ok {
+		return
+	}
+
+	pc.updateDefaults(ctx, c, project, bson.M{"$unset": bson.M{"defaults": ""}, "$set": bson.M{"updatedAt": time.Now()}})
+}
+
+// updateDefaults applies an update to the defaults of a project and
+// answers with the updated project
+func (pc *ProjectController) updateDefaults(ctx context.Context, c *gin.Context, project *models.Project, update bson.M) {
+	var updated models.Project
+	err := pc.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": project.ID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update project defaults",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+	})
+}
+
+// projectAssignee finds the active user with the given email that tasks of
+// the project can be offered to, writing the error response otherwise
+func (pc *ProjectController) projectAssignee(ctx context.Context, c *gin.Context, project *models.Project, email string) (*models.ProjectAssignee, bool) {
+	var user models.User
+	err := pc.userCollection.FindOne(ctx, bson.M{
+		"email":         strings.TrimSpace(email),
+		"deactivatedAt": bson.M{"$exists": false},
+	}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "No user with this email",
+		})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to find user",
+		})
+		return nil, false
+	}
+	if user.ID == project.User {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Tasks cannot be delegated to yourself",
+		})
+		return nil, false
+	}
+
+	return &models.ProjectAssignee{User: user.ID, Email: user.Email}, true
+}
+
+// projectDefaults returns the defaults of a project, nil when it has none,
+// writing the error response when it cannot be read
+func (tc *TaskController) projectDefaults(ctx context.Context, c *gin.Context, projectID primitive.ObjectID) (*models.ProjectDefaults, bool) {
+	var project models.Project
+	err := tc.projectCollection.FindOne(ctx, bson.M{"_id": projectID}, options.FindOne().SetProjection(bson.M{"defaults": 1})).Decode(&project)
+	if err != nil && err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch project",
+		})
+		return nil, false
+	}
+	return project.Defaults, true
+}
+
+// applyProjectDefaults fills in what a new task did not set from the
+// defaults of its project. Reminders need a due date, and the task is
+// offered to the assignee only while it is open.
+func applyProjectDefaults(task *models.Task, defaults *models.ProjectDefaults, prioritySet bool, tagsSet bool) {
+	if defaults == nil {
+		return
+	}
+	if !prioritySet && defaults.Priority != "" {
+		task.Priority = defaults.Priority
+	}
+	if !tagsSet && len(defaults.Tags) > 0 {
+		task.Tags = defaults.Tags
+	}
+	if task.DueDate != nil {
+		for _, before := range defaults.Reminders {
+			task.Reminders = append(task.Reminders, models.Reminder{ID: primitive.NewObjectID(), Before: before})
+		}
+	}
+	if defaults.Assignee != nil && defaults.Assignee.User != task.User && !task.Completed {
+		task.Delegation = &models.Delegation{To: defaults.Assignee.User, Email: defaults.Assignee.Email, OfferedAt: time.Now()}
+	}
+}
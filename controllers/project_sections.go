@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxProjectSections caps the sections of a project
+const maxProjectSections = 50
+
+// SectionGroup is a section of a project with its tasks. Tasks without a
+// section are listed last under the key "none".
+type SectionGroup struct {
+	Key   string        `json:"key"`
+	Name  string        `json:"name"`
+	Count int           `json:"count"`
+	Open  int           `json:"openCount"`
+	Tasks []models.Task `json:"tasks"`
+}
+
+// GetSections lists the sections of a project in order, each with its tasks
+func (pc *ProjectController) GetSections(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	project, ok := pc.findProject(ctx, c)
+	if !ok {
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "completed", Value: 1}, {Key: "dueDate", Value: 1}, {Key: "createdAt", Value: 1}})
+	cursor, err := pc.taskCollection.Find(ctx, bson.M{"user": project.User, "project": project.ID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch tasks",
+		})
+		return
+	}
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse tasks",
+		})
+		return
+	}
+
+	// Tasks filed under a section that no longer exists count as unsectioned
+	tasksBySection := map[string][]models.Task{}
+	for _, task := range tasks {
+		key := noGroup
+		if task.Section != nil {
+			if _, ok := project.Section(*task.Section); ok {
+				key = task.Section.Hex()
+			}
+		}
+		tasksBySection[key] = append(tasksBySection[key], task)
+	}
+
+	groups := []SectionGroup{}
+	for _, section := range project.Sections {
+		groups = append(groups, sectionGroup(section.ID.Hex(), section.Name, tasksBySection))
+	}
+	groups = append(groups, sectionGroup(noGroup, "", tasksBySection))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    groups,
+	})
+}
+
+// CreateSection adds a section to a project, at the end unless "position"
+// (counted from 0) is given
+func (pc *ProjectController) CreateSection(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Name     string `json:"name" binding:"required,max=100"`
+		Position *int   `json:"position"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	project, ok := pc.findProject(ctx, c)
+	if !ok {
+		return
+	}
+
+	if len(project.Sections) >= maxProjectSections {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("A project can have at most %d sections", maxProjectSections),
+		})
+		return
+	}
+
+	position := len(project.Sections)
+	if input.Position != nil && *input.Position >= 0 && *input.Position < position {
+		position = *input.Position
+	}
+
+	section := models.ProjectSection{ID: primitive.NewObjectID(), Name: strings.TrimSpace(input.Name)}
+	pc.updateSections(ctx, c, project, bson.M{
+		"$push": bson.M{"sections": bson.M{"$each": bson.A{section}, "$position": position}},
+	}, http.StatusCreated)
+}
+
+// UpdateSection renames a section of a project
+func (pc *ProjectController) UpdateSection(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Name string `json:"name" binding:"required,max=100"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	project, ok := pc.findProject(ctx, c)
+	if !ok {
+		return
+	}
+	section, ok := findSection(c, project)
+	if !ok {
+		return
+	}
+
+	pc.updateSections(ctx, c, project, bson.M{
+		"$set": bson.M{"sections.$[section].name": strings.TrimSpace(input.Name)},
+	}, http.StatusOK, options.FindOneAndUpdate().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"section._id": section.ID}},
+	}))
+}
+
+// ReorderSections puts the sections of a project in the order of "order", which
+// must list every section ID once
+func (pc *ProjectController) ReorderSections(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Order []string `json:"order" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	project, ok := pc.findProject(ctx, c)
+	if !ok {
+		return
+	}
+
+	sections := []models.ProjectSection{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, id := range input.Order {
+		sectionID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid section ID format",
+			})
+			return
+		}
+		section, ok := project.Section(sectionID)
+		if !ok || seen[sectionID] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Order must list every section of the project once",
+			})
+			return
+		}
+		seen[sectionID] = true
+		sections = append(sections, section)
+	}
+	if len(sections) != len(project.Sections) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Order must list every section of the project once",
+		})
+		return
+	}
+
+	pc.updateSections(ctx, c, project, bson.M{"$set": bson.M{"sections": sections}}, http.StatusOK)
+}
+
+// DeleteSection removes a section from a project. Its tasks stay in the
+// project without a section.
+func (pc *ProjectController) DeleteSection(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	project, ok := pc.findProject(ctx, c)
+	if !ok {
+		return
+	}
+	section, ok := findSection(c, project)
+	if !ok {
+		return
+	}
+
+	_, err := pc.taskCollection.UpdateMany(
+		ctx,
+		bson.M{"project": project.ID, "section": section.ID},
+		bson.M{"$unset": bson.M{"section": ""}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to detach section tasks",
+		})
+		return
+	}
+
+	pc.updateSections(ctx, c, project, bson.M{
+		"$pull": bson.M{"sections": bson.M{"_id": section.ID}},
+	}, http.StatusOK)
+}
+
+// updateSections applies an update to the sections of a project and
+// responds with the updated project
+func (pc *ProjectController) updateSections(ctx context.Context, c *gin.Context, project *models.Project, update bson.M, status int, opts ...*options.FindOneAndUpdateOptions) {
+	if set, ok := update["$set"].(bson.M); ok {
+		set["updatedAt"] = time.Now()
+	} else {
+		update["$set"] = bson.M{"updatedAt": time.Now()}
+	}
+
+	opts = append(opts, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	var updated models.Project
+	err := pc.collection.FindOneAndUpdate(ctx, bson.M{"_id": project.ID}, update, opts...).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Project not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update project sections",
+		})
+		return
+	}
+
+	c.JSON(status, gin.H{
+		"success": true,
+		"data":    updated,
+	})
+}
+
+// findSection returns the section of a project referenced by the
+// :sectionId parameter, writing the error response when there is none
+func findSection(c *gin.Context, project *models.Project) (models.ProjectSection, bool) {
+	sectionID, err := primitive.ObjectIDFromHex(c.Param("sectionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid section ID format",
+		})
+		return models.ProjectSection{}, false
+	}
+
+	section, ok := project.Section(sectionID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Section not found",
+		})
+		return models.ProjectSection{}, false
+	}
+	return section, true
+}
+
+// sectionGroup builds the group of a section from the tasks keyed by section
+func sectionGroup(key string, name string, tasksBySection map[string][]models.Task) SectionGroup {
+	tasks := tasksBySection[key]
+	if tasks == nil {
+		tasks = []models.Task{}
+	}
+	return SectionGroup{
+		Key:   key,
+		Name:  name,
+		Count: len(tasks),
+		Open:  countOpen(tasks),
+		Tasks: tasks,
+	}
+}
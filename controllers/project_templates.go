@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxProjectTemplates caps the templates a user can save
+const maxProjectTemplates = 50
+
+// maxTemplateTasks caps the tasks of a template
+const maxTemplateTasks = 200
+
+// GetTemplates lists the starter templates and the authenticated user's own
+// templates, newest first
+func (pc *ProjectController) GetTemplates(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	cursor, err := pc.templateCollection.Find(ctx, bson.M{"user": userID}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch templates",
+		})
+		return
+	}
+	templates := []models.ProjectTemplate{}
+	if err := cursor.All(ctx, &templates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse templates",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"starters":  models.StarterTemplates,
+			"templates": templates,
+		},
+	})
+}
+
+// SaveTemplate saves a project with its sections and tasks as a template of
+// the authenticated user, named after the project unless "name" is given.
+// Due dates are kept relative to the day the project was created.
+func (pc *ProjectController) SaveTemplate(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Name string `json:"name" binding:"max=100"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	project, ok := pc.findProject(ctx, c)
+	if !ok {
+		return
+	}
+
+	count, err := pc.templateCollection.CountDocuments(ctx, bson.M{"user": project.User})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count templates",
+		})
+		return
+	}
+	if count >= maxProjectTemplates {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("An account can have at most %d templates", maxProjectTemplates),
+		})
+		return
+	}
+
+	cursor, err := pc.taskCollection.Find(ctx, bson.M{"user": project.User, "project": project.ID},
+		options.Find().SetSort(bson.D{{Key: "dueDate", Value: 1}, {Key: "createdAt", Value: 1}}).SetLimit(maxTemplateTasks+1))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch tasks",
+		})
+		return
+	}
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse tasks",
+		})
+		return
+	}
+	if len(tasks) > maxTemplateTasks {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("A template can hold at most %d tasks", maxTemplateTasks),
+		})
+		return
+	}
+
+	template := models.ProjectTemplate{
+		Name:      project.Name,
+		Color:     project.Color,
+		Icon:      project.Icon,
+		Tasks:     []models.TemplateTask{},
+		User:      project.User,
+		CreatedAt: time.Now(),
+	}
+	if input.Name != "" {
+		template.Name = input.Name
+	}
+	for _, section := range project.Sections {
+		template.Sections = append(template.Sections, section.Name)
+	}
+	start := utils.StartOfDay(project.CreatedAt.Local())
+	for _, task := range tasks {
+		templateTask := models.TemplateTask{
+			Title:       task.Title,
+			Description: task.Description,
+			Priority:    task.Priority,
+			Estimate:    task.Estimate,
+			Context:     task.Context,
+			Tags:        task.Tags,
+			Color:       task.Color,
+			Icon:        task.Icon,
+		}
+		if task.Section != nil {
+			if section, ok := project.Section(*task.Section); ok {
+				templateTask.Section = section.Name
+			}
+		}
+		if task.DueDate != nil {
+			dueInDays := int(math.Max(0, math.Round(utils.StartOfDay(task.DueDate.Local()).Sub(start).Hours()/24)))
+			templateTask.DueInDays = &dueInDays
+		}
+		template.Tasks = append(template.Tasks, templateTask)
+	}
+
+	result, err := pc.templateCollection.InsertOne(ctx, template)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to save template",
+		})
+		return
+	}
+	template.ID = result.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    template,
+	})
+}
+
+// DeleteTemplate deletes a template of the authenticated user
+func (pc *ProjectController) DeleteTemplate(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid template ID format",
+		})
+		return
+	}
+
+	result, err := pc.templateCollection.DeleteOne(ctx, bson.M{"_id": objectID, "user": userID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete template",
+		})
+		return
+	}
+	if result.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Template not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Template deleted successfully",
+	})
+}
+
+// findTemplate resolves a starter template key or the ID of one of the
+// user's templates, writing the error response when there is none
+func (pc *ProjectController) findTemplate(ctx context.Context, c *gin.Context, reference string, userID interface{}) (models.ProjectTemplate, bool) {
+	if template, ok := models.StarterTemplate(reference); ok {
+		return template, true
+	}
+
+	var template models.ProjectTemplate
+	objectID, err := primitive.ObjectIDFromHex(reference)
+	if err == nil {
+		err = pc.templateCollection.FindOne(ctx, bson.M{"_id": objectID, "user": userID}).Decode(&template)
+		if err != nil && err != mongo.ErrNoDocuments {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to fetch template",
+			})
+			return template, false
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Template not found",
+		})
+		return template, false
+	}
+	return template, true
+}
+
+// templateQuota checks that the tasks of a template fit in the user's
+// MAX_TASKS, writing the error response otherwise
+func (pc *ProjectController) templateQuota(ctx context.Context, c *gin.Context, userID interface{}, template models.ProjectTemplate) bool {
+	if pc.maxTasks == 0 || len(template.Tasks) == 0 {
+		return true
+	}
+
+	count, err := pc.taskCollection.CountDocuments(ctx, bson.M{"user": userID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count tasks",
+		})
+		return false
+	}
+	if count+int64(len(template.Tasks)) > pc.maxTasks {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("An account can have at most %d tasks", pc.maxTasks),
+		})
+		return false
+	}
+	return true
+}
+
+// templateSections returns the sections of a new project created from a
+// template
+func templateSections(template models.ProjectTemplate) []models.ProjectSection {
+	sections := make([]models.ProjectSection, 0, len(template.Sections))
+	for _, name := range template.Sections {
+		sections = append(sections, models.ProjectSection{ID: primitive.NewObjectID(), Name: name})
+	}
+	return sections
+}
+
+// createTemplateTasks creates the tasks of a template in a new project,
+// filed under the sections of the same name. Task contexts the user does
+// not have are left out.
+func (pc *ProjectController) createTemplateTasks(ctx context.Context, project *models.Project, template models.ProjectTemplate) error {
+	if len(template.Tasks) == 0 {
+		return nil
+	}
+
+	names, err := pc.contextCollection.Distinct(ctx, "name", bson.M{"user": project.User})
+	if err != nil {
+		return err
+	}
+	contexts := map[string]bool{}
+	for _, name := range names {
+		if name, ok := name.(string); ok {
+			contexts[name] = true
+		}
+	}
+
+	sections := map[string]primitive.ObjectID{}
+	for _, section := range project.Sections {
+		sections[section.Name] = section.ID
+	}
+
+	today := utils.StartOfDay(project.CreatedAt.Local())
+	tasks := make([]interface{}, 0, len(template.Tasks))
+	created := make([]models.Task, 0, len(template.Tasks))
+	for _, templateTask := range template.Tasks {
+		task := models.NewTask(templateTask.Title, project.User)
+		task.Description = templateTask.Description
+		task.Estimate = templateTask.Estimate
+		task.Project = &project.ID
+		if id, ok := sections[templateTask.Section]; ok {
+			task.Section = &id
+		}
+		task.Tags = templateTask.Tags
+		task.Color = templateTask.Color
+		task.Icon = templateTask.Icon
+		if templateTask.Priority != "" {
+			task.Priority = templateTask.Priority
+		}
+		if contexts[templateTask.Context] {
+			task.Context = templateTask.Context
+		}
+		if templateTask.DueInDays != nil {
+			// Due by the end of the day
+			dueDate := today.AddDate(0, 0, *templateTask.DueInDays+1).Add(-time.Minute)
+			task.DueDate = &dueDate
+		}
+		tasks = append(tasks, task)
+		created = append(created, *task)
+	}
+
+	return writeWithCounts(ctx, pc.taskCollection, pc.counters(), func(ctx context.Context) (countChanges, error) {
+		if _, err := pc.taskCollection.InsertMany(ctx, tasks); err != nil {
+			return nil, err
+		}
+		changes := countChanges{}
+		for _, task := range created {
+			changes.count(task, 1)
+		}
+		return changes, nil
+	})
+}
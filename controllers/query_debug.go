@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"gotodolist/authz"
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// queryDebugRequested reports whether the request asked for ?debug=true.
+// Only users with the debug:query permission (admins) may use it, unless the API runs in debug mode; otherwise the
+// forbidden response is written and ok is false.
+func queryDebugRequested(c *gin.Context) (requested bool, ok bool) {
+	if c.Query("debug") != "true" {
+		return false, true
+	}
+	if gin.Mode() == gin.DebugMode {
+		return true, true
+	}
+
+	user, exists := c.Get("user")
+	if userObj, isUser := user.(models.User); exists && isUser && authz.Can(userObj, authz.QueryDebug, primitive.NilObjectID) {
+		return true, true
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"success": false,
+		"error":   "Query debugging requires admin access",
+	})
+	return false, false
+}
+
+// explainFind returns the winning plan and execution stats of a find
+func explainFind(ctx context.Context, collection *mongo.Collection, filter, sort interface{}, skip, limit int64) (gin.H, error) {
+	command := bson.D{
+		{Key: "explain", Value: bson.D{
+			{Key: "find", Value: collection.Name()},
+			{Key: "filter", Value: filter},
+			{Key: "sort", Value: sort},
+			{Key: "skip", Value: skip},
+			{Key: "limit", Value: limit},
+		}},
+		{Key: "verbosity", Value: "executionStats"},
+	}
+
+	var result struct {
+		QueryPlanner struct {
+			WinningPlan bson.M `bson:"winningPlan"`
+		} `bson:"queryPlanner"`
+		ExecutionStats bson.M `bson:"executionStats"`
+	}
+	if err := collection.Database().RunCommand(ctx, command).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return gin.H{
+		"winningPlan":    result.QueryPlanner.WinningPlan,
+		"executionStats": result.ExecutionStats,
+	}, nil
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// quotaWarningRatio is the share of a quota from which responses warn that
+// it is nearly used up
+const quotaWarningRatio = 0.9
+
+// QuotaWarning tells a client that a quota is nearly used up, so it can
+// prompt the user before the limit rejects a request
+type QuotaWarning struct {
+	Quota   string `json:"quota"`
+	Used    int64  `json:"used"`
+	Limit   int64  `json:"limit"`
+	Message string `json:"message"`
+}
+
+// QuotaWarnings returns the warning of a quota whose usage reached the
+// warning ratio. The user is notified the first time, and again only after
+// their usage went back below the ratio. key identifies the quota among the
+// user's, what names the counted items, such as "contexts".
+func (nc *NotificationController) QuotaWarnings(ctx context.Context, userID primitive.ObjectID, key, what string, used, limit int64) []QuotaWarning {
+	field := "quotaWarnings." + key
+	if float64(used) < quotaWarningRatio*float64(limit) {
+		// Re-arm the notification once usage dropped
+		_, err := nc.userCollection.UpdateOne(ctx, bson.M{"_id": userID, field: bson.M{"$exists": true}}, bson.M{"$unset": bson.M{field: ""}})
+		if err != nil {
+			nc.logger.Warning("Failed to reset quota warning: " + err.Error())
+		}
+		return nil
+	}
+
+	warning := QuotaWarning{
+		Quota:   key,
+		Used:    used,
+		Limit:   limit,
+		Message: fmt.Sprintf("%d of %d %s used", used, limit, what),
+	}
+
+	result, err := nc.userCollection.UpdateOne(ctx, bson.M{"_id": userID, field: bson.M{"$exists": false}}, bson.M{"$set": bson.M{field: time.Now()}})
+	if err != nil {
+		nc.logger.Warning("Failed to record quota warning: " + err.Error())
+	} else if result.ModifiedCount == 1 {
+		notification := models.NewNotification(userID, models.NotifyAlerts, "Approaching a limit", warning.Message+".")
+		if _, err := nc.NotifyUser(ctx, userID, notification); err != nil {
+			nc.logger.Warning("Failed to send quota notification: " + err.Error())
+		}
+	}
+
+	return []QuotaWarning{warning}
+}
+
+// withWarnings adds the quota warnings to a response when there are any
+func withWarnings(response gin.H, warnings []QuotaWarning) gin.H {
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	return response
+}
+
+// taskQuota counts the tasks of the user when MAX_TASKS is set, writing the
+// error response once the limit is reached
+func (tc *TaskController) taskQuota(ctx context.Context, c *gin.Context, userID interface{}) (int64, bool) {
+	if tc.maxTasks == 0 {
+		return 0, true
+	}
+
+	count, err := tc.collection.CountDocuments(ctx, bson.M{"user": userID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count tasks",
+		})
+		return 0, false
+	}
+	if count >= tc.maxTasks {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("An account can have at most %d tasks", tc.maxTasks),
+		})
+		return 0, false
+	}
+	return count, true
+}
+
+// taskQuotaWarnings warns when the user's tasks approach MAX_TASKS
+func (tc *TaskController) taskQuotaWarnings(ctx context.Context, userID primitive.ObjectID, used int64) []QuotaWarning {
+	if tc.maxTasks == 0 {
+		return nil
+	}
+	return tc.notifier.QuotaWarnings(ctx, userID, "tasks", "tasks", used, tc.maxTasks)
+}
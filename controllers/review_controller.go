@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// reviewSectionLimit caps the tasks returned in each weekly review section,
+// the section count is always exact
+const reviewSectionLimit = 100
+
+// staleAfter is how long an open task can stay untouched before the weekly
+// review flags it as stale
+const staleAfter = 30 * 24 * time.Hour
+
+// ReviewController bundles the data of a GTD weekly review
+type ReviewController struct {
+	taskCollection *mongo.Collection
+}
+
+// NewReviewController creates a new review controller
+func NewReviewController(taskCollection *mongo.Collection) *ReviewController {
+	return &ReviewController{
+		taskCollection: taskCollection,
+	}
+}
+
+// reviewSection is one list of a weekly review
+type reviewSection struct {
+	name   string
+	filter bson.M
+	sort   bson.D
+}
+
+// GetWeeklyReview returns what a weekly review goes through in one response:
+// the tasks completed over the last 7 days, the overdue ones, the stale ones
+// untouched for 30 days, the ones due over the next 7 days and the inbox of
+// captured tasks waiting to be triaged
+func (rc *ReviewController) GetWeeklyReview(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	now := time.Now()
+	weekAgo := utils.StartOfDay(now).AddDate(0, 0, -7)
+	weekAhead := utils.StartOfDay(now).AddDate(0, 0, 8)
+
+	sections := []reviewSection{
+		{
+			name:   "completed",
+			filter: completedSince(userID, weekAgo),
+			sort:   bson.D{{Key: "completedAt", Value: -1}, {Key: "updatedAt", Value: -1}},
+		},
+		{
+			name:   "overdue",
+			filter: bson.M{"user": userID, "completed": false, "dueDate": bson.M{"$lt": now}},
+			sort:   bson.D{{Key: "dueDate", Value: 1}},
+		},
+		{
+			name:   "stale",
+			filter: bson.M{"user": userID, "completed": false, "updatedAt": bson.M{"$lt": now.Add(-staleAfter)}},
+			sort:   bson.D{{Key: "updatedAt", Value: 1}},
+		},
+		{
+			name:   "upcoming",
+			filter: bson.M{"user": userID, "completed": false, "dueDate": bson.M{"$gte": now, "$lt": weekAhead}},
+			sort:   bson.D{{Key: "dueDate", Value: 1}},
+		},
+		{
+			name:   "inbox",
+			filter: inboxFilter(userID),
+			sort:   bson.D{{Key: "createdAt", Value: 1}},
+		},
+	}
+
+	review := gin.H{
+		"from": weekAgo,
+		"to":   weekAhead,
+	}
+	for _, section := range sections {
+		count, err := rc.taskCollection.CountDocuments(ctx, section.filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to count tasks",
+			})
+			return
+		}
+
+		findOptions := options.Find().SetSort(section.sort).SetLimit(reviewSectionLimit)
+		cursor, err := rc.taskCollection.Find(ctx, section.filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to fetch tasks",
+			})
+			return
+		}
+
+		tasks := []models.Task{}
+		err = cursor.All(ctx, &tasks)
+		cursor.Close(ctx)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to parse tasks",
+			})
+			return
+		}
+
+		review[section.name] = gin.H{
+			"count": count,
+			"tasks": tasks,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    review,
+	})
+}
+
+// completedSince matches the tasks of a user completed since the given
+// time. Tasks completed before completion times were recorded fall back to
+// their last update.
+func completedSince(userID interface{}, since time.Time) bson.M {
+	return bson.M{
+		"user":      userID,
+		"completed": true,
+		"$or": bson.A{
+			bson.M{"completedAt": bson.M{"$gte": since}},
+			bson.M{"completedAt": bson.M{"$exists": false}, "updatedAt": bson.M{"$gte": since}},
+		},
+	}
+}
@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gotodolist/configs"
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// StatsController handles reporting endpoints
+type StatsController struct {
+	taskCollection *mongo.Collection
+	holidays       *HolidayController
+}
+
+// NewStatsController creates a new stats controller. The workload report
+// follows the users' work schedules and holidays when holidays is not nil.
+// Reports only read tasks, so they all go to the heavy read preference.
+func NewStatsController(taskCollection *mongo.Collection, holidays *HolidayController) *StatsController {
+	return &StatsController{
+		taskCollection: configs.HeavyReads(taskCollection),
+		holidays:       holidays,
+	}
+}
+
+// workloadBucket is the planned effort of a day or week
+type workloadBucket struct {
+	Start       string `json:"start"`
+	Tasks       int    `json:"tasks"`
+	Unestimated int    `json:"unestimated"`
+	Estimate    int    `json:"estimate"` // Minutes
+	Capacity    int    `json:"capacity"` // Minutes
+	Overloaded  bool   `json:"overloaded"`
+}
+
+// GetWorkload aggregates the estimated effort of open tasks per day or week
+// against the daily capacity, flagging overloaded periods. Holidays have no
+// capacity, and once the user set a work schedule, neither have the days
+// outside it while working days default to its hours.
+func (sc *StatsController) GetWorkload(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	period := utils.GetQueryDefault(c, "period", "day")
+	if period != "day" && period != "week" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Period must be one of: day, week",
+		})
+		return
+	}
+
+	calendar := newWorkCalendar(models.User{})
+	if sc.holidays != nil {
+		loaded, err := sc.holidays.calendar(ctx, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to get work schedule",
+			})
+			return
+		}
+		calendar = loaded
+	}
+
+	defaultCapacity := utils.GetEnv("DAILY_CAPACITY_MINUTES", "480")
+	if calendar.hasSchedule {
+		defaultCapacity = strconv.Itoa(calendar.schedule.Minutes())
+	}
+	capacity, err := strconv.Atoi(utils.GetQueryDefault(c, "capacity", defaultCapacity))
+	if err != nil || capacity <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Capacity must be a positive number of minutes",
+		})
+		return
+	}
+
+	days, err := strconv.Atoi(utils.GetQueryDefault(c, "days", "14"))
+	if err != nil || days < 1 || days > 366 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Days must be between 1 and 366",
+		})
+		return
+	}
+
+	from := utils.StartOfDay(time.Now())
+	if fromParam := c.Query("from"); fromParam != "" {
+		from, err = time.ParseInLocation(utils.DayLayout, fromParam, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "From must use the YYYY-MM-DD format",
+			})
+			return
+		}
+	}
+	to := from.AddDate(0, 0, days)
+
+	cursor, err := sc.taskCollection.Find(ctx, bson.M{
+		"user":      userID,
+		"completed": false,
+		"dueDate":   bson.M{"$lt": to},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch tasks",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse tasks",
+		})
+		return
+	}
+
+	// Prepare one bucket per period in the range
+	weekly := period == "week"
+	buckets := []*workloadBucket{}
+	bucketByStart := map[string]*workloadBucket{}
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		key := utils.PeriodStart(day, weekly).Format(utils.DayLayout)
+		bucket, ok := bucketByStart[key]
+		if !ok {
+			bucket = &workloadBucket{Start: key}
+			bucketByStart[key] = bucket
+			buckets = append(buckets, bucket)
+		}
+		if calendar.IsHoliday(day) || (calendar.hasSchedule && !calendar.schedule.IsWorkingDay(day.Weekday())) {
+			continue
+		}
+		bucket.Capacity += capacity
+	}
+
+	// Overdue work still has to be done, so it is planned on the first day
+	overdue := workloadBucket{Start: "overdue"}
+	for _, task := range tasks {
+		target := &overdue
+		if !task.DueDate.Before(from) {
+			target = bucketByStart[utils.PeriodStart(*task.DueDate, weekly).Format(utils.DayLayout)]
+		}
+		if target == nil {
+			continue
+		}
+		target.Tasks++
+		target.Estimate += task.Estimate
+		if task.Estimate == 0 {
+			target.Unestimated++
+		}
+	}
+	if len(buckets) > 0 {
+		buckets[0].Estimate += overdue.Estimate
+	}
+
+	overloaded := []string{}
+	for _, bucket := range buckets {
+		bucket.Overloaded = bucket.Estimate > bucket.Capacity
+		if bucket.Overloaded {
+			overloaded = append(overloaded, bucket.Start)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"period":        period,
+			"dailyCapacity": capacity,
+			"overdue": gin.H{
+				"tasks":       overdue.Tasks,
+				"unestimated": overdue.Unestimated,
+				"estimate":    overdue.Estimate,
+			},
+			"buckets":    buckets,
+			"overloaded": overloaded,
+		},
+	})
+}
@@ -0,0 +1,497 @@
+package controllers
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// recentIncidents is how long resolved incidents stay on the status page
+const recentIncidents = 7 * 24 * time.Hour
+
+// Overall statuses of the status page
+const (
+	StatusOperational = "operational"
+	StatusDegraded    = "degraded"
+	StatusOutage      = "outage"
+)
+
+// StatusController serves the public status page and lets admins post the
+// incidents shown on it
+type StatusController struct {
+	collection *mongo.Collection // Incidents
+	health     *HealthController
+	logger     *utils.Logger
+
+	mu      sync.Mutex
+	status  *StatusReport
+	expires time.Time
+}
+
+// StatusReport is the availability of the service as shown on the status page
+type StatusReport struct {
+	Status    string            `json:"status"`   // operational, degraded or outage
+	Database  string            `json:"database"` // up or down
+	Jobs      string            `json:"jobs"`     // ok or degraded
+	Version   string            `json:"version"`
+	Uptime    UptimeSummary     `json:"uptime"`
+	Incidents []models.Incident `json:"incidents"` // Open ones, then those resolved in the last 7 days
+	CheckedAt time.Time         `json:"checkedAt"`
+}
+
+// UptimeSummary is the share of health snapshots that reached the database,
+// in percent, nil when there are none for the period
+type UptimeSummary struct {
+	Day  *float64 `json:"day"`  // Last 24 hours
+	Week *float64 `json:"week"` // Last 7 days
+}
+
+// NewStatusController creates a status controller reading the health
+// snapshots of the health controller. The status is cached per instance for
+// STATUS_CACHE_TTL (30s by default), as the page is public.
+func NewStatusController(collection *mongo.Collection, health *HealthController) *StatusController {
+	return &StatusController{
+		collection: collection,
+		health:     health,
+		logger:     utils.GetLogger().Named("status"),
+	}
+}
+
+// GetStatus serves the status page, as HTML to browsers and as JSON when
+// the request accepts application/json or asks for ?format=json
+func (sc *StatusController) GetStatus(c *gin.Context) {
+	report := sc.currentStatus()
+
+	if c.Query("format") == "json" || c.NegotiateFormat(gin.MIMEHTML, gin.MIMEJSON) == gin.MIMEJSON {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    report,
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := statusPage.Execute(c.Writer, report); err != nil {
+		sc.logger.Warning("Failed to render status page: " + err.Error())
+	}
+}
+
+// currentStatus returns the cached status, checking it again once expired
+func (sc *StatusController) currentStatus() *StatusReport {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.status == nil || time.Now().After(sc.expires) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		ttl, err := time.ParseDuration(utils.GetEnv("STATUS_CACHE_TTL", "30s"))
+		if err != nil || ttl < 0 {
+			ttl = 30 * time.Second
+		}
+		sc.status = sc.checkStatus(ctx)
+		sc.expires = time.Now().Add(ttl)
+	}
+	return sc.status
+}
+
+// checkStatus checks the database and the jobs of the instance and reads
+// the uptime and incidents. What cannot be read is left out rather than
+// failing the page, which matters most during an outage.
+func (sc *StatusController) checkStatus(ctx context.Context) *StatusReport {
+	report := &StatusReport{
+		Status:    StatusOperational,
+		Database:  "up",
+		Jobs:      "ok",
+		Version:   utils.GetBuildInfo().Version,
+		Incidents: []models.Incident{},
+		CheckedAt: time.Now(),
+	}
+
+	if err := sc.health.client.Ping(ctx, nil); err != nil {
+		report.Database = "down"
+		report.Status = StatusOutage
+		return report
+	}
+	if !sc.health.scheduler.Healthy() {
+		report.Jobs = "degraded"
+		report.Status = StatusDegraded
+	}
+
+	now := time.Now()
+	report.Uptime.Day = sc.uptime(ctx, now.Add(-24*time.Hour))
+	report.Uptime.Week = sc.uptime(ctx, now.Add(-7*24*time.Hour))
+
+	cursor, err := sc.collection.Find(ctx, bson.M{
+		"$or": bson.A{
+			bson.M{"resolvedAt": bson.M{"$exists": false}},
+			bson.M{"resolvedAt": bson.M{"$gt": now.Add(-recentIncidents)}},
+		},
+	}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		sc.logger.Warning("Failed to fetch incidents: " + err.Error())
+		return report
+	}
+	var incidents []models.Incident
+	if err := cursor.All(ctx, &incidents); err != nil {
+		sc.logger.Warning("Failed to parse incidents: " + err.Error())
+		return report
+	}
+
+	// Open incidents first, and the worst one sets the status
+	for _, incident := range incidents {
+		if incident.ResolvedAt != nil {
+			continue
+		}
+		report.Incidents = append(report.Incidents, incident)
+		switch {
+		case incident.Impact == models.ImpactMajor:
+			report.Status = StatusOutage
+		case incident.Impact == models.ImpactMinor && report.Status == StatusOperational:
+			report.Status = StatusDegraded
+		}
+	}
+	for _, incident := range incidents {
+		if incident.ResolvedAt != nil {
+			report.Incidents = append(report.Incidents, incident)
+		}
+	}
+	return report
+}
+
+// uptime returns the uptime since a time from the health snapshots of every
+// instance, nil when there are none
+func (sc *StatusController) uptime(ctx context.Context, since time.Time) *float64 {
+	cursor, err := sc.health.collection.Find(ctx, bson.M{"createdAt": bson.M{"$gte": since}}, options.Find().SetProjection(bson.M{"dbUp": 1}))
+	if err != nil {
+		sc.logger.Warning("Failed to fetch health snapshots: " + err.Error())
+		return nil
+	}
+	var snapshots []models.HealthSnapshot
+	if err := cursor.All(ctx, &snapshots); err != nil {
+		sc.logger.Warning("Failed to parse health snapshots: " + err.Error())
+		return nil
+	}
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	percent := summarizeHealth(snapshots)["uptimePercent"].(float64)
+	return &percent
+}
+
+// GetIncidents returns every incident, most recent first
+func (sc *StatusController) GetIncidents(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := sc.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch incidents",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	incidents := []models.Incident{}
+	if err := cursor.All(ctx, &incidents); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse incidents",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(incidents),
+		"data":    incidents,
+	})
+}
+
+// CreateIncident posts a new incident on the status page
+func (sc *StatusController) CreateIncident(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Title   string `json:"title" binding:"required"`
+		Message string `json:"message" binding:"required"`
+		Status  string `json:"status"`
+		Impact  string `json:"impact"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	incident := models.NewIncident(input.Title, input.Message, c.MustGet("userId").(primitive.ObjectID))
+	if !validIncident(c, input.Status, input.Impact) {
+		return
+	}
+	if input.Status != "" {
+		incident.Status = input.Status
+	}
+	if input.Impact != "" {
+		incident.Impact = input.Impact
+	}
+	if incident.Status == models.IncidentResolved {
+		incident.ResolvedAt = &incident.CreatedAt
+	}
+
+	result, err := sc.collection.InsertOne(ctx, incident)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create incident",
+		})
+		return
+	}
+
+	incident.ID = result.InsertedID.(primitive.ObjectID)
+	sc.resetStatus()
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    incident,
+	})
+}
+
+// UpdateIncident posts an update of an incident: a new message, status or
+// impact. Setting the status to resolved records when it was resolved.
+func (sc *StatusController) UpdateIncident(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid incident ID format",
+		})
+		return
+	}
+
+	var input struct {
+		Title   *string `json:"title"`
+		Message *string `json:"message"`
+		Status  string  `json:"status"`
+		Impact  string  `json:"impact"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+	if !validIncident(c, input.Status, input.Impact) {
+		return
+	}
+
+	now := time.Now()
+	set := bson.M{"updatedAt": now}
+	unset := bson.M{}
+	if input.Title != nil && *input.Title != "" {
+		set["title"] = *input.Title
+	}
+	if input.Message != nil && *input.Message != "" {
+		set["message"] = *input.Message
+	}
+	if input.Impact != "" {
+		set["impact"] = input.Impact
+	}
+	if input.Status != "" {
+		set["status"] = input.Status
+		if input.Status == models.IncidentResolved {
+			set["resolvedAt"] = now
+		} else {
+			unset["resolvedAt"] = ""
+		}
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	var incident models.Incident
+	err = sc.collection.FindOneAndUpdate(ctx, bson.M{"_id": objectID}, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&incident)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Incident not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update incident",
+		})
+		return
+	}
+	sc.resetStatus()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    incident,
+	})
+}
+
+// DeleteIncident removes an incident from the status page. ?dryRun=true
+// only reports whether it exists.
+func (sc *StatusController) DeleteIncident(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid incident ID format",
+		})
+		return
+	}
+
+	report := DeletionReport{DryRun: c.Query("dryRun") == "true"}
+	if report.DryRun {
+		report.Deleted, err = sc.collection.CountDocuments(ctx, bson.M{"_id": objectID})
+	} else {
+		var result *mongo.DeleteResult
+		result, err = sc.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+		if err == nil {
+			report.Deleted = result.DeletedCount
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to delete incident",
+		})
+		return
+	}
+	if report.Deleted == 0 {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Incident not found",
+		})
+		return
+	}
+	if !report.DryRun {
+		sc.resetStatus()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// resetStatus drops the cached status after an incident changed, so that
+// this instance shows it at once and the others within STATUS_CACHE_TTL
+func (sc *StatusController) resetStatus() {
+	sc.mu.Lock()
+	sc.status = nil
+	sc.mu.Unlock()
+}
+
+// validIncident checks the optional status and impact of an incident,
+// writing the error response otherwise
+func validIncident(c *gin.Context, status, impact string) bool {
+	if status != "" && !models.IsIncidentStatus(status) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Status must be one of: investigating, identified, monitoring, resolved",
+		})
+		return false
+	}
+	if impact != "" && !models.IsIncidentImpact(impact) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Impact must be one of: minor, major, notice",
+		})
+		return false
+	}
+	return true
+}
+
+// statusPage is the HTML of the status page. It is self-contained, so it
+// still renders when nothing else does.
+var statusPage = template.Must(template.New("status").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04 UTC") },
+	"percent": func(value *float64) string {
+		if value == nil {
+			return "n/a"
+		}
+		return strconv.FormatFloat(*value, 'f', -1, 64) + "%"
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <meta http-equiv="refresh" content="60">
+  <title>Status - Todolist API</title>
+  <style>
+    body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; max-width: 720px; margin: 2rem auto; padding: 0 1rem; color: #222; }
+    .banner { padding: 1rem 1.25rem; border-radius: 6px; color: #fff; font-size: 1.25rem; }
+    .operational { background: #2e7d32; }
+    .degraded { background: #ef6c00; }
+    .outage { background: #c62828; }
+    table { width: 100%; border-collapse: collapse; margin: 1.5rem 0; }
+    td { padding: .4rem 0; border-bottom: 1px solid #eee; }
+    td:last-child { text-align: right; }
+    .incident { border-left: 4px solid #999; padding: .25rem 1rem; margin: 1rem 0; }
+    .incident.major { border-color: #c62828; }
+    .incident.minor { border-color: #ef6c00; }
+    .incident.notice { border-color: #1565c0; }
+    .muted { color: #777; font-size: .9rem; }
+  </style>
+</head>
+<body>
+  <h1>Todolist API status</h1>
+  <div class="banner {{.Status}}">
+    {{if eq .Status "operational"}}All systems operational{{else if eq .Status "degraded"}}Degraded performance{{else}}Service disruption{{end}}
+  </div>
+  <table>
+    <tr><td>Database</td><td>{{.Database}}</td></tr>
+    <tr><td>Background jobs</td><td>{{.Jobs}}</td></tr>
+    <tr><td>Uptime, last 24 hours</td><td>{{percent .Uptime.Day}}</td></tr>
+    <tr><td>Uptime, last 7 days</td><td>{{percent .Uptime.Week}}</td></tr>
+    <tr><td>Version</td><td>{{.Version}}</td></tr>
+  </table>
+  <h2>Incidents</h2>
+  {{range .Incidents}}
+  <div class="incident {{.Impact}}">
+    <h3>{{.Title}}</h3>
+    <p>{{.Message}}</p>
+    <p class="muted">{{.Status}} &middot; started {{time .CreatedAt}}{{if .ResolvedAt}} &middot; resolved {{time .ResolvedAt}}{{else}} &middot; updated {{time .UpdatedAt}}{{end}}</p>
+  </div>
+  {{else}}
+  <p class="muted">No incidents in the last 7 days.</p>
+  {{end}}
+  <p class="muted">Checked {{time .CheckedAt}}. Also available as JSON with <code>Accept: application/json</code>.</p>
+</body>
+</html>
+`))
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/authz"
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetTaskActivity returns the activity history of a task, newest first
+func (tc *TaskController) GetTaskActivity(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid task ID format",
+		})
+		return
+	}
+
+	var task models.Task
+	err = tc.collection.FindOne(ctx, bson.M{"_id": objectID}, options.FindOne().SetProjection(bson.M{"user": 1})).Decode(&task)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Task not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch task",
+		})
+		return
+	}
+	if !authorize(c, authz.TaskRead, task.User, "Task not found", "Not authorized to access this task") {
+		return
+	}
+
+	cursor, err := tc.activityCollection.Find(ctx, bson.M{"task": objectID}, options.Find().SetSort(bson.M{"createdAt": -1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch task activity",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	activity := []models.TaskActivity{}
+	if err := cursor.All(ctx, &activity); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to decode task activity",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(activity),
+		"data":    activity,
+	})
+}
+
+// recordActivity appends an entry to a task's activity history
+func recordActivity(ctx context.Context, collection *mongo.Collection, activity models.TaskActivity) error {
+	if activity.CreatedAt.IsZero() {
+		activity.CreatedAt = time.Now()
+	}
+	_, err := collection.InsertOne(ctx, activity)
+	return err
+}
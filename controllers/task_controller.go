@@ -2,10 +2,12 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"gotodolist/authz"
 	"gotodolist/models"
 	"gotodolist/utils"
 
@@ -18,19 +20,56 @@ import (
 
 // TaskController handles task-related operations
 type TaskController struct {
-	collection *mongo.Collection
+	collection         *mongo.Collection
+	goalCollection     *mongo.Collection
+	contextCollection  *mongo.Collection
+	projectCollection  *mongo.Collection
+	tagCollection      *mongo.Collection
+	activityCollection *mongo.Collection
+	versionCollection  *mongo.Collection
+	versionLimit       int
+	maxTasks           int64
+	automations        *AutomationController
+	notifier           *NotificationController
+	holidays           *HolidayController
+	logger             *utils.Logger
 }
 
-// NewTaskController creates a new task controller
-func NewTaskController(collection *mongo.Collection) *TaskController {
+// NewTaskController creates a new task controller. Task events are
+// dispatched to the automations, which may be nil, and due dates moved off
+// the user's days off by holidays, which may be nil too. TASK_VERSION_LIMIT
+// bounds the versions kept per task (20 by default) and MAX_TASKS the
+// tasks of each user (0, the default, for no limit).
+func NewTaskController(collection *mongo.Collection, goalCollection *mongo.Collection, contextCollection *mongo.Collection, projectCollection *mongo.Collection, tagCollection *mongo.Collection, activityCollection *mongo.Collection, versionCollection *mongo.Collection, automations *AutomationController, notifier *NotificationController, holidays *HolidayController) *TaskController {
+	versionLimit, err := strconv.Atoi(utils.GetEnv("TASK_VERSION_LIMIT", "20"))
+	if err != nil || versionLimit < 1 {
+		versionLimit = 20
+	}
+	maxTasks, err := strconv.ParseInt(utils.GetEnv("MAX_TASKS", "0"), 10, 64)
+	if err != nil || maxTasks < 0 {
+		maxTasks = 0
+	}
+
 	return &TaskController{
-		collection: collection,
+		collection:         collection,
+		goalCollection:     goalCollection,
+		contextCollection:  contextCollection,
+		projectCollection:  projectCollection,
+		tagCollection:      tagCollection,
+		activityCollection: activityCollection,
+		versionCollection:  versionCollection,
+		versionLimit:       versionLimit,
+		maxTasks:           maxTasks,
+		automations:        automations,
+		notifier:           notifier,
+		holidays:           holidays,
+		logger:             utils.GetLogger().Named("tasks"),
 	}
 }
 
 // GetTasks retrieves all tasks for the authenticated user
 func (tc *TaskController) GetTasks(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c)
 	defer cancel()
 
 	// Get user ID from context
@@ -43,62 +82,32 @@ func (tc *TaskController) GetTasks(c *gin.Context) {
 		return
 	}
 
-	// Parse query parameters for filtering, sorting and pagination
-	completed := c.Query("completed")
-	priority := c.Query("priority")
-	sortField := c.Query("sort")
-	sortDir := utils.GetQueryDefault(c, "sortDir", "asc")
-	page, _ := strconv.Atoi(utils.GetQueryDefault(c, "page", "1"))
-	limit, _ := strconv.Atoi(utils.GetQueryDefault(c, "limit", "10"))
-
-	// Ensure page and limit are valid
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 10
-	}
-
-	// Calculate skip for pagination
-	skip := (page - 1) * limit
-
-	// Build query
-	query := bson.M{"user": userID}
-
-	// Add filters if provided
-	if completed != "" {
-		query["completed"] = completed == "true"
-	}
-
-	if priority != "" {
-		query["priority"] = priority
+	debug, ok := queryDebugRequested(c)
+	if !ok {
+		return
 	}
 
-	// Build sort options
-	findOptions := options.Find()
-
-	// Apply sorting
-	if sortField != "" {
-		var sortOrder int
-		if sortDir == "desc" {
-			sortOrder = -1
-		} else {
-			sortOrder = 1
-		}
-
-		// Use the sortOrder variable
-		findOptions.SetSort(bson.M{sortField: sortOrder})
-	} else {
-		// Default sort by createdAt
-		findOptions.SetSort(bson.M{"createdAt": -1})
+	// Parse query parameters for filtering, sorting and pagination
+	list, err := ParseTaskListQuery(c, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
 	}
+	query, sort, page, limit := list.Filter, list.SortOrder(), list.Page, list.Limit
+	skip := list.Skip()
 
-	// Apply pagination
-	findOptions.SetSkip(int64(skip))
-	findOptions.SetLimit(int64(limit))
+	findOptions := options.Find().
+		SetSort(sort).
+		SetSkip(int64(skip)).
+		SetLimit(int64(limit))
 
 	// Count total documents for pagination
+	countStart := time.Now()
 	total, err := tc.collection.CountDocuments(ctx, query)
+	countDuration := time.Since(countStart)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -108,6 +117,7 @@ func (tc *TaskController) GetTasks(c *gin.Context) {
 	}
 
 	// Execute query with options
+	findStart := time.Now()
 	cursor, err := tc.collection.Find(ctx, query, findOptions)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -126,6 +136,7 @@ func (tc *TaskController) GetTasks(c *gin.Context) {
 		})
 		return
 	}
+	findDuration := time.Since(findStart)
 
 	// Pagination result
 	totalPages := (int(total) + limit - 1) / limit
@@ -134,23 +145,59 @@ func (tc *TaskController) GetTasks(c *gin.Context) {
 		"page":       page,
 		"limit":      limit,
 		"totalPages": totalPages,
+		"links":      utils.SetPaginationLinks(c, page, limit, totalPages),
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"success":    true,
 		"pagination": pagination,
 		"count":      len(tasks),
 		"data":       tasks,
-	})
+	}
+
+	// Bucket the page into groups when asked to
+	if list.GroupBy != "" {
+		groups, err := groupTasks(ctx, tc.collection, query, list.GroupBy, tasks)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to group tasks",
+			})
+			return
+		}
+		response["data"] = groups
+	}
+
+	// Attach the query plan and timings when debugging slow filters
+	if debug {
+		explain, err := explainFind(ctx, tc.collection, query, sort, int64(skip), int64(limit))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Failed to explain query",
+			})
+			return
+		}
+		response["debug"] = gin.H{
+			"filter":  query,
+			"sort":    list.Sort,
+			"groupBy": list.GroupBy,
+			"countMs": float64(countDuration.Microseconds()) / 1000,
+			"findMs":  float64(findDuration.Microseconds()) / 1000,
+			"explain": explain,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // GetTask retrieves a single task by ID
 func (tc *TaskController) GetTask(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c)
 	defer cancel()
 
 	// Get user ID from context
-	userID, exists := c.Get("userId")
+	_, exists := c.Get("userId")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
@@ -186,12 +233,8 @@ func (tc *TaskController) GetTask(c *gin.Context) {
 		return
 	}
 
-	// Check if the task belongs to the user
-	if task.User != userID {
-		c.JSON(http.StatusForbidden, gin.H{
-			"success": false,
-			"error":   "Not authorized to access this task",
-		})
+	// Check that the user may act on the task
+	if !authorize(c, authz.TaskRead, task.User, "Task not found", "Not authorized to access this task") {
 		return
 	}
 
@@ -203,7 +246,7 @@ func (tc *TaskController) GetTask(c *gin.Context) {
 
 // CreateTask creates a new task
 func (tc *TaskController) CreateTask(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c)
 	defer cancel()
 
 	// Get user ID from context
@@ -217,11 +260,24 @@ func (tc *TaskController) CreateTask(c *gin.Context) {
 	}
 
 	var input struct {
-		Title       string     `json:"title" binding:"required"`
-		Description string     `json:"description"`
-		Completed   bool       `json:"completed"`
-		DueDate     *time.Time `json:"dueDate"`
-		Priority    string     `json:"priority"`
+		Title        string     `json:"title" binding:"required"`
+		Description  string     `json:"description"`
+		Completed    bool       `json:"completed"`
+		StartDate    *time.Time `json:"startDate"`
+		DueDate      *time.Time `json:"dueDate"`
+		DependsOn    []string   `json:"dependsOn"`
+		Priority     string     `json:"priority"`
+		Estimate     int        `json:"estimate"`
+		Goal         string     `json:"goal"`
+		Section      string     `json:"section"`
+		Context      string     `json:"context"`
+		Project      string     `json:"project"`
+		Color        string     `json:"color"`
+		Icon         string     `json:"icon"`
+		Tags         []string   `json:"tags"`
+		AutoComplete bool       `json:"autoComplete"`
+		Recurrence   string     `json:"recurrence"` // Rule such as weekly or FREQ=WEEKLY;BYDAY=MO,WE
+		Defaults     *bool      `json:"defaults"`   // false skips the defaults of the project
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -241,18 +297,144 @@ func (tc *TaskController) CreateTask(c *gin.Context) {
 		return
 	}
 
+	if !validAppearance(c, input.Color, input.Icon) {
+		return
+	}
+
+	tags, ok := validTags(c, input.Tags)
+	if !ok {
+		return
+	}
+
+	if input.Estimate < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Estimate must be a positive number of minutes",
+		})
+		return
+	}
+
+	// A task cannot be due before it starts
+	if input.StartDate != nil && input.DueDate != nil && input.DueDate.Before(*input.StartDate) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Due date cannot be before start date",
+		})
+		return
+	}
+
+	dueDate, ok := tc.shiftDueDate(ctx, c, userID, input.DueDate)
+	if !ok {
+		return
+	}
+
+	var rule string
+	if input.Recurrence != "" {
+		rule, ok = parseRecurrence(c, input.Recurrence, dueDate)
+		if !ok {
+			return
+		}
+	}
+
+	// Validate dependencies if provided
+	dependsOn, ok := tc.ownedDependencies(ctx, c, input.DependsOn, userID, primitive.NilObjectID)
+	if !ok {
+		return
+	}
+
+	// Validate goal if provided
+	var goalID *primitive.ObjectID
+	if input.Goal != "" {
+		id, ok := tc.ownedGoal(ctx, c, input.Goal, userID)
+		if !ok {
+			return
+		}
+		goalID = &id
+	}
+
+	// Validate context if provided
+	var contextName string
+	if input.Context != "" {
+		name, ok := tc.ownedContext(ctx, c, input.Context, userID)
+		if !ok {
+			return
+		}
+		contextName = name
+	}
+
+	// Validate project if provided
+	var projectID *primitive.ObjectID
+	if input.Project != "" {
+		id, ok := tc.ownedProject(ctx, c, input.Project, userID)
+		if !ok {
+			return
+		}
+		projectID = &id
+	}
+
+	// The project's defaults apply unless the task opts out
+	var defaults *models.ProjectDefaults
+	if projectID != nil && (input.Defaults == nil || *input.Defaults) {
+		defaults, ok = tc.projectDefaults(ctx, c, *projectID)
+		if !ok {
+			return
+		}
+	}
+
+	// Validate section if provided, it must belong to the project
+	var sectionID *primitive.ObjectID
+	if input.Section != "" {
+		id, ok := tc.projectSection(ctx, c, projectID, input.Section)
+		if !ok {
+			return
+		}
+		sectionID = &id
+	}
+
+	count, ok := tc.taskQuota(ctx, c, userID)
+	if !ok {
+		return
+	}
+
 	// Create a new task
 	task := models.NewTask(input.Title, userID.(primitive.ObjectID))
 	task.Description = input.Description
 	task.Completed = input.Completed
-	task.DueDate = input.DueDate
+	if task.Completed {
+		task.CompletedAt = &task.CreatedAt
+	}
+	task.StartDate = input.StartDate
+	task.DueDate = dueDate
+	task.DependsOn = dependsOn
+	task.Estimate = input.Estimate
+	task.Goal = goalID
+	task.Section = sectionID
+	task.Context = contextName
+	task.Project = projectID
+	task.Color = input.Color
+	task.Icon = input.Icon
+	task.Tags = tags
+	task.AutoComplete = input.AutoComplete
 
 	if input.Priority != "" {
 		task.Priority = input.Priority
 	}
+	applyProjectDefaults(task, defaults, input.Priority != "", input.Tags != nil)
 
-	result, err := tc.collection.InsertOne(ctx, task)
+	// A recurring task starts a series named after it
+	task.ID = primitive.NewObjectID()
+	if rule != "" {
+		task.Recurrence = &models.Recurrence{Rule: rule, Series: task.ID, Start: *dueDate, Occurrence: 1}
+	}
+
+	err := writeWithCounts(ctx, tc.collection, tc.counters(), func(ctx context.Context) (countChanges, error) {
+		if _, err := tc.collection.InsertOne(ctx, task); err != nil {
+			return nil, err
+		}
+		return taskChange(nil, task), nil
+	})
 	if err != nil {
+		tc.logger.With("user", task.User.Hex()).Error("Failed to create task: " + err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to create task",
@@ -260,18 +442,32 @@ func (tc *TaskController) CreateTask(c *gin.Context) {
 		return
 	}
 
-	// Get the created task to return
-	task.ID = result.InsertedID.(primitive.ObjectID)
+	*task = tc.automations.Dispatch(ctx, models.TriggerTaskCreated, *task)
+	if task.Delegation != nil {
+		notification := models.NewNotification(task.Delegation.To, models.NotifyAssignments,
+			"A task was offered to you",
+			fmt.Sprintf("%q was offered to you as the assignee of its project. Accept or decline it from your delegations.", task.Title))
+		notification.Task = &task.ID
+		if _, err := tc.notifier.NotifyUser(ctx, task.Delegation.To, notification); err != nil {
+			tc.logger.With("task", task.ID.Hex()).Error("Failed to notify delegation offer: " + err.Error())
+		}
+	}
+	if task.Completed {
+		if err := tc.materializeNext(ctx, *task); err != nil {
+			tc.logger.With("task", task.ID.Hex()).Error("Failed to create the next occurrence: " + err.Error())
+		}
+	}
+	warnings := tc.taskQuotaWarnings(ctx, task.User, count+1)
 
-	c.JSON(http.StatusCreated, gin.H{
+	c.JSON(http.StatusCreated, withWarnings(gin.H{
 		"success": true,
 		"data":    task,
-	})
+	}, warnings))
 }
 
 // UpdateTask updates an existing task
 func (tc *TaskController) UpdateTask(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c)
 	defer cancel()
 
 	// Get user ID from context
@@ -295,11 +491,23 @@ func (tc *TaskController) UpdateTask(c *gin.Context) {
 	}
 
 	var input struct {
-		Title       string     `json:"title"`
-		Description string     `json:"description"`
-		Completed   bool       `json:"completed"`
-		DueDate     *time.Time `json:"dueDate"`
-		Priority    string     `json:"priority"`
+		Title        string     `json:"title"`
+		Description  string     `json:"description"`
+		Completed    bool       `json:"completed"`
+		StartDate    *time.Time `json:"startDate"`
+		DueDate      *time.Time `json:"dueDate"`
+		DependsOn    []string   `json:"dependsOn"` // Replaces the dependencies when provided
+		Priority     string     `json:"priority"`
+		Estimate     *int       `json:"estimate"` // Zero clears the estimate
+		Goal         *string    `json:"goal"`     // An empty string detaches the task from its goal
+		Section      *string    `json:"section"`  // An empty string clears the section
+		Context      *string    `json:"context"`  // An empty string clears the context
+		Project      *string    `json:"project"`  // An empty string takes the task out of its project
+		Color        *string    `json:"color"`    // An empty string clears the color
+		Icon         *string    `json:"icon"`     // An empty string clears the icon
+		Tags         []string   `json:"tags"`     // Replaces the tags when provided, an empty list clears them
+		AutoComplete *bool      `json:"autoComplete"`
+		Recurrence   *string    `json:"recurrence"` // An empty string ends the series with this task
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -319,6 +527,13 @@ func (tc *TaskController) UpdateTask(c *gin.Context) {
 		return
 	}
 
+	// Edits of a recurring task apply to this occurrence unless the scope
+	// is the series
+	scope, ok := recurrenceScope(c)
+	if !ok {
+		return
+	}
+
 	// Get the existing task first
 	var existingTask models.Task
 	err = tc.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&existingTask)
@@ -337,11 +552,42 @@ func (tc *TaskController) UpdateTask(c *gin.Context) {
 		return
 	}
 
-	// Check if the task belongs to the user
-	if existingTask.User != userID {
-		c.JSON(http.StatusForbidden, gin.H{
+	// Check that the user may act on the task
+	if !authorize(c, authz.TaskUpdate, existingTask.User, "Task not found", "Not authorized to update this task") {
+		return
+	}
+
+	if input.Color != nil && !validAppearance(c, *input.Color, "") {
+		return
+	}
+	if input.Icon != nil && !validAppearance(c, "", *input.Icon) {
+		return
+	}
+	tags, ok := validTags(c, input.Tags)
+	if !ok {
+		return
+	}
+
+	if input.Estimate != nil && *input.Estimate < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Estimate must be a positive number of minutes",
+		})
+		return
+	}
+
+	// A task cannot be due before it starts
+	startDate, dueDate := existingTask.StartDate, existingTask.DueDate
+	if input.StartDate != nil {
+		startDate = input.StartDate
+	}
+	if input.DueDate != nil {
+		dueDate = input.DueDate
+	}
+	if startDate != nil && dueDate != nil && dueDate.Before(*startDate) {
+		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   "Not authorized to update this task",
+			"error":   "Due date cannot be before start date",
 		})
 		return
 	}
@@ -359,19 +605,119 @@ func (tc *TaskController) UpdateTask(c *gin.Context) {
 		updateSet["description"] = input.Description
 	}
 	updateSet["completed"] = input.Completed
+	if input.StartDate != nil {
+		updateSet["startDate"] = input.StartDate
+	}
 	if input.DueDate != nil {
-		updateSet["dueDate"] = input.DueDate
+		shifted, ok := tc.shiftDueDate(ctx, c, userID, input.DueDate)
+		if !ok {
+			return
+		}
+		updateSet["dueDate"] = shifted
+		dueDate = shifted
+	}
+	if input.DependsOn != nil {
+		dependsOn, ok := tc.ownedDependencies(ctx, c, input.DependsOn, userID, objectID)
+		if !ok {
+			return
+		}
+		updateSet["dependsOn"] = dependsOn
 	}
 	if input.Priority != "" {
 		updateSet["priority"] = input.Priority
 	}
+	if input.Estimate != nil {
+		updateSet["estimate"] = *input.Estimate
+	}
+	if input.Color != nil {
+		updateSet["color"] = *input.Color
+	}
+	if input.Icon != nil {
+		updateSet["icon"] = *input.Icon
+	}
+	if input.AutoComplete != nil {
+		updateSet["autoComplete"] = *input.AutoComplete
+	}
+
+	// Fields cleared with an empty string are unset, and any update
+	// triages a task captured in the inbox
+	updateUnset := bson.M{"inbox": ""}
+	if input.Tags != nil && len(tags) == 0 {
+		updateUnset["tags"] = ""
+	} else if input.Tags != nil {
+		updateSet["tags"] = tags
+	}
+	if input.Completed && !existingTask.Completed {
+		updateSet["completedAt"] = updateSet["updatedAt"]
+	} else if !input.Completed {
+		updateUnset["completedAt"] = ""
+	}
+	if input.Goal != nil {
+		if *input.Goal == "" {
+			updateUnset["goal"] = ""
+		} else {
+			goalID, ok := tc.ownedGoal(ctx, c, *input.Goal, userID)
+			if !ok {
+				return
+			}
+			updateSet["goal"] = goalID
+		}
+	}
+	if input.Context != nil {
+		if *input.Context == "" {
+			updateUnset["context"] = ""
+		} else {
+			contextName, ok := tc.ownedContext(ctx, c, *input.Context, userID)
+			if !ok {
+				return
+			}
+			updateSet["context"] = contextName
+		}
+	}
+	projectID := existingTask.Project
+	if input.Project != nil {
+		if *input.Project == "" {
+			updateUnset["project"] = ""
+			projectID = nil
+		} else {
+			id, ok := tc.ownedProject(ctx, c, *input.Project, userID)
+			if !ok {
+				return
+			}
+			updateSet["project"] = id
+			projectID = &id
+		}
+	}
+	// A task leaving its project leaves its section too
+	if input.Section != nil && *input.Section != "" {
+		sectionID, ok := tc.projectSection(ctx, c, projectID, *input.Section)
+		if !ok {
+			return
+		}
+		updateSet["section"] = sectionID
+	} else if input.Section != nil || (existingTask.Project != nil && (projectID == nil || *projectID != *existingTask.Project)) {
+		updateUnset["section"] = ""
+	}
 
-	_, err = tc.collection.UpdateOne(
-		ctx,
-		bson.M{"_id": objectID},
-		bson.M{"$set": updateSet},
-	)
+	if !recurrenceUpdate(c, existingTask, input.Recurrence, scope, dueDate, updateSet, updateUnset) {
+		return
+	}
+
+	update := bson.M{"$set": updateSet, "$unset": updateUnset}
+
+	err = writeWithCounts(ctx, tc.collection, tc.counters(), func(ctx context.Context) (countChanges, error) {
+		_, changes, err := updateCounted(ctx, tc.collection, bson.M{"_id": objectID}, update)
+		return changes, err
+	})
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Task not found",
+		})
+		return
+	}
 	if err != nil {
+		tc.logger.With("task", objectID.Hex()).Error("Failed to update task: " + err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   "Failed to update task",
@@ -379,6 +725,12 @@ func (tc *TaskController) UpdateTask(c *gin.Context) {
 		return
 	}
 
+	if scope == scopeSeries && existingTask.Recurrence != nil {
+		if err := tc.applyToSeries(ctx, existingTask, updateSet, updateUnset); err != nil {
+			tc.logger.With("task", objectID.Hex()).Error("Failed to update the series: " + err.Error())
+		}
+	}
+
 	// Get the updated task
 	var updatedTask models.Task
 	err = tc.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&updatedTask)
@@ -390,6 +742,18 @@ func (tc *TaskController) UpdateTask(c *gin.Context) {
 		return
 	}
 
+	if err := tc.saveVersion(ctx, existingTask); err != nil {
+		tc.logger.With("task", objectID.Hex()).Error("Failed to save task version: " + err.Error())
+	}
+
+	updatedTask = tc.automations.Dispatch(ctx, models.TriggerTaskUpdated, updatedTask)
+	if updatedTask.Completed && !existingTask.Completed {
+		updatedTask = tc.automations.Dispatch(ctx, models.TriggerTaskCompleted, updatedTask)
+		if err := tc.materializeNext(ctx, updatedTask); err != nil {
+			tc.logger.With("task", objectID.Hex()).Error("Failed to create the next occurrence: " + err.Error())
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data":    updatedTask,
@@ -398,11 +762,11 @@ func (tc *TaskController) UpdateTask(c *gin.Context) {
 
 // DeleteTask deletes a task
 func (tc *TaskController) DeleteTask(c *gin.Context) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := requestContext(c)
 	defer cancel()
 
 	// Get user ID from context
-	userID, exists := c.Get("userId")
+	_, exists := c.Get("userId")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
@@ -439,26 +803,344 @@ func (tc *TaskController) DeleteTask(c *gin.Context) {
 		return
 	}
 
-	// Check if the task belongs to the user
-	if task.User != userID {
-		c.JSON(http.StatusForbidden, gin.H{
+	// Check that the user may act on the task
+	if !authorize(c, authz.TaskDelete, task.User, "Task not found", "Not authorized to delete this task") {
+		return
+	}
+
+	err = writeWithCounts(ctx, tc.collection, tc.counters(), func(ctx context.Context) (countChanges, error) {
+		_, changes, err := deleteCounted(ctx, tc.collection, bson.M{"_id": objectID})
+		return changes, err
+	})
+	if err != nil && err != mongo.ErrNoDocuments {
+		tc.logger.With("task", objectID.Hex()).Error("Failed to delete task: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"error":   "Not authorized to delete this task",
+			"error":   "Failed to delete task",
 		})
 		return
 	}
 
-	_, err = tc.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{},
+	})
+}
+
+// ownedGoal parses a goal ID and checks that the goal belongs to the user,
+// writing the error response otherwise
+func (tc *TaskController) ownedGoal(ctx context.Context, c *gin.Context, id string, userID interface{}) (primitive.ObjectID, bool) {
+	goalID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid goal ID format",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	count, err := tc.goalCollection.CountDocuments(ctx, bson.M{"_id": goalID, "user": userID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"error":   "Failed to delete task",
+			"error":   "Failed to fetch goal",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	if count == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Goal not found",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	return goalID, true
+}
+
+// priorityRank orders priorities so they can be compared against a threshold
+var priorityRank = map[string]int{
+	"low":    1,
+	"medium": 2,
+	"high":   3,
+}
+
+// GetMatrix buckets open tasks into the four Eisenhower quadrants
+func (tc *TaskController) GetMatrix(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
 		})
 		return
 	}
 
+	// Tasks due within urgentDays are urgent, tasks at or above importantPriority are important
+	urgentDays, err := strconv.Atoi(utils.GetQueryDefault(c, "urgentDays", utils.GetEnv("MATRIX_URGENT_DAYS", "2")))
+	if err != nil || urgentDays < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "urgentDays must be a positive number",
+		})
+		return
+	}
+
+	importantPriority := utils.GetQueryDefault(c, "importantPriority", utils.GetEnv("MATRIX_IMPORTANT_PRIORITY", "high"))
+	importantRank, ok := priorityRank[importantPriority]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "importantPriority must be one of: low, medium, high",
+		})
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "dueDate", Value: 1}, {Key: "createdAt", Value: -1}})
+	cursor, err := tc.collection.Find(ctx, bson.M{"user": userID, "completed": false, "snoozedUntil": notSnoozed(), "startDate": notDeferred()}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch tasks",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse tasks",
+		})
+		return
+	}
+
+	urgentBefore := utils.StartOfDay(time.Now()).AddDate(0, 0, urgentDays+1)
+	matrix := map[string][]models.Task{
+		"doFirst":   {},
+		"schedule":  {},
+		"delegate":  {},
+		"eliminate": {},
+	}
+
+	for _, task := range tasks {
+		urgent := task.DueDate != nil && task.DueDate.Before(urgentBefore)
+		important := priorityRank[task.Priority] >= importantRank
+
+		switch {
+		case urgent && important:
+			matrix["doFirst"] = append(matrix["doFirst"], task)
+		case important:
+			matrix["schedule"] = append(matrix["schedule"], task)
+		case urgent:
+			matrix["delegate"] = append(matrix["delegate"], task)
+		default:
+			matrix["eliminate"] = append(matrix["eliminate"], task)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    gin.H{},
+		"thresholds": gin.H{
+			"urgentDays":        urgentDays,
+			"importantPriority": importantPriority,
+		},
+		"data": matrix,
 	})
 }
+
+// ownedContext normalizes a context name and checks that the user created
+// that context, writing the error response otherwise
+func (tc *TaskController) ownedContext(ctx context.Context, c *gin.Context, name string, userID interface{}) (string, bool) {
+	name, ok := validContextName(c, name)
+	if !ok {
+		return "", false
+	}
+
+	count, err := tc.contextCollection.CountDocuments(ctx, bson.M{"user": userID, "name": name})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch context",
+		})
+		return "", false
+	}
+
+	if count == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Context not found",
+		})
+		return "", false
+	}
+
+	return name, true
+}
+
+// ownedProject parses a project ID and checks that the project belongs to
+// the user, writing the error response otherwise
+func (tc *TaskController) ownedProject(ctx context.Context, c *gin.Context, id string, userID interface{}) (primitive.ObjectID, bool) {
+	projectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid project ID format",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	count, err := tc.projectCollection.CountDocuments(ctx, bson.M{"_id": projectID, "user": userID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch project",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	if count == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Project not found",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	return projectID, true
+}
+
+// projectSection parses a section ID and checks that it is a section of the
+// project, writing the error response otherwise
+func (tc *TaskController) projectSection(ctx context.Context, c *gin.Context, projectID *primitive.ObjectID, id string) (primitive.ObjectID, bool) {
+	sectionID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid section ID format",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	if projectID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Only tasks of a project can have a section",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	count, err := tc.projectCollection.CountDocuments(ctx, bson.M{"_id": *projectID, "sections._id": sectionID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch project",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	if count == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Section not found",
+		})
+		return primitive.NilObjectID, false
+	}
+
+	return sectionID, true
+}
+
+// ownedDependencies parses dependency task IDs and checks that they belong to
+// the user and do not reference the task itself, writing the error response otherwise
+func (tc *TaskController) ownedDependencies(ctx context.Context, c *gin.Context, ids []string, userID interface{}, taskID primitive.ObjectID) ([]primitive.ObjectID, bool) {
+	dependsOn := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, id := range ids {
+		dependencyID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid dependency ID format",
+			})
+			return nil, false
+		}
+		if dependencyID == taskID {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "A task cannot depend on itself",
+			})
+			return nil, false
+		}
+		if !seen[dependencyID] {
+			seen[dependencyID] = true
+			dependsOn = append(dependsOn, dependencyID)
+		}
+	}
+
+	if len(dependsOn) == 0 {
+		return dependsOn, true
+	}
+
+	count, err := tc.collection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": dependsOn}, "user": userID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch dependencies",
+		})
+		return nil, false
+	}
+
+	if count != int64(len(dependsOn)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dependency task not found",
+		})
+		return nil, false
+	}
+
+	return dependsOn, true
+}
+
+// validTags normalizes the tags of a task, answering the request when one is
+// invalid or there are too many
+func validTags(c *gin.Context, tags []string) ([]string, bool) {
+	if tags == nil {
+		return nil, true
+	}
+	normalized, ok := utils.NormalizeTags(tags)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("Tags must be at most %d words of letters, digits, - or _ up to 32 characters", utils.MaxTags),
+		})
+		return nil, false
+	}
+	return normalized, true
+}
+
+// validAppearance validates optional color and icon metadata, writing the
+// error response when one of them is invalid
+func validAppearance(c *gin.Context, color, icon string) bool {
+	if color != "" && !utils.IsValidColor(color) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Color must be a hex color such as #ff8800",
+		})
+		return false
+	}
+
+	if icon != "" && !utils.IsValidIcon(icon) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Icon must be an emoji or a name of at most 32 characters",
+		})
+		return false
+	}
+
+	return true
+}
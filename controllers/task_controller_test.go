@@ -0,0 +1,62 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gotodolist/controllers"
+	"gotodolist/routes"
+	"gotodolist/testutil"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTaskRouter serves the task routes without a database, for requests
+// rejected before reaching one
+func newTaskRouter(t *testing.T) *testutil.Client {
+	gin.SetMode(gin.TestMode)
+
+	user := testutil.NewUser()
+	taskController := controllers.NewTaskController(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	router := gin.New()
+	routes.SetupTaskRoutes(router, taskController, testutil.NewAuthMiddleware(user))
+	return testutil.NewClient(t, router, user)
+}
+
+// TestTaskRoutesRequireToken checks that requests without a valid token are
+// turned away
+func TestTaskRoutesRequireToken(t *testing.T) {
+	client := newTaskRouter(t)
+
+	client.Token = ""
+	testutil.Error(t, client.Do(t, http.MethodGet, "/tasks/", nil), http.StatusUnauthorized, "Authorization header required")
+
+	client.Token = "not-a-token"
+	testutil.Error(t, client.Do(t, http.MethodGet, "/tasks/", nil), http.StatusUnauthorized, "Invalid or expired token")
+}
+
+// TestCreateTaskValidation checks that invalid tasks are rejected
+func TestCreateTaskValidation(t *testing.T) {
+	client := newTaskRouter(t)
+
+	tests := []struct {
+		name  string
+		input gin.H
+		error string
+	}{
+		{"no title", gin.H{"description": "Water the plants"}, "Invalid input data"},
+		{"priority", gin.H{"title": "Water the plants", "priority": "urgent"}, "Priority must be one of: low, medium, high"},
+		{"color", gin.H{"title": "Water the plants", "color": "green"}, "Color must be a hex color such as #ff8800"},
+		{"icon", gin.H{"title": "Water the plants", "icon": "a watering can for the plants in the garden"}, "Icon must be an emoji or a name of at most 32 characters"},
+		{"estimate", gin.H{"title": "Water the plants", "estimate": -5}, "Estimate must be a positive number of minutes"},
+		{"tags", gin.H{"title": "Water the plants", "tags": []string{"in the garden"}}, fmt.Sprintf("Tags must be at most %d words of letters, digits, - or _ up to 32 characters", utils.MaxTags)},
+		{"due before start", gin.H{"title": "Water the plants", "startDate": "2024-05-02T00:00:00Z", "dueDate": "2024-05-01T00:00:00Z"}, "Due date cannot be before start date"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.Error(t, client.Do(t, http.MethodPost, "/tasks/", test.input), http.StatusBadRequest, test.error)
+		})
+	}
+}
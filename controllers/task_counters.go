@@ -0,0 +1,482 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	"gotodolist/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// taskCounters are the collections of the groups that store the counts of
+// their tasks. A nil collection skips that kind of group.
+type taskCounters struct {
+	goals    *mongo.Collection
+	contexts *mongo.Collection
+	projects *mongo.Collection
+	tags     *mongo.Collection
+}
+
+// Kinds of the groups counting tasks
+const (
+	goalGroup = iota
+	contextGroup
+	projectGroup
+	tagGroup
+)
+
+// countGroup is a group counting tasks: a goal or project by ID, a context
+// or tag by user and name
+type countGroup struct {
+	kind int
+	id   primitive.ObjectID
+	user primitive.ObjectID
+	name string
+}
+
+// countChanges are the changes of the counts of groups made by task writes
+type countChanges map[countGroup]models.TaskCounts
+
+// taskChange returns the count changes of a task write, the task being
+// uncounted as it was before and counted as it is after. A nil task stands
+// for a task inserted or deleted.
+func taskChange(before, after *models.Task) countChanges {
+	changes := countChanges{}
+	if before != nil {
+		changes.count(*before, -1)
+	}
+	if after != nil {
+		changes.count(*after, 1)
+	}
+	return changes
+}
+
+// count adds n tasks like task to the groups it belongs to
+func (changes countChanges) count(task models.Task, n int64) {
+	if task.Goal != nil {
+		changes.add(countGroup{kind: goalGroup, id: *task.Goal}, task.Completed, n)
+	}
+	if task.Context != "" {
+		changes.add(countGroup{kind: contextGroup, user: task.User, name: task.Context}, task.Completed, n)
+	}
+	if task.Project != nil {
+		changes.add(countGroup{kind: projectGroup, id: *task.Project}, task.Completed, n)
+	}
+	for _, tag := range task.Tags {
+		changes.add(countGroup{kind: tagGroup, user: task.User, name: tag}, task.Completed, n)
+	}
+}
+
+// addCounts adds tasks to a group, or takes them away with a negative sign
+func (changes countChanges) addCounts(group countGroup, counts models.TaskCounts, sign int64) {
+	changes.add(group, false, sign*counts.Open)
+	changes.add(group, true, sign*counts.Completed)
+}
+
+// add adds n open or completed tasks to a group
+func (changes countChanges) add(group countGroup, completed bool, n int64) {
+	counts := changes[group]
+	if completed {
+		counts.Completed += n
+	} else {
+		counts.Open += n
+	}
+	changes[group] = counts
+}
+
+// merge adds the changes of another write
+func (changes countChanges) merge(other countChanges) {
+	for group, counts := range other {
+		changes.addCounts(group, counts, 1)
+	}
+}
+
+// apply increments the counts stored on the groups by the changes. Tags
+// get a document on their first task and keep it at zero tasks, as it
+// holds their appearance.
+func (changes countChanges) apply(ctx context.Context, counters taskCounters) error {
+	for group, counts := range changes {
+		if counts.Open == 0 && counts.Completed == 0 {
+			continue
+		}
+		inc := bson.M{"$inc": bson.M{"taskCounts.open": counts.Open, "taskCounts.completed": counts.Completed}}
+
+		var err error
+		switch {
+		case group.kind == goalGroup && counters.goals != nil:
+			_, err = counters.goals.UpdateOne(ctx, bson.M{"_id": group.id}, inc)
+		case group.kind == contextGroup && counters.contexts != nil:
+			_, err = counters.contexts.UpdateOne(ctx, bson.M{"user": group.user, "name": group.name}, inc)
+		case group.kind == projectGroup && counters.projects != nil:
+			_, err = counters.projects.UpdateOne(ctx, bson.M{"_id": group.id}, inc)
+		case group.kind == tagGroup && counters.tags != nil:
+			_, err = counters.tags.UpdateOne(ctx, bson.M{"user": group.user, "name": group.name}, inc, options.Update().SetUpsert(true))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeWithCounts runs a task write and applies the count changes it
+// returns, in a transaction when the deployment supports them, so that the
+// counts change with the tasks or not at all. Writes return the changes
+// they made from the documents they atomically changed, such as the task
+// a FindOneAndUpdate returns, so concurrent writes each apply their own
+// change and the counts cannot drift. On a standalone server, which has no
+// transactions, a failure between the write and the counts leaves them off
+// until the consistency check repairs them. The write may run more than
+// once, as a transaction is retried on conflicts.
+func writeWithCounts(ctx context.Context, taskCollection *mongo.Collection, counters taskCounters, write func(ctx context.Context) (countChanges, error)) error {
+	return withTransaction(ctx, taskCollection.Database().Client(), func(ctx context.Context) error {
+		changes, err := write(ctx)
+		if err != nil {
+			return err
+		}
+		return changes.apply(ctx, counters)
+	})
+}
+
+// transactionSupport caches, per client, whether its deployment supports
+// transactions
+var transactionSupport sync.Map
+
+// withTransaction runs fn in a transaction when the deployment of the
+// client supports them, a replica set or a sharded cluster, and directly
+// otherwise. Within a transaction already running on ctx, fn joins it.
+func withTransaction(ctx context.Context, client *mongo.Client, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil || !supportsTransactions(ctx, client) {
+		return fn(ctx)
+	}
+
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+// supportsTransactions reports whether the deployment of a client is a
+// replica set or a sharded cluster, asking it once
+func supportsTransactions(ctx context.Context, client *mongo.Client) bool {
+	if supported, ok := transactionSupport.Load(client); ok {
+		return supported.(bool)
+	}
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.M{"hello": 1}).Decode(&hello); err != nil {
+		return false
+	}
+	supported := hello.SetName != "" || hello.Msg == "isdbgrid"
+	transactionSupport.Store(client, supported)
+	return supported
+}
+
+// countedFields are the task fields its counts depend on
+var countedFields = []string{"user", "completed", "goal", "context", "project", "tags"}
+
+// updateCounted applies an update of top level fields with $set and $unset
+// to the task matching filter, returning the task as it was and the count
+// change of the update. The task is read by the update itself, so the
+// change is exact even when other writes race it.
+func updateCounted(ctx context.Context, taskCollection *mongo.Collection, filter bson.M, update bson.M) (models.Task, countChanges, error) {
+	var before models.Task
+	err := taskCollection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.Before)).Decode(&before)
+	if err != nil {
+		return before, nil, err
+	}
+	after, err := countedUpdate(before, update)
+	if err != nil {
+		return before, nil, err
+	}
+	return before, taskChange(&before, &after), nil
+}
+
+// deleteCounted deletes the task matching filter, returning it and the
+// count change of its deletion
+func deleteCounted(ctx context.Context, taskCollection *mongo.Collection, filter bson.M) (models.Task, countChanges, error) {
+	var deleted models.Task
+	err := taskCollection.FindOneAndDelete(ctx, filter).Decode(&deleted)
+	if err != nil {
+		return deleted, nil, err
+	}
+	return deleted, taskChange(&deleted, nil), nil
+}
+
+// updateByCompletion applies an update to the tasks matching filter, the
+// open and the completed ones apart, returning how many of each it
+// modified, so a bulk write knows the count change it made
+func updateByCompletion(ctx context.Context, taskCollection *mongo.Collection, filter bson.M, update interface{}) (models.TaskCounts, error) {
+	var modified models.TaskCounts
+	for _, completed := range []bool{false, true} {
+		matching := bson.M{"completed": true}
+		if !completed {
+			matching["completed"] = bson.M{"$ne": true}
+		}
+		for key, value := range filter {
+			matching[key] = value
+		}
+		result, err := taskCollection.UpdateMany(ctx, matching, update)
+		if err != nil {
+			return modified, err
+		}
+		if completed {
+			modified.Completed = result.ModifiedCount
+		} else {
+			modified.Open = result.ModifiedCount
+		}
+	}
+	return modified, nil
+}
+
+// countedUpdate returns the fields the counts depend on of a task as an
+// update of top level fields with $set and $unset leaves them
+func countedUpdate(before models.Task, update bson.M) (models.Task, error) {
+	document, err := bsonDocument(before)
+	if err != nil {
+		return before, err
+	}
+	set, _ := update["$set"].(bson.M)
+	unset, _ := update["$unset"].(bson.M)
+
+	counted := bson.M{}
+	for _, field := range countedFields {
+		if value, ok := set[field]; ok {
+			counted[field] = value
+		} else if _, ok := unset[field]; !ok && document[field] != nil {
+			counted[field] = document[field]
+		}
+	}
+
+	var after models.Task
+	data, err := bson.Marshal(counted)
+	if err != nil {
+		return after, err
+	}
+	err = bson.Unmarshal(data, &after)
+	return after, err
+}
+
+// recountTaskCounts recounts the tasks of the goals, contexts, projects
+// and tags the given tasks belong to and stores the counts on them. It
+// repairs counts and fills in missing ones; task writes change the counts
+// with writeWithCounts instead. Outside a transaction a write landing
+// between a count and its storing is missed, so a repair running while
+// tasks are written can itself be off by those writes and is best checked
+// again.
+func recountTaskCounts(ctx context.Context, taskCollection *mongo.Collection, counters taskCounters, tasks ...models.Task) error {
+	type nameKey struct {
+		user primitive.ObjectID
+		name string
+	}
+	goals := map[primitive.ObjectID]bool{}
+	contexts := map[nameKey]bool{}
+	projects := map[nameKey]primitive.ObjectID{}
+	tags := map[nameKey]bool{}
+	for _, task := range tasks {
+		if task.Goal != nil {
+			goals[*task.Goal] = true
+		}
+		if task.Context != "" {
+			contexts[nameKey{task.User, task.Context}] = true
+		}
+		if task.Project != nil {
+			projects[nameKey{task.User, task.Project.Hex()}] = *task.Project
+		}
+		for _, tag := range task.Tags {
+			tags[nameKey{task.User, tag}] = true
+		}
+	}
+
+	if counters.goals != nil {
+		for goalID := range goals {
+			counts, err := countTasks(ctx, taskCollection, bson.M{"goal": goalID})
+			if err != nil {
+				return err
+			}
+			if _, err := counters.goals.UpdateOne(ctx, bson.M{"_id": goalID}, bson.M{"$set": bson.M{"taskCounts": counts}}); err != nil {
+				return err
+			}
+		}
+	}
+	if counters.contexts != nil {
+		for key := range contexts {
+			counts, err := countTasks(ctx, taskCollection, bson.M{"user": key.user, "context": key.name})
+			if err != nil {
+				return err
+			}
+			if _, err := counters.contexts.UpdateOne(ctx, bson.M{"user": key.user, "name": key.name}, bson.M{"$set": bson.M{"taskCounts": counts}}); err != nil {
+				return err
+			}
+		}
+	}
+	if counters.projects != nil {
+		for key, projectID := range projects {
+			counts, err := countTasks(ctx, taskCollection, bson.M{"user": key.user, "project": projectID})
+			if err != nil {
+				return err
+			}
+			if _, err := counters.projects.UpdateOne(ctx, bson.M{"_id": projectID}, bson.M{"$set": bson.M{"taskCounts": counts}}); err != nil {
+				return err
+			}
+		}
+	}
+	if counters.tags != nil {
+		for key := range tags {
+			counts, err := countTasks(ctx, taskCollection, bson.M{"user": key.user, "tags": key.name})
+			if err != nil {
+				return err
+			}
+			filter := bson.M{"user": key.user, "name": key.name}
+			if _, err := counters.tags.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"taskCounts": counts}}, options.Update().SetUpsert(true)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// counters returns the collections the task counts are stored in
+func (tc *TaskController) counters() taskCounters {
+	return taskCounters{goals: tc.goalCollection, contexts: tc.contextCollection, projects: tc.projectCollection, tags: tc.tagCollection}
+}
+
+// counters returns the collections the counts of a project's tasks are
+// stored in
+func (pc *ProjectController) counters() taskCounters {
+	return taskCounters{goals: pc.goalCollection, contexts: pc.contextCollection, projects: pc.collection, tags: pc.tagCollection}
+}
+
+// countTasks counts the open and completed tasks matching a filter
+func countTasks(ctx context.Context, taskCollection *mongo.Collection, filter bson.M) (models.TaskCounts, error) {
+	counts := models.TaskCounts{}
+	cursor, err := taskCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": "$completed", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return counts, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Completed bool  `bson:"_id"`
+		Count     int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return counts, err
+	}
+	for _, row := range rows {
+		if row.Completed {
+			counts.Completed = row.Count
+		} else {
+			counts.Open = row.Count
+		}
+	}
+	return counts, nil
+}
+
+// BackfillTaskCounts stores the task counts of the goals, contexts and
+// projects created before counts were kept, and creates the documents of the
+// tags carried by tasks, returning how many it filled in. It is safe to run
+// on several instances at once.
+func BackfillTaskCounts(ctx context.Context, taskCollection *mongo.Collection, goalCollection *mongo.Collection, contextCollection *mongo.Collection, projectCollection *mongo.Collection, tagCollection *mongo.Collection) (int, error) {
+	missing := bson.M{"taskCounts": bson.M{"$exists": false}}
+	projection := options.Find().SetProjection(bson.M{"_id": 1, "user": 1, "name": 1})
+	filled := 0
+
+	cursor, err := goalCollection.Find(ctx, missing, projection)
+	if err != nil {
+		return filled, err
+	}
+	var goals []models.Goal
+	if err := cursor.All(ctx, &goals); err != nil {
+		return filled, err
+	}
+	for _, goal := range goals {
+		goalID := goal.ID
+		if err := recountTaskCounts(ctx, taskCollection, taskCounters{goals: goalCollection}, models.Task{Goal: &goalID}); err != nil {
+			return filled, err
+		}
+		filled++
+	}
+
+	cursor, err = contextCollection.Find(ctx, missing, projection)
+	if err != nil {
+		return filled, err
+	}
+	var contexts []models.Context
+	if err := cursor.All(ctx, &contexts); err != nil {
+		return filled, err
+	}
+	for _, item := range contexts {
+		if err := recountTaskCounts(ctx, taskCollection, taskCounters{contexts: contextCollection}, models.Task{User: item.User, Context: item.Name}); err != nil {
+			return filled, err
+		}
+		filled++
+	}
+
+	cursor, err = projectCollection.Find(ctx, missing, projection)
+	if err != nil {
+		return filled, err
+	}
+	var projects []models.Project
+	if err := cursor.All(ctx, &projects); err != nil {
+		return filled, err
+	}
+	for _, project := range projects {
+		projectID := project.ID
+		if err := recountTaskCounts(ctx, taskCollection, taskCounters{projects: projectCollection}, models.Task{User: project.User, Project: &projectID}); err != nil {
+			return filled, err
+		}
+		filled++
+	}
+
+	// Tags have no document until their counts are stored
+	cursor, err = taskCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"tags": bson.M{"$exists": true}}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"user": "$user", "name": "$tags"}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": tagCollection.Name(),
+			"let":  bson.M{"user": "$_id.user", "name": "$_id.name"},
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$user", "$$user"}},
+					bson.M{"$eq": bson.A{"$name", "$$name"}},
+				}}}}},
+				{{Key: "$project", Value: bson.M{"_id": 1}}},
+			},
+			"as": "tag",
+		}}},
+		{{Key: "$match", Value: bson.M{"tag": bson.M{"$size": 0}}}},
+	})
+	if err != nil {
+		return filled, err
+	}
+	var tags []struct {
+		Key models.Tag `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &tags); err != nil {
+		return filled, err
+	}
+	for _, tag := range tags {
+		if err := recountTaskCounts(ctx, taskCollection, taskCounters{tags: tagCollection}, models.Task{User: tag.Key.User, Tags: []string{tag.Key.Name}}); err != nil {
+			return filled, err
+		}
+		filled++
+	}
+	return filled, nil
+}
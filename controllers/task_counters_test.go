@@ -0,0 +1,156 @@
+package controllers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+	"gotodolist/models"
+	"gotodolist/routes"
+	"gotodolist/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestTaskCountsFollowTaskWrites checks that the counts stored on a project
+// and on its tags follow the tasks created and deleted through the API
+func TestTaskCountsFollowTaskWrites(t *testing.T) {
+	db := testutil.Database(t)
+	gin.SetMode(gin.TestMode)
+
+	users := db.Collection("users")
+	tasks := db.Collection("tasks")
+	projects := db.Collection("projects")
+	tags := db.Collection("tags")
+
+	user := testutil.NewUser()
+	project := testutil.NewProject(user)
+	testutil.Insert(t, users, user)
+	testutil.Insert(t, projects, project)
+
+	taskController := controllers.NewTaskController(tasks, db.Collection("goals"), db.Collection("contexts"), projects, tags, db.Collection("task_activity"), db.Collection("task_versions"), nil, nil, nil)
+	projectController := controllers.NewProjectController(projects, tasks, db.Collection("project_templates"), db.Collection("goals"), db.Collection("contexts"), tags, users, nil)
+	authMiddleware := middleware.NewAuthMiddleware(users, middleware.NewUserCache(), nil)
+	router := gin.New()
+	routes.SetupTaskRoutes(router, taskController, authMiddleware)
+	routes.SetupProjectRoutes(router, projectController, authMiddleware)
+	client := testutil.NewClient(t, router, user)
+
+	var open, done models.Task
+	testutil.Data(t, client.Do(t, http.MethodPost, "/tasks/", gin.H{"title": "Paint the hall", "project": project.ID.Hex(), "tags": []string{"diy"}}), http.StatusCreated, &open)
+	testutil.Data(t, client.Do(t, http.MethodPost, "/tasks/", gin.H{"title": "Buy paint", "project": project.ID.Hex(), "tags": []string{"diy", "errands"}, "completed": true}), http.StatusCreated, &done)
+
+	var stored models.Project
+	testutil.Data(t, client.Do(t, http.MethodGet, "/projects/"+project.ID.Hex(), nil), http.StatusOK, &stored)
+	if want := (models.TaskCounts{Open: 1, Completed: 1}); stored.TaskCounts != want {
+		t.Errorf("project counts %+v, want %+v", stored.TaskCounts, want)
+	}
+
+	var counts []controllers.TagCount
+	testutil.Data(t, client.Do(t, http.MethodGet, "/tasks/tags", nil), http.StatusOK, &counts)
+	want := []controllers.TagCount{{Tag: "diy", Count: 2, Open: 1}, {Tag: "errands", Count: 1, Open: 0}}
+	if len(counts) != len(want) || counts[0] != want[0] || counts[1] != want[1] {
+		t.Errorf("tags %+v, want %+v", counts, want)
+	}
+
+	testutil.Data(t, client.Do(t, http.MethodDelete, "/tasks/"+done.ID.Hex(), nil), http.StatusOK, nil)
+
+	testutil.Data(t, client.Do(t, http.MethodGet, "/projects/"+project.ID.Hex(), nil), http.StatusOK, &stored)
+	if want := (models.TaskCounts{Open: 1}); stored.TaskCounts != want {
+		t.Errorf("project counts after delete %+v, want %+v", stored.TaskCounts, want)
+	}
+	testutil.Data(t, client.Do(t, http.MethodGet, "/tasks/tags", nil), http.StatusOK, &counts)
+	if len(counts) != 1 || counts[0] != (controllers.TagCount{Tag: "diy", Count: 1, Open: 1}) {
+		t.Errorf("tags after delete %+v, want only diy with one open task", counts)
+	}
+}
+
+// TestTaskCountsUnderConcurrentWrites checks that the counts stored on a
+// project and its tags match its tasks after edits and tag changes race
+func TestTaskCountsUnderConcurrentWrites(t *testing.T) {
+	db := testutil.Database(t)
+	gin.SetMode(gin.TestMode)
+
+	users := db.Collection("users")
+	tasks := db.Collection("tasks")
+	projects := db.Collection("projects")
+	tags := db.Collection("tags")
+
+	user := testutil.NewUser()
+	project := testutil.NewProject(user)
+	testutil.Insert(t, users, user)
+	testutil.Insert(t, projects, project)
+
+	taskController := controllers.NewTaskController(tasks, db.Collection("goals"), db.Collection("contexts"), projects, tags, db.Collection("task_activity"), db.Collection("task_versions"), nil, nil, nil)
+	projectController := controllers.NewProjectController(projects, tasks, db.Collection("project_templates"), db.Collection("goals"), db.Collection("contexts"), tags, users, nil)
+	authMiddleware := middleware.NewAuthMiddleware(users, middleware.NewUserCache(), nil)
+	router := gin.New()
+	routes.SetupTaskRoutes(router, taskController, authMiddleware)
+	routes.SetupProjectRoutes(router, projectController, authMiddleware)
+	client := testutil.NewClient(t, router, user)
+
+	ids := make([]string, 4)
+	for i := range ids {
+		var task models.Task
+		testutil.Data(t, client.Do(t, http.MethodPost, "/tasks/", gin.H{"title": fmt.Sprintf("Task %d", i), "project": project.ID.Hex(), "tags": []string{"diy"}}), http.StatusCreated, &task)
+		ids[i] = task.ID.Hex()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := ids[i%len(ids)]
+			var w *httptest.ResponseRecorder
+			switch i % 4 {
+			case 0, 1:
+				w = client.Do(t, http.MethodPut, "/tasks/"+id, gin.H{"title": "Edited", "completed": i%8 < 4, "project": project.ID.Hex()})
+			case 2:
+				w = client.Do(t, http.MethodPatch, "/tasks/tags/errands", gin.H{"add": ids})
+			default:
+				w = client.Do(t, http.MethodPatch, "/tasks/tags/errands", gin.H{"remove": []string{id}})
+			}
+			if w.Code != http.StatusOK {
+				t.Errorf("request %d answered %d: %s", i, w.Code, w.Body.String())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var listed []models.Task
+	testutil.Data(t, client.Do(t, http.MethodGet, "/tasks/", nil), http.StatusOK, &listed)
+	var want, errands models.TaskCounts
+	for _, task := range listed {
+		counted := []*models.TaskCounts{&want}
+		for _, tag := range task.Tags {
+			if tag == "errands" {
+				counted = append(counted, &errands)
+			}
+		}
+		for _, counts := range counted {
+			if task.Completed {
+				counts.Completed++
+			} else {
+				counts.Open++
+			}
+		}
+	}
+
+	var stored models.Project
+	testutil.Data(t, client.Do(t, http.MethodGet, "/projects/"+project.ID.Hex(), nil), http.StatusOK, &stored)
+	if stored.TaskCounts != want {
+		t.Errorf("project counts %+v, want %+v from its tasks", stored.TaskCounts, want)
+	}
+	var counts []controllers.TagCount
+	testutil.Data(t, client.Do(t, http.MethodGet, "/tasks/tags", nil), http.StatusOK, &counts)
+	for _, count := range counts {
+		if count.Tag == "errands" && (count.Count != errands.Open+errands.Completed || count.Open != errands.Open) {
+			t.Errorf("errands counts %+v, want %+v from its tasks", count, errands)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetTaskCounts returns the badge counts of a sidebar in a single
+// aggregation: the open tasks in the inbox, due today, upcoming over the
+// next 7 days and overdue, and the open tasks of each goal, context and
+// tag.
+// Snoozed tasks are left out like in the default task list.
+func (tc *TaskController) GetTaskCounts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	now := time.Now()
+	startOfToday := utils.StartOfDay(now)
+	endOfToday := startOfToday.AddDate(0, 0, 1)
+
+	count := func(match bson.M) bson.A {
+		return bson.A{bson.M{"$match": match}, bson.M{"$count": "count"}}
+	}
+	groupBy := func(field string) bson.A {
+		return bson.A{
+			bson.M{"$match": bson.M{field: bson.M{"$exists": true}}},
+			bson.M{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user": userID, "completed": false, "snoozedUntil": notSnoozed()}}},
+		{{Key: "$facet", Value: bson.M{
+			"inbox":    count(bson.M{"inbox": true}),
+			"today":    count(bson.M{"dueDate": bson.M{"$gte": startOfToday, "$lt": endOfToday}, "startDate": notDeferred()}),
+			"upcoming": count(bson.M{"dueDate": bson.M{"$gte": endOfToday, "$lt": endOfToday.AddDate(0, 0, 7)}}),
+			"overdue":  count(bson.M{"dueDate": bson.M{"$lt": now}}),
+			"goals":    groupBy("goal"),
+			"contexts": groupBy("context"),
+			"tags": bson.A{
+				bson.M{"$unwind": "$tags"},
+				bson.M{"$group": bson.M{"_id": "$tags", "count": bson.M{"$sum": 1}}},
+			},
+		}}},
+	}
+
+	cursor, err := tc.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count tasks",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	type total struct {
+		Count int64 `bson:"count"`
+	}
+	var facets []struct {
+		Inbox    []total `bson:"inbox"`
+		Today    []total `bson:"today"`
+		Upcoming []total `bson:"upcoming"`
+		Overdue  []total `bson:"overdue"`
+		Goals    []struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Count int64              `bson:"count"`
+		} `bson:"goals"`
+		Contexts []struct {
+			Name  string `bson:"_id"`
+			Count int64  `bson:"count"`
+		} `bson:"contexts"`
+		Tags []struct {
+			Name  string `bson:"_id"`
+			Count int64  `bson:"count"`
+		} `bson:"tags"`
+	}
+	if err := cursor.All(ctx, &facets); err != nil || len(facets) != 1 {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse task counts",
+		})
+		return
+	}
+	result := facets[0]
+
+	// $count yields no document at all when nothing matches
+	first := func(totals []total) int64 {
+		if len(totals) == 0 {
+			return 0
+		}
+		return totals[0].Count
+	}
+
+	goals := map[string]int64{}
+	for _, goal := range result.Goals {
+		goals[goal.ID.Hex()] = goal.Count
+	}
+	contexts := map[string]int64{}
+	for _, item := range result.Contexts {
+		contexts[item.Name] = item.Count
+	}
+	tags := map[string]int64{}
+	for _, item := range result.Tags {
+		tags[item.Name] = item.Count
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"inbox":    first(result.Inbox),
+			"today":    first(result.Today),
+			"upcoming": first(result.Upcoming),
+			"overdue":  first(result.Overdue),
+			"goals":    goals,
+			"contexts": contexts,
+			"tags":     tags,
+		},
+	})
+}
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// noGroup is the key of the group of tasks without a value for the field
+const noGroup = "none"
+
+// taskGroupField is a field GET /tasks can group by
+type taskGroupField struct {
+	sortField string      // Task field sorted on to keep each group contiguous
+	key       interface{} // Aggregation expression of the group key
+}
+
+// taskGroupFields are the fields GET /tasks can group by. A task with tags
+// is in the group of its first tag in alphabetical order, the one MongoDB
+// sorts it by, so that counts and pages agree.
+var taskGroupFields = map[string]taskGroupField{
+	"priority": {"priority", "$priority"},
+	"dueDate":  {"dueDate", bson.M{"$dateToString": bson.M{"format": "%Y-%m-%d", "date": "$dueDate"}}},
+	"goal":     {"goal", "$goal"},
+	"section":  {"section", "$section"},
+	"context":  {"context", "$context"},
+	"project":  {"project", "$project"},
+	"tag":      {"tags", bson.M{"$min": "$tags"}},
+}
+
+// TaskGroup is a bucket of a grouped task list. Count covers every page,
+// Tasks only the requested one.
+type TaskGroup struct {
+	Key   string        `json:"key"`
+	Count int64         `json:"count"`
+	Tasks []models.Task `json:"tasks"`
+}
+
+// taskGroupKey returns the key of the group of a task, matching the keys
+// computed by the aggregation
+func taskGroupKey(task models.Task, groupBy string) string {
+	switch groupBy {
+	case "priority":
+		return task.Priority
+	case "dueDate":
+		if task.DueDate != nil {
+			return task.DueDate.UTC().Format(utils.DayLayout)
+		}
+	case "goal":
+		if task.Goal != nil {
+			return task.Goal.Hex()
+		}
+	case "section":
+		if task.Section != nil {
+			return task.Section.Hex()
+		}
+	case "context":
+		if task.Context != "" {
+			return task.Context
+		}
+	case "project":
+		if task.Project != nil {
+			return task.Project.Hex()
+		}
+	case "tag":
+		if len(task.Tags) > 0 {
+			first := task.Tags[0]
+			for _, tag := range task.Tags[1:] {
+				if tag < first {
+					first = tag
+				}
+			}
+			return first
+		}
+	}
+	return noGroup
+}
+
+// groupTasks buckets a page of tasks, in the order their groups first
+// appear, with the size of each group over the whole filter
+func groupTasks(ctx context.Context, collection *mongo.Collection, filter bson.M, groupBy string, tasks []models.Task) ([]TaskGroup, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": taskGroupFields[groupBy].key, "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Key   interface{} `bson:"_id"`
+		Count int64       `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{}
+	for _, row := range rows {
+		key := noGroup
+		switch value := row.Key.(type) {
+		case string:
+			key = value
+		case primitive.ObjectID:
+			key = value.Hex()
+		}
+		counts[key] += row.Count
+	}
+
+	groups := []TaskGroup{}
+	index := map[string]int{}
+	for _, task := range tasks {
+		key := taskGroupKey(task, groupBy)
+		i, seen := index[key]
+		if !seen {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, TaskGroup{Key: key, Count: counts[key], Tasks: []models.Task{}})
+		}
+		groups[i].Tasks = append(groups[i].Tasks, task)
+	}
+	return groups, nil
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxCaptureLength is the largest text accepted by an inbox capture, in bytes
+const maxCaptureLength = 10000
+
+// maxCaptureTitle is the longest task title made from a capture, in characters
+const maxCaptureTitle = 200
+
+// inboxFilter matches the open tasks of a user that were captured and not
+// triaged yet
+func inboxFilter(userID interface{}) bson.M {
+	return bson.M{"user": userID, "inbox": true, "completed": false}
+}
+
+// CaptureTask files a piece of text in the inbox as an untriaged task. The
+// body is either plain text or {"text": "..."}; its first line becomes the
+// title and the rest the description. Automations run after the response so
+// share sheets and bots get their answer right away.
+func (tc *TaskController) CaptureTask(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	var text string
+	if c.ContentType() == "text/plain" {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxCaptureLength+1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Failed to read request body",
+			})
+			return
+		}
+		text = string(body)
+	} else {
+		var input struct {
+			Text string `json:"text"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid input data",
+			})
+			return
+		}
+		text = input.Text
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" || len(text) > maxCaptureLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Text must be between 1 and 10000 bytes",
+		})
+		return
+	}
+
+	title, description, _ := strings.Cut(text, "\n")
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) > maxCaptureTitle {
+		description = text
+		title = string([]rune(title)[:maxCaptureTitle-1]) + "…"
+	}
+
+	count, ok := tc.taskQuota(ctx, c, userID)
+	if !ok {
+		return
+	}
+
+	task := models.NewTask(title, userID.(primitive.ObjectID))
+	task.Description = strings.TrimSpace(description)
+	task.Inbox = true
+
+	result, err := tc.collection.InsertOne(ctx, task)
+	if err != nil {
+		tc.logger.With("user", task.User.Hex()).Error("Failed to capture task: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to create task",
+		})
+		return
+	}
+	task.ID = result.InsertedID.(primitive.ObjectID)
+
+	go func(task models.Task) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		tc.automations.Dispatch(ctx, models.TriggerTaskCreated, task)
+	}(*task)
+	warnings := tc.taskQuotaWarnings(ctx, task.User, count+1)
+
+	c.JSON(http.StatusCreated, withWarnings(gin.H{
+		"success": true,
+		"data":    task,
+	}, warnings))
+}
+
+// GetInbox lists the captured tasks waiting to be triaged, oldest first.
+// Updating a task with PUT /tasks/:id takes it out of the inbox.
+func (tc *TaskController) GetInbox(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(utils.GetQueryDefault(c, "page", "1"))
+	limit, _ := strconv.Atoi(utils.GetQueryDefault(c, "limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := inboxFilter(userID)
+	total, err := tc.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to count tasks",
+		})
+		return
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.M{"createdAt": 1}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := tc.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch tasks",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []models.Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse tasks",
+		})
+		return
+	}
+
+	totalPages := (int(total) + limit - 1) / limit
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"pagination": gin.H{
+			"total":      total,
+			"page":       page,
+			"limit":      limit,
+			"totalPages": totalPages,
+			"links":      utils.SetPaginationLinks(c, page, limit, totalPages),
+		},
+		"count": len(tasks),
+		"data":  tasks,
+	})
+}
@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// maxSuggestions caps the number of tasks GET /tasks/next returns
+const maxSuggestions = 10
+
+// nextTaskFactors are the scoring factors of GET /tasks/next with their
+// default weight, each weight can be set with its environment variable or
+// overridden by the query parameter of the same name
+var nextTaskFactors = []struct {
+	name          string
+	env           string
+	defaultWeight string
+}{
+	{"due", "NEXT_WEIGHT_DUE", "3"},
+	{"priority", "NEXT_WEIGHT_PRIORITY", "2"},
+	{"age", "NEXT_WEIGHT_AGE", "1"},
+	{"estimate", "NEXT_WEIGHT_ESTIMATE", "1"},
+}
+
+// TaskSuggestion is a task suggested by GET /tasks/next with its score
+// between 0 and 1 and the factors it was computed from
+type TaskSuggestion struct {
+	Task    models.Task        `json:"task"`
+	Score   float64            `json:"score"`
+	Factors map[string]float64 `json:"factors"`
+}
+
+// scoreTask rates how much a task should be done next. Each factor is
+// between 0 and 1: due is 1 once overdue and 1 / (1 + days left) before,
+// priority follows the priority rank, age reaches 1 after 30 days and
+// estimate favours quick wins, with 0.5 for tasks without an estimate.
+func scoreTask(task models.Task, weights map[string]float64, now time.Time) TaskSuggestion {
+	factors := map[string]float64{
+		"due":      0,
+		"priority": float64(priorityRank[task.Priority]) / 3,
+		"age":      math.Min(now.Sub(task.CreatedAt).Hours()/24/30, 1),
+		"estimate": 0.5,
+	}
+	if task.DueDate != nil {
+		daysLeft := math.Max(task.DueDate.Sub(now).Hours()/24, 0)
+		factors["due"] = 1 / (1 + daysLeft)
+	}
+	if task.Estimate > 0 {
+		factors["estimate"] = 1 / (1 + float64(task.Estimate)/30)
+	}
+
+	var score, total float64
+	for name, weight := range weights {
+		score += weight * factors[name]
+		total += weight
+	}
+	if total > 0 {
+		score /= total
+	}
+
+	for name, value := range factors {
+		factors[name] = math.Round(value*1000) / 1000
+	}
+	return TaskSuggestion{Task: task, Score: math.Round(score*1000) / 1000, Factors: factors}
+}
+
+// GetNextTasks suggests what to do next among the open tasks that can be
+// worked on now: not snoozed, started and not waiting on an open
+// dependency. The best scored tasks come first, or with ?random=true a
+// random pick weighted by score, so a bot asking twice gets some variety.
+func (tc *TaskController) GetNextTasks(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	count, err := strconv.Atoi(utils.GetQueryDefault(c, "count", "1"))
+	if err != nil || count < 1 || count > maxSuggestions {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "count must be between 1 and 10",
+		})
+		return
+	}
+
+	weights := map[string]float64{}
+	for _, factor := range nextTaskFactors {
+		weight, err := strconv.ParseFloat(utils.GetQueryDefault(c, factor.name, utils.GetEnv(factor.env, factor.defaultWeight)), 64)
+		if err != nil || weight < 0 || math.IsInf(weight, 0) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Weights must be positive numbers",
+			})
+			return
+		}
+		weights[factor.name] = weight
+	}
+
+	cursor, err := tc.collection.Find(ctx, bson.M{"user": userID, "completed": false})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch tasks",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse tasks",
+		})
+		return
+	}
+
+	// Every open task is loaded so that snoozed or deferred ones still
+	// block the tasks depending on them
+	open := map[string]bool{}
+	for _, task := range tasks {
+		open[task.ID.Hex()] = true
+	}
+
+	now := time.Now()
+	candidates := []TaskSuggestion{}
+	for _, task := range tasks {
+		actionable := (task.SnoozedUntil == nil || !task.SnoozedUntil.After(now)) &&
+			(task.StartDate == nil || !task.StartDate.After(now))
+		for _, dependency := range task.DependsOn {
+			actionable = actionable && !open[dependency.Hex()]
+		}
+		if actionable {
+			candidates = append(candidates, scoreTask(task, weights, now))
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	suggestions := candidates
+	if c.Query("random") == "true" {
+		suggestions = pickWeighted(candidates, count)
+	} else if len(suggestions) > count {
+		suggestions = suggestions[:count]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"weights": weights,
+		"count":   len(suggestions),
+		"data":    suggestions,
+	})
+}
+
+// pickWeighted draws up to count suggestions at random without
+// replacement, each with a chance proportional to its score
+func pickWeighted(candidates []TaskSuggestion, count int) []TaskSuggestion {
+	pool := append([]TaskSuggestion{}, candidates...)
+	picked := []TaskSuggestion{}
+	for len(picked) < count && len(pool) > 0 {
+		var total float64
+		for _, candidate := range pool {
+			total += candidate.Score
+		}
+
+		// Every score being 0 leaves a uniform draw
+		i := rand.Intn(len(pool))
+		if total > 0 {
+			target := rand.Float64() * total
+			for i = 0; i < len(pool)-1; i++ {
+				target -= pool[i].Score
+				if target < 0 {
+					break
+				}
+			}
+		}
+
+		picked = append(picked, pool[i])
+		pool = append(pool[:i], pool[i+1:]...)
+	}
+	return picked
+}
@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskListQuery is the filter, sort and page of a GET /tasks request
+type TaskListQuery struct {
+	Filter  bson.M
+	Sort    bson.M
+	GroupBy string // Field the tasks are grouped by, empty when not grouped
+	Page    int
+	Limit   int
+}
+
+// Skip returns the number of tasks before the requested page
+func (q *TaskListQuery) Skip() int {
+	return (q.Page - 1) * q.Limit
+}
+
+// SortOrder returns the sort of the query, led by the group field when
+// grouping so that each group is contiguous across pages
+func (q *TaskListQuery) SortOrder() bson.D {
+	order := bson.D{}
+	groupField := ""
+	if q.GroupBy != "" {
+		groupField = taskGroupFields[q.GroupBy].sortField
+		order = append(order, bson.E{Key: groupField, Value: 1})
+	}
+	for field, direction := range q.Sort {
+		if field != groupField {
+			order = append(order, bson.E{Key: field, Value: direction})
+		}
+	}
+	return order
+}
+
+// notAfter matches a date that is missing or not after At, encoding as
+// {"$not": {"$gt": At}}. Every task list filters two dates this way, and a
+// struct costs one allocation where the nested bson.M cost five.
+type notAfter struct {
+	Not struct {
+		At time.Time `bson:"$gt"`
+	} `bson:"$not"`
+}
+
+// notAfterTime returns the notAfter condition of t
+func notAfterTime(t time.Time) notAfter {
+	var condition notAfter
+	condition.Not.At = t
+	return condition
+}
+
+// notDeferred matches the startDate of tasks that can be worked on now,
+// either without a start date or with one that has arrived
+func notDeferred() notAfter {
+	return notAfterTime(time.Now())
+}
+
+// ParseTaskListQuery builds the query of a task list from the request
+// parameters. It runs on every list request, so perf/ benchmarks it.
+func ParseTaskListQuery(c *gin.Context, userID interface{}) (*TaskListQuery, error) {
+	completed := c.Query("completed")
+	priority := c.Query("priority")
+	goal := c.Query("goal")
+	contextName := c.Query("context")
+	project := c.Query("project")
+	tags := c.Query("tags")
+	tagMode := utils.GetQueryDefault(c, "tagMode", "all")
+	snoozed := c.Query("snoozed")
+	deferred := c.Query("deferred")
+	groupBy := c.Query("groupBy")
+	sortField := c.Query("sort")
+	sortDir := utils.GetQueryDefault(c, "sortDir", "asc")
+	page, _ := strconv.Atoi(utils.GetQueryDefault(c, "page", "1"))
+	limit, _ := strconv.Atoi(utils.GetQueryDefault(c, "limit", "10"))
+
+	// Ensure page and limit are valid
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	// Build query
+	query := bson.M{"user": userID}
+
+	// Add filters if provided
+	if completed != "" {
+		query["completed"] = completed == "true"
+	}
+
+	if priority != "" {
+		query["priority"] = priority
+	}
+
+	if goal != "" {
+		goalID, err := primitive.ObjectIDFromHex(goal)
+		if err != nil {
+			return nil, errors.New("Invalid goal ID format")
+		}
+		query["goal"] = goalID
+	}
+
+	if project == "none" {
+		query["project"] = bson.M{"$exists": false}
+	} else if project != "" {
+		projectID, err := primitive.ObjectIDFromHex(project)
+		if err != nil {
+			return nil, errors.New("Invalid project ID format")
+		}
+		query["project"] = projectID
+	}
+
+	if contextName == "none" {
+		query["context"] = bson.M{"$exists": false}
+	} else if contextName != "" {
+		name, ok := utils.NormalizeContext(contextName)
+		if !ok {
+			return nil, errors.New("Invalid context")
+		}
+		query["context"] = name
+	}
+
+	// Tasks with every listed tag, or with any of them in "any" mode
+	if tags != "" {
+		names, ok := utils.NormalizeTags(strings.Split(tags, ","))
+		if !ok {
+			return nil, errors.New("Invalid tag")
+		}
+		switch tagMode {
+		case "all":
+			query["tags"] = bson.M{"$all": names}
+		case "any":
+			query["tags"] = bson.M{"$in": names}
+		default:
+			return nil, errors.New("tagMode must be one of: all, any")
+		}
+	}
+
+	// Snoozed tasks are hidden unless asked for
+	switch snoozed {
+	case "true":
+		query["snoozedUntil"] = bson.M{"$gt": time.Now()}
+	case "all":
+	default:
+		query["snoozedUntil"] = notSnoozed()
+	}
+
+	// So are tasks that cannot be started yet
+	switch deferred {
+	case "true":
+		query["startDate"] = bson.M{"$gt": time.Now()}
+	case "all":
+	default:
+		query["startDate"] = notDeferred()
+	}
+
+	if _, ok := taskGroupFields[groupBy]; groupBy != "" && !ok {
+		return nil, errors.New("groupBy must be one of: priority, dueDate, goal, section, context, project, tag")
+	}
+
+	// Apply sorting
+	sort := bson.M{"createdAt": -1} // Default sort by createdAt
+	if sortField != "" {
+		sortOrder := 1
+		if sortDir == "desc" {
+			sortOrder = -1
+		}
+		sort = bson.M{sortField: sortOrder}
+	}
+
+	return &TaskListQuery{Filter: query, Sort: sort, GroupBy: groupBy, Page: page, Limit: limit}, nil
+}
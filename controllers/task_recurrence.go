@@ -0,0 +1,697 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gotodolist/models"
+	"gotodolist/recurrence"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Scopes of an update of a recurring task, given by the scope parameter
+const (
+	scopeOccurrence = "occurrence"
+	scopeSeries     = "series"
+)
+
+// seriesFields are the task fields an update with the series scope applies
+// to the other open occurrences too
+var seriesFields = []string{
+	"title", "description", "priority", "estimate", "goal", "section", "context",
+	"project", "color", "icon", "tags", "autoComplete",
+}
+
+// scheduleFields are the fields an update of one occurrence moves without
+// moving the series, the next occurrence following the scheduled dates
+var scheduleFields = []string{"startDate", "dueDate"}
+
+// recurrenceScope reads the scope parameter of an update, answering the
+// request when it is invalid
+func recurrenceScope(c *gin.Context) (string, bool) {
+	switch scope := c.DefaultQuery("scope", scopeOccurrence); scope {
+	case scopeOccurrence, scopeSeries:
+		return scope, true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{
+		"success": false,
+		"error":   "Scope must be one of: occurrence, series",
+	})
+	return "", false
+}
+
+// parseRecurrence validates a recurrence rule and returns its canonical
+// form, answering the request when it is invalid. Recurring tasks need a
+// due date to repeat from.
+func parseRecurrence(c *gin.Context, text string, dueDate *time.Time) (string, bool) {
+	rule, err := recurrence.Parse(text)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return "", false
+	}
+	if dueDate == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Recurring tasks need a due date",
+		})
+		return "", false
+	}
+	return rule.String(), true
+}
+
+// recurrenceUpdate adds to the update of a task the change of its rule, an
+// empty one ending the series with the task, and the series values of the
+// fields edited on this occurrence only. With the series scope, the edited
+// fields follow the series again.
+func recurrenceUpdate(c *gin.Context, task models.Task, rule *string, scope string, dueDate *time.Time, set, unset bson.M) bool {
+	if rule != nil && *rule == "" {
+		if task.Recurrence != nil {
+			unset["recurrence"] = ""
+		}
+		return true
+	}
+	if rule != nil {
+		canonical, ok := parseRecurrence(c, *rule, dueDate)
+		if !ok {
+			return false
+		}
+		if task.Recurrence == nil {
+			// The task starts a new series
+			set["recurrence"] = models.Recurrence{Rule: canonical, Series: task.ID, Start: *dueDate, Occurrence: 1}
+			return true
+		}
+		set["recurrence.rule"] = canonical
+		unset["recurrence.last"] = ""
+	}
+	if task.Recurrence == nil {
+		return true
+	}
+
+	document, err := bsonDocument(task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update task",
+		})
+		return false
+	}
+	for _, field := range append(append([]string{}, seriesFields...), scheduleFields...) {
+		_, setting := set[field]
+		_, unsetting := unset[field]
+		if !setting && !unsetting {
+			continue
+		}
+		if scope == scopeSeries {
+			unset["recurrence.overrides."+field] = ""
+		} else if _, recorded := task.Recurrence.Overrides[field]; !recorded {
+			set["recurrence.overrides."+field] = document[field]
+		}
+	}
+	// A series moved as a whole stays on the day it moved to
+	if due, ok := set["dueDate"]; ok && scope == scopeSeries {
+		set["recurrence.start"] = due
+	}
+	return true
+}
+
+// applyToSeries applies the series fields and rule of an update to the
+// other open occurrences of the series of a task
+func (tc *TaskController) applyToSeries(ctx context.Context, task models.Task, set, unset bson.M) error {
+	seriesSet := bson.M{"updatedAt": set["updatedAt"]}
+	seriesUnset := bson.M{}
+	for _, field := range seriesFields {
+		if value, ok := set[field]; ok {
+			seriesSet[field] = value
+			seriesUnset["recurrence.overrides."+field] = ""
+		}
+		if _, ok := unset[field]; ok {
+			seriesUnset[field] = ""
+			seriesUnset["recurrence.overrides."+field] = ""
+		}
+	}
+	if rule, ok := set["recurrence.rule"]; ok {
+		seriesSet["recurrence.rule"] = rule
+		seriesUnset["recurrence.last"] = ""
+	}
+	if _, ok := unset["recurrence"]; ok {
+		// Ending the series ends it for every occurrence
+		seriesUnset = bson.M{"recurrence": ""}
+	}
+
+	update := bson.M{"$set": seriesSet}
+	if len(seriesUnset) > 0 {
+		update["$unset"] = seriesUnset
+	}
+	open := bson.M{
+		"recurrence.series": task.Recurrence.Series,
+		"user":              task.User,
+		"completed":         false,
+		"_id":               bson.M{"$ne": task.ID},
+	}
+
+	// Occurrences are updated one by one so each counts the groups it leaves
+	return writeWithCounts(ctx, tc.collection, tc.counters(), func(ctx context.Context) (countChanges, error) {
+		ids, err := tc.collection.Distinct(ctx, "_id", open)
+		if err != nil {
+			return nil, err
+		}
+		changes := countChanges{}
+		for _, id := range ids {
+			filter := bson.M{"_id": id, "recurrence.series": task.Recurrence.Series, "completed": false}
+			_, changed, err := updateCounted(ctx, tc.collection, filter, update)
+			if err == mongo.ErrNoDocuments {
+				// Completed or deleted meanwhile
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			changes.merge(changed)
+		}
+		return changes, nil
+	})
+}
+
+// materializeNext creates the occurrence following a recurring task, the
+// first one after now so missed occurrences are skipped, and links it as
+// the next of the task. Only the first caller for a task creates it, so
+// completing a task while the job runs creates a single occurrence. A
+// series whose rule has no more occurrences is marked as ended instead.
+// Skipped occurrences are passed over and a moved one is created at its new
+// date, following the series dates again after it. A paused series creates
+// nothing until it resumes.
+func (tc *TaskController) materializeNext(ctx context.Context, task models.Task) error {
+	current := task.Recurrence
+	if current == nil || current.Next != nil || current.Last || current.Paused {
+		return nil
+	}
+	pending := bson.M{
+		"_id":               task.ID,
+		"recurrence.next":   bson.M{"$exists": false},
+		"recurrence.last":   bson.M{"$ne": true},
+		"recurrence.paused": bson.M{"$ne": true},
+	}
+
+	source, err := seriesSource(task)
+	if err != nil {
+		return err
+	}
+	rule, err := recurrence.Parse(current.Rule)
+	if err != nil {
+		return err
+	}
+	var due time.Time
+	occurrence, ok := 0, source.DueDate != nil
+	if ok {
+		due, occurrence, ok = rule.After(current.Start, *source.DueDate, current.Occurrence, time.Now())
+	}
+	var moved *time.Time
+	for ok {
+		exception, found := current.Exception(due)
+		if !found {
+			break
+		}
+		if exception.MovedTo != nil {
+			moved = exception.MovedTo
+			break
+		}
+		due, ok = rule.Next(current.Start, due, occurrence)
+		occurrence++
+	}
+	if !ok {
+		_, err := tc.collection.UpdateOne(ctx, pending, bson.M{"$set": bson.M{"recurrence.last": true}})
+		return err
+	}
+
+	id := primitive.NewObjectID()
+	result, err := tc.collection.UpdateOne(ctx, pending, bson.M{"$set": bson.M{"recurrence.next": id}})
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		// Created meanwhile by another request or instance
+		return nil
+	}
+
+	scheduled := due
+	if moved != nil {
+		due = *moved
+	}
+	next := nextOccurrence(source, due)
+	next.ID = id
+	next.Recurrence = &models.Recurrence{Rule: current.Rule, Series: current.Series, Start: current.Start, Occurrence: occurrence, Exceptions: current.Exceptions}
+	if moved != nil {
+		// Recorded like a move of this occurrence only, so the one after it
+		// follows the series dates again
+		next.Recurrence.Overrides = map[string]interface{}{"dueDate": scheduled, "startDate": nil}
+		if source.StartDate != nil {
+			next.Recurrence.Overrides["startDate"] = source.StartDate.Add(scheduled.Sub(*source.DueDate))
+		}
+	}
+	err = writeWithCounts(ctx, tc.collection, tc.counters(), func(ctx context.Context) (countChanges, error) {
+		if _, err := tc.collection.InsertOne(ctx, next); err != nil {
+			return nil, err
+		}
+		return taskChange(nil, &next), nil
+	})
+	if err != nil {
+		// Released for the next run to retry
+		tc.collection.UpdateOne(ctx, bson.M{"_id": task.ID, "recurrence.next": id}, bson.M{"$unset": bson.M{"recurrence.next": ""}})
+		return err
+	}
+
+	tc.automations.Dispatch(ctx, models.TriggerTaskCreated, next)
+	return nil
+}
+
+// seriesSource returns a task with the series values of the fields edited
+// on this occurrence only, the task the next occurrence is copied from
+func seriesSource(task models.Task) (models.Task, error) {
+	if len(task.Recurrence.Overrides) == 0 {
+		return task, nil
+	}
+	document, err := bsonDocument(task)
+	if err != nil {
+		return task, err
+	}
+	for field, value := range task.Recurrence.Overrides {
+		if value == nil {
+			delete(document, field)
+		} else {
+			document[field] = value
+		}
+	}
+
+	var source models.Task
+	data, err := bson.Marshal(document)
+	if err != nil {
+		return task, err
+	}
+	err = bson.Unmarshal(data, &source)
+	return source, err
+}
+
+// nextOccurrence copies a task as a new open occurrence due at due. The
+// start date and the reminders at a set time move with the due date, the
+// subtasks are reopened, and the state of the occurrence itself such as
+// its dependencies, snooze and delegation is left behind.
+func nextOccurrence(source models.Task, due time.Time) models.Task {
+	now := time.Now()
+	next := source
+	shift := due.Sub(*source.DueDate)
+
+	next.Completed = false
+	next.CompletedAt = nil
+	next.DueDate = &due
+	if source.StartDate != nil {
+		start := source.StartDate.Add(shift)
+		next.StartDate = &start
+	}
+	next.DependsOn = nil
+	next.Inbox = false
+	next.SnoozedUntil = nil
+	next.Delegation = nil
+	next.Position = 0
+	next.CreatedAt = now
+	next.UpdatedAt = now
+
+	next.Subtasks = nil
+	for _, subtask := range source.Subtasks {
+		next.Subtasks = append(next.Subtasks, models.Subtask{ID: primitive.NewObjectID(), Title: subtask.Title})
+	}
+	next.CountSubtasks()
+	next.Reminders = nil
+	for _, reminder := range source.Reminders {
+		copied := models.Reminder{ID: primitive.NewObjectID(), Before: reminder.Before}
+		if reminder.At != nil {
+			at := reminder.At.Add(shift)
+			copied.At = &at
+		}
+		next.Reminders = append(next.Reminders, copied)
+	}
+	return next
+}
+
+// MaterializeRecurrences creates the next occurrence of the recurring tasks
+// that were completed or whose due date passed, the ones a completion did
+// not create already
+func (tc *TaskController) MaterializeRecurrences(ctx context.Context) error {
+	cursor, err := tc.collection.Find(ctx, bson.M{
+		"recurrence.series": bson.M{"$exists": true},
+		"recurrence.next":   bson.M{"$exists": false},
+		"recurrence.last":   bson.M{"$ne": true},
+		"recurrence.paused": bson.M{"$ne": true},
+		"$or": bson.A{
+			bson.M{"completed": true},
+			bson.M{"dueDate": bson.M{"$lte": time.Now()}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if err := tc.materializeNext(ctx, task); err != nil {
+			tc.logger.With("task", task.ID.Hex()).Error("Failed to create the next occurrence: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// maxRecurrenceExceptions caps the skipped and moved occurrences of a series
+const maxRecurrenceExceptions = 100
+
+// SkippedOccurrence is the result of skipping an occurrence: the due date
+// recorded as an exception and the occurrence created in its place, none
+// when the series ended with the skipped one
+type SkippedOccurrence struct {
+	Skipped time.Time    `json:"skipped"`
+	Next    *models.Task `json:"next,omitempty"`
+}
+
+// SkipOccurrence skips an open occurrence of a recurring task, without
+// changing the rule: its due date is recorded as an exception of the
+// series, the next occurrence is created if it was not already, and the
+// task is deleted
+func (tc *TaskController) SkipOccurrence(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	task, ok := tc.ownedTask(ctx, c)
+	if !ok || !recurring(c, task) {
+		return
+	}
+	source, err := seriesSource(task)
+	if err != nil {
+		tc.logger.With("task", task.ID.Hex()).Error("Failed to read the series of a task: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to skip occurrence",
+		})
+		return
+	}
+	if task.Completed || source.DueDate == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Only open occurrences with a due date can be skipped",
+		})
+		return
+	}
+	if task.Recurrence.Paused && task.Recurrence.Next == nil {
+		// The paused state lives on the occurrence, which would be lost
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Resume the series to skip its last occurrence",
+		})
+		return
+	}
+	if !exceptionsLeft(c, task) {
+		return
+	}
+
+	var skipped models.Task
+	err = tc.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": task.ID, "completed": false},
+		bson.M{"$push": bson.M{"recurrence.exceptions": models.RecurrenceException{Date: *source.DueDate}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&skipped)
+	if err == mongo.ErrNoDocuments {
+		// Completed or deleted meanwhile
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Only open occurrences with a due date can be skipped",
+		})
+		return
+	}
+	if err == nil {
+		err = tc.materializeNext(ctx, skipped)
+	}
+	if err == nil {
+		err = writeWithCounts(ctx, tc.collection, tc.counters(), func(ctx context.Context) (countChanges, error) {
+			deleted, changes, err := deleteCounted(ctx, tc.collection, bson.M{"_id": task.ID})
+			if err == nil {
+				skipped = deleted
+			}
+			return changes, err
+		})
+	}
+	if err != nil {
+		tc.logger.With("task", task.ID.Hex()).Error("Failed to skip occurrence: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to skip occurrence",
+		})
+		return
+	}
+
+	result := SkippedOccurrence{Skipped: *source.DueDate}
+	if next := skipped.Recurrence.Next; next != nil {
+		var created models.Task
+		if err := tc.collection.FindOne(ctx, bson.M{"_id": *next}).Decode(&created); err == nil {
+			result.Next = &created
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// AddRecurrenceException skips an upcoming occurrence of the series of a
+// recurring task, or moves it to "movedTo", without changing the rule. The
+// occurrence is the one the rule puts on the day of "date", in the time
+// zone of date, after the occurrences created already: those are skipped
+// with SkipOccurrence or moved by updating their due date. Answers with the
+// last occurrence of the series, which carries the exceptions.
+func (tc *TaskController) AddRecurrenceException(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	var input struct {
+		Date    time.Time  `json:"date" binding:"required"`
+		MovedTo *time.Time `json:"movedTo"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	task, ok := tc.ownedTask(ctx, c)
+	if !ok || !recurring(c, task) {
+		return
+	}
+	last, ok := tc.seriesLast(ctx, c, task)
+	if !ok || !exceptionsLeft(c, last) {
+		return
+	}
+	if last.Recurrence.Last {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "The series has ended",
+		})
+		return
+	}
+
+	due, ok := upcomingOccurrence(last, input.Date)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Date must be the day of an upcoming occurrence of the series",
+		})
+		return
+	}
+
+	// An occurrence skipped or moved again replaces its exception
+	filter := seriesLastFilter(task)
+	exception := models.RecurrenceException{Date: due, MovedTo: input.MovedTo}
+	_, err := tc.collection.UpdateMany(ctx, filter, bson.M{"$pull": bson.M{"recurrence.exceptions": bson.M{"date": due}}})
+	if err == nil {
+		_, err = tc.collection.UpdateMany(ctx, filter, bson.M{
+			"$push": bson.M{"recurrence.exceptions": exception},
+			"$set":  bson.M{"updatedAt": time.Now()},
+		})
+	}
+	if err != nil {
+		tc.logger.With("task", task.ID.Hex()).Error("Failed to add a recurrence exception: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update the series",
+		})
+		return
+	}
+	tc.respondSeriesLast(ctx, c, task)
+}
+
+// PauseRecurrence pauses the series of a recurring task: no occurrence is
+// created until it resumes, and the open ones stay as they are
+func (tc *TaskController) PauseRecurrence(c *gin.Context) {
+	tc.updateSeries(c, bson.M{"$set": bson.M{"recurrence.paused": true}})
+}
+
+// ResumeRecurrence resumes a paused series. The occurrence following one
+// completed or overdue meanwhile is created at once, due after now.
+func (tc *TaskController) ResumeRecurrence(c *gin.Context) {
+	tc.updateSeries(c, bson.M{"$unset": bson.M{"recurrence.paused": ""}})
+}
+
+// EndRecurrence ends the series of a recurring task after the occurrences
+// created already, which keep their recurrence
+func (tc *TaskController) EndRecurrence(c *gin.Context) {
+	tc.updateSeries(c, bson.M{"$set": bson.M{"recurrence.last": true}, "$unset": bson.M{"recurrence.paused": ""}})
+}
+
+// updateSeries applies an update to the last occurrence of the series of
+// the task of the request, the one the next occurrence is created from,
+// creates the next occurrence when it is due, and answers with it
+func (tc *TaskController) updateSeries(c *gin.Context, update bson.M) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	task, ok := tc.ownedTask(ctx, c)
+	if !ok || !recurring(c, task) {
+		return
+	}
+
+	now := time.Now()
+	if set, ok := update["$set"].(bson.M); ok {
+		set["updatedAt"] = now
+	} else {
+		update["$set"] = bson.M{"updatedAt": now}
+	}
+	if _, err := tc.collection.UpdateMany(ctx, seriesLastFilter(task), update); err != nil {
+		tc.logger.With("task", task.ID.Hex()).Error("Failed to update the series: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update the series",
+		})
+		return
+	}
+
+	last, ok := tc.seriesLast(ctx, c, task)
+	if !ok {
+		return
+	}
+	if last.Completed || (last.DueDate != nil && !last.DueDate.After(now)) {
+		if err := tc.materializeNext(ctx, last); err != nil {
+			tc.logger.With("task", last.ID.Hex()).Error("Failed to create the next occurrence: " + err.Error())
+		}
+	}
+	tc.respondSeriesLast(ctx, c, task)
+}
+
+// recurring reports whether a task is an occurrence of a series, answering
+// the request when it is not
+func recurring(c *gin.Context, task models.Task) bool {
+	if task.Recurrence == nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Task is not recurring",
+		})
+		return false
+	}
+	return true
+}
+
+// exceptionsLeft reports whether the series of a task can take one more
+// exception, answering the request when it cannot
+func exceptionsLeft(c *gin.Context, task models.Task) bool {
+	if len(task.Recurrence.Exceptions) >= maxRecurrenceExceptions {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("A series can have at most %d skipped or moved occurrences", maxRecurrenceExceptions),
+		})
+		return false
+	}
+	return true
+}
+
+// seriesLastFilter matches the last occurrence of the series of a task,
+// the one no occurrence was created after yet
+func seriesLastFilter(task models.Task) bson.M {
+	return bson.M{
+		"recurrence.series": task.Recurrence.Series,
+		"user":              task.User,
+		"recurrence.next":   bson.M{"$exists": false},
+	}
+}
+
+// seriesLast loads the last occurrence of the series of a task, answering
+// the request when it cannot
+func (tc *TaskController) seriesLast(ctx context.Context, c *gin.Context, task models.Task) (models.Task, bool) {
+	var last models.Task
+	err := tc.collection.FindOne(ctx, seriesLastFilter(task)).Decode(&last)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "The series has ended",
+		})
+		return last, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch task",
+		})
+		return last, false
+	}
+	return last, true
+}
+
+// respondSeriesLast answers with the last occurrence of the series of a
+// task, which may have been created by the request
+func (tc *TaskController) respondSeriesLast(ctx context.Context, c *gin.Context, task models.Task) {
+	last, ok := tc.seriesLast(ctx, c, task)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    last,
+	})
+}
+
+// upcomingOccurrence returns the due date the rule of a series gives the
+// occurrence on the day of date, looking at the occurrences after the
+// last one created. It returns false when there is none that day or when
+// it is not after now.
+func upcomingOccurrence(last models.Task, date time.Time) (time.Time, bool) {
+	source, err := seriesSource(last)
+	if err != nil || source.DueDate == nil {
+		return time.Time{}, false
+	}
+	rule, err := recurrence.Parse(last.Recurrence.Rule)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	year, month, day := date.Date()
+	dayStart := time.Date(year, month, day, 0, 0, 0, 0, date.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	due, occurrence, ok := *source.DueDate, last.Recurrence.Occurrence, true
+	// Ten years of daily occurrences bound the search
+	for i := 0; ok && due.Before(dayEnd) && i < 3660; i++ {
+		due, ok = rule.Next(last.Recurrence.Start, due, occurrence)
+		occurrence++
+		if ok && !due.Before(dayStart) && due.Before(dayEnd) {
+			return due, due.After(time.Now())
+		}
+	}
+	return time.Time{}, false
+}
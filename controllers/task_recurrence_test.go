@@ -0,0 +1,70 @@
+package controllers_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+	"gotodolist/models"
+	"gotodolist/routes"
+	"gotodolist/testutil"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TestRecurrenceExceptions checks that skipping an occurrence and an
+// upcoming one leaves the rule as it is, and that an ended series creates
+// no more occurrences
+func TestRecurrenceExceptions(t *testing.T) {
+	db := testutil.Database(t)
+	gin.SetMode(gin.TestMode)
+
+	users := db.Collection("users")
+	tasks := db.Collection("tasks")
+	user := testutil.NewUser()
+	testutil.Insert(t, users, user)
+
+	taskController := controllers.NewTaskController(tasks, db.Collection("goals"), db.Collection("contexts"), db.Collection("projects"), db.Collection("tags"), db.Collection("task_activity"), db.Collection("task_versions"), nil, nil, nil)
+	authMiddleware := middleware.NewAuthMiddleware(users, middleware.NewUserCache(), nil)
+	router := gin.New()
+	routes.SetupTaskRoutes(router, taskController, authMiddleware)
+	client := testutil.NewClient(t, router, user)
+
+	due := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	var first models.Task
+	testutil.Data(t, client.Do(t, http.MethodPost, "/tasks/", gin.H{"title": "Water the plants", "dueDate": due, "recurrence": "daily"}), http.StatusCreated, &first)
+
+	// The third occurrence, two days after the first, is skipped ahead
+	var last models.Task
+	testutil.Data(t, client.Do(t, http.MethodPost, "/tasks/"+first.ID.Hex()+"/recurrence/exceptions", gin.H{"date": due.AddDate(0, 0, 2)}), http.StatusOK, &last)
+	if last.ID != first.ID || len(last.Recurrence.Exceptions) != 1 {
+		t.Fatalf("exceptions %+v on %s, want one on the first occurrence", last.Recurrence.Exceptions, last.ID.Hex())
+	}
+
+	var skipped controllers.SkippedOccurrence
+	testutil.Data(t, client.Do(t, http.MethodPost, "/tasks/"+first.ID.Hex()+"/recurrence/skip", nil), http.StatusOK, &skipped)
+	if !skipped.Skipped.Equal(due) || skipped.Next == nil {
+		t.Fatalf("skipped %v with next %v, want %v and a next occurrence", skipped.Skipped, skipped.Next, due)
+	}
+	if want := due.AddDate(0, 0, 1); !skipped.Next.DueDate.Equal(want) || skipped.Next.Recurrence.Rule != first.Recurrence.Rule {
+		t.Errorf("next due %v with rule %s, want %v with %s", skipped.Next.DueDate, skipped.Next.Recurrence.Rule, want, first.Recurrence.Rule)
+	}
+	testutil.Data(t, client.Do(t, http.MethodGet, "/tasks/"+first.ID.Hex(), nil), http.StatusNotFound, nil)
+
+	testutil.Data(t, client.Do(t, http.MethodPost, "/tasks/"+skipped.Next.ID.Hex()+"/recurrence/end", nil), http.StatusOK, &last)
+	if !last.Recurrence.Last {
+		t.Errorf("series not ended: %+v", last.Recurrence)
+	}
+	testutil.Data(t, client.Do(t, http.MethodPut, "/tasks/"+skipped.Next.ID.Hex(), gin.H{"completed": true}), http.StatusOK, nil)
+	count, err := tasks.CountDocuments(context.Background(), bson.M{"recurrence.series": first.ID})
+	if err != nil {
+		t.Fatalf("count occurrences: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("%d occurrences after ending the series, want 1", count)
+	}
+}
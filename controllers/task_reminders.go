@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxReminders caps the reminders of a task
+const maxReminders = 10
+
+// maxReminderLead is the furthest before the due date a reminder can be sent
+const maxReminderLead = 7 * 24 * time.Hour
+
+// reminderGrace is how late a reminder is still sent, after an outage or when
+// it is set in the past. Later ones are marked as sent without notifying.
+const reminderGrace = 24 * time.Hour
+
+// SetTaskReminders replaces the reminders of a task. Each one is either set
+// at a time, {"at": "..."}, or a number of minutes before the due date,
+// {"before": 30}. Reminders kept from the current list are not sent again.
+func (tc *TaskController) SetTaskReminders(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Reminders []struct {
+			At     *time.Time `json:"at"`
+			Before int        `json:"before"`
+		} `json:"reminders"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+	if len(input.Reminders) > maxReminders {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("A task can have at most %d reminders", maxReminders),
+		})
+		return
+	}
+
+	task, ok := tc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+
+	reminders := []models.Reminder{}
+	seen := map[string]bool{}
+	for _, reminder := range input.Reminders {
+		if (reminder.At == nil) == (reminder.Before == 0) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Each reminder needs either at or before",
+			})
+			return
+		}
+		if reminder.Before < 0 || time.Duration(reminder.Before)*time.Minute > maxReminderLead {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "before must be between 1 and 10080 minutes",
+			})
+			return
+		}
+
+		// Duplicates would send the same reminder twice
+		key := fmt.Sprintf("before:%d", reminder.Before)
+		if reminder.At != nil {
+			key = "at:" + reminder.At.UTC().Format(time.RFC3339)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		reminders = append(reminders, keptReminder(task.Reminders, models.Reminder{At: reminder.At, Before: reminder.Before}))
+	}
+
+	update := bson.M{"$set": bson.M{"reminders": reminders, "updatedAt": time.Now()}}
+	if len(reminders) == 0 {
+		update = bson.M{"$unset": bson.M{"reminders": ""}, "$set": bson.M{"updatedAt": time.Now()}}
+	}
+	tc.applyUpdate(ctx, c, task.ID, update)
+}
+
+// keptReminder returns the current reminder set at the same time as a new
+// one, so it keeps its ID and sent state, or the new one with an ID
+func keptReminder(current []models.Reminder, reminder models.Reminder) models.Reminder {
+	for _, existing := range current {
+		sameAt := existing.At != nil && reminder.At != nil && existing.At.Equal(*reminder.At)
+		if sameAt || (existing.At == nil && reminder.At == nil && existing.Before == reminder.Before) {
+			return existing
+		}
+	}
+	reminder.ID = primitive.NewObjectID()
+	return reminder
+}
+
+// SendReminders notifies the owners of open tasks whose reminders are due.
+// Each reminder is claimed before notifying so it is sent once per time,
+// and the reminders of a task due together make a single notification.
+func (tc *TaskController) SendReminders(ctx context.Context) error {
+	now := time.Now()
+	cursor, err := tc.collection.Find(ctx, bson.M{
+		"completed":   false,
+		"reminders.0": bson.M{"$exists": true},
+		"$or": []bson.M{
+			{"reminders.at": bson.M{"$lte": now}},
+			{"reminders.before": bson.M{"$exists": true}, "dueDate": bson.M{"$lte": now.Add(maxReminderLead)}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		notify := false
+		for _, reminder := range task.Reminders {
+			at, pending := reminder.Pending(task.DueDate, now)
+			if !pending {
+				continue
+			}
+
+			result, err := tc.collection.UpdateOne(ctx,
+				bson.M{"_id": task.ID, "reminders": bson.M{"$elemMatch": bson.M{"_id": reminder.ID, "sentFor": bson.M{"$ne": at}}}},
+				bson.M{"$set": bson.M{"reminders.$.sentFor": at}},
+			)
+			if err != nil {
+				return err
+			}
+			if result.ModifiedCount == 1 && now.Sub(at) <= reminderGrace {
+				notify = true
+			}
+		}
+		if !notify {
+			continue
+		}
+
+		message := fmt.Sprintf("Reminder for %q.", task.Title)
+		if task.DueDate != nil {
+			message = fmt.Sprintf("%q is due %s.", task.Title, task.DueDate.Local().Format("Mon Jan 2 at 15:04"))
+		}
+		notification := models.NewNotification(task.User, models.NotifyReminders, "Reminder: "+task.Title, message)
+		notification.Task = &task.ID
+		if _, err := tc.notifier.NotifyUser(ctx, task.User, notification); err != nil {
+			tc.logger.With("task", task.ID.Hex()).Error("Failed to send reminder: " + err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/authz"
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxSnooze is the furthest a task can be snoozed
+const maxSnooze = 365 * 24 * time.Hour
+
+// notSnoozed matches the snoozedUntil of tasks that are not snoozed or whose
+// snooze has passed, so they reappear on time even before the wake job runs
+func notSnoozed() notAfter {
+	return notAfterTime(time.Now())
+}
+
+// SnoozeTask hides an open task from the default views until the given time
+func (tc *TaskController) SnoozeTask(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var input struct {
+		Until time.Time `json:"until" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	now := time.Now()
+	if !input.Until.After(now) || input.Until.After(now.Add(maxSnooze)) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Snooze time must be in the future and within a year",
+		})
+		return
+	}
+
+	task, ok := tc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+	if task.Completed {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Completed tasks cannot be snoozed",
+		})
+		return
+	}
+
+	tc.applyUpdate(ctx, c, task.ID, bson.M{"$set": bson.M{"snoozedUntil": input.Until, "updatedAt": now}})
+}
+
+// UnsnoozeTask brings a snoozed task back to the default views
+func (tc *TaskController) UnsnoozeTask(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	task, ok := tc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+
+	tc.applyUpdate(ctx, c, task.ID, bson.M{
+		"$unset": bson.M{"snoozedUntil": ""},
+		"$set":   bson.M{"updatedAt": time.Now()},
+	})
+}
+
+// WakeSnoozedTasks clears the snooze of the tasks whose time has come and
+// notifies their owners that open ones are back
+func (tc *TaskController) WakeSnoozedTasks(ctx context.Context) error {
+	cursor, err := tc.collection.Find(ctx, bson.M{"snoozedUntil": bson.M{"$lte": time.Now()}})
+	if err != nil {
+		return err
+	}
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		// Only the instance clearing this exact snooze notifies, a task
+		// snoozed again meanwhile keeps its new time
+		result, err := tc.collection.UpdateOne(ctx,
+			bson.M{"_id": task.ID, "snoozedUntil": task.SnoozedUntil},
+			bson.M{"$unset": bson.M{"snoozedUntil": ""}},
+		)
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 || task.Completed {
+			continue
+		}
+
+		notification := models.NewNotification(task.User, models.NotifyReminders, "Task is back", "\""+task.Title+"\" is no longer snoozed.")
+		notification.Task = &task.ID
+		if _, err := tc.notifier.NotifyUser(ctx, task.User, notification); err != nil {
+			tc.logger.With("task", task.ID.Hex()).Error("Failed to notify snooze wake: " + err.Error())
+		}
+	}
+	return nil
+}
+
+// EnsureIndexes creates the index used to find the tasks to wake, the ones
+// recounting the tasks of goals and contexts, the ones filtering by tag and
+// by project, the one finding the occurrences of recurring tasks, the one
+// numbering the versions of each task and the one keeping tag documents
+// unique per user
+func (tc *TaskController) EnsureIndexes(ctx context.Context) error {
+	_, err := tc.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"snoozedUntil": 1}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "goal", Value: 1}, {Key: "completed", Value: 1}}},
+		{Keys: bson.D{{Key: "user", Value: 1}, {Key: "context", Value: 1}, {Key: "completed", Value: 1}}},
+		{Keys: bson.D{{Key: "user", Value: 1}, {Key: "tags", Value: 1}}},
+		{Keys: bson.D{{Key: "user", Value: 1}, {Key: "project", Value: 1}}},
+		{Keys: bson.M{"recurrence.series": 1}, Options: options.Index().SetSparse(true)},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tc.versionCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "task", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tc.tagCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// ownedTask loads the task of the :id parameter owned by the
+// authenticated user, answering the request when it cannot
+func (tc *TaskController) ownedTask(ctx context.Context, c *gin.Context) (models.Task, bool) {
+	var task models.Task
+
+	_, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return task, false
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid task ID format",
+		})
+		return task, false
+	}
+
+	err = tc.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&task)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Task not found",
+		})
+		return task, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch task",
+		})
+		return task, false
+	}
+
+	if !authorize(c, authz.TaskUpdate, task.User, "Task not found", "Not authorized to update this task") {
+		return task, false
+	}
+	return task, true
+}
+
+// applyUpdate applies an update to a task and answers with the updated task
+func (tc *TaskController) applyUpdate(ctx context.Context, c *gin.Context, id primitive.ObjectID, update bson.M) {
+	var task models.Task
+	err := tc.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&task)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update task",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    task,
+	})
+}
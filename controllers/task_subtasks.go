@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxSubtasks caps the subtasks of a task
+const maxSubtasks = 100
+
+// CreateSubtask adds a subtask to a task, at the end unless "position"
+// (counted from 0) is given
+func (tc *TaskController) CreateSubtask(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	var input struct {
+		Title    string `json:"title" binding:"required,max=500"`
+		Position *int   `json:"position"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	task, ok := tc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+
+	if len(task.Subtasks) >= maxSubtasks {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("A task can have at most %d subtasks", maxSubtasks),
+		})
+		return
+	}
+
+	position := len(task.Subtasks)
+	if input.Position != nil && *input.Position >= 0 && *input.Position < position {
+		position = *input.Position
+	}
+
+	subtask := models.Subtask{ID: primitive.NewObjectID(), Title: strings.TrimSpace(input.Title)}
+	tc.updateSubtasks(ctx, c, task, bson.M{"_id": task.ID}, bson.M{
+		"$push": bson.M{"subtasks": bson.M{"$each": bson.A{subtask}, "$position": position}},
+		"$inc":  bson.M{"totalSubtasks": 1},
+	}, http.StatusCreated)
+}
+
+// UpdateSubtask renames a subtask, or completes or reopens it with
+// "completed". When the last open subtask of a task with autoComplete is
+// completed, the task is completed too.
+func (tc *TaskController) UpdateSubtask(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	var input struct {
+		Title     *string `json:"title" binding:"omitempty,max=500"`
+		Completed *bool   `json:"completed"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || (input.Title != nil && strings.TrimSpace(*input.Title) == "") {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	task, ok := tc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+	subtask, ok := findSubtask(c, task)
+	if !ok {
+		return
+	}
+
+	set := bson.M{}
+	unset := bson.M{}
+	if input.Title != nil {
+		set["subtasks.$[subtask].title"] = strings.TrimSpace(*input.Title)
+	}
+	if input.Completed != nil && *input.Completed != subtask.Completed {
+		set["subtasks.$[subtask].completed"] = *input.Completed
+		if *input.Completed {
+			set["subtasks.$[subtask].completedAt"] = time.Now()
+		} else {
+			unset["subtasks.$[subtask].completedAt"] = ""
+		}
+	}
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if input.Completed != nil && *input.Completed != subtask.Completed {
+		update["$inc"] = bson.M{"completedSubtasks": completedDelta(*input.Completed)}
+	}
+	tc.updateSubtasks(ctx, c, task, subtaskFilter(task, subtask), update, http.StatusOK, options.FindOneAndUpdate().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"subtask._id": subtask.ID}},
+	}))
+}
+
+// ReorderSubtasks puts the subtasks of a task in the order of "order", which
+// must list every subtask ID once
+func (tc *TaskController) ReorderSubtasks(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	var input struct {
+		Order []string `json:"order" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	task, ok := tc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+
+	subtasks := []models.Subtask{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, id := range input.Order {
+		subtaskID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Invalid subtask ID format",
+			})
+			return
+		}
+		subtask, ok := task.Subtask(subtaskID)
+		if !ok || seen[subtaskID] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Order must list every subtask of the task once",
+			})
+			return
+		}
+		seen[subtaskID] = true
+		subtasks = append(subtasks, subtask)
+	}
+	if len(subtasks) != len(task.Subtasks) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Order must list every subtask of the task once",
+		})
+		return
+	}
+
+	reordered := models.Task{Subtasks: subtasks}
+	reordered.CountSubtasks()
+	tc.updateSubtasks(ctx, c, task, bson.M{"_id": task.ID}, bson.M{"$set": bson.M{
+		"subtasks":          subtasks,
+		"completedSubtasks": reordered.CompletedSubtasks,
+		"totalSubtasks":     reordered.TotalSubtasks,
+	}}, http.StatusOK)
+}
+
+// DeleteSubtask removes a subtask from a task
+func (tc *TaskController) DeleteSubtask(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	task, ok := tc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+	subtask, ok := findSubtask(c, task)
+	if !ok {
+		return
+	}
+
+	inc := bson.M{"totalSubtasks": -1}
+	if subtask.Completed {
+		inc["completedSubtasks"] = -1
+	}
+	tc.updateSubtasks(ctx, c, task, subtaskFilter(task, subtask), bson.M{
+		"$pull": bson.M{"subtasks": bson.M{"_id": subtask.ID}},
+		"$inc":  inc,
+	}, http.StatusOK)
+}
+
+// updateSubtasks applies an update to the subtasks of a task, matched by
+// filter, and responds with the updated task. The update changes the subtask
+// counters in the same write, so they cannot drift from the checklist. A
+// task with autoComplete whose subtasks are now all completed is completed
+// as if it had been updated.
+func (tc *TaskController) updateSubtasks(ctx context.Context, c *gin.Context, task models.Task, filter bson.M, update bson.M, status int, opts ...*options.FindOneAndUpdateOptions) {
+	now := time.Now()
+	if set, ok := update["$set"].(bson.M); ok {
+		set["updatedAt"] = now
+	} else {
+		update["$set"] = bson.M{"updatedAt": now}
+	}
+
+	opts = append(opts, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	var updatedTask models.Task
+	err := tc.collection.FindOneAndUpdate(ctx, filter, update, opts...).Decode(&updatedTask)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			if _, ok := filter["subtasks"]; ok {
+				c.JSON(http.StatusConflict, gin.H{
+					"success": false,
+					"error":   "The subtask was changed meanwhile, try again",
+				})
+				return
+			}
+			c.JSON(http.StatusNotFound, gin.H{
+				"success": false,
+				"error":   "Task not found",
+			})
+			return
+		}
+		tc.logger.With("task", task.ID.Hex()).Error("Failed to update subtasks: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update subtasks",
+		})
+		return
+	}
+
+	if updatedTask.AutoComplete && !updatedTask.Completed && updatedTask.SubtasksCompleted() {
+		completed, err := tc.completeTask(ctx, updatedTask, now)
+		if err != nil {
+			tc.logger.With("task", task.ID.Hex()).Error("Failed to complete task with its subtasks: " + err.Error())
+		} else {
+			updatedTask = completed
+		}
+	}
+
+	c.JSON(status, gin.H{
+		"success": true,
+		"data":    updatedTask,
+	})
+}
+
+// completeTask completes a task whose subtasks are all completed, saving a
+// version and running the automations UpdateTask runs on a completion
+func (tc *TaskController) completeTask(ctx context.Context, task models.Task, now time.Time) (models.Task, error) {
+	var completed models.Task
+	err := writeWithCounts(ctx, tc.collection, tc.counters(), func(ctx context.Context) (countChanges, error) {
+		err := tc.collection.FindOneAndUpdate(ctx,
+			bson.M{"_id": task.ID, "completed": false},
+			bson.M{"$set": bson.M{"completed": true, "completedAt": now, "updatedAt": now}, "$unset": bson.M{"inbox": ""}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&completed)
+		if err != nil {
+			return nil, err
+		}
+		// The filter matched the task open
+		open := completed
+		open.Completed = false
+		return taskChange(&open, &completed), nil
+	})
+	if err == mongo.ErrNoDocuments {
+		// Completed meanwhile by another request
+		return task, nil
+	}
+	if err != nil {
+		return task, err
+	}
+
+	if err := tc.saveVersion(ctx, task); err != nil {
+		tc.logger.With("task", task.ID.Hex()).Error("Failed to save task version: " + err.Error())
+	}
+	completed = tc.automations.Dispatch(ctx, models.TriggerTaskUpdated, completed)
+	completed = tc.automations.Dispatch(ctx, models.TriggerTaskCompleted, completed)
+	if err := tc.materializeNext(ctx, completed); err != nil {
+		tc.logger.With("task", task.ID.Hex()).Error("Failed to create the next occurrence: " + err.Error())
+	}
+	return completed, nil
+}
+
+// subtaskFilter matches a task while its subtask is still in the state it
+// was read in, so that the counter change of an update applies only once
+func subtaskFilter(task models.Task, subtask models.Subtask) bson.M {
+	return bson.M{
+		"_id":      task.ID,
+		"subtasks": bson.M{"$elemMatch": bson.M{"_id": subtask.ID, "completed": subtask.Completed}},
+	}
+}
+
+// completedDelta is the change of the completed subtasks counter when a
+// subtask is completed or reopened
+func completedDelta(completed bool) int {
+	if completed {
+		return 1
+	}
+	return -1
+}
+
+// BackfillSubtaskCounts sets the subtask counters of the tasks whose
+// counters do not match their checklist, such as the tasks written before
+// they were kept, returning how many it fixed. Each task is recounted in a
+// single write, so it is safe to run while subtasks are edited.
+func BackfillSubtaskCounts(ctx context.Context, taskCollection *mongo.Collection) (int64, error) {
+	total := bson.M{"$size": bson.M{"$ifNull": bson.A{"$subtasks", bson.A{}}}}
+	completed := bson.M{"$size": bson.M{"$filter": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$subtasks", bson.A{}}},
+		"cond":  "$$this.completed",
+	}}}
+	filter := bson.M{"$expr": bson.M{"$or": bson.A{
+		bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{"$totalSubtasks", 0}}, total}},
+		bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{"$completedSubtasks", 0}}, completed}},
+	}}}
+	result, err := taskCollection.UpdateMany(ctx, filter, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"totalSubtasks": total, "completedSubtasks": completed}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// findSubtask returns the subtask of a task referenced by the :subtaskId
+// parameter, writing the error response when there is none
+func findSubtask(c *gin.Context, task models.Task) (models.Subtask, bool) {
+	subtaskID, err := primitive.ObjectIDFromHex(c.Param("subtaskId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid subtask ID format",
+		})
+		return models.Subtask{}, false
+	}
+
+	subtask, ok := task.Subtask(subtaskID)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Subtask not found",
+		})
+		return models.Subtask{}, false
+	}
+	return subtask, true
+}
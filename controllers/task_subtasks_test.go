@@ -0,0 +1,61 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+	"gotodolist/models"
+	"gotodolist/routes"
+	"gotodolist/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSubtaskCountsInTaskList checks that the subtask counters listed by
+// GET /tasks follow the subtasks added, completed and removed
+func TestSubtaskCountsInTaskList(t *testing.T) {
+	db := testutil.Database(t)
+	gin.SetMode(gin.TestMode)
+
+	users := db.Collection("users")
+	tasks := db.Collection("tasks")
+
+	user := testutil.NewUser()
+	task := testutil.NewTask(user)
+	testutil.Insert(t, users, user)
+	testutil.Insert(t, tasks, task)
+
+	taskController := controllers.NewTaskController(tasks, db.Collection("goals"), db.Collection("contexts"), db.Collection("projects"), db.Collection("tags"), db.Collection("task_activity"), db.Collection("task_versions"), nil, nil, nil)
+	authMiddleware := middleware.NewAuthMiddleware(users, middleware.NewUserCache(), nil)
+	router := gin.New()
+	routes.SetupTaskRoutes(router, taskController, authMiddleware)
+	client := testutil.NewClient(t, router, user)
+
+	counts := func(step string, completed, total int) {
+		t.Helper()
+		var listed []models.Task
+		testutil.Data(t, client.Do(t, http.MethodGet, "/tasks/", nil), http.StatusOK, &listed)
+		if len(listed) != 1 {
+			t.Fatalf("%s: %d tasks listed, want 1", step, len(listed))
+		}
+		if listed[0].CompletedSubtasks != completed || listed[0].TotalSubtasks != total {
+			t.Errorf("%s: %d of %d subtasks completed, want %d of %d", step, listed[0].CompletedSubtasks, listed[0].TotalSubtasks, completed, total)
+		}
+	}
+
+	var updated models.Task
+	path := "/tasks/" + task.ID.Hex() + "/subtasks"
+	testutil.Data(t, client.Do(t, http.MethodPost, path, gin.H{"title": "Buy milk"}), http.StatusCreated, &updated)
+	testutil.Data(t, client.Do(t, http.MethodPost, path, gin.H{"title": "Buy eggs"}), http.StatusCreated, &updated)
+	counts("added", 0, 2)
+
+	first := updated.Subtasks[0].ID.Hex()
+	testutil.Data(t, client.Do(t, http.MethodPut, path+"/"+first, gin.H{"completed": true}), http.StatusOK, nil)
+	testutil.Data(t, client.Do(t, http.MethodPut, path+"/"+first, gin.H{"completed": true}), http.StatusOK, nil)
+	counts("completed", 1, 2)
+
+	testutil.Data(t, client.Do(t, http.MethodDelete, path+"/"+first, nil), http.StatusOK, nil)
+	counts("removed", 0, 1)
+}
@@ -0,0 +1,410 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TagCount is a tag of the user's tasks with the number of tasks carrying it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+	Open  int64  `json:"open"` // Tasks not completed yet
+	Color string `json:"color,omitempty"`
+	Icon  string `json:"icon,omitempty"`
+}
+
+// tagCount reports a tag document
+func tagCount(item models.Tag) TagCount {
+	return TagCount{
+		Tag:   item.Name,
+		Count: item.TaskCounts.Open + item.TaskCounts.Completed,
+		Open:  item.TaskCounts.Open,
+		Color: item.Color,
+		Icon:  item.Icon,
+	}
+}
+
+// GetTaskTags lists the distinct tags of the authenticated user's tasks,
+// most used first, from the counts stored on their tag documents. Tags no
+// task carries any more keep their document but are not listed.
+func (tc *TaskController) GetTaskTags(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	cursor, err := tc.tagCollection.Find(ctx, bson.M{"user": userID, "$or": bson.A{
+		bson.M{"taskCounts.open": bson.M{"$gt": 0}},
+		bson.M{"taskCounts.completed": bson.M{"$gt": 0}},
+	}})
+	if err != nil {
+		tc.logger.Error("Failed to list tags: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to list tags",
+		})
+		return
+	}
+	var items []models.Tag
+	if err := cursor.All(ctx, &items); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse tags",
+		})
+		return
+	}
+
+	tags := make([]TagCount, 0, len(items))
+	for _, item := range items {
+		tags = append(tags, tagCount(item))
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tags,
+	})
+}
+
+// UpdateTag changes the color or icon of one of the user's tags, an empty
+// color or icon clearing it. The appearance is kept on the tag's document,
+// so it stays when no task carries the tag for a while.
+func (tc *TaskController) UpdateTag(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	tag, ok := tagParam(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Color *string `json:"color"`
+		Icon  *string `json:"icon"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || (input.Color == nil && input.Icon == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	updateSet := bson.M{}
+	updateUnset := bson.M{}
+	if input.Color != nil {
+		if !validAppearance(c, *input.Color, "") {
+			return
+		}
+		if *input.Color == "" {
+			updateUnset["color"] = ""
+		} else {
+			updateSet["color"] = *input.Color
+		}
+	}
+	if input.Icon != nil {
+		if !validAppearance(c, "", *input.Icon) {
+			return
+		}
+		if *input.Icon == "" {
+			updateUnset["icon"] = ""
+		} else {
+			updateSet["icon"] = *input.Icon
+		}
+	}
+
+	update := bson.M{}
+	if len(updateSet) > 0 {
+		update["$set"] = updateSet
+	}
+	if len(updateUnset) > 0 {
+		update["$unset"] = updateUnset
+	}
+	var updated models.Tag
+	err := tc.tagCollection.FindOneAndUpdate(ctx,
+		bson.M{"user": userID, "name": tag},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Tag not found",
+		})
+		return
+	}
+	if err != nil {
+		tc.logger.Error("Failed to update tag: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update tag",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tagCount(updated),
+	})
+}
+
+// UpdateTagTasks adds the :tag tag to the tasks listed in "add" and removes
+// it from the tasks listed in "remove". Tasks of other users, tasks that
+// already have the tag and tasks that already have MaxTags tags are left
+// untouched by "add", as are tasks without the tag by "remove".
+func (tc *TaskController) UpdateTagTasks(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	tag, ok := tagParam(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Add    []string `json:"add"`
+		Remove []string `json:"remove"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+	if len(input.Add)+len(input.Remove) == 0 || len(input.Add)+len(input.Remove) > maxBulkTasks {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   fmt.Sprintf("Between 1 and %d task IDs are required", maxBulkTasks),
+		})
+		return
+	}
+
+	add, ok := parseTaskIDs(c, input.Add)
+	if !ok {
+		return
+	}
+	remove, ok := parseTaskIDs(c, input.Remove)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	group := countGroup{kind: tagGroup, user: userID.(primitive.ObjectID), name: tag}
+	var added, removed models.TaskCounts
+	err := writeWithCounts(ctx, tc.collection, tc.counters(), func(ctx context.Context) (countChanges, error) {
+		var err error
+		added, removed = models.TaskCounts{}, models.TaskCounts{}
+		if len(add) > 0 {
+			added, err = updateByCompletion(
+				ctx,
+				tc.collection,
+				bson.M{
+					"_id":  bson.M{"$in": add},
+					"user": userID,
+					"tags": bson.M{"$ne": tag},
+					// Tasks at the tag limit cannot take another one
+					"tags." + strconv.Itoa(utils.MaxTags-1): bson.M{"$exists": false},
+				},
+				bson.M{"$push": bson.M{"tags": tag}, "$set": bson.M{"updatedAt": now}},
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(remove) > 0 {
+			removed, err = updateByCompletion(
+				ctx,
+				tc.collection,
+				bson.M{"_id": bson.M{"$in": remove}, "user": userID, "tags": tag},
+				bson.M{"$pull": bson.M{"tags": tag}, "$set": bson.M{"updatedAt": now}},
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+		changes := countChanges{}
+		changes.addCounts(group, added, 1)
+		changes.addCounts(group, removed, -1)
+		return changes, nil
+	})
+	if err != nil {
+		tc.logger.Error("Failed to update tag tasks: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update tag tasks",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"added":   added.Open + added.Completed,
+			"removed": removed.Open + removed.Completed,
+		},
+	})
+}
+
+// MergeTag replaces the :tag tag with the tag given as "into" on every task
+// of the user. Each task is retagged by a single update, keeping the place
+// of the tag and dropping it when the task already has the other one, so a
+// merge that fails half way can simply be retried.
+func (tc *TaskController) MergeTag(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	// Get user ID from context
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	tag, ok := tagParam(c)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Into string `json:"into" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+	into, ok := utils.NormalizeTag(input.Into)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid tag",
+		})
+		return
+	}
+	if into == tag {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "A tag cannot be merged into itself",
+		})
+		return
+	}
+
+	// Rename the tag in place, then drop the second copy of the target
+	renamed := bson.M{"$map": bson.M{
+		"input": "$tags",
+		"in":    bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$$this", tag}}, into, "$$this"}},
+	}}
+	deduplicated := bson.M{"$reduce": bson.M{
+		"input":        renamed,
+		"initialValue": bson.A{},
+		"in": bson.M{"$cond": bson.A{
+			bson.M{"$in": bson.A{"$$this", "$$value"}},
+			"$$value",
+			bson.M{"$concatArrays": bson.A{"$$value", bson.A{"$$this"}}},
+		}},
+	}}
+	user := userID.(primitive.ObjectID)
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{"tags": deduplicated, "updatedAt": time.Now()}}}}
+	var moved int64
+	err := writeWithCounts(ctx, tc.collection, tc.counters(), func(ctx context.Context) (countChanges, error) {
+		// Tasks with both tags only lose this one, the others move to the target
+		both, err := updateByCompletion(ctx, tc.collection, bson.M{"user": userID, "tags": bson.M{"$all": bson.A{tag, into}}}, update)
+		if err != nil {
+			return nil, err
+		}
+		only, err := updateByCompletion(ctx, tc.collection, bson.M{"user": userID, "tags": bson.M{"$eq": tag, "$ne": into}}, update)
+		if err != nil {
+			return nil, err
+		}
+		moved = both.Open + both.Completed + only.Open + only.Completed
+
+		changes := countChanges{}
+		changes.addCounts(countGroup{kind: tagGroup, user: user, name: tag}, both, -1)
+		changes.addCounts(countGroup{kind: tagGroup, user: user, name: tag}, only, -1)
+		changes.addCounts(countGroup{kind: tagGroup, user: user, name: into}, only, 1)
+		return changes, nil
+	})
+	if err != nil {
+		tc.logger.Error("Failed to merge tag: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to merge tag",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"tag":   into,
+			"moved": moved,
+		},
+	})
+}
+
+// tagParam normalizes the :tag parameter, writing the error response when
+// it is not a valid tag
+func tagParam(c *gin.Context) (string, bool) {
+	tag, ok := utils.NormalizeTag(c.Param("tag"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid tag",
+		})
+		return "", false
+	}
+	return tag, true
+}
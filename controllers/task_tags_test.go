@@ -0,0 +1,69 @@
+package controllers_test
+
+import (
+	"net/http"
+	"testing"
+
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+	"gotodolist/models"
+	"gotodolist/routes"
+	"gotodolist/testutil"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestTagAppearanceOutlivesItsTasks checks that the color and icon of a tag
+// are listed with it and are back when the tag is used again after its last
+// task lost it
+func TestTagAppearanceOutlivesItsTasks(t *testing.T) {
+	db := testutil.Database(t)
+	gin.SetMode(gin.TestMode)
+
+	users := db.Collection("users")
+	tasks := db.Collection("tasks")
+
+	user := testutil.NewUser()
+	testutil.Insert(t, users, user)
+
+	taskController := controllers.NewTaskController(tasks, db.Collection("goals"), db.Collection("contexts"), db.Collection("projects"), db.Collection("tags"), db.Collection("task_activity"), db.Collection("task_versions"), nil, nil, nil)
+	router := gin.New()
+	routes.SetupTaskRoutes(router, taskController, middleware.NewAuthMiddleware(users, middleware.NewUserCache(), nil))
+	client := testutil.NewClient(t, router, user)
+
+	testutil.Data(t, client.Do(t, http.MethodPut, "/tasks/tags/garden", gin.H{"color": "#4caf50"}), http.StatusNotFound, nil)
+
+	var task models.Task
+	testutil.Data(t, client.Do(t, http.MethodPost, "/tasks/", gin.H{"title": "Mow the lawn", "tags": []string{"garden"}}), http.StatusCreated, &task)
+	testutil.Data(t, client.Do(t, http.MethodPut, "/tasks/tags/garden", gin.H{"color": "#zzz"}), http.StatusBadRequest, nil)
+
+	var tag controllers.TagCount
+	testutil.Data(t, client.Do(t, http.MethodPut, "/tasks/tags/garden", gin.H{"color": "#4caf50", "icon": "🌱"}), http.StatusOK, &tag)
+	if want := (controllers.TagCount{Tag: "garden", Count: 1, Open: 1, Color: "#4caf50", Icon: "🌱"}); tag != want {
+		t.Errorf("tag %+v, want %+v", tag, want)
+	}
+
+	var counts []controllers.TagCount
+	testutil.Data(t, client.Do(t, http.MethodPatch, "/tasks/tags/garden", gin.H{"remove": []string{task.ID.Hex()}}), http.StatusOK, nil)
+	testutil.Data(t, client.Do(t, http.MethodGet, "/tasks/tags", nil), http.StatusOK, &counts)
+	if len(counts) != 0 {
+		t.Errorf("tags %+v, want none while no task carries one", counts)
+	}
+
+	testutil.Data(t, client.Do(t, http.MethodPatch, "/tasks/tags/garden", gin.H{"add": []string{task.ID.Hex()}}), http.StatusOK, nil)
+	testutil.Data(t, client.Do(t, http.MethodGet, "/tasks/tags", nil), http.StatusOK, &counts)
+	if len(counts) != 1 || counts[0].Color != "#4caf50" || counts[0].Icon != "🌱" {
+		t.Errorf("tags %+v, want garden with its color and icon", counts)
+	}
+}
+
+// TestTagRequestValidation checks that invalid tag changes are rejected
+func TestTagRequestValidation(t *testing.T) {
+	client := newTaskRouter(t)
+
+	testutil.Error(t, client.Do(t, http.MethodPut, "/tasks/tags/in%20the%20garden", gin.H{"color": "#4caf50"}), http.StatusBadRequest, "Invalid tag")
+	testutil.Error(t, client.Do(t, http.MethodPut, "/tasks/tags/garden", gin.H{}), http.StatusBadRequest, "Invalid input data")
+	testutil.Error(t, client.Do(t, http.MethodPut, "/tasks/tags/garden", gin.H{"color": "green"}), http.StatusBadRequest, "Color must be a hex color such as #ff8800")
+	testutil.Data(t, client.Do(t, http.MethodPatch, "/tasks/tags/garden", gin.H{"add": []string{}}), http.StatusBadRequest, nil)
+	testutil.Error(t, client.Do(t, http.MethodPost, "/tasks/tags/garden/merge", gin.H{"into": "Garden"}), http.StatusBadRequest, "A tag cannot be merged into itself")
+}
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// restorableFields are the task fields a version restore brings back, the
+// board placement, snooze and inbox state and the subtasks, whose edits
+// are not versioned, are left as they are
+var restorableFields = []string{
+	"title", "description", "completed", "completedAt", "startDate", "dueDate",
+	"dependsOn", "priority", "estimate", "goal", "section", "context", "project", "color",
+	"icon", "tags", "autoComplete",
+}
+
+// GetTaskVersions lists the saved versions of a task, newest first
+func (tc *TaskController) GetTaskVersions(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	task, ok := tc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.M{"version": -1})
+	cursor, err := tc.versionCollection.Find(ctx, bson.M{"task": task.ID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch task versions",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	versions := []models.TaskVersion{}
+	if err := cursor.All(ctx, &versions); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse task versions",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(versions),
+		"data":    versions,
+	})
+}
+
+// RestoreTaskVersion brings a task back to a saved version. The current
+// state is saved as a new version first, so a restore can be undone too.
+// A goal or context deleted since the version was saved is cleared.
+func (tc *TaskController) RestoreTaskVersion(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	number, err := strconv.Atoi(c.Param("v"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid version number",
+		})
+		return
+	}
+
+	task, ok := tc.ownedTask(ctx, c)
+	if !ok {
+		return
+	}
+
+	var version models.TaskVersion
+	err = tc.versionCollection.FindOne(ctx, bson.M{"task": task.ID, "version": number}).Decode(&version)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   "Version not found",
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch task version",
+		})
+		return
+	}
+
+	snapshot, err := bsonDocument(version.Snapshot)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse task version",
+		})
+		return
+	}
+
+	if goal := version.Snapshot.Goal; goal != nil {
+		count, err := tc.goalCollection.CountDocuments(ctx, bson.M{"_id": *goal, "user": task.User})
+		if err == nil && count == 0 {
+			delete(snapshot, "goal")
+		}
+	}
+	if name := version.Snapshot.Context; name != "" {
+		count, err := tc.contextCollection.CountDocuments(ctx, bson.M{"user": task.User, "name": name})
+		if err == nil && count == 0 {
+			delete(snapshot, "context")
+		}
+	}
+	if project := version.Snapshot.Project; project != nil {
+		count, err := tc.projectCollection.CountDocuments(ctx, bson.M{"_id": *project, "user": task.User})
+		if err == nil && count == 0 {
+			delete(snapshot, "project")
+			delete(snapshot, "section")
+		}
+	}
+	if section := version.Snapshot.Section; section != nil && version.Snapshot.Project != nil {
+		count, err := tc.projectCollection.CountDocuments(ctx, bson.M{"_id": *version.Snapshot.Project, "sections._id": *section})
+		if err == nil && count == 0 {
+			delete(snapshot, "section")
+		}
+	}
+
+	updateSet := bson.M{"updatedAt": time.Now()}
+	updateUnset := bson.M{}
+	for _, field := range restorableFields {
+		if value, ok := snapshot[field]; ok {
+			updateSet[field] = value
+		} else {
+			updateUnset[field] = ""
+		}
+	}
+	update := bson.M{"$set": updateSet}
+	if len(updateUnset) > 0 {
+		update["$unset"] = updateUnset
+	}
+
+	if err := tc.saveVersion(ctx, task); err != nil {
+		tc.logger.With("task", task.ID.Hex()).Error("Failed to save task version: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to save the current version",
+		})
+		return
+	}
+
+	err = writeWithCounts(ctx, tc.collection, tc.counters(), func(ctx context.Context) (countChanges, error) {
+		_, changes, err := updateCounted(ctx, tc.collection, bson.M{"_id": task.ID}, update)
+		return changes, err
+	})
+	var restoredTask models.Task
+	if err == nil {
+		err = tc.collection.FindOne(ctx, bson.M{"_id": task.ID}).Decode(&restoredTask)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to restore task",
+		})
+		return
+	}
+
+	restoredTask = tc.automations.Dispatch(ctx, models.TriggerTaskUpdated, restoredTask)
+	if restoredTask.Completed && !task.Completed {
+		restoredTask = tc.automations.Dispatch(ctx, models.TriggerTaskCompleted, restoredTask)
+		if err := tc.materializeNext(ctx, restoredTask); err != nil {
+			tc.logger.With("task", task.ID.Hex()).Error("Failed to create the next occurrence: " + err.Error())
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    restoredTask,
+	})
+}
+
+// saveVersion stores a task as its next version and drops the versions
+// beyond the retention limit
+func (tc *TaskController) saveVersion(ctx context.Context, task models.Task) error {
+	var latest models.TaskVersion
+	findOptions := options.FindOne().SetSort(bson.M{"version": -1}).SetProjection(bson.M{"version": 1})
+	err := tc.versionCollection.FindOne(ctx, bson.M{"task": task.ID}, findOptions).Decode(&latest)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	version := models.TaskVersion{
+		Task:      task.ID,
+		User:      task.User,
+		Version:   latest.Version + 1,
+		Snapshot:  task,
+		CreatedAt: time.Now(),
+	}
+	if _, err := tc.versionCollection.InsertOne(ctx, version); err != nil {
+		return err
+	}
+
+	_, err = tc.versionCollection.DeleteMany(ctx, bson.M{
+		"task":    task.ID,
+		"version": bson.M{"$lte": version.Version - tc.versionLimit},
+	})
+	return err
+}
+
+// bsonDocument returns a value as it is stored, keyed by its bson field names
+func bsonDocument(value interface{}) (bson.M, error) {
+	data, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var document bson.M
+	err = bson.Unmarshal(data, &document)
+	return document, err
+}
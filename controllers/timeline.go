@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// errDependencyCycle is returned when task dependencies form a cycle
+var errDependencyCycle = errors.New("task dependencies contain a cycle")
+
+// timelineEntry is a task scheduled on a Gantt-style timeline. Offsets and
+// durations are expressed in days from the start of the timeline.
+type timelineEntry struct {
+	ID             primitive.ObjectID   `json:"id"`
+	Title          string               `json:"title"`
+	Completed      bool                 `json:"completed"`
+	StartDate      *time.Time           `json:"startDate"`
+	DueDate        *time.Time           `json:"dueDate"`
+	DependsOn      []primitive.ObjectID `json:"dependsOn"`
+	Duration       float64              `json:"duration"`
+	EarliestStart  float64              `json:"earliestStart"`
+	EarliestFinish float64              `json:"earliestFinish"`
+	Slack          float64              `json:"slack"`
+	Critical       bool                 `json:"critical"`
+}
+
+// GetTimeline returns the tasks of a project in dependency order with their
+// critical path, for rendering Gantt charts
+func (pc *ProjectController) GetTimeline(c *gin.Context) {
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	project, ok := pc.findProject(ctx, c)
+	if !ok {
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "startDate", Value: 1}, {Key: "dueDate", Value: 1}, {Key: "createdAt", Value: 1}})
+	cursor, err := pc.taskCollection.Find(ctx, bson.M{"user": project.User, "project": project.ID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to fetch tasks",
+		})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []models.Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to parse tasks",
+		})
+		return
+	}
+
+	timeline, err := buildTimeline(tasks)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"error":   "Task dependencies contain a cycle",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"count":   len(timeline),
+		"data":    timeline,
+	})
+}
+
+// buildTimeline orders tasks so that every task comes after its dependencies
+// and marks the critical path, i.e. the tasks without slack. Dependencies on
+// tasks outside the given set are ignored.
+func buildTimeline(tasks []models.Task) ([]timelineEntry, error) {
+	index := make(map[primitive.ObjectID]int, len(tasks))
+	for i, task := range tasks {
+		index[task.ID] = i
+	}
+
+	// Build the dependency graph restricted to the given tasks
+	dependents := make([][]int, len(tasks))
+	dependencies := make([][]int, len(tasks))
+	inDegree := make([]int, len(tasks))
+	for i, task := range tasks {
+		for _, dependency := range task.DependsOn {
+			j, ok := index[dependency]
+			if !ok || j == i {
+				continue
+			}
+			dependents[j] = append(dependents[j], i)
+			dependencies[i] = append(dependencies[i], j)
+			inDegree[i]++
+		}
+	}
+
+	// Topological order (Kahn's algorithm), keeping the input order for ties
+	order := make([]int, 0, len(tasks))
+	queue := []int{}
+	for i := range tasks {
+		if inDegree[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		order = append(order, current)
+		for _, next := range dependents[current] {
+			inDegree[next]--
+			if inDegree[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+	if len(order) != len(tasks) {
+		return nil, errDependencyCycle
+	}
+
+	// Forward pass: earliest start and finish
+	duration := make([]float64, len(tasks))
+	earliestStart := make([]float64, len(tasks))
+	earliestFinish := make([]float64, len(tasks))
+	projectEnd := 0.0
+	for _, i := range order {
+		duration[i] = taskDuration(tasks[i])
+		for _, j := range dependencies[i] {
+			earliestStart[i] = math.Max(earliestStart[i], earliestFinish[j])
+		}
+		earliestFinish[i] = earliestStart[i] + duration[i]
+		projectEnd = math.Max(projectEnd, earliestFinish[i])
+	}
+
+	// Backward pass: latest finish gives the slack of each task
+	latestFinish := make([]float64, len(tasks))
+	for i := range latestFinish {
+		latestFinish[i] = projectEnd
+	}
+	for k := len(order) - 1; k >= 0; k-- {
+		i := order[k]
+		for _, next := range dependents[i] {
+			latestFinish[i] = math.Min(latestFinish[i], latestFinish[next]-duration[next])
+		}
+	}
+
+	entries := make([]timelineEntry, 0, len(tasks))
+	for _, i := range order {
+		task := tasks[i]
+		slack := roundDays(latestFinish[i] - earliestFinish[i])
+		dependsOn := task.DependsOn
+		if dependsOn == nil {
+			dependsOn = []primitive.ObjectID{}
+		}
+		entries = append(entries, timelineEntry{
+			ID:             task.ID,
+			Title:          task.Title,
+			Completed:      task.Completed,
+			StartDate:      task.StartDate,
+			DueDate:        task.DueDate,
+			DependsOn:      dependsOn,
+			Duration:       roundDays(duration[i]),
+			EarliestStart:  roundDays(earliestStart[i]),
+			EarliestFinish: roundDays(earliestFinish[i]),
+			Slack:          slack,
+			Critical:       slack == 0,
+		})
+	}
+
+	return entries, nil
+}
+
+// taskDuration returns the planned length of a task in days; tasks without
+// both a start and a due date are treated as milestones
+func taskDuration(task models.Task) float64 {
+	if task.StartDate == nil || task.DueDate == nil || task.DueDate.Before(*task.StartDate) {
+		return 0
+	}
+	return task.DueDate.Sub(*task.StartDate).Hours() / 24
+}
+
+// roundDays rounds a number of days to two decimals
+func roundDays(days float64) float64 {
+	return math.Round(days*100) / 100
+}
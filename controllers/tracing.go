@@ -0,0 +1,17 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestContext is the usual 10 second handler context, carrying the
+// request ID for handlers whose work outlives the response, such as the
+// notifications sent by automations
+func requestContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(utils.WithRequestID(context.Background(), c.GetString("requestId")), 10*time.Second)
+}
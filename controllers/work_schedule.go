@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// workCalendar tells the working days and hours of a user from their work
+// schedule and holidays
+type workCalendar struct {
+	holidays    models.HolidaySettings
+	schedule    models.WorkSchedule
+	hasSchedule bool                    // Whether the user set their own schedule
+	daysOff     map[int]map[string]bool // Holidays by year, filled on first use
+}
+
+// newWorkCalendar creates the calendar of a user loaded with their holiday
+// settings and work schedule
+func newWorkCalendar(user models.User) *workCalendar {
+	wc := &workCalendar{
+		holidays:    models.HolidaySettings{Custom: []models.CustomHoliday{}},
+		schedule:    user.WorkSchedule.Resolved(),
+		hasSchedule: user.WorkSchedule != nil,
+		daysOff:     map[int]map[string]bool{},
+	}
+	if user.Holidays != nil {
+		wc.holidays = *user.Holidays
+	}
+	return wc
+}
+
+// IsHoliday reports whether a day is one of the user's holidays
+func (wc *workCalendar) IsHoliday(day time.Time) bool {
+	day = day.In(time.Local)
+	if _, ok := wc.daysOff[day.Year()]; !ok {
+		wc.daysOff[day.Year()] = map[string]bool{}
+		for _, holiday := range holidaysIn(wc.holidays, day.Year()) {
+			wc.daysOff[day.Year()][holiday.Date] = true
+		}
+	}
+	return wc.daysOff[day.Year()][day.Format(utils.DayLayout)]
+}
+
+// IsWorkingDay reports whether a day is a working day of the schedule and
+// not a holiday
+func (wc *workCalendar) IsWorkingDay(day time.Time) bool {
+	return wc.schedule.IsWorkingDay(day.In(time.Local).Weekday()) && !wc.IsHoliday(day)
+}
+
+// InHours reports whether t falls within the working hours of a working day
+func (wc *workCalendar) InHours(t time.Time) bool {
+	return wc.schedule.InHours(t.In(time.Local)) && !wc.IsHoliday(t)
+}
+
+// NextWorkingDay returns the first working day after the day of t, at the
+// start of the working hours
+func (wc *workCalendar) NextWorkingDay(t time.Time) time.Time {
+	day := utils.StartOfDay(t.In(time.Local)).AddDate(0, 0, 1)
+	// Bounded so a calendar without working days cannot loop forever
+	for i := 0; i < 366 && !wc.IsWorkingDay(day); i++ {
+		day = day.AddDate(0, 0, 1)
+	}
+	start, _ := time.Parse("15:04", wc.schedule.Start)
+	return day.Add(time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute)
+}
+
+// GetWorkSchedule returns the authenticated user's working days and hours,
+// the default schedule until they set one, with their next working day
+func (hc *HolidayController) GetWorkSchedule(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	calendar, err := hc.calendar(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to get work schedule",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"schedule":       calendar.schedule,
+			"default":        !calendar.hasSchedule,
+			"nextWorkingDay": calendar.NextWorkingDay(time.Now()),
+		},
+	})
+}
+
+// UpdateWorkSchedule replaces the authenticated user's working days and hours
+func (hc *HolidayController) UpdateWorkSchedule(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userID, exists := c.Get("userId")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "User not authenticated",
+		})
+		return
+	}
+
+	var input models.WorkSchedule
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Invalid input data",
+		})
+		return
+	}
+
+	days := map[time.Weekday]bool{}
+	for _, day := range input.Days {
+		if day < time.Sunday || day > time.Saturday || days[day] {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Days must be distinct weekdays from 0 (Sunday) to 6 (Saturday)",
+			})
+			return
+		}
+		days[day] = true
+	}
+	if len(input.Days) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "At least one working day is required",
+		})
+		return
+	}
+	if !models.ValidWorkTime(input.Start) || !models.ValidWorkTime(input.End) || input.Minutes() <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Start and end must be HH:MM times with start before end",
+		})
+		return
+	}
+
+	_, err := hc.userCollection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{
+		"workSchedule": input,
+		"updatedAt":    time.Now(),
+	}})
+	if err != nil {
+		hc.logger.Error("Work schedule update failed: " + err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to update work schedule",
+		})
+		return
+	}
+	hc.userCache.Invalidate(userID.(primitive.ObjectID))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    input,
+	})
+}
@@ -0,0 +1,417 @@
+// Package doctor checks that an instance is ready to serve, without
+// starting it: its configuration, its database and the services it reports
+// to. It backs the -doctor flag.
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gotodolist/configs"
+	"gotodolist/emails"
+	"gotodolist/middleware"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Check outcomes
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// durationSettings are the duration settings with their defaults, which
+// the server falls back to when a value does not parse
+var durationSettings = [][2]string{
+	{"JWT_EXPIRE", "24h"},
+	{"USER_CACHE_TTL", "30s"},
+	{"ADMIN_STATS_TTL", "1m"},
+	{"DATA_EXPORT_TTL", "168h"},
+	{"ESCALATION_INTERVAL", "15m"},
+	{"CLEANUP_INTERVAL", "24h"},
+	{"HEALTH_SNAPSHOT_INTERVAL", "5m"},
+	{"ACTIVITY_COMPACT_AFTER", "720h"},
+	{"ACTIVITY_RETENTION", "0"},
+	{"KEY_CACHE_TTL", "5m"},
+	{"STATUS_CACHE_TTL", "30s"},
+	{"PASSWORD_RESET_TTL", "1h"},
+	{"EMAIL_CHANGE_TTL", "24h"},
+	{"EMAIL_VERIFICATION_TTL", "48h"},
+}
+
+// numberSettings are the integer settings with their defaults
+var numberSettings = [][2]string{
+	{"MAX_TASKS", "0"},
+	{"MAX_SESSIONS", "0"},
+	{"TASK_VERSION_LIMIT", "20"},
+	{"USER_CACHE_SIZE", "10000"},
+	{"MONGO_SLOW_QUERY_MS", "100"},
+	{"SLOW_REQUEST_MS", "1000"},
+	{"SLOW_REQUEST_ALERT_P95_MS", "0"},
+	{"MAIL_MAX_ATTEMPTS", "6"},
+}
+
+// indexedCollections are the collections the server creates indexes on at
+// startup
+var indexedCollections = []string{
+	"users", "tasks", "task_versions", "task_notes", "task_activity", "contexts",
+	"automations", "automation_runs", "data_exports", "health_snapshots", "job_leases",
+	"email_outbox", "projects",
+}
+
+// Check is the outcome of a single check
+type Check struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// Report lists the outcome of every check
+type Report struct {
+	Checks []Check
+}
+
+// Ready reports whether no check failed. Warnings do not stop the server
+// from starting.
+func (r *Report) Ready() bool {
+	for _, check := range r.Checks {
+		if check.Status == StatusFail {
+			return false
+		}
+	}
+	return true
+}
+
+// Print writes the report, one check per line, followed by the verdict
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintln(w, "Readiness report")
+	failed, warned := 0, 0
+	for _, check := range r.Checks {
+		fmt.Fprintf(w, "  %-5s %-28s %s\n", check.Status, check.Name, check.Detail)
+		switch check.Status {
+		case StatusFail:
+			failed++
+		case StatusWarn:
+			warned++
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(w, "Not ready: %d failed, %d warnings\n", failed, warned)
+		return
+	}
+	fmt.Fprintf(w, "Ready: %d checks, %d warnings\n", len(r.Checks), warned)
+}
+
+// add records the outcome of a check
+func (r *Report) add(name string, status string, detail string) {
+	r.Checks = append(r.Checks, Check{Name: name, Status: status, Detail: detail})
+}
+
+// Run runs every check. The database checks are skipped when MongoDB
+// cannot be reached.
+func Run(ctx context.Context) *Report {
+	// Keep gin's debug warnings out of the report
+	gin.SetMode(gin.TestMode)
+
+	report := &Report{}
+	checkConfig(report)
+
+	client := checkConnection(ctx, report)
+	if client != nil {
+		defer client.Disconnect(context.Background())
+		db := client.Database(utils.GetEnv("DB_NAME", "todolist"))
+		checkIndexes(ctx, report, db)
+		checkValidators(ctx, report, db)
+	}
+
+	checkKeys(ctx, report)
+	checkSentry(ctx, report)
+	return report
+}
+
+// checkConfig validates the settings read from the environment
+func checkConfig(report *Report) {
+	if path := utils.ConfigFilePath(); path != "" {
+		report.add("config file", StatusOK, path)
+	}
+
+	switch mode := utils.GetEnv("GIN_MODE", "debug"); mode {
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+		report.add("GIN_MODE", StatusOK, mode)
+	default:
+		report.add("GIN_MODE", StatusFail, "must be debug, release or test: "+mode)
+	}
+
+	switch secret := utils.GetEnv("JWT_SECRET", ""); {
+	case utils.GetEnv("KEY_PROVIDER", "env") != "env":
+	case secret == "" || secret == "your-secret-key":
+		status := StatusWarn
+		if utils.GetEnv("GIN_MODE", "debug") == gin.ReleaseMode {
+			status = StatusFail
+		}
+		report.add("JWT_SECRET", status, "not set, tokens are signed with the default secret")
+	case len(secret) < 32:
+		report.add("JWT_SECRET", StatusWarn, "shorter than 32 characters")
+	default:
+		report.add("JWT_SECRET", StatusOK, "set")
+	}
+
+	port, err := strconv.Atoi(utils.GetEnv("PORT", "8080"))
+	if err != nil || port < 1 || port > 65535 {
+		report.add("PORT", StatusFail, "not a port number: "+utils.GetEnv("PORT", "8080"))
+	} else {
+		report.add("PORT", StatusOK, strconv.Itoa(port))
+	}
+	if path := utils.GetEnv("LISTEN_SOCKET", ""); path != "" {
+		if _, err := strconv.ParseUint(utils.GetEnv("LISTEN_SOCKET_MODE", "0660"), 8, 32); err != nil {
+			report.add("LISTEN_SOCKET_MODE", StatusFail, "not an octal mode: "+utils.GetEnv("LISTEN_SOCKET_MODE", "0660"))
+		}
+		if info, err := os.Stat(filepath.Dir(path)); err != nil || !info.IsDir() {
+			report.add("LISTEN_SOCKET", StatusFail, "directory does not exist: "+filepath.Dir(path))
+		} else {
+			report.add("LISTEN_SOCKET", StatusOK, path)
+		}
+	}
+
+	for _, setting := range durationSettings {
+		value := utils.GetEnv(setting[0], setting[1])
+		if duration, err := time.ParseDuration(value); err != nil || duration < 0 {
+			report.add(setting[0], StatusWarn, "not a duration, "+setting[1]+" is used: "+value)
+		}
+	}
+	for _, setting := range numberSettings {
+		value := utils.GetEnv(setting[0], setting[1])
+		if number, err := strconv.Atoi(value); err != nil || number < 0 {
+			report.add(setting[0], StatusWarn, "not a positive number, "+setting[1]+" is used: "+value)
+		}
+	}
+
+	if prefix := configs.CollectionPrefix(); !configs.ValidCollectionPrefix(prefix) {
+		report.add("COLLECTION_PREFIX", StatusFail, "not a valid collection name prefix: "+prefix)
+	}
+	if value := utils.GetEnv("READ_PREFERENCE_HEAVY", "primary"); !validReadPreference(value) {
+		report.add("READ_PREFERENCE_HEAVY", StatusWarn, "unknown mode, primary is used: "+value)
+	}
+	switch value := utils.GetEnv("SCHEMA_VALIDATION", "error"); value {
+	case "error", "warn", "off":
+	default:
+		report.add("SCHEMA_VALIDATION", StatusWarn, "must be error, warn or off, error is used: "+value)
+	}
+	switch value := utils.GetEnv("SESSION_LIMIT_MODE", "evict"); value {
+	case "evict", "reject":
+	default:
+		report.add("SESSION_LIMIT_MODE", StatusWarn, "must be evict or reject, evict is used: "+value)
+	}
+	checkMailTransport(report)
+	if _, err := mail.ParseAddress(utils.GetEnv("MAIL_FROM", utils.GetEnv("SMTP_FROM", "gotodolist <no-reply@localhost>"))); err != nil {
+		report.add("MAIL_FROM", StatusWarn, "not an email address: "+err.Error())
+	}
+	for _, setting := range []string{"PASSWORD_RESET_URL", "EMAIL_VERIFY_URL"} {
+		if value := utils.GetEnv(setting, ""); value != "" {
+			if parsed, err := url.Parse(value); err != nil || !parsed.IsAbs() {
+				report.add(setting, StatusWarn, "not an absolute URL, emails carry the token instead: "+value)
+			}
+		}
+	}
+	if language := utils.GetEnv("EMAIL_LANGUAGE", emails.DefaultLanguage); !emails.IsLanguage(language) {
+		report.add("EMAIL_LANGUAGE", StatusWarn, "must be one of "+strings.Join(emails.Languages, ", ")+", "+emails.DefaultLanguage+" is used: "+language)
+	}
+	if _, err := emails.Render(emails.Alert, emails.DefaultLanguage, emails.Sample(emails.Alert)); err != nil {
+		report.add("Email templates", StatusFail, err.Error())
+	}
+
+	if err := configs.ConfigureProxies(gin.New()); err != nil {
+		report.add("TRUSTED_PROXIES", StatusFail, err.Error())
+	}
+
+	if utils.GetEnv("OPENAPI_VALIDATION", "false") == "true" {
+		if _, err := middleware.RequestValidator("./swagger.yaml"); err != nil {
+			report.add("OPENAPI_VALIDATION", StatusFail, "cannot load swagger.yaml: "+err.Error())
+		} else {
+			report.add("OPENAPI_VALIDATION", StatusOK, "swagger.yaml loaded")
+		}
+	}
+}
+
+// validReadPreference reports whether a read preference mode is known
+func validReadPreference(value string) bool {
+	mode, err := readpref.ModeFromString(value)
+	if err != nil {
+		return false
+	}
+	_, err = readpref.New(mode)
+	return err == nil
+}
+
+// checkMailTransport checks that MAIL_TRANSPORT is known and has the
+// settings it needs, the server logging emails instead of sending them
+// otherwise
+func checkMailTransport(report *Report) {
+	transport := utils.GetEnv("MAIL_TRANSPORT", utils.DefaultMailTransport())
+	var missing []string
+	switch transport {
+	case "smtp":
+		missing = []string{"SMTP_HOST"}
+	case "sendgrid":
+		missing = []string{"SENDGRID_API_KEY"}
+	case "mailgun":
+		missing = []string{"MAILGUN_API_KEY", "MAILGUN_DOMAIN"}
+	case "log":
+		if utils.RequireVerifiedEmail() {
+			report.add("MAIL_TRANSPORT", StatusWarn, "log while REQUIRE_VERIFIED_EMAIL is true, verification links are only logged")
+		} else {
+			report.add("MAIL_TRANSPORT", StatusWarn, "log, account emails and alerts are logged instead of sent")
+		}
+		return
+	default:
+		report.add("MAIL_TRANSPORT", StatusFail, "must be smtp, sendgrid, mailgun or log: "+transport)
+		return
+	}
+
+	for _, setting := range missing {
+		if utils.GetEnv(setting, "") == "" {
+			report.add("MAIL_TRANSPORT", StatusFail, transport+" needs "+setting+", emails are logged instead of sent")
+			return
+		}
+	}
+	report.add("MAIL_TRANSPORT", StatusOK, transport)
+}
+
+// checkConnection connects to MongoDB and reports its version, returning
+// nil when it cannot be reached
+func checkConnection(ctx context.Context, report *Report) *mongo.Client {
+	uri := utils.GetEnv("MONGO_URI", "mongodb://localhost:27017")
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err == nil {
+		err = client.Ping(ctx, nil)
+	}
+	if err != nil {
+		report.add("mongodb", StatusFail, "cannot connect: "+err.Error())
+		if client != nil {
+			client.Disconnect(context.Background())
+		}
+		return nil
+	}
+
+	var info struct {
+		Version string `bson:"version"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "buildInfo", Value: 1}}).Decode(&info); err != nil {
+		report.add("mongodb", StatusOK, "connected")
+	} else {
+		report.add("mongodb", StatusOK, "connected, version "+info.Version)
+	}
+	return client
+}
+
+// checkIndexes reports the indexes of the collections the server indexes.
+// Missing indexes are only a warning: the server creates them on startup.
+func checkIndexes(ctx context.Context, report *Report, db *mongo.Database) {
+	for _, name := range indexedCollections {
+		cursor, err := db.Collection(configs.CollectionPrefix() + name).Indexes().List(ctx)
+		if err != nil {
+			report.add("indexes "+name, StatusWarn, "cannot list: "+err.Error())
+			continue
+		}
+		var indexes []bson.M
+		if err := cursor.All(ctx, &indexes); err != nil {
+			report.add("indexes "+name, StatusWarn, "cannot list: "+err.Error())
+			continue
+		}
+
+		// Every collection has the _id index
+		if len(indexes) <= 1 {
+			report.add("indexes "+name, StatusWarn, "missing, created when the server starts")
+		} else {
+			report.add("indexes "+name, StatusOK, fmt.Sprintf("%d indexes", len(indexes)))
+		}
+	}
+}
+
+// checkValidators reports whether the tasks and users collections have the
+// schema validator the server applies on startup
+func checkValidators(ctx context.Context, report *Report, db *mongo.Database) {
+	if utils.GetEnv("SCHEMA_VALIDATION", "error") == "off" {
+		return
+	}
+	for _, name := range []string{"tasks", "users"} {
+		specs, err := db.ListCollectionSpecifications(ctx, bson.M{"name": configs.CollectionPrefix() + name})
+		if err != nil {
+			report.add("validator "+name, StatusWarn, "cannot list: "+err.Error())
+			continue
+		}
+
+		present := false
+		if len(specs) > 0 && specs[0].Options != nil {
+			_, err := specs[0].Options.LookupErr("validator", "$jsonSchema")
+			present = err == nil
+		}
+		if !present {
+			report.add("validator "+name, StatusWarn, "missing, applied when the server starts")
+		} else {
+			report.add("validator "+name, StatusOK, "present")
+		}
+	}
+}
+
+// checkKeys fetches the JWT signing keys from the key provider, unless it
+// is the default env provider covered by the JWT_SECRET check
+func checkKeys(ctx context.Context, report *Report) {
+	provider := utils.GetEnv("KEY_PROVIDER", "env")
+	if provider == "env" {
+		return
+	}
+
+	keys, err := utils.Keys().Keys(ctx, utils.JWTKey)
+	if err != nil {
+		report.add("keys "+provider, StatusFail, "cannot read "+utils.JWTKey+": "+err.Error())
+		return
+	}
+	detail := "current key " + keys[0].ID
+	if len(keys) > 1 {
+		detail += ", previous key " + keys[1].ID
+	}
+	report.add("keys "+provider, StatusOK, detail)
+}
+
+// checkSentry checks that SENTRY_DSN, when set, is valid and its host
+// answers. The key itself can only be verified by sending an event.
+func checkSentry(ctx context.Context, report *Report) {
+	dsn := utils.GetEnv("SENTRY_DSN", "")
+	if dsn == "" {
+		return
+	}
+	if _, err := utils.NewSentryReporter(dsn); err != nil {
+		report.add("sentry", StatusFail, err.Error())
+		return
+	}
+
+	parsed, _ := url.Parse(dsn)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.Scheme+"://"+parsed.Host+"/", nil)
+	if err != nil {
+		report.add("sentry", StatusFail, err.Error())
+		return
+	}
+	response, err := (&http.Client{Timeout: 5 * time.Second}).Do(request)
+	if err != nil {
+		report.add("sentry", StatusWarn, "host unreachable: "+err.Error())
+		return
+	}
+	response.Body.Close()
+	report.add("sentry", StatusOK, "DSN valid, "+parsed.Host+" reachable")
+}
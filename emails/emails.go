@@ -0,0 +1,218 @@
+// Package emails renders the emails sent to users from the templates
+// embedded in templates/. Each email has a plain text version, the fallback
+// of clients that do not show HTML, and an HTML version wrapped in a shared
+// layout. Templates are kept per language, a language missing a template
+// using the English one.
+//
+// templates/layout.html and templates/layout.txt wrap the content of every
+// email. Each language directory holds common.tmpl, with the greeting and
+// footer shared by its emails, and for each email <name>.txt, defining its
+// "subject" and text "content", and <name>.html, defining its HTML
+// "content" and, for emails with a link, the "action" label of its button.
+package emails
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+// Emails, by template name
+const (
+	VerifyEmail       = "verify_email"        // Link verifying the email address of a new account
+	PasswordReset     = "password_reset"      // Token to choose a new password
+	PasswordResetDone = "password_reset_done" // Confirmation that the password was reset
+	EmailChange       = "email_change"        // Token confirming a new email address, sent to it
+	EmailChanged      = "email_changed"       // Notice of a changed email address, sent to the old one
+	NewLogin          = "new_login"           // Login from a device the user was not logged in on
+	Alert             = "alert"               // Notification of the alerts event
+)
+
+// Names lists every email
+var Names = []string{VerifyEmail, PasswordReset, PasswordResetDone, EmailChange, EmailChanged, NewLogin, Alert}
+
+// DefaultLanguage is the language of the emails of users without one, and
+// the one whose templates stand in for those missing in other languages
+const DefaultLanguage = "en"
+
+// Languages lists the languages emails are written in
+var Languages = []string{"en", "de"}
+
+// dateLayouts formats times in the emails of each language
+var dateLayouts = map[string]string{
+	"en": "January 2, 2006 at 15:04 MST",
+	"de": "2. January 2006 um 15:04 MST",
+}
+
+// germanMonths translates the month names written by the German layout
+var germanMonths = strings.NewReplacer(
+	"January", "Januar", "February", "Februar", "March", "März", "June", "Juni",
+	"July", "Juli", "October", "Oktober", "December", "Dezember",
+)
+
+// Data holds the values an email template refers to, such as Username
+type Data map[string]interface{}
+
+// Message is a rendered email
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// templates are the parsed templates of an email in a language
+type templates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+var (
+	loadOnce sync.Once
+	loaded   map[string]templates // By language and name, such as "de/alert"
+	loadErr  error
+)
+
+// IsLanguage reports whether emails can be written in a language
+func IsLanguage(language string) bool {
+	for _, known := range Languages {
+		if known == language {
+			return true
+		}
+	}
+	return false
+}
+
+// IsName reports whether an email exists
+func IsName(name string) bool {
+	for _, known := range Names {
+		if known == name {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchLanguage returns the first language of an Accept-Language header
+// that emails are written in, such as "de" for "de-CH, en;q=0.8", or ""
+// when there is none. Quality values are ignored, browsers list languages
+// by preference already.
+func MatchLanguage(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		language := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+		if IsLanguage(language) {
+			return language
+		}
+	}
+	return ""
+}
+
+// Render writes an email in a language, the default language standing in
+// for unknown ones. The subject is available to the layout as .Subject.
+func Render(name, language string, data Data) (Message, error) {
+	loadOnce.Do(load)
+	if loadErr != nil {
+		return Message{}, loadErr
+	}
+	if !IsLanguage(language) {
+		language = DefaultLanguage
+	}
+	set, ok := loaded[language+"/"+name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email %q", name)
+	}
+
+	values := Data{}
+	for key, value := range data {
+		values[key] = value
+	}
+
+	var subject bytes.Buffer
+	if err := set.text.ExecuteTemplate(&subject, "subject", values); err != nil {
+		return Message{}, err
+	}
+	values["Subject"] = strings.TrimSpace(subject.String())
+
+	var text, html bytes.Buffer
+	if err := set.text.ExecuteTemplate(&text, "layout.txt", values); err != nil {
+		return Message{}, err
+	}
+	if err := set.html.ExecuteTemplate(&html, "layout.html", values); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: values["Subject"].(string),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    strings.TrimSpace(html.String()) + "\n",
+	}, nil
+}
+
+// load parses the templates of every email in every language
+func load() {
+	loaded = map[string]templates{}
+	for _, language := range Languages {
+		for _, name := range Names {
+			set, err := parse(language, name)
+			if err != nil {
+				loadErr = fmt.Errorf("email template %s/%s: %w", language, name, err)
+				return
+			}
+			loaded[language+"/"+name] = set
+		}
+	}
+}
+
+// parse parses the text and HTML templates of an email in a language
+func parse(language, name string) (templates, error) {
+	funcs := map[string]interface{}{
+		"language": func() string { return language },
+		"date":     func(t time.Time) string { return formatDate(language, t) },
+	}
+	common := languageFile(language, "common.tmpl")
+
+	text, err := texttemplate.New("").Funcs(funcs).ParseFS(templateFiles,
+		"templates/layout.txt", common, languageFile(language, name+".txt"))
+	if err != nil {
+		return templates{}, err
+	}
+	html, err := htmltemplate.New("").Funcs(funcs).ParseFS(templateFiles,
+		"templates/layout.html", common, languageFile(language, name+".html"))
+	if err != nil {
+		return templates{}, err
+	}
+	return templates{text: text, html: html}, nil
+}
+
+// languageFile returns the path of a template file in a language, or in
+// the default language when the language does not have it
+func languageFile(language, file string) string {
+	path := "templates/" + language + "/" + file
+	if _, err := fs.Stat(templateFiles, path); err != nil {
+		return "templates/" + DefaultLanguage + "/" + file
+	}
+	return path
+}
+
+// formatDate writes a time in UTC the way a language does, users having
+// no time zone of their own
+func formatDate(language string, t time.Time) string {
+	layout, ok := dateLayouts[language]
+	if !ok {
+		layout = dateLayouts[DefaultLanguage]
+	}
+	formatted := t.UTC().Format(layout)
+	if language == "de" {
+		formatted = germanMonths.Replace(formatted)
+	}
+	return formatted
+}
@@ -0,0 +1,36 @@
+package emails
+
+import "time"
+
+// Sample returns example values for an email, filling every field its
+// templates refer to, so that it can be previewed
+func Sample(name string) Data {
+	sample := Data{"Username": "johndoe"}
+	expires := time.Now().Add(time.Hour).UTC()
+
+	switch name {
+	case VerifyEmail:
+		sample["Email"] = "john@example.com"
+		sample["Link"] = "https://api.example.com/auth/verify-email/0123456789abcdef"
+		sample["ExpiresAt"] = expires
+	case PasswordReset:
+		sample["Link"] = "https://app.example.com/reset-password?token=0123456789abcdef"
+		sample["Token"] = "0123456789abcdef"
+		sample["ExpiresAt"] = expires
+	case EmailChange:
+		sample["Email"] = "john.doe@example.com"
+		sample["Link"] = "https://app.example.com/verify-email?token=0123456789abcdef"
+		sample["Token"] = "0123456789abcdef"
+		sample["ExpiresAt"] = expires
+	case EmailChanged:
+		sample["Email"] = "john.doe@example.com"
+	case NewLogin:
+		sample["Time"] = time.Now().UTC()
+		sample["IPAddress"] = "203.0.113.42"
+		sample["UserAgent"] = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 Safari/605.1.15"
+	case Alert:
+		sample["Title"] = "Approaching a limit"
+		sample["Message"] = "90 of 100 contexts used."
+	}
+	return sample
+}
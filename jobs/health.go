@@ -0,0 +1,88 @@
+package jobs
+
+import (
+	"sort"
+	"time"
+)
+
+// unhealthyFailures is the number of failed runs in a row after which a job is unhealthy
+const unhealthyFailures = 3
+
+// JobStatus is the health of a job on this instance
+type JobStatus struct {
+	Name                string     `json:"name"`
+	Interval            string     `json:"interval"`
+	Holder              bool       `json:"holder"`  // Whether this instance held the lease at the last attempt
+	Stopped             bool       `json:"stopped"` // Set while the job's loop waits to be restarted
+	LastRunAt           *time.Time `json:"lastRunAt,omitempty"`
+	LastRunID           string     `json:"lastRunId,omitempty"` // Request ID of the notifications sent by the last run
+	LastSuccessAt       *time.Time `json:"lastSuccessAt,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Panics              int        `json:"panics"`
+	Restarts            int        `json:"restarts"`
+	NextRunAt           *time.Time `json:"nextRunAt,omitempty"`
+	Healthy             bool       `json:"healthy"`
+}
+
+// healthy reports whether the job is running and not failing repeatedly
+func (js *JobStatus) healthy() bool {
+	return !js.Stopped && js.ConsecutiveFailures < unhealthyFailures
+}
+
+// update changes the status of a job under the lock
+func (s *Scheduler) update(name string, change func(status *JobStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change(s.status[name])
+}
+
+// finished records the outcome of an attempt and returns the number of
+// consecutive failures
+func (s *Scheduler) finished(name string, err error) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.status[name]
+	if err != nil {
+		status.ConsecutiveFailures++
+		status.LastError = err.Error()
+		return status.ConsecutiveFailures
+	}
+
+	now := time.Now()
+	status.LastSuccessAt = &now
+	status.LastError = ""
+	status.ConsecutiveFailures = 0
+	return 0
+}
+
+// Status returns the health of every registered job, by name
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(s.status))
+	for _, status := range s.status {
+		snapshot := *status
+		snapshot.Healthy = status.healthy()
+		statuses = append(statuses, snapshot)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// Healthy reports whether every job is healthy on this instance
+func (s *Scheduler) Healthy() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, status := range s.status {
+		if !status.healthy() {
+			return false
+		}
+	}
+	return true
+}
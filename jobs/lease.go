@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Lease is a MongoDB-backed lock that lets exactly one instance run a job.
+// The holder must renew it before it expires, otherwise another instance
+// can take it over.
+type Lease struct {
+	collection *mongo.Collection
+	name       string
+	owner      string
+	ttl        time.Duration
+}
+
+// NewLease creates a lease for the named job held on behalf of owner
+func NewLease(collection *mongo.Collection, name, owner string, ttl time.Duration) *Lease {
+	return &Lease{
+		collection: collection,
+		name:       name,
+		owner:      owner,
+		ttl:        ttl,
+	}
+}
+
+// EnsureLeaseIndexes creates the TTL index that removes expired leases
+func EnsureLeaseIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expiresAt": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Acquire takes the lease, or renews it when already held by this owner.
+// It reports false when another owner holds an unexpired lease.
+func (l *Lease) Acquire(ctx context.Context) (bool, error) {
+	now := time.Now()
+	_, err := l.collection.UpdateOne(
+		ctx,
+		bson.M{
+			"_id": l.name,
+			"$or": []bson.M{
+				{"owner": l.owner},
+				{"expiresAt": bson.M{"$lt": now}},
+			},
+		},
+		bson.M{"$set": bson.M{
+			"owner":      l.owner,
+			"acquiredAt": now,
+			"expiresAt":  now.Add(l.ttl),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		// The upsert collides with a lease held by someone else
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Release gives the lease up if this owner holds it
+func (l *Lease) Release(ctx context.Context) error {
+	_, err := l.collection.DeleteOne(ctx, bson.M{"_id": l.name, "owner": l.owner})
+	return err
+}
@@ -0,0 +1,188 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"gotodolist/utils"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Job is a periodic background task that must run on a single instance
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// maxBackoff caps the delay between retries of a failing job
+const maxBackoff = 15 * time.Minute
+
+// Scheduler runs registered jobs on their interval. Before every run the
+// instance must hold the job's lease, so only one replica runs each job and
+// another one takes over when the holder stops renewing.
+//
+// A job that panics only fails its own run: the panic is logged, reported
+// to Sentry when SENTRY_DSN is set, and the job is retried with backoff like
+// any failure. Each job is supervised and restarted if its loop dies.
+type Scheduler struct {
+	leaseCollection *mongo.Collection
+	jobs            []Job
+	logger          *utils.Logger
+	reporter        *utils.SentryReporter
+
+	mu     sync.Mutex
+	status map[string]*JobStatus
+}
+
+// NewScheduler creates a new scheduler storing its leases in the given collection
+func NewScheduler(leaseCollection *mongo.Collection) *Scheduler {
+	s := &Scheduler{
+		leaseCollection: leaseCollection,
+		logger:          utils.GetLogger().Named("jobs"),
+		status:          map[string]*JobStatus{},
+	}
+	if dsn := utils.GetEnv("SENTRY_DSN", ""); dsn != "" {
+		var err error
+		if s.reporter, err = utils.NewSentryReporter(dsn); err != nil {
+			s.logger.Warning("Sentry reporting disabled: " + err.Error())
+		}
+	}
+	return s
+}
+
+// Register adds a job to the scheduler, it must be called before Start
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+	s.status[job.Name] = &JobStatus{Name: job.Name, Interval: job.Interval.String()}
+}
+
+// Start runs every registered job in its own goroutine until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	initCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := EnsureLeaseIndexes(initCtx, s.leaseCollection); err != nil {
+		s.logger.Warning("Failed to create job lease index: " + err.Error())
+	}
+
+	for _, job := range s.jobs {
+		go s.supervise(ctx, job)
+	}
+}
+
+// supervise keeps a job's loop running, restarting it with backoff when it
+// dies from a panic outside the job itself, such as in lease handling
+func (s *Scheduler) supervise(ctx context.Context, job Job) {
+	backoff := time.Second
+	for !s.loop(ctx, job) {
+		s.update(job.Name, func(status *JobStatus) {
+			status.Restarts++
+			status.Stopped = true
+		})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// loop runs a job on every interval while this instance holds its lease.
+// It returns true when ctx is cancelled and false if it panicked.
+func (s *Scheduler) loop(ctx context.Context, job Job) (stopped bool) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.recovered(job, "Job loop panicked", recovered)
+		}
+	}()
+
+	// The lease outlives one interval so the holder keeps it between runs
+	lease := NewLease(s.leaseCollection, job.Name, utils.InstanceID(), 2*job.Interval)
+	s.update(job.Name, func(status *JobStatus) { status.Stopped = false })
+
+	for {
+		wait := job.Interval
+		if failures := s.tick(ctx, job, lease); failures > 1 {
+			// Back off exponentially from the second failure in a row
+			wait = min(job.Interval<<min(failures-1, 16), max(maxBackoff, job.Interval))
+		}
+		s.update(job.Name, func(status *JobStatus) {
+			next := time.Now().Add(wait)
+			status.NextRunAt = &next
+		})
+
+		select {
+		case <-ctx.Done():
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			lease.Release(releaseCtx)
+			cancel()
+			return true
+		case <-time.After(wait):
+		}
+	}
+}
+
+// tick runs the job once if the lease can be acquired and returns the
+// number of consecutive failed runs
+func (s *Scheduler) tick(ctx context.Context, job Job, lease *Lease) int {
+	logger := s.logger.With("job", job.Name)
+
+	acquired, err := lease.Acquire(ctx)
+	if err != nil {
+		logger.Error("Failed to acquire lease: " + err.Error())
+		return s.finished(job.Name, err)
+	}
+	s.update(job.Name, func(status *JobStatus) { status.Holder = acquired })
+	if !acquired {
+		return 0
+	}
+
+	// Each run gets an ID, carried to the notifications it sends like a
+	// request ID, to tell its work apart from the other runs
+	runID := utils.NewRequestID()
+	logger = logger.With("requestId", runID)
+	s.update(job.Name, func(status *JobStatus) { status.LastRunID = runID })
+
+	runCtx, cancel := context.WithTimeout(utils.WithRequestID(ctx, runID), job.Interval)
+	defer cancel()
+
+	start := time.Now()
+	if err := s.run(runCtx, job); err != nil {
+		logger.Error("Job failed: " + err.Error())
+		return s.finished(job.Name, err)
+	}
+	logger.Debug("Job completed in " + time.Since(start).String())
+	return s.finished(job.Name, nil)
+}
+
+// run calls the job, turning a panic into an error so it only fails this run
+func (s *Scheduler) run(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.recovered(job, "Job panicked", recovered)
+			s.update(job.Name, func(status *JobStatus) { status.Panics++ })
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	s.update(job.Name, func(status *JobStatus) {
+		now := time.Now()
+		status.LastRunAt = &now
+	})
+	return job.Run(ctx)
+}
+
+// recovered logs a recovered panic with its stack and reports it to Sentry
+func (s *Scheduler) recovered(job Job, message string, recovered interface{}) {
+	stack := debug.Stack()
+	s.logger.With("job", job.Name).Error(fmt.Sprintf("%s: %v\n%s", message, recovered, stack))
+	if s.reporter != nil {
+		s.reporter.Report(fmt.Sprint(recovered), stack, map[string]string{"job": job.Name})
+	}
+}
@@ -1,22 +1,54 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"gotodolist/configs"
 	"gotodolist/controllers"
+	"gotodolist/doctor"
+	"gotodolist/jobs"
 	"gotodolist/middleware"
+	"gotodolist/models"
 	"gotodolist/routes"
 	"gotodolist/utils"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	// Load environment variables
+	doctorMode := flag.Bool("doctor", false, "Check the configuration, database and services, print a readiness report and exit")
+	configPath := flag.String("config", "", "YAML or TOML config file, overridden by environment variables")
+	flag.Parse()
+
+	// Load environment variables, then the config file they override
 	utils.LoadEnv()
+	configFile, err := utils.LoadConfigFile(*configPath)
+	if err != nil {
+		log.Println("Failed to load config file: " + err.Error())
+		os.Exit(1)
+	}
+	if err := utils.LoadSecretFiles(); err != nil {
+		log.Println("Failed to load secret file: " + err.Error())
+		os.Exit(1)
+	}
+
+	// Only report whether the instance could start
+	if *doctorMode {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		report := doctor.Run(ctx)
+		cancel()
+		report.Print(os.Stdout)
+		if !report.Ready() {
+			os.Exit(1)
+		}
+		return
+	}
 
 	// Set Gin mode
 	mode := utils.GetEnv("GIN_MODE", "debug")
@@ -34,57 +66,200 @@ func main() {
 	// Log application startup
 	logger.Info("Starting Todolist API application")
 	logger.Info("Running in " + mode + " mode")
+	logger.Info("Instance ID: " + utils.InstanceID())
+	logger.Info("Version: " + utils.GetBuildInfo().Version)
+	if configFile != "" {
+		logger.Info("Config file: " + configFile)
+	}
+
+	// Reload the config file and secret files on SIGHUP
+	utils.WatchConfigReload(func(path string, err error) {
+		if err != nil {
+			logger.Error("Failed to reload settings: " + err.Error())
+			return
+		}
+		source := "secret files"
+		if path != "" {
+			source = path + " and secret files"
+		}
+		logger.Warning("Reloaded " + source + ", log level " + string(logger.Level()))
+	})
 
 	// Initialize Gin router (without default logger)
 	router := gin.New()
 
-	// Use our custom logger and recovery middleware
+	// Answer unsupported methods with 405 and OPTIONS with 204, both with an Allow header
+	router.HandleMethodNotAllowed = true
+	router.NoMethod(middleware.MethodNotAllowed(router))
+
+	// Trust reverse proxies so ClientIP is the real client address
+	if err := configs.ConfigureProxies(router); err != nil {
+		logger.Error("Failed to configure trusted proxies: " + err.Error())
+		os.Exit(1)
+	}
+
+	// Use our custom request ID, logger and recovery middleware, requests
+	// are counted for the health history
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger())
-	router.Use(gin.Recovery())
+	router.Use(middleware.RequestStats())
+	router.Use(middleware.Recovery())
+
+	// Log sanitized bodies of the routes in LOG_BODIES, only in debug mode
+	router.Use(middleware.BodyLogger())
 
 	// Configure CORS
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{utils.GetEnv("CORS_ORIGIN", "*")},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
+	router.Use(middleware.CORS())
+
+	// Optionally validate requests against the OpenAPI specification
+	if utils.GetEnv("OPENAPI_VALIDATION", "false") == "true" {
+		validator, err := middleware.RequestValidator("./swagger.yaml")
+		if err != nil {
+			logger.Error("Failed to load request validator: " + err.Error())
+			os.Exit(1)
+		}
+		router.Use(validator)
+		logger.Info("OpenAPI request validation enabled")
+	}
 
 	// Connect to MongoDB
 	mongoURI := utils.GetEnv("MONGO_URI", "mongodb://localhost:27017")
 	client := configs.ConnectDB(mongoURI)
 	logger.Success("Connected to MongoDB")
 
-	// Initialize collections
+	// Wire the controllers over the collections and set up their routes
 	dbName := utils.GetEnv("DB_NAME", "todolist")
-	tasksCollection := configs.GetCollection(client, "tasks", dbName)
-	usersCollection := configs.GetCollection(client, "users", dbName)
-
-	// Initialize controllers
-	taskController := controllers.NewTaskController(tasksCollection)
-	authController := controllers.NewAuthController(usersCollection)
-
-	// Initialize middlewares
-	authMiddleware := middleware.NewAuthMiddleware(usersCollection)
-
-	// Setup routes
-	routes.SetupTaskRoutes(router, taskController, authMiddleware)
-	routes.SetupAuthRoutes(router, authController, authMiddleware)
+	app := routes.NewApp(client, dbName)
+	app.Register(router)
+	if utils.PprofEnabled() {
+		routes.SetupDebugRoutes(router, app.AuthMiddleware)
+		logger.Info("Profiling endpoints enabled under /debug/pprof")
+	}
+	if gin.Mode() == gin.DebugMode {
+		routes.SetupEmailPreviewRoutes(router, controllers.NewEmailPreviewController())
+		logger.Info("Email previews enabled under /debug/emails")
+	}
 	logger.Info("Routes initialized successfully")
 
+	// Start background jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	app.Scheduler.Register(jobs.Job{
+		Name:     "email-delivery",
+		Interval: time.Minute,
+		Run:      app.MailQueue.Deliver,
+	})
+	app.Scheduler.Register(jobs.Job{
+		Name:     "data-exports",
+		Interval: time.Minute,
+		Run:      app.DataExportController.ProcessExports,
+	})
+	escalationInterval, err := time.ParseDuration(utils.GetEnv("ESCALATION_INTERVAL", "15m"))
+	if err != nil || escalationInterval <= 0 {
+		escalationInterval = 15 * time.Minute
+	}
+	app.Scheduler.Register(jobs.Job{
+		Name:     "overdue-escalation",
+		Interval: escalationInterval,
+		Run:      app.EscalationController.Run,
+	})
+	app.Scheduler.Register(jobs.Job{
+		Name:     "snooze-wake",
+		Interval: time.Minute,
+		Run:      app.TaskController.WakeSnoozedTasks,
+	})
+	app.Scheduler.Register(jobs.Job{
+		Name:     "task-reminders",
+		Interval: time.Minute,
+		Run:      app.TaskController.SendReminders,
+	})
+	app.Scheduler.Register(jobs.Job{
+		Name:     "task-recurrence",
+		Interval: time.Minute,
+		Run:      app.TaskController.MaterializeRecurrences,
+	})
+	cleanupInterval, err := time.ParseDuration(utils.GetEnv("CLEANUP_INTERVAL", "24h"))
+	if err != nil || cleanupInterval <= 0 {
+		cleanupInterval = 24 * time.Hour
+	}
+	app.Scheduler.Register(jobs.Job{
+		Name:     "orphan-cleanup",
+		Interval: cleanupInterval,
+		Run:      app.MaintenanceController.Run,
+	})
+	app.Scheduler.Register(jobs.Job{
+		Name:     "activity-compaction",
+		Interval: cleanupInterval,
+		Run:      app.MaintenanceController.CompactActivity,
+	})
+	app.Scheduler.Register(jobs.Job{
+		Name:     "consistency-check",
+		Interval: cleanupInterval,
+		Run:      app.MaintenanceController.RunConsistencyCheck,
+	})
+	app.Scheduler.Register(jobs.Job{
+		Name:     "automations-due-soon",
+		Interval: 5 * time.Minute,
+		Run:      app.AutomationController.RunDueSoon,
+	})
+	indexCtx, cancelIndex := context.WithTimeout(jobsCtx, 10*time.Second)
+	if err := app.AuthController.EnsureIndexes(indexCtx); err != nil {
+		logger.Warning("Failed to create user index: " + err.Error())
+	}
+	if err := app.MailQueue.EnsureIndexes(indexCtx); err != nil {
+		logger.Warning("Failed to create email outbox indexes: " + err.Error())
+	}
+	if err := app.DataExportController.EnsureIndexes(indexCtx); err != nil {
+		logger.Warning("Failed to create data export index: " + err.Error())
+	}
+	if err := app.TaskController.EnsureIndexes(indexCtx); err != nil {
+		logger.Warning("Failed to create task indexes: " + err.Error())
+	}
+	if err := app.NoteController.EnsureIndexes(indexCtx); err != nil {
+		logger.Warning("Failed to create note index: " + err.Error())
+	}
+	if err := app.ContextController.EnsureIndexes(indexCtx); err != nil {
+		logger.Warning("Failed to create context index: " + err.Error())
+	}
+	if err := app.ProjectController.EnsureIndexes(indexCtx); err != nil {
+		logger.Warning("Failed to create project index: " + err.Error())
+	}
+	if err := app.MaintenanceController.EnsureIndexes(indexCtx); err != nil {
+		logger.Warning("Failed to create activity index: " + err.Error())
+	}
+	if err := app.AutomationController.EnsureIndexes(indexCtx); err != nil {
+		logger.Warning("Failed to create automation indexes: " + err.Error())
+	}
+	if err := app.HealthController.EnsureIndexes(indexCtx); err != nil {
+		logger.Warning("Failed to create health snapshot index: " + err.Error())
+	}
+	if err := configs.ApplySchema(indexCtx, app.TasksCollection, models.TaskSchema()); err != nil {
+		logger.Warning("Failed to apply tasks schema validator: " + err.Error())
+	}
+	if err := configs.ApplySchema(indexCtx, app.UsersCollection, models.UserSchema()); err != nil {
+		logger.Warning("Failed to apply users schema validator: " + err.Error())
+	}
+	cancelIndex()
+	go func() {
+		filled, err := controllers.BackfillTaskCounts(jobsCtx, app.TasksCollection, app.GoalsCollection, app.ContextsCollection, app.ProjectsCollection, app.TagsCollection)
+		if err != nil {
+			logger.Warning("Failed to backfill task counts: " + err.Error())
+		} else if filled > 0 {
+			logger.Info("Backfilled task counts of " + strconv.Itoa(filled) + " goals, contexts, projects and tags")
+		}
+		fixed, err := controllers.BackfillSubtaskCounts(jobsCtx, app.TasksCollection)
+		if err != nil {
+			logger.Warning("Failed to backfill subtask counts: " + err.Error())
+		} else if fixed > 0 {
+			logger.Info("Backfilled subtask counts of " + strconv.FormatInt(fixed, 10) + " tasks")
+		}
+	}()
+	app.Scheduler.Start(jobsCtx)
+	app.HealthController.Start(jobsCtx)
+
 	// Setup Swagger documentation
 	router.GET("/api-docs/*any", middleware.Swagger())
 
-	// Define health check route
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":    "up",
-			"timestamp": time.Now(),
-		})
-	})
-
 	// Default welcome route
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -93,10 +268,15 @@ func main() {
 	})
 
 	// Start the server
-	port := utils.GetEnv("PORT", "8080")
-	logger.Info("Server running on port " + port)
+	listener, address, err := configs.Listen()
+	if err != nil {
+		logger.Error("Failed to listen on " + address + ": " + err.Error())
+		os.Exit(1)
+	}
+	logger.Info("Server running on " + address)
 
-	if err := router.Run(":" + port); err != nil {
+	// HEAD requests are served by the GET routes
+	if err := http.Serve(listener, configs.LocalPeers(middleware.HeadHandler(router))); err != nil {
 		logger.Error("Failed to start server: " + err.Error())
 		os.Exit(1)
 	}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"gotodolist/authz"
 	"gotodolist/models"
 	"gotodolist/utils"
 
@@ -20,12 +21,17 @@ import (
 // AuthMiddleware contains the dependencies needed for auth middleware
 type AuthMiddleware struct {
 	userCollection *mongo.Collection
+	userCache      *UserCache
+	policyGate     *PolicyGate
 }
 
-// NewAuthMiddleware creates a new auth middleware
-func NewAuthMiddleware(userCollection *mongo.Collection) *AuthMiddleware {
+// NewAuthMiddleware creates a new auth middleware, users are looked up
+// through the given cache and must have accepted the policies of the gate
+func NewAuthMiddleware(userCollection *mongo.Collection, userCache *UserCache, policyGate *PolicyGate) *AuthMiddleware {
 	return &AuthMiddleware{
 		userCollection: userCollection,
+		userCache:      userCache,
+		policyGate:     policyGate,
 	}
 }
 
@@ -62,7 +68,7 @@ func (am *AuthMiddleware) Protect() gin.HandlerFunc {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 			}
-			return []byte(utils.GetEnv("JWT_SECRET", "your-secret-key")), nil
+			return utils.TokenKey(c.Request.Context(), token)
 		})
 
 		if err != nil || !token.Valid {
@@ -96,29 +102,80 @@ func (am *AuthMiddleware) Protect() gin.HandlerFunc {
 			return
 		}
 
-		// Find the user in the database
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+		// In stateless mode the token claims are trusted as they are, so
+		// deleted users and role changes only apply once the token expires,
+		// and neither policy acceptance nor email verification is enforced.
+		// The session is still passed on, so that logging out ends only it.
+		if user, ok := userFromClaims(userID, claims); ok && utils.StatelessAuth() {
+			c.Set("user", user)
+			c.Set("userId", userID)
+			c.Set("userFromToken", true)
+			if sessionID, hasSession := tokenSession(claims); hasSession {
+				c.Set("sessionId", sessionID)
+			}
+			c.Next()
+			return
+		}
 
-		var user models.User
-		err = am.userCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
+		// Find the user in the cache or the database
+		user, cached := am.userCache.Get(userID)
+		if !cached {
+			if !am.loadUser(c, userID, &user) {
+				return
+			}
+			am.userCache.Set(user)
+		}
+
+		// A session missing from a cached user may have started since, on
+		// another instance
+		sessionID, hasSession := tokenSession(claims)
+		if _, ok := user.Session(sessionID, time.Now()); hasSession && !ok && cached {
+			if !am.loadUser(c, userID, &user) {
+				return
+			}
+			am.userCache.Set(user)
+		}
+
+		// Tokens issued before the user's token version was bumped are revoked
+		if tokenVersion(claims) != user.TokenVersion || !user.IsActive() {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "Token has been revoked",
+			})
+			c.Abort()
+			return
+		}
+
+		// So are the tokens of a session that was logged out or evicted
+		if hasSession {
+			if _, ok := user.Session(sessionID, time.Now()); !ok {
 				c.JSON(http.StatusUnauthorized, gin.H{
 					"success": false,
-					"error":   "User not found",
+					"error":   "Session has ended, log in again",
 				})
 				c.Abort()
 				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{
+			c.Set("sessionId", sessionID)
+		}
+
+		// With REQUIRE_VERIFIED_EMAIL, so must their email address be
+		// verified. Authentication routes stay reachable so they can verify
+		// it, have the link sent again, or fix the address.
+		if utils.RequireVerifiedEmail() && !user.EmailVerified() && !strings.HasPrefix(c.Request.URL.Path, "/auth/") {
+			c.JSON(http.StatusForbidden, gin.H{
 				"success": false,
-				"error":   "Failed to authenticate user",
+				"error":   "Email address must be verified to continue, follow the link emailed to " + user.Email,
 			})
 			c.Abort()
 			return
 		}
 
+		// Users must accept the latest policies before using the API
+		if !am.policyGate.check(c, user) {
+			return
+		}
+
 		// Set user information in the context
 		c.Set("user", user)
 		c.Set("userId", userID)
@@ -126,3 +183,89 @@ func (am *AuthMiddleware) Protect() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// userFromClaims builds the user embedded in an access token. Tokens issued
+// before the claims were added carry no username and are not usable.
+func userFromClaims(userID primitive.ObjectID, claims jwt.MapClaims) (models.User, bool) {
+	username, _ := claims["username"].(string)
+	if username == "" {
+		return models.User{}, false
+	}
+	email, _ := claims["email"].(string)
+	role, _ := claims["role"].(string)
+
+	return models.User{
+		ID:       userID,
+		Username: username,
+		Email:    email,
+		Role:     role,
+	}, true
+}
+
+// tokenVersion reads the version claim, tokens issued before it existed are version 0
+func tokenVersion(claims jwt.MapClaims) int {
+	version, _ := claims["ver"].(float64)
+	return int(version)
+}
+
+// tokenSession reads the session claim, tokens issued before sessions
+// existed have none
+func tokenSession(claims jwt.MapClaims) (primitive.ObjectID, bool) {
+	text, _ := claims["sid"].(string)
+	id, err := primitive.ObjectIDFromHex(text)
+	return id, err == nil
+}
+
+// loadUser reads a user from the database, writing the error response and
+// aborting when it cannot be found
+func (am *AuthMiddleware) loadUser(c *gin.Context, userID primitive.ObjectID, user *models.User) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := am.userCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "User not found",
+			})
+			c.Abort()
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to authenticate user",
+		})
+		c.Abort()
+		return false
+	}
+
+	return true
+}
+
+// Require restricts routes to the users allowed to perform an action by
+// the authz rules, it must run after Protect
+func (am *AuthMiddleware) Require(action authz.Action) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "User not authenticated",
+			})
+			c.Abort()
+			return
+		}
+
+		if !authz.Can(user.(models.User), action, primitive.NilObjectID) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Permission required: " + string(action),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxRecordedBody = 64 * 1024 // Bytes of a response kept to be redacted
+	maxLoggedBody   = 4096      // Bytes of a redacted body that are logged
+)
+
+// bodyRecorder copies the start of the response body while it is written
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	if room := maxRecordedBody - w.body.Len(); room > 0 {
+		w.body.Write(data[:min(len(data), room)])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// BodyLogger logs the request and response bodies of the routes listed in
+// LOG_BODIES, to help diagnosing client integrations. Routes are given as
+// comma-separated patterns such as "/auth/login" or "/tasks/*", matched
+// against the route or the request path. Passwords, tokens and secrets are
+// redacted. It only runs in debug mode and is a no-op otherwise.
+func BodyLogger() gin.HandlerFunc {
+	patterns := splitPatterns(utils.GetEnv("LOG_BODIES", ""))
+	if gin.Mode() != gin.DebugMode || len(patterns) == 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	logger := utils.GetLogger().Named("http.body")
+	logger.Warning("Request and response bodies are logged for: " + strings.Join(patterns, ", "))
+
+	return func(c *gin.Context) {
+		if !bodyRouteMatches(patterns, c.FullPath(), c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		var requestBody []byte
+		if c.Request.Body != nil {
+			requestBody, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewReader(requestBody))
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		logger.With("requestId", c.GetString("requestId")).Debug(fmt.Sprintf("%s %s | %d | request=%s | response=%s",
+			c.Request.Method, c.Request.URL.Path, c.Writer.Status(),
+			sanitizeBody(requestBody, c.ContentType()),
+			sanitizeBody(recorder.body.Bytes(), c.Writer.Header().Get("Content-Type"))))
+	}
+}
+
+// splitPatterns splits a comma-separated list of route patterns
+func splitPatterns(value string) []string {
+	patterns := []string{}
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// bodyRouteMatches reports whether the route or path matches a pattern, where a
+// trailing "*" matches any suffix
+func bodyRouteMatches(patterns []string, route, path string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(route, prefix) || strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if pattern == route || pattern == path {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeBody renders a body for the logs: JSON has its sensitive fields
+// redacted, other content is summarized, and long bodies are truncated
+func sanitizeBody(body []byte, contentType string) string {
+	if len(body) == 0 {
+		return "-"
+	}
+	if !strings.Contains(contentType, "json") {
+		return fmt.Sprintf("<%d bytes of %s>", len(body), contentType)
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		// Truncated or invalid JSON cannot be redacted safely
+		return fmt.Sprintf("<%d bytes of unparsed JSON>", len(body))
+	}
+	redacted, _ := json.Marshal(redactValue(value))
+	if len(redacted) > maxLoggedBody {
+		return string(redacted[:maxLoggedBody]) + "...(truncated)"
+	}
+	return string(redacted)
+}
+
+// redactValue replaces the values of sensitive keys at any depth
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if sensitiveKey(key) {
+				v[key] = "[REDACTED]"
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// sensitiveKey reports whether a JSON key holds a credential
+func sensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range []string{"password", "token", "secret", "authorization", "apikey", "api_key"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
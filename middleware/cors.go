@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"gotodolist/utils"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORS builds the CORS middleware from the environment.
+//
+// CORS_ORIGIN is a comma-separated list of origins, where "*" allows any
+// origin and "https://*.example.com" allows every subdomain of example.com.
+// It defaults to "*" in debug mode and to no cross-origin access in release
+// mode. CORS_CREDENTIALS enables credentialed requests; browsers reject "*"
+// for those, so allowed origins are reflected instead, and allowing any
+// origin with credentials additionally requires CORS_REFLECT_ORIGIN=true.
+func CORS() gin.HandlerFunc {
+	logger := utils.GetLogger()
+
+	defaultOrigins := "*"
+	if gin.Mode() == gin.ReleaseMode {
+		defaultOrigins = ""
+	}
+	origins := parseOrigins(utils.GetEnv("CORS_ORIGIN", defaultOrigins))
+	credentials := utils.GetEnv("CORS_CREDENTIALS", "true") == "true"
+	reflect := utils.GetEnv("CORS_REFLECT_ORIGIN", "false") == "true"
+
+	config := cors.Config{
+		AllowMethods:     []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
+		ExposeHeaders:    []string{"Content-Length", "Link"},
+		AllowCredentials: credentials,
+		MaxAge:           12 * time.Hour,
+	}
+
+	allowAny := false
+	for _, origin := range origins {
+		if origin == "*" {
+			allowAny = true
+		}
+	}
+
+	switch {
+	case allowAny && !credentials:
+		config.AllowAllOrigins = true
+	case allowAny && !reflect:
+		logger.Warning("CORS_ORIGIN=* cannot be used with credentials, set CORS_REFLECT_ORIGIN=true to reflect any origin; credentials are disabled")
+		config.AllowAllOrigins = true
+		config.AllowCredentials = false
+	case allowAny:
+		logger.Warning("CORS reflects any origin with credentials enabled")
+		config.AllowOriginFunc = func(origin string) bool {
+			return origin != "null"
+		}
+	default:
+		config.AllowOriginFunc = func(origin string) bool {
+			return originAllowed(origins, origin)
+		}
+	}
+
+	return cors.New(config)
+}
+
+// parseOrigins splits a comma-separated origin list
+func parseOrigins(value string) []string {
+	origins := []string{}
+	for _, origin := range strings.Split(value, ",") {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// originAllowed matches an origin against exact origins and wildcard
+// subdomain patterns such as https://*.example.com
+func originAllowed(patterns []string, origin string) bool {
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		if strings.EqualFold(pattern, origin) {
+			return true
+		}
+
+		scheme, host, ok := strings.Cut(pattern, "://*.")
+		if !ok || !strings.EqualFold(scheme, parsed.Scheme) {
+			continue
+		}
+		// The wildcard covers one or more labels, but never the bare domain
+		if strings.HasSuffix(strings.ToLower(parsed.Host), "."+strings.ToLower(host)) {
+			return true
+		}
+	}
+	return false
+}
@@ -10,7 +10,7 @@ import (
 
 // Logger is a middleware function that logs requests using our custom logger
 func Logger() gin.HandlerFunc {
-	logger := utils.GetLogger()
+	logger := utils.GetLogger().Named("http")
 
 	return func(c *gin.Context) {
 		// Start timer
@@ -23,6 +23,6 @@ func Logger() gin.HandlerFunc {
 		latency := time.Since(startTime)
 
 		// Log request details
-		logger.LogRequest(c, latency)
+		logger.With("requestId", c.GetString("requestId")).LogRequest(c, latency)
 	}
 }
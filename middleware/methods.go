@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AllowedMethods returns the methods the router serves for a path, including
+// the implicit HEAD of GET routes and OPTIONS
+func AllowedMethods(router *gin.Engine, path string) []string {
+	seen := map[string]bool{}
+	for _, route := range router.Routes() {
+		if routeMatches(route.Path, path) {
+			seen[route.Method] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil
+	}
+	if seen[http.MethodGet] {
+		seen[http.MethodHead] = true
+	}
+	seen[http.MethodOptions] = true
+
+	// Keep a stable, conventional order
+	allowed := []string{}
+	for _, method := range []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions,
+	} {
+		if seen[method] {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
+// routeMatches checks a request path against a gin route pattern
+func routeMatches(pattern, path string) bool {
+	patternSegments := strings.Split(pattern, "/")
+	pathSegments := strings.Split(path, "/")
+
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+		if i >= len(pathSegments) {
+			return false
+		}
+		if strings.HasPrefix(segment, ":") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(pathSegments)
+}
+
+// MethodNotAllowed is the router's NoMethod handler. OPTIONS requests are
+// answered with 204 and other unsupported methods with 405, both carrying an
+// Allow header listing the methods of the path.
+func MethodNotAllowed(router *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed := AllowedMethods(router, c.Request.URL.Path)
+		c.Header("Allow", strings.Join(allowed, ", "))
+
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusMethodNotAllowed, gin.H{
+			"success": false,
+			"error":   "Method not allowed",
+		})
+	}
+}
+
+// HeadHandler serves HEAD requests through the matching GET route. No route
+// is registered for HEAD, and the HTTP server drops the body of HEAD responses.
+func HeadHandler(router *gin.Engine) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			// The server keeps the original request, so it still knows to skip the body
+			r = r.Clone(r.Context())
+			r.Method = http.MethodGet
+		}
+		router.ServeHTTP(w, r)
+	})
+}
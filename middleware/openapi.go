@@ -0,0 +1,361 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// openAPISchema is the subset of an OpenAPI schema object used for validation
+type openAPISchema struct {
+	Ref        string                    `yaml:"$ref"`
+	Type       string                    `yaml:"type"`
+	Format     string                    `yaml:"format"`
+	Enum       []interface{}             `yaml:"enum"`
+	Required   []string                  `yaml:"required"`
+	Properties map[string]*openAPISchema `yaml:"properties"`
+	Items      *openAPISchema            `yaml:"items"`
+	Pattern    string                    `yaml:"pattern"`
+	MaxLength  *int                      `yaml:"maxLength"`
+	Minimum    *float64                  `yaml:"minimum"`
+	Maximum    *float64                  `yaml:"maximum"`
+	AllOf      []*openAPISchema          `yaml:"allOf"`
+}
+
+// openAPIParameter is an operation or path parameter
+type openAPIParameter struct {
+	In       string         `yaml:"in"`
+	Name     string         `yaml:"name"`
+	Required bool           `yaml:"required"`
+	Schema   *openAPISchema `yaml:"schema"`
+}
+
+// openAPIOperation is a single method of a path
+type openAPIOperation struct {
+	Parameters  []openAPIParameter `yaml:"parameters"`
+	RequestBody *struct {
+		Required bool `yaml:"required"`
+		Content  map[string]struct {
+			Schema *openAPISchema `yaml:"schema"`
+		} `yaml:"content"`
+	} `yaml:"requestBody"`
+}
+
+// openAPISpec is the subset of an OpenAPI document used for validation
+type openAPISpec struct {
+	Paths      map[string]map[string]yaml.Node `yaml:"paths"`
+	Components struct {
+		Schemas map[string]*openAPISchema `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+// validationError describes one invalid field of a request
+type validationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// requestValidator validates requests against the operations of a spec
+type requestValidator struct {
+	schemas    map[string]*openAPISchema
+	operations map[string]*openAPIOperation // Keyed by "METHOD /path/{param}"
+	pathParams map[string][]openAPIParameter
+}
+
+// RequestValidator validates request bodies and query parameters against the
+// OpenAPI specification, answering invalid requests with a structured 400.
+// Routes that are not documented in the specification are not validated.
+func RequestValidator(specPath string) (gin.HandlerFunc, error) {
+	data, err := os.ReadFile(specPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenAPI spec: %v", err)
+	}
+
+	var spec openAPISpec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %v", err)
+	}
+
+	validator := &requestValidator{
+		schemas:    spec.Components.Schemas,
+		operations: map[string]*openAPIOperation{},
+		pathParams: map[string][]openAPIParameter{},
+	}
+
+	for path, items := range spec.Paths {
+		for method, node := range items {
+			if method == "parameters" {
+				var params []openAPIParameter
+				if err := node.Decode(&params); err != nil {
+					return nil, fmt.Errorf("invalid parameters for %s: %v", path, err)
+				}
+				validator.pathParams[path] = params
+				continue
+			}
+			var operation openAPIOperation
+			if err := node.Decode(&operation); err != nil {
+				return nil, fmt.Errorf("invalid operation %s %s: %v", method, path, err)
+			}
+			validator.operations[strings.ToUpper(method)+" "+path] = &operation
+		}
+	}
+
+	return validator.handle, nil
+}
+
+// routeToSpecPath converts a gin route such as /tasks/:id/ to /tasks/{id}
+func routeToSpecPath(route string) string {
+	segments := strings.Split(strings.TrimSuffix(route, "/"), "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	path := strings.Join(segments, "/")
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// handle is the middleware validating the current request
+func (v *requestValidator) handle(c *gin.Context) {
+	path := routeToSpecPath(c.FullPath())
+	operation, ok := v.operations[c.Request.Method+" "+path]
+	if !ok {
+		c.Next()
+		return
+	}
+
+	var errs []validationError
+
+	// Validate query parameters
+	params := append(append([]openAPIParameter{}, v.pathParams[path]...), operation.Parameters...)
+	for _, param := range params {
+		if param.In != "query" {
+			continue
+		}
+		raw, present := c.GetQuery(param.Name)
+		if !present || raw == "" {
+			if param.Required {
+				errs = append(errs, validationError{Field: param.Name, Message: "is required"})
+			}
+			continue
+		}
+		if param.Schema != nil {
+			errs = append(errs, v.validateQueryValue(param.Name, raw, v.resolve(param.Schema))...)
+		}
+	}
+
+	// Validate the JSON body
+	if operation.RequestBody != nil {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Failed to read request body",
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Bodies of another media type the operation accepts are not JSON
+		content, hasJSON := operation.RequestBody.Content["application/json"]
+		if _, declared := operation.RequestBody.Content[c.ContentType()]; declared && c.ContentType() != "application/json" {
+			hasJSON = false
+		}
+		switch {
+		case len(bytes.TrimSpace(body)) == 0:
+			if operation.RequestBody.Required {
+				errs = append(errs, validationError{Field: "body", Message: "is required"})
+			}
+		case hasJSON && content.Schema != nil:
+			var value interface{}
+			if err := json.Unmarshal(body, &value); err != nil {
+				errs = append(errs, validationError{Field: "body", Message: "must be valid JSON"})
+			} else {
+				errs = append(errs, v.validateValue("", value, content.Schema)...)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Request validation failed",
+			"details": errs,
+		})
+		return
+	}
+
+	c.Next()
+}
+
+// resolve follows a $ref to a component schema
+func (v *requestValidator) resolve(schema *openAPISchema) *openAPISchema {
+	for schema != nil && schema.Ref != "" {
+		schema = v.schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+// validateQueryValue validates a raw query string against a schema
+func (v *requestValidator) validateQueryValue(field, raw string, schema *openAPISchema) []validationError {
+	if schema == nil {
+		return nil
+	}
+
+	var value interface{} = raw
+	switch schema.Type {
+	case "integer":
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return []validationError{{Field: field, Message: "must be an integer"}}
+		}
+		value = float64(n)
+	case "number":
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return []validationError{{Field: field, Message: "must be a number"}}
+		}
+		value = n
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return []validationError{{Field: field, Message: "must be true or false"}}
+		}
+		value = b
+	}
+
+	return v.validateValue(field, value, schema)
+}
+
+// validateValue validates a decoded JSON value against a schema
+func (v *requestValidator) validateValue(field string, value interface{}, schema *openAPISchema) []validationError {
+	schema = v.resolve(schema)
+	if schema == nil || value == nil {
+		return nil
+	}
+
+	name := field
+	if name == "" {
+		name = "body"
+	}
+	fail := func(message string) []validationError {
+		return []validationError{{Field: name, Message: message}}
+	}
+
+	var errs []validationError
+	for _, part := range schema.AllOf {
+		errs = append(errs, v.validateValue(field, value, part)...)
+	}
+
+	if len(schema.Enum) > 0 {
+		allowed := make([]string, len(schema.Enum))
+		match := false
+		for i, option := range schema.Enum {
+			allowed[i] = fmt.Sprint(option)
+			if allowed[i] == fmt.Sprint(value) {
+				match = true
+			}
+		}
+		if !match {
+			return fail("must be one of: " + strings.Join(allowed, ", "))
+		}
+	}
+
+	switch schema.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return fail("must be a string")
+		}
+		if schema.MaxLength != nil && utf8.RuneCountInString(s) > *schema.MaxLength {
+			return fail(fmt.Sprintf("must be at most %d characters", *schema.MaxLength))
+		}
+		if schema.Pattern != "" {
+			if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(s) {
+				return fail("has an invalid format")
+			}
+		}
+		switch schema.Format {
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339, s); err != nil {
+				return fail("must be an RFC 3339 date-time")
+			}
+		case "date":
+			if _, err := time.Parse("2006-01-02", s); err != nil {
+				return fail("must be a date (YYYY-MM-DD)")
+			}
+		case "email":
+			if _, err := mail.ParseAddress(s); err != nil {
+				return fail("must be a valid email address")
+			}
+		}
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok {
+			return fail("must be a " + schema.Type)
+		}
+		if schema.Type == "integer" && n != float64(int64(n)) {
+			return fail("must be an integer")
+		}
+		if schema.Minimum != nil && n < *schema.Minimum {
+			return fail(fmt.Sprintf("must be at least %v", *schema.Minimum))
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			return fail(fmt.Sprintf("must be at most %v", *schema.Maximum))
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fail("must be a boolean")
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fail("must be an array")
+		}
+		for i, item := range items {
+			errs = append(errs, v.validateValue(fmt.Sprintf("%s[%d]", name, i), item, schema.Items)...)
+		}
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fail("must be an object")
+		}
+		for _, required := range schema.Required {
+			if object[required] == nil {
+				errs = append(errs, validationError{Field: joinField(field, required), Message: "is required"})
+			}
+		}
+		for key, property := range schema.Properties {
+			if propertyValue, ok := object[key]; ok {
+				errs = append(errs, v.validateValue(joinField(field, key), propertyValue, property)...)
+			}
+		}
+	}
+
+	return errs
+}
+
+// joinField builds the dotted name of a nested field
+func joinField(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+	return parent + "." + field
+}
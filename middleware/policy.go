@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"gotodolist/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PolicyGate knows the latest version of each policy document and blocks
+// users who have not accepted them. Versions are cached per instance for a
+// short time, so a new version is enforced everywhere within a minute.
+type PolicyGate struct {
+	collection *mongo.Collection
+
+	mu      sync.Mutex
+	latest  map[string]int
+	expires time.Time
+}
+
+// NewPolicyGate creates a policy gate reading the given policy collection
+func NewPolicyGate(collection *mongo.Collection) *PolicyGate {
+	return &PolicyGate{
+		collection: collection,
+	}
+}
+
+// LatestVersions returns the latest published version of each policy type
+func (pg *PolicyGate) LatestVersions(ctx context.Context) (map[string]int, error) {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+
+	if pg.latest != nil && time.Now().Before(pg.expires) {
+		return pg.latest, nil
+	}
+
+	cursor, err := pg.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$type",
+			"version": bson.M{"$max": "$version"},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Type    string `bson:"_id"`
+		Version int    `bson:"version"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	latest := map[string]int{}
+	for _, row := range rows {
+		latest[row.Type] = row.Version
+	}
+	pg.latest = latest
+	pg.expires = time.Now().Add(time.Minute)
+	return latest, nil
+}
+
+// Invalidate drops the cached versions, it is called when a version is published
+func (pg *PolicyGate) Invalidate() {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+	pg.latest = nil
+}
+
+// check answers 451 when the user has not accepted the latest policies.
+// Authentication and policy routes stay reachable so users can accept them.
+func (pg *PolicyGate) check(c *gin.Context, user models.User) bool {
+	if pg == nil {
+		return true
+	}
+	path := c.Request.URL.Path
+	if strings.HasPrefix(path, "/auth/") || strings.HasPrefix(path, "/policies") {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	latest, err := pg.LatestVersions(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Failed to check policy acceptance",
+		})
+		c.Abort()
+		return false
+	}
+
+	pending := []gin.H{}
+	for _, policyType := range models.PolicyTypes {
+		version, published := latest[policyType]
+		if published && user.AcceptedPolicies[policyType].Version < version {
+			pending = append(pending, gin.H{"type": policyType, "version": version})
+		}
+	}
+	if len(pending) == 0 {
+		return true
+	}
+
+	c.JSON(http.StatusUnavailableForLegalReasons, gin.H{
+		"success":  false,
+		"error":    "The latest policies must be accepted to continue",
+		"policies": pending,
+	})
+	c.Abort()
+	return false
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Recovery recovers from panics in handlers. It logs the stack trace, reports
+// the panic to Sentry when SENTRY_DSN is set, and answers with the standard
+// error envelope carrying the request ID.
+func Recovery() gin.HandlerFunc {
+	logger := utils.GetLogger().Named("http")
+
+	var reporter *utils.SentryReporter
+	if dsn := utils.GetEnv("SENTRY_DSN", ""); dsn != "" {
+		var err error
+		reporter, err = utils.NewSentryReporter(dsn)
+		if err != nil {
+			logger.Warning("Sentry reporting disabled: " + err.Error())
+		}
+	}
+
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			requestID := c.GetString("requestId")
+			stack := debug.Stack()
+			logger.Error(fmt.Sprintf("Panic recovered | %s | %s %s | %v\n%s",
+				requestID, c.Request.Method, c.Request.URL.Path, recovered, stack))
+
+			// The client is gone, so there is nobody to answer
+			if brokenConnection(recovered) {
+				c.Abort()
+				return
+			}
+
+			if reporter != nil {
+				reporter.Report(fmt.Sprint(recovered), stack, map[string]string{
+					"requestId": requestID,
+					"method":    c.Request.Method,
+					"route":     c.FullPath(),
+				})
+			}
+
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"success":   false,
+				"error":     "Internal server error",
+				"requestId": requestID,
+			})
+		}()
+
+		c.Next()
+	}
+}
+
+// brokenConnection checks whether a panic was caused by the client closing
+// the connection while the response was written
+func brokenConnection(recovered interface{}) bool {
+	err, ok := recovered.(error)
+	if !ok {
+		return false
+	}
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var syscallErr *os.SyscallError
+	if !errors.As(opErr, &syscallErr) {
+		return false
+	}
+	message := strings.ToLower(syscallErr.Error())
+	return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+}
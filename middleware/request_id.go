@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestID tags every request with an ID, reusing a sane incoming
+// X-Request-ID, and echoes it in the response headers
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if requestID == "" || len(requestID) > 128 {
+			requestID = utils.NewRequestID()
+		}
+
+		c.Set("requestId", requestID)
+		c.Header("X-Request-ID", requestID)
+		c.Next()
+	}
+}
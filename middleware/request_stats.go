@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// latencySamples is the number of recent latencies kept per route for its p95
+const latencySamples = 200
+
+// Requests served by this instance since startup
+var (
+	requestCount     atomic.Int64
+	clientErrorCount atomic.Int64
+	serverErrorCount atomic.Int64
+	slowRequestCount atomic.Int64
+)
+
+// routeLatency holds the slow request count and recent latencies of a route
+type routeLatency struct {
+	requests int64
+	slow     int64
+	samples  []time.Duration // Ring of the last latencySamples latencies
+	next     int
+}
+
+var (
+	routeLatenciesMu sync.Mutex
+	routeLatencies   = map[string]*routeLatency{} // Keyed by "METHOD /route/:param"
+)
+
+// RouteLatency is the latency summary of a route on this instance
+type RouteLatency struct {
+	Route    string  `json:"route"`
+	Requests int64   `json:"requests"`
+	Slow     int64   `json:"slow"`    // Requests over the slow request threshold
+	Samples  int     `json:"samples"` // Recent requests the p95 is computed from
+	P95Ms    float64 `json:"p95Ms"`
+}
+
+// RequestCounts returns the number of requests served since startup and
+// how many of them were answered with a 4xx and a 5xx status
+func RequestCounts() (requests, clientErrors, serverErrors int64) {
+	return requestCount.Load(), clientErrorCount.Load(), serverErrorCount.Load()
+}
+
+// SlowRequestCount returns the number of slow requests since startup
+func SlowRequestCount() int64 {
+	return slowRequestCount.Load()
+}
+
+// SlowRequestThreshold returns the latency above which requests are logged
+// as slow, read from SLOW_REQUEST_MS. Zero disables slow request logging.
+func SlowRequestThreshold() time.Duration {
+	ms, err := strconv.Atoi(utils.GetEnv("SLOW_REQUEST_MS", "1000"))
+	if err != nil || ms < 0 {
+		ms = 1000
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// RouteLatencies returns the latency summary of every route served since
+// startup, slowest p95 first
+func RouteLatencies() []RouteLatency {
+	routeLatenciesMu.Lock()
+	defer routeLatenciesMu.Unlock()
+
+	routes := make([]RouteLatency, 0, len(routeLatencies))
+	for route, stats := range routeLatencies {
+		samples := append([]time.Duration{}, stats.samples...)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		p95 := samples[(len(samples)*95+99)/100-1]
+		routes = append(routes, RouteLatency{
+			Route:    route,
+			Requests: stats.requests,
+			Slow:     stats.slow,
+			Samples:  len(samples),
+			P95Ms:    float64(p95.Microseconds()) / 1000,
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].P95Ms > routes[j].P95Ms })
+	return routes
+}
+
+// RequestStats counts the requests and error responses of the instance,
+// and logs the requests slower than SLOW_REQUEST_MS with their route. It
+// must run outside Recovery so recovered panics count as server errors.
+func RequestStats() gin.HandlerFunc {
+	logger := utils.GetLogger().Named("http")
+	threshold := SlowRequestThreshold()
+
+	return func(c *gin.Context) {
+		startTime := time.Now()
+		c.Next()
+		latency := time.Since(startTime)
+
+		requestCount.Add(1)
+		switch status := c.Writer.Status(); {
+		case status >= 500:
+			serverErrorCount.Add(1)
+		case status >= 400:
+			clientErrorCount.Add(1)
+		}
+
+		// Unmatched paths are left out so scanners cannot grow the map
+		if c.FullPath() == "" {
+			return
+		}
+		route := c.Request.Method + " " + c.FullPath()
+		slow := threshold > 0 && latency >= threshold
+		recordLatency(route, latency, slow)
+
+		if slow {
+			slowRequestCount.Add(1)
+			logger.With(
+				"requestId", c.GetString("requestId"),
+				"route", route,
+				"status", c.Writer.Status(),
+				"duration", latency.Round(time.Millisecond),
+			).Warning("Slow request")
+		}
+	}
+}
+
+// recordLatency adds a latency to the recent samples of a route
+func recordLatency(route string, latency time.Duration, slow bool) {
+	routeLatenciesMu.Lock()
+	defer routeLatenciesMu.Unlock()
+
+	stats, ok := routeLatencies[route]
+	if !ok {
+		stats = &routeLatency{}
+		routeLatencies[route] = stats
+	}
+	stats.requests++
+	if slow {
+		stats.slow++
+	}
+	if len(stats.samples) < latencySamples {
+		stats.samples = append(stats.samples, latency)
+	} else {
+		stats.samples[stats.next] = latency
+		stats.next = (stats.next + 1) % latencySamples
+	}
+}
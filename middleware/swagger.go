@@ -1,15 +1,79 @@
 package middleware
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
 )
 
-// Swagger serves the Swagger UI and the OpenAPI specification
+// openAPIJSON is the specification converted to JSON, built on first use
+type openAPIJSON struct {
+	once    sync.Once
+	body    []byte
+	etag    string
+	version string
+	err     error
+}
+
+// load converts swagger.yaml to JSON. Keys are sorted, so the document only
+// changes when the specification does.
+func (doc *openAPIJSON) load() {
+	data, err := os.ReadFile("./swagger.yaml")
+	if err != nil {
+		doc.err = err
+		return
+	}
+
+	var spec interface{}
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		doc.err = err
+		return
+	}
+	spec = jsonCompatible(spec)
+
+	doc.body, doc.err = json.Marshal(spec)
+	sum := sha256.Sum256(doc.body)
+	doc.etag = `"` + hex.EncodeToString(sum[:8]) + `"`
+	if info, ok := spec.(map[string]interface{})["info"].(map[string]interface{}); ok {
+		doc.version = fmt.Sprint(info["version"])
+	}
+}
+
+// jsonCompatible converts YAML maps with non-string keys, such as response
+// codes, to maps that can be encoded as JSON
+func jsonCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = jsonCompatible(item)
+		}
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = jsonCompatible(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonCompatible(item)
+		}
+	}
+	return value
+}
+
+// Swagger serves the Swagger UI and the OpenAPI specification, as YAML and
+// as JSON for client generators
 func Swagger() gin.HandlerFunc {
+	doc := &openAPIJSON{}
+
 	return func(c *gin.Context) {
 		if c.Request.URL.Path == "/api-docs" || c.Request.URL.Path == "/api-docs/" {
 			c.Redirect(http.StatusMovedPermanently, "/api-docs/index.html")
@@ -22,6 +86,26 @@ func Swagger() gin.HandlerFunc {
 			return
 		}
 
+		if c.Request.URL.Path == "/api-docs/openapi.json" {
+			doc.once.Do(doc.load)
+			if doc.err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"error":   "Failed to load the API specification",
+				})
+				return
+			}
+
+			c.Header("ETag", doc.etag)
+			c.Header("X-API-Version", doc.version)
+			if c.GetHeader("If-None-Match") == doc.etag {
+				c.Status(http.StatusNotModified)
+				return
+			}
+			c.Data(http.StatusOK, "application/json; charset=utf-8", doc.body)
+			return
+		}
+
 		if filepath.Ext(c.Request.URL.Path) == "" || c.Request.URL.Path == "/api-docs/index.html" {
 			// Serve the Swagger UI HTML
 			serveSwaggerUI(c)
@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"container/list"
+	"strconv"
+	"sync"
+	"time"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserCache is a per-instance LRU cache of user documents with a short TTL,
+// sparing Protect a database lookup on every request. Changes made through
+// the API invalidate the entry; other instances see them once the TTL expires.
+type UserCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	size    int
+	order   *list.List // Most recently used first
+	entries map[primitive.ObjectID]*list.Element
+}
+
+// cachedUser is an entry of the cache
+type cachedUser struct {
+	user    models.User
+	expires time.Time
+}
+
+// NewUserCache creates a cache configured by USER_CACHE_TTL (a duration,
+// 30s by default, 0 disables caching) and USER_CACHE_SIZE (10000 users)
+func NewUserCache() *UserCache {
+	ttl, err := time.ParseDuration(utils.GetEnv("USER_CACHE_TTL", "30s"))
+	if err != nil || ttl < 0 {
+		ttl = 30 * time.Second
+	}
+	size, err := strconv.Atoi(utils.GetEnv("USER_CACHE_SIZE", "10000"))
+	if err != nil || size < 1 {
+		size = 10000
+	}
+
+	return &UserCache{
+		ttl:     ttl,
+		size:    size,
+		order:   list.New(),
+		entries: map[primitive.ObjectID]*list.Element{},
+	}
+}
+
+// Get returns a cached user that has not expired
+func (uc *UserCache) Get(id primitive.ObjectID) (models.User, bool) {
+	if uc == nil || uc.ttl == 0 {
+		return models.User{}, false
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	element, ok := uc.entries[id]
+	if !ok {
+		return models.User{}, false
+	}
+	entry := element.Value.(*cachedUser)
+	if time.Now().After(entry.expires) {
+		uc.order.Remove(element)
+		delete(uc.entries, id)
+		return models.User{}, false
+	}
+
+	uc.order.MoveToFront(element)
+	return entry.user, true
+}
+
+// Set caches a user, evicting the least recently used one when full
+func (uc *UserCache) Set(user models.User) {
+	if uc == nil || uc.ttl == 0 {
+		return
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	entry := &cachedUser{user: user, expires: time.Now().Add(uc.ttl)}
+	if element, ok := uc.entries[user.ID]; ok {
+		element.Value = entry
+		uc.order.MoveToFront(element)
+		return
+	}
+
+	uc.entries[user.ID] = uc.order.PushFront(entry)
+	if uc.order.Len() > uc.size {
+		oldest := uc.order.Back()
+		uc.order.Remove(oldest)
+		delete(uc.entries, oldest.Value.(*cachedUser).user.ID)
+	}
+}
+
+// Invalidate drops a user, it must be called whenever a user document changes
+func (uc *UserCache) Invalidate(id primitive.ObjectID) {
+	if uc == nil {
+		return
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	if element, ok := uc.entries[id]; ok {
+		uc.order.Remove(element)
+		delete(uc.entries, id)
+	}
+}
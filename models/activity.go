@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Task activity types
+const (
+	ActivityPriorityEscalated  = "priority_escalated"
+	ActivityAutomationApplied  = "automation_applied"
+	ActivityDelegated          = "delegated"
+	ActivityDelegationDeclined = "delegation_declined"
+	ActivityDailySummary       = "daily_summary"
+)
+
+// TaskActivity is an entry of a task's activity history
+type TaskActivity struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Task      primitive.ObjectID  `bson:"task" json:"task"`
+	User      primitive.ObjectID  `bson:"user" json:"user"`                       // Owner of the task
+	Actor     *primitive.ObjectID `bson:"actor,omitempty" json:"actor,omitempty"` // Unset for automatic changes
+	Type      string              `bson:"type" json:"type"`
+	Field     string              `bson:"field,omitempty" json:"field,omitempty"`
+	From      interface{}         `bson:"from,omitempty" json:"from,omitempty"`
+	To        interface{}         `bson:"to,omitempty" json:"to,omitempty"`
+	Reason    string              `bson:"reason,omitempty" json:"reason,omitempty"`
+	Changes   []ActivityChange    `bson:"changes,omitempty" json:"changes,omitempty"` // Only set on daily summaries
+	CreatedAt time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// ActivityChange sums up the changes of a field over a day in a daily
+// summary: the value before the first and after the last of them
+type ActivityChange struct {
+	Field string      `bson:"field" json:"field"`
+	From  interface{} `bson:"from,omitempty" json:"from,omitempty"`
+	To    interface{} `bson:"to,omitempty" json:"to,omitempty"`
+	Count int         `bson:"count" json:"count"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Announcement severities
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Announcement is a message broadcast by admins to every client
+type Announcement struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title     string             `bson:"title" json:"title"`
+	Message   string             `bson:"message" json:"message"`
+	Severity  string             `bson:"severity" json:"severity"`
+	ExpiresAt *time.Time         `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"` // Shown until then, forever when unset
+	CreatedBy primitive.ObjectID `bson:"createdBy" json:"createdBy"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// NewAnnouncement creates a new announcement with default values
+func NewAnnouncement(title, message string, createdBy primitive.ObjectID) *Announcement {
+	now := time.Now()
+	return &Announcement{
+		Title:     title,
+		Message:   message,
+		Severity:  SeverityInfo,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsAnnouncementSeverity checks that a severity is known
+func IsAnnouncementSeverity(severity string) bool {
+	return severity == SeverityInfo || severity == SeverityWarning || severity == SeverityCritical
+}
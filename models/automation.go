@@ -0,0 +1,135 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Automation trigger events
+const (
+	TriggerTaskCreated   = "task_created"
+	TriggerTaskUpdated   = "task_updated"
+	TriggerTaskCompleted = "task_completed"
+	TriggerTaskDueSoon   = "task_due_soon"
+)
+
+// Automation action types
+const (
+	ActionSetPriority = "set_priority"
+	ActionSetDueDate  = "set_due_date"
+	ActionCreateTask  = "create_task"
+	ActionNotify      = "notify"
+)
+
+// AutomationTriggers lists every event an automation can run on
+var AutomationTriggers = []string{TriggerTaskCreated, TriggerTaskUpdated, TriggerTaskCompleted, TriggerTaskDueSoon}
+
+// AutomationActions lists every action an automation can take
+var AutomationActions = []string{ActionSetPriority, ActionSetDueDate, ActionCreateTask, ActionNotify}
+
+// Automation is a user-defined trigger → condition → action rule run on task events
+type Automation struct {
+	ID         primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	User       primitive.ObjectID   `bson:"user" json:"user"`
+	Name       string               `bson:"name" json:"name"`
+	Enabled    bool                 `bson:"enabled" json:"enabled"`
+	Trigger    AutomationTrigger    `bson:"trigger" json:"trigger"`
+	Conditions AutomationConditions `bson:"conditions" json:"conditions"`
+	Actions    []AutomationAction   `bson:"actions" json:"actions"`
+	RunCount   int                  `bson:"runCount" json:"runCount"`
+	LastRunAt  *time.Time           `bson:"lastRunAt,omitempty" json:"lastRunAt,omitempty"`
+	CreatedAt  time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time            `bson:"updatedAt" json:"updatedAt"`
+}
+
+// AutomationTrigger is the task event an automation runs on
+type AutomationTrigger struct {
+	Event       string `bson:"event" json:"event"`
+	WithinHours int    `bson:"withinHours,omitempty" json:"withinHours,omitempty"` // Due soon window of task_due_soon
+}
+
+// AutomationConditions must all match the task for the actions to run. Unset
+// conditions match every task.
+type AutomationConditions struct {
+	TitleContains string              `bson:"titleContains,omitempty" json:"titleContains,omitempty"` // Case insensitive, e.g. "#work"
+	Priority      string              `bson:"priority,omitempty" json:"priority,omitempty"`
+	Goal          *primitive.ObjectID `bson:"goal,omitempty" json:"goal,omitempty"`
+	HasDueDate    *bool               `bson:"hasDueDate,omitempty" json:"hasDueDate,omitempty"`
+	Tags          []string            `bson:"tags,omitempty" json:"tags,omitempty"` // The task must carry every tag
+}
+
+// AutomationAction is a change made when an automation runs
+type AutomationAction struct {
+	Type       string `bson:"type" json:"type"`
+	Priority   string `bson:"priority,omitempty" json:"priority,omitempty"`     // set_priority, create_task
+	DueInHours int    `bson:"dueInHours,omitempty" json:"dueInHours,omitempty"` // set_due_date, create_task
+	Title      string `bson:"title,omitempty" json:"title,omitempty"`           // create_task, notify; {{title}} is replaced by the task title
+	Message    string `bson:"message,omitempty" json:"message,omitempty"`       // notify
+}
+
+// Matches reports whether a task meets every condition
+func (c AutomationConditions) Matches(task *Task) bool {
+	if c.TitleContains != "" && !strings.Contains(strings.ToLower(task.Title), strings.ToLower(c.TitleContains)) {
+		return false
+	}
+	if c.Priority != "" && task.Priority != c.Priority {
+		return false
+	}
+	if c.Goal != nil && (task.Goal == nil || *task.Goal != *c.Goal) {
+		return false
+	}
+	if c.HasDueDate != nil && (task.DueDate != nil) != *c.HasDueDate {
+		return false
+	}
+	for _, tag := range c.Tags {
+		if !hasTag(task.Tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// AutomationRun records an automation run on a task
+type AutomationRun struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Automation primitive.ObjectID `bson:"automation" json:"automation"`
+	User       primitive.ObjectID `bson:"user" json:"user"`
+	Task       primitive.ObjectID `bson:"task" json:"task"`
+	Event      string             `bson:"event" json:"event"`
+	Key        string             `bson:"key,omitempty" json:"-"` // Unique per due date for task_due_soon, so it runs once
+	Actions    []string           `bson:"actions" json:"actions"` // Actions applied
+	Error      string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// IsAutomationTrigger checks that a trigger event is known
+func IsAutomationTrigger(event string) bool {
+	for _, known := range AutomationTriggers {
+		if known == event {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAutomationAction checks that an action type is known
+func IsAutomationAction(action string) bool {
+	for _, known := range AutomationActions {
+		if known == action {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTag reports whether tags holds tag
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BoardColumn is a column of a kanban board
+type BoardColumn struct {
+	Key      string `bson:"key" json:"key"`
+	Name     string `bson:"name" json:"name"`
+	WIPLimit int    `bson:"wipLimit,omitempty" json:"wipLimit,omitempty"` // Maximum open tasks in the column, 0 means unlimited
+}
+
+// Board holds the kanban columns of a user
+type Board struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	User      primitive.ObjectID `bson:"user" json:"user"`
+	Columns   []BoardColumn      `bson:"columns" json:"columns"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// NewBoard creates a new board with the default Backlog/Doing/Done columns
+func NewBoard(userID primitive.ObjectID) *Board {
+	now := time.Now()
+	return &Board{
+		User: userID,
+		Columns: []BoardColumn{
+			{Key: "backlog", Name: "Backlog"},
+			{Key: "doing", Name: "Doing"},
+			{Key: "done", Name: "Done"},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Column returns the column with the given key
+func (b *Board) Column(key string) (BoardColumn, bool) {
+	for _, column := range b.Columns {
+		if column.Key == key {
+			return column, true
+		}
+	}
+	return BoardColumn{}, false
+}
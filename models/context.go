@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Context is a GTD context such as @home or @errands, the place or tool a
+// task needs. Tasks refer to it by name.
+type Context struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name       string             `bson:"name" json:"name"` // Always starts with @
+	User       primitive.ObjectID `bson:"user" json:"user"`
+	TaskCounts TaskCounts         `bson:"taskCounts" json:"-"` // Open tasks are reported as openTasks
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// NewContext creates a new context
+func NewContext(name string, userID primitive.ObjectID) *Context {
+	now := time.Now()
+	return &Context{
+		Name:      name,
+		User:      userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// ContextResponse is a context together with its number of open tasks
+type ContextResponse struct {
+	Context
+	OpenTasks int64 `json:"openTasks"`
+}
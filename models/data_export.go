@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Data export statuses
+const (
+	ExportPending = "pending"
+	ExportReady   = "ready"
+	ExportFailed  = "failed"
+)
+
+// DataExport is a user's request for a copy of everything stored about them.
+// The archive is compiled by a background job and kept until ExpiresAt.
+type DataExport struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	User        primitive.ObjectID `bson:"user" json:"user"`
+	Status      string             `bson:"status" json:"status"`
+	Error       string             `bson:"error,omitempty" json:"error,omitempty"`
+	Archive     []byte             `bson:"archive,omitempty" json:"-"` // Zip file, only set once ready
+	Size        int                `bson:"size,omitempty" json:"size,omitempty"`
+	RequestID   string             `bson:"requestId,omitempty" json:"requestId,omitempty"` // Request that asked for it
+	RequestedAt time.Time          `bson:"requestedAt" json:"requestedAt"`
+	CompletedAt *time.Time         `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time         `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"` // Removed by a TTL index
+}
+
+// NewDataExport creates a pending export for a user
+func NewDataExport(userID primitive.ObjectID) *DataExport {
+	return &DataExport{
+		User:        userID,
+		Status:      ExportPending,
+		RequestedAt: time.Now(),
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Delegation is the pending offer of a task to another user, who becomes its
+// owner once they accept it
+type Delegation struct {
+	To        primitive.ObjectID `bson:"to" json:"to"`
+	Email     string             `bson:"email" json:"email"`
+	OfferedAt time.Time          `bson:"offeredAt" json:"offeredAt"`
+}
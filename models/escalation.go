@@ -0,0 +1,53 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// EscalationRule raises an open task to Priority once it is overdue by OverdueHours
+type EscalationRule struct {
+	OverdueHours int    `bson:"overdueHours" json:"overdueHours" binding:"min=1"`
+	Priority     string `bson:"priority" json:"priority" binding:"required"`
+}
+
+// EscalationSettings configures the automatic priority escalation of a user's overdue tasks
+type EscalationSettings struct {
+	Enabled bool             `bson:"enabled" json:"enabled"`
+	Rules   []EscalationRule `bson:"rules,omitempty" json:"rules"`
+}
+
+// DefaultEscalationRules apply when escalation is enabled without rules
+var DefaultEscalationRules = []EscalationRule{
+	{OverdueHours: 24, Priority: "medium"},
+	{OverdueHours: 72, Priority: "high"},
+}
+
+// Resolved returns the settings with the default rules applied, sorted by threshold
+func (s *EscalationSettings) Resolved() EscalationSettings {
+	resolved := EscalationSettings{Rules: DefaultEscalationRules}
+	if s == nil {
+		return resolved
+	}
+	resolved.Enabled = s.Enabled
+	if len(s.Rules) > 0 {
+		resolved.Rules = append([]EscalationRule{}, s.Rules...)
+		sort.Slice(resolved.Rules, func(i, j int) bool {
+			return resolved.Rules[i].OverdueHours < resolved.Rules[j].OverdueHours
+		})
+	}
+	return resolved
+}
+
+// Target returns the rule of the largest threshold a task overdue by the
+// given duration has passed, if any. The settings must be resolved.
+func (s EscalationSettings) Target(overdue time.Duration) (EscalationRule, bool) {
+	var target EscalationRule
+	found := false
+	for _, rule := range s.Rules {
+		if overdue >= time.Duration(rule.OverdueHours)*time.Hour {
+			target, found = rule, true
+		}
+	}
+	return target, found
+}
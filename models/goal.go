@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Goal represents a higher-level objective that groups tasks
+type Goal struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name" binding:"required"`
+	Description string             `bson:"description,omitempty" json:"description"`
+	TargetDate  *time.Time         `bson:"targetDate,omitempty" json:"targetDate"`
+	TaskCounts  TaskCounts         `bson:"taskCounts" json:"-"` // Reported as progress
+	User        primitive.ObjectID `bson:"user" json:"user"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// NewGoal creates a new goal with default values
+func NewGoal(name string, userID primitive.ObjectID) *Goal {
+	now := time.Now()
+	return &Goal{
+		Name:      name,
+		User:      userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// GoalProgress is the progress of a goal rolled up from its tasks
+type GoalProgress struct {
+	TotalTasks     int64   `json:"totalTasks"`
+	CompletedTasks int64   `json:"completedTasks"`
+	Percent        float64 `json:"percent"`
+	Overdue        bool    `json:"overdue"` // Target date passed before the goal was reached
+}
+
+// GoalResponse is a goal together with its progress
+type GoalResponse struct {
+	Goal
+	Progress GoalProgress `json:"progress"`
+}
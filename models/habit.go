@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Habit frequencies
+const (
+	HabitDaily  = "daily"
+	HabitWeekly = "weekly"
+)
+
+// Habit represents a repeatable habit tracked separately from tasks
+type Habit struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name" binding:"required"`
+	Description string             `bson:"description,omitempty" json:"description"`
+	Frequency   string             `bson:"frequency" json:"frequency"`     // daily or weekly
+	TargetCount int                `bson:"targetCount" json:"targetCount"` // Check-ins required per period
+	User        primitive.ObjectID `bson:"user" json:"user"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// NewHabit creates a new habit with default values
+func NewHabit(name string, userID primitive.ObjectID) *Habit {
+	now := time.Now()
+	return &Habit{
+		Name:        name,
+		Frequency:   HabitDaily,
+		TargetCount: 1,
+		User:        userID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// HabitCheckIn records that a habit was performed on a given day
+type HabitCheckIn struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Habit     primitive.ObjectID `bson:"habit" json:"habit"`
+	User      primitive.ObjectID `bson:"user" json:"user"`
+	Date      string             `bson:"date" json:"date"` // Day of the check-in (YYYY-MM-DD)
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+}
+
+// HabitStats summarizes the streaks of a habit
+type HabitStats struct {
+	Habit          primitive.ObjectID `json:"habit"`
+	Name           string             `json:"name"`
+	Frequency      string             `json:"frequency"`
+	CurrentStreak  int                `json:"currentStreak"`
+	LongestStreak  int                `json:"longestStreak"`
+	TotalCheckIns  int                `json:"totalCheckIns"`
+	CompletedToday bool               `json:"completedToday"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HealthSnapshot is the health of an instance at a point in time. Request,
+// slow request and slow query counts cover the period since the previous
+// snapshot.
+type HealthSnapshot struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Instance     string             `bson:"instance" json:"instance"`
+	DBUp         bool               `bson:"dbUp" json:"dbUp"`
+	DBLatencyMs  float64            `bson:"dbLatencyMs" json:"dbLatencyMs"` // Round trip of a ping, 0 when the database is down
+	Requests     int64              `bson:"requests" json:"requests"`
+	ClientErrors int64              `bson:"clientErrors" json:"clientErrors"` // 4xx responses
+	ServerErrors int64              `bson:"serverErrors" json:"serverErrors"` // 5xx responses
+	ErrorRate    float64            `bson:"errorRate" json:"errorRate"`       // Share of requests answered with a 5xx
+	SlowQueries  int64              `bson:"slowQueries" json:"slowQueries"`
+	SlowRequests int64              `bson:"slowRequests" json:"slowRequests"`
+	JobsHealthy  bool               `bson:"jobsHealthy" json:"jobsHealthy"`
+	CreatedAt    time.Time          `bson:"createdAt" json:"createdAt"`
+}
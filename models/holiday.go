@@ -0,0 +1,19 @@
+package models
+
+// CustomHoliday is a day off of the user's own, such as a local holiday or a
+// vacation day. Date is formatted 2006-01-02; a yearly holiday falls on the
+// same month and day every year.
+type CustomHoliday struct {
+	Date   string `bson:"date" json:"date" binding:"required"`
+	Name   string `bson:"name" json:"name" binding:"required,max=100"`
+	Yearly bool   `bson:"yearly,omitempty" json:"yearly"`
+}
+
+// HolidaySettings configures the days a user does not work, and whether
+// due dates falling on them move to the next working day
+type HolidaySettings struct {
+	Locale        string          `bson:"locale,omitempty" json:"locale"` // Built-in holiday calendar, empty for none
+	Custom        []CustomHoliday `bson:"custom,omitempty" json:"custom"`
+	SkipWeekends  bool            `bson:"skipWeekends" json:"skipWeekends"` // Days outside the work schedule are days off too
+	ShiftDueDates bool            `bson:"shiftDueDates" json:"shiftDueDates"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Incident statuses, in the order an incident usually goes through them
+const (
+	IncidentInvestigating = "investigating"
+	IncidentIdentified    = "identified"
+	IncidentMonitoring    = "monitoring"
+	IncidentResolved      = "resolved"
+)
+
+// Incident impacts
+const (
+	ImpactMinor  = "minor"  // Some features are slow or failing
+	ImpactMajor  = "major"  // The API is mostly unusable
+	ImpactNotice = "notice" // Planned maintenance or information only
+)
+
+// Incident is a disruption of the service posted by admins on the public
+// status page
+type Incident struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title      string             `bson:"title" json:"title"`
+	Message    string             `bson:"message" json:"message"` // Latest update
+	Status     string             `bson:"status" json:"status"`
+	Impact     string             `bson:"impact" json:"impact"`
+	ResolvedAt *time.Time         `bson:"resolvedAt,omitempty" json:"resolvedAt,omitempty"`
+	CreatedBy  primitive.ObjectID `bson:"createdBy" json:"-"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// NewIncident creates a new incident being investigated
+func NewIncident(title, message string, createdBy primitive.ObjectID) *Incident {
+	now := time.Now()
+	return &Incident{
+		Title:     title,
+		Message:   message,
+		Status:    IncidentInvestigating,
+		Impact:    ImpactMinor,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// IsIncidentStatus checks that a status is known
+func IsIncidentStatus(status string) bool {
+	return status == IncidentInvestigating || status == IncidentIdentified || status == IncidentMonitoring || status == IncidentResolved
+}
+
+// IsIncidentImpact checks that an impact is known
+func IsIncidentImpact(impact string) bool {
+	return impact == ImpactMinor || impact == ImpactMajor || impact == ImpactNotice
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskNote is a timestamped entry appended to a task, such as a progress note
+type TaskNote struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Task      primitive.ObjectID `bson:"task" json:"task"`
+	User      primitive.ObjectID `bson:"user" json:"user"`
+	Text      string             `bson:"text" json:"text"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt time.Time          `bson:"updatedAt" json:"updatedAt"`
+	Edited    bool               `bson:"edited,omitempty" json:"edited,omitempty"` // Text changed after the note was added
+}
+
+// NewTaskNote creates a new note on a task
+func NewTaskNote(taskID primitive.ObjectID, userID primitive.ObjectID, text string) *TaskNote {
+	now := time.Now()
+	return &TaskNote{
+		Task:      taskID,
+		User:      userID,
+		Text:      text,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
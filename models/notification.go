@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification event types
+const (
+	NotifyReminders   = "reminders"
+	NotifyDigests     = "digests"
+	NotifyMentions    = "mentions"
+	NotifyAssignments = "assignments"
+	NotifyShares      = "shares"
+	NotifyAlerts      = "alerts" // Quota warnings, and operational alerts for admins
+)
+
+// Notification channels
+const (
+	ChannelEmail = "email"
+	ChannelPush  = "push"
+	ChannelInApp = "inApp"
+)
+
+// NotificationEvents lists every event type a user can configure
+var NotificationEvents = []string{NotifyReminders, NotifyDigests, NotifyMentions, NotifyAssignments, NotifyShares, NotifyAlerts}
+
+// NotificationChannels lists every channel a notification can be sent through
+var NotificationChannels = []string{ChannelEmail, ChannelPush, ChannelInApp}
+
+// NotificationPreferences maps an event type to the channels it is enabled on.
+// Missing entries are enabled by default.
+type NotificationPreferences map[string]map[string]bool
+
+// Enabled reports whether an event should be delivered through a channel
+func (p NotificationPreferences) Enabled(event, channel string) bool {
+	if enabled, ok := p[event][channel]; ok {
+		return enabled
+	}
+	return true
+}
+
+// Resolved returns the full event/channel matrix with defaults applied
+func (p NotificationPreferences) Resolved() NotificationPreferences {
+	resolved := NotificationPreferences{}
+	for _, event := range NotificationEvents {
+		resolved[event] = map[string]bool{}
+		for _, channel := range NotificationChannels {
+			resolved[event][channel] = p.Enabled(event, channel)
+		}
+	}
+	return resolved
+}
+
+// IsNotificationEvent checks that an event type is known
+func IsNotificationEvent(event string) bool {
+	for _, known := range NotificationEvents {
+		if known == event {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNotificationChannel checks that a channel is known
+func IsNotificationChannel(channel string) bool {
+	for _, known := range NotificationChannels {
+		if known == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification is a message delivered to a user through the in-app channel
+type Notification struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	User      primitive.ObjectID  `bson:"user" json:"user"`
+	Event     string              `bson:"event" json:"event"`
+	Title     string              `bson:"title" json:"title"`
+	Message   string              `bson:"message" json:"message"`
+	Task      *primitive.ObjectID `bson:"task,omitempty" json:"task,omitempty"`           // Task the notification is about
+	RequestID string              `bson:"requestId,omitempty" json:"requestId,omitempty"` // Request or job run that sent it
+	ReadAt    *time.Time          `bson:"readAt,omitempty" json:"readAt,omitempty"`
+	CreatedAt time.Time           `bson:"createdAt" json:"createdAt"`
+}
+
+// NewNotification creates an unread notification for a user
+func NewNotification(userID primitive.ObjectID, event, title, message string) *Notification {
+	return &Notification{
+		User:      userID,
+		Event:     event,
+		Title:     title,
+		Message:   message,
+		CreatedAt: time.Now(),
+	}
+}
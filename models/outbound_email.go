@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Outbound email statuses, an email is removed once sent
+const (
+	EmailPending = "pending"
+	EmailFailed  = "failed"
+)
+
+// OutboundEmail is an email waiting in the outbox to be sent, or one that
+// could not be sent. Failed emails lose their body and are removed by a
+// TTL index at ExpiresAt.
+type OutboundEmail struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	To            string             `bson:"to" json:"to"`
+	Template      string             `bson:"template" json:"template"` // Name of the email, such as password_reset
+	Subject       string             `bson:"subject" json:"subject"`
+	Text          string             `bson:"text,omitempty" json:"-"`
+	HTML          string             `bson:"html,omitempty" json:"-"`
+	Status        string             `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	LastError     string             `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	NextAttemptAt time.Time          `bson:"nextAttemptAt" json:"nextAttemptAt"`
+	ExpiresAt     *time.Time         `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+}
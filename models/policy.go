@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Policy document types
+const (
+	PolicyTerms   = "terms"
+	PolicyPrivacy = "privacy"
+)
+
+// PolicyTypes lists every policy users may have to accept
+var PolicyTypes = []string{PolicyTerms, PolicyPrivacy}
+
+// Policy is one published version of a policy document. Versions of a type
+// are numbered from 1 and users must accept the latest one.
+type Policy struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Type        string             `bson:"type" json:"type"`
+	Version     int                `bson:"version" json:"version"`
+	Title       string             `bson:"title" json:"title"`
+	Content     string             `bson:"content" json:"content"`
+	PublishedBy primitive.ObjectID `bson:"publishedBy" json:"publishedBy"`
+	PublishedAt time.Time          `bson:"publishedAt" json:"publishedAt"`
+}
+
+// PolicyAcceptance records which version of a policy a user accepted
+type PolicyAcceptance struct {
+	Version    int       `bson:"version" json:"version"`
+	AcceptedAt time.Time `bson:"acceptedAt" json:"acceptedAt"`
+}
+
+// IsPolicyType checks that a policy type is known
+func IsPolicyType(policyType string) bool {
+	for _, known := range PolicyTypes {
+		if known == policyType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Project deletion modes, deciding what happens to the tasks of the project
+const (
+	ProjectDeleteOrphan  = "orphan"  // Tasks are kept and lose their project
+	ProjectDeleteCascade = "cascade" // Tasks are deleted with the project
+)
+
+// Project is a list that groups tasks, such as "Home renovation" or
+// "Q3 launch". Tasks refer to it by ID.
+type Project struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name       string             `bson:"name" json:"name"`
+	Color      string             `bson:"color,omitempty" json:"color,omitempty"`       // Hex color such as #ff8800
+	Icon       string             `bson:"icon,omitempty" json:"icon,omitempty"`         // Emoji or icon name
+	Sections   []ProjectSection   `bson:"sections,omitempty" json:"sections,omitempty"` // In display order
+	Defaults   *ProjectDefaults   `bson:"defaults,omitempty" json:"defaults,omitempty"` // Applied to the tasks created in it
+	User       primitive.ObjectID `bson:"user" json:"user"`                             // Owner
+	TaskCounts TaskCounts         `bson:"taskCounts" json:"taskCounts"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	UpdatedAt  time.Time          `bson:"updatedAt" json:"updatedAt"`
+}
+
+// ProjectDefaults are the settings a task created in a project starts with,
+// unless the task sets them itself
+type ProjectDefaults struct {
+	Priority  string           `bson:"priority,omitempty" json:"priority,omitempty"`
+	Tags      []string         `bson:"tags,omitempty" json:"tags,omitempty"`
+	Reminders []int            `bson:"reminders,omitempty" json:"reminders,omitempty"` // Minutes before the due date
+	Assignee  *ProjectAssignee `bson:"assignee,omitempty" json:"assignee,omitempty"`   // Offered the new tasks
+}
+
+// ProjectAssignee is the user the tasks of a project are offered to when
+// they are created
+type ProjectAssignee struct {
+	User  primitive.ObjectID `bson:"user" json:"user"`
+	Email string             `bson:"email" json:"email"`
+}
+
+// ProjectSection is an ordered section of a project, such as "This week"
+// or "Backlog", that its tasks can be filed under
+type ProjectSection struct {
+	ID   primitive.ObjectID `bson:"_id" json:"id"`
+	Name string             `bson:"name" json:"name"`
+}
+
+// NewProject creates a new project
+func NewProject(name string, userID primitive.ObjectID) *Project {
+	now := time.Now()
+	return &Project{
+		Name:      name,
+		User:      userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Section returns the section with the given ID
+func (p *Project) Section(id primitive.ObjectID) (ProjectSection, bool) {
+	for _, section := range p.Sections {
+		if section.ID == id {
+			return section, true
+		}
+	}
+	return ProjectSection{}, false
+}
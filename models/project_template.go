@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TemplateTask is a task created when a project template is used
+type TemplateTask struct {
+	Title       string   `bson:"title" json:"title"`
+	Description string   `bson:"description,omitempty" json:"description,omitempty"`
+	Priority    string   `bson:"priority,omitempty" json:"priority,omitempty"`
+	Estimate    int      `bson:"estimate,omitempty" json:"estimate,omitempty"`
+	Section     string   `bson:"section,omitempty" json:"section,omitempty"` // Name of one of the template's sections
+	Context     string   `bson:"context,omitempty" json:"context,omitempty"` // Kept when the user has this context
+	Tags        []string `bson:"tags,omitempty" json:"tags,omitempty"`
+	Color       string   `bson:"color,omitempty" json:"color,omitempty"`
+	Icon        string   `bson:"icon,omitempty" json:"icon,omitempty"`
+	DueInDays   *int     `bson:"dueInDays,omitempty" json:"dueInDays,omitempty"` // Due at the end of that day after the project is created
+}
+
+// ProjectTemplate is a project with its sections and tasks saved to be
+// created again. Starter templates ship with the API and are identified by
+// their Key.
+type ProjectTemplate struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Key         string             `bson:"-" json:"key,omitempty"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description,omitempty" json:"description,omitempty"`
+	Color       string             `bson:"color,omitempty" json:"color,omitempty"`
+	Icon        string             `bson:"icon,omitempty" json:"icon,omitempty"`
+	Sections    []string           `bson:"sections,omitempty" json:"sections,omitempty"` // Section names in display order
+	Tasks       []TemplateTask     `bson:"tasks" json:"tasks"`
+	User        primitive.ObjectID `bson:"user" json:"-"`
+	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt,omitempty"`
+}
+
+// days returns a pointer to a number of days, for the starter templates
+func days(n int) *int {
+	return &n
+}
+
+// StarterTemplates are offered to every user when creating a project
+var StarterTemplates = []ProjectTemplate{
+	{
+		Key:         "moving-house",
+		Name:        "Moving house",
+		Description: "Everything to plan for a move, from notice to unpacking",
+		Sections:    []string{"Before the move", "Packing", "After the move"},
+		Tasks: []TemplateTask{
+			{Title: "Give notice to the landlord", Priority: "high", Section: "Before the move", DueInDays: days(1)},
+			{Title: "Get quotes from moving companies", Estimate: 60, Section: "Before the move", Tags: []string{"calls"}, DueInDays: days(7)},
+			{Title: "Book the movers", Priority: "high", Section: "Before the move", Tags: []string{"calls"}, DueInDays: days(10)},
+			{Title: "Declutter and donate what is not moving", Estimate: 240, Section: "Packing", DueInDays: days(14)},
+			{Title: "Buy boxes and packing supplies", Estimate: 60, Section: "Packing", Tags: []string{"errands"}, DueInDays: days(14)},
+			{Title: "Update the address with the bank, employer and post office", Estimate: 60, Section: "Before the move", Tags: []string{"admin"}, DueInDays: days(21)},
+			{Title: "Transfer utilities and internet", Section: "Before the move", Tags: []string{"admin", "calls"}, DueInDays: days(21)},
+			{Title: "Pack room by room, labelling each box", Estimate: 600, Section: "Packing", DueInDays: days(27)},
+			{Title: "Clean the old place and hand over the keys", Priority: "high", Estimate: 180, Section: "After the move", DueInDays: days(30)},
+			{Title: "Unpack the essentials", Estimate: 180, Section: "After the move", DueInDays: days(31)},
+		},
+	},
+	{
+		Key:         "sprint-board",
+		Name:        "Sprint board",
+		Description: "A two-week sprint from planning to retrospective",
+		Sections:    []string{"Backlog", "This sprint", "Ceremonies"},
+		Tasks: []TemplateTask{
+			{Title: "Sprint planning", Priority: "high", Estimate: 120, Section: "Ceremonies", Tags: []string{"meeting"}, DueInDays: days(0)},
+			{Title: "Refine the backlog for the next sprint", Estimate: 60, Section: "Backlog", DueInDays: days(7)},
+			{Title: "Mid-sprint check-in", Estimate: 30, Section: "Ceremonies", Tags: []string{"meeting"}, DueInDays: days(7)},
+			{Title: "Code freeze and final testing", Priority: "high", Section: "This sprint", Tags: []string{"release"}, DueInDays: days(12)},
+			{Title: "Sprint review and demo", Estimate: 60, Section: "Ceremonies", Tags: []string{"meeting"}, DueInDays: days(13)},
+			{Title: "Sprint retrospective", Estimate: 60, Section: "Ceremonies", Tags: []string{"meeting"}, DueInDays: days(13)},
+		},
+	},
+	{
+		Key:         "weekly-review",
+		Name:        "Weekly review",
+		Description: "Get clear, get current and get creative once a week",
+		Sections:    []string{"Get clear", "Get current"},
+		Tasks: []TemplateTask{
+			{Title: "Empty the inbox and process loose notes", Estimate: 20, Section: "Get clear", DueInDays: days(0)},
+			{Title: "Review the calendar for last and next week", Estimate: 15, Section: "Get current", DueInDays: days(0)},
+			{Title: "Review open tasks and waiting-for items", Estimate: 20, Section: "Get current", DueInDays: days(0)},
+			{Title: "Review goals and pick next actions", Estimate: 20, Section: "Get current", DueInDays: days(0)},
+		},
+	},
+}
+
+// StarterTemplate returns the starter template with the given key
+func StarterTemplate(key string) (ProjectTemplate, bool) {
+	for _, template := range StarterTemplates {
+		if template.Key == key {
+			return template, true
+		}
+	}
+	return ProjectTemplate{}, false
+}
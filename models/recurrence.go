@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Recurrence makes a task one occurrence of a repeating series. The next
+// occurrence is created once this one is completed or its due date passes.
+type Recurrence struct {
+	Rule       string                `bson:"rule" json:"rule"`                                 // Canonical RRULE such as FREQ=WEEKLY;BYDAY=MO
+	Series     primitive.ObjectID    `bson:"series" json:"series"`                             // ID of the first occurrence
+	Start      time.Time             `bson:"start" json:"start"`                               // Due date of the first occurrence
+	Occurrence int                   `bson:"occurrence" json:"occurrence"`                     // Number of this occurrence, from 1
+	Next       *primitive.ObjectID   `bson:"next,omitempty" json:"next,omitempty"`             // Occurrence created after this one
+	Last       bool                  `bson:"last,omitempty" json:"last,omitempty"`             // The series has no occurrence after this one, by its rule or ended
+	Paused     bool                  `bson:"paused,omitempty" json:"paused,omitempty"`         // No occurrence is created until the series resumes
+	Exceptions []RecurrenceException `bson:"exceptions,omitempty" json:"exceptions,omitempty"` // Occurrences skipped or moved, the rule left as it is
+	// Series values of the fields edited on this occurrence only, by field
+	// name, nil for fields the series leaves unset. The next occurrence
+	// gets them back.
+	Overrides map[string]interface{} `bson:"overrides,omitempty" json:"-"`
+}
+
+// RecurrenceException changes a single occurrence of a series, found by the
+// due date the rule gives it: the occurrence is skipped, or created due at
+// MovedTo when it is set
+type RecurrenceException struct {
+	Date    time.Time  `bson:"date" json:"date"`
+	MovedTo *time.Time `bson:"movedTo,omitempty" json:"movedTo,omitempty"`
+}
+
+// Exception returns the exception of the occurrence due at date
+func (r *Recurrence) Exception(date time.Time) (RecurrenceException, bool) {
+	for _, exception := range r.Exceptions {
+		if exception.Date.Equal(date) {
+			return exception, true
+		}
+	}
+	return RecurrenceException{}, false
+}
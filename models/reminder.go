@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reminder notifies the owner of a task at a set time, or a number of
+// minutes before the task is due
+type Reminder struct {
+	ID      primitive.ObjectID `bson:"_id" json:"id"`
+	At      *time.Time         `bson:"at,omitempty" json:"at,omitempty"`
+	Before  int                `bson:"before,omitempty" json:"before,omitempty"`   // Minutes before the due date
+	SentFor *time.Time         `bson:"sentFor,omitempty" json:"sentFor,omitempty"` // Time the reminder was last sent for
+}
+
+// Time returns when the reminder is due, and false for a reminder relative
+// to the due date of a task without one
+func (r Reminder) Time(dueDate *time.Time) (time.Time, bool) {
+	if r.At != nil {
+		return *r.At, true
+	}
+	if dueDate == nil {
+		return time.Time{}, false
+	}
+	return dueDate.Add(-time.Duration(r.Before) * time.Minute), true
+}
+
+// Pending reports whether the reminder is due at now and was not sent for
+// its current time yet. A reminder sent for an earlier due date is pending
+// again once the due date moved.
+func (r Reminder) Pending(dueDate *time.Time, now time.Time) (time.Time, bool) {
+	at, ok := r.Time(dueDate)
+	if !ok || at.After(now) || (r.SentFor != nil && r.SentFor.Equal(at)) {
+		return at, false
+	}
+	return at, true
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// JSON Schema fragments shared by the collection validators
+var (
+	schemaObjectID = bson.M{"bsonType": "objectId"}
+	schemaString   = bson.M{"bsonType": "string"}
+	schemaBool     = bson.M{"bsonType": "bool"}
+	schemaDate     = bson.M{"bsonType": "date"}
+	schemaInt      = bson.M{"bsonType": bson.A{"int", "long"}}
+	schemaObject   = bson.M{"bsonType": "object"}
+)
+
+// TaskSchema is the $jsonSchema validator of the tasks collection. It
+// mirrors Task: the fields always written are required and known fields
+// must have their type, while unknown fields are allowed so that documents
+// written by older or newer versions stay valid.
+func TaskSchema() bson.M {
+	return bson.M{
+		"bsonType": "object",
+		"required": bson.A{"title", "completed", "priority", "user", "createdAt", "updatedAt"},
+		"properties": bson.M{
+			"_id":               schemaObjectID,
+			"title":             bson.M{"bsonType": "string", "minLength": 1},
+			"description":       schemaString,
+			"completed":         schemaBool,
+			"completedAt":       schemaDate,
+			"startDate":         schemaDate,
+			"dueDate":           schemaDate,
+			"dependsOn":         bson.M{"bsonType": "array", "items": schemaObjectID},
+			"priority":          bson.M{"enum": bson.A{"low", "medium", "high"}},
+			"estimate":          bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+			"goal":              schemaObjectID,
+			"section":           schemaObjectID,
+			"context":           schemaString,
+			"project":           schemaObjectID,
+			"color":             schemaString,
+			"icon":              schemaString,
+			"tags":              bson.M{"bsonType": "array", "items": schemaString},
+			"column":            schemaString,
+			"inbox":             schemaBool,
+			"snoozedUntil":      schemaDate,
+			"reminders":         bson.M{"bsonType": "array", "items": schemaObject},
+			"delegation":        schemaObject,
+			"subtasks":          bson.M{"bsonType": "array", "items": schemaObject},
+			"autoComplete":      schemaBool,
+			"completedSubtasks": schemaInt,
+			"totalSubtasks":     schemaInt,
+			"recurrence":        schemaObject,
+			"position":          schemaInt,
+			"user":              schemaObjectID,
+			"createdAt":         schemaDate,
+			"updatedAt":         schemaDate,
+		},
+	}
+}
+
+// UserSchema is the $jsonSchema validator of the users collection, which
+// mirrors User like TaskSchema mirrors Task
+func UserSchema() bson.M {
+	return bson.M{
+		"bsonType": "object",
+		"required": bson.A{"username", "email", "password", "createdAt", "updatedAt"},
+		"properties": bson.M{
+			"_id":                     schemaObjectID,
+			"username":                bson.M{"bsonType": "string", "minLength": 1},
+			"email":                   bson.M{"bsonType": "string", "pattern": `^[^@\s]+@[^@\s]+$`},
+			"password":                bson.M{"bsonType": "string", "minLength": 1},
+			"sessions":                bson.M{"bsonType": "array", "items": schemaObject},
+			"refreshToken":            schemaString,
+			"refreshTokenExpire":      schemaDate,
+			"passwordReset":           schemaObject,
+			"emailVerification":       schemaObject,
+			"emailChange":             schemaObject,
+			"language":                schemaString,
+			"notificationPreferences": schemaObject,
+			"role":                    bson.M{"enum": bson.A{RoleUser, RoleAdmin}},
+			"tokenVersion":            schemaInt,
+			"deactivatedAt":           schemaDate,
+			"lastLoginAt":             schemaDate,
+			"acceptedPolicies":        schemaObject,
+			"escalation":              schemaObject,
+			"quotaWarnings":           schemaObject,
+			"holidays":                schemaObject,
+			"workSchedule":            schemaObject,
+			"createdAt":               schemaDate,
+			"updatedAt":               schemaDate,
+		},
+	}
+}
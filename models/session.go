@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is a login of a user on one device, kept alive by refreshing its
+// refresh token. Access tokens name their session, which must still exist.
+type Session struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	TokenHash  string             `bson:"tokenHash" json:"-"` // Hash of the current refresh token
+	UserAgent  string             `bson:"userAgent,omitempty" json:"userAgent,omitempty"`
+	CreatedAt  time.Time          `bson:"createdAt" json:"createdAt"`
+	LastUsedAt time.Time          `bson:"lastUsedAt" json:"lastUsedAt"` // Last login or token refresh
+	ExpiresAt  time.Time          `bson:"expiresAt" json:"expiresAt"`   // When the refresh token expires
+}
+
+// Session returns the session of a user with the given ID, if it has not
+// expired
+func (u *User) Session(id primitive.ObjectID, now time.Time) (Session, bool) {
+	for _, session := range u.Sessions {
+		if session.ID == id && session.ExpiresAt.After(now) {
+			return session, true
+		}
+	}
+	return Session{}, false
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tag stores the task counts and appearance of one of a user's tags. Tags
+// are set on the tasks themselves, a tag document is created with the first
+// task carrying the tag and kept after the last one, with its appearance.
+type Tag struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	User       primitive.ObjectID `bson:"user" json:"-"`
+	Name       string             `bson:"name" json:"tag"`
+	Color      string             `bson:"color,omitempty" json:"color,omitempty"` // Hex color such as #ff8800
+	Icon       string             `bson:"icon,omitempty" json:"icon,omitempty"`   // Emoji or icon name
+	TaskCounts TaskCounts         `bson:"taskCounts" json:"-"`                    // Reported as count and open
+}
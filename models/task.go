@@ -8,15 +8,40 @@ import (
 
 // Task represents a task in the todo list
 type Task struct {
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Title       string             `bson:"title" json:"title" binding:"required"`
-	Description string             `bson:"description,omitempty" json:"description"`
-	Completed   bool               `bson:"completed" json:"completed"`
-	DueDate     *time.Time         `bson:"dueDate,omitempty" json:"dueDate"`
-	Priority    string             `bson:"priority" json:"priority"`
-	User        primitive.ObjectID `bson:"user" json:"user"`
-	CreatedAt   time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt   time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Title        string               `bson:"title" json:"title" binding:"required"`
+	Description  string               `bson:"description,omitempty" json:"description"`
+	Completed    bool                 `bson:"completed" json:"completed"`
+	CompletedAt  *time.Time           `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+	StartDate    *time.Time           `bson:"startDate,omitempty" json:"startDate"`
+	DueDate      *time.Time           `bson:"dueDate,omitempty" json:"dueDate"`
+	DependsOn    []primitive.ObjectID `bson:"dependsOn,omitempty" json:"dependsOn,omitempty"` // Tasks that must finish first
+	Priority     string               `bson:"priority" json:"priority"`
+	Estimate     int                  `bson:"estimate,omitempty" json:"estimate,omitempty"` // Estimated effort in minutes
+	Goal         *primitive.ObjectID  `bson:"goal,omitempty" json:"goal,omitempty"`
+	Section      *primitive.ObjectID  `bson:"section,omitempty" json:"section,omitempty"`           // Section of the project
+	Context      string               `bson:"context,omitempty" json:"context,omitempty"`           // GTD context such as @home
+	Project      *primitive.ObjectID  `bson:"project,omitempty" json:"project,omitempty"`           // Project the task is listed in
+	Color        string               `bson:"color,omitempty" json:"color,omitempty"`               // Hex color such as #ff8800
+	Icon         string               `bson:"icon,omitempty" json:"icon,omitempty"`                 // Emoji or icon name
+	Tags         []string             `bson:"tags,omitempty" json:"tags,omitempty"`                 // Lowercase labels such as work
+	Column       string               `bson:"column,omitempty" json:"column,omitempty"`             // Kanban board column key
+	Inbox        bool                 `bson:"inbox,omitempty" json:"inbox,omitempty"`               // Captured and not triaged yet
+	SnoozedUntil *time.Time           `bson:"snoozedUntil,omitempty" json:"snoozedUntil,omitempty"` // Hidden from default views until then
+	Reminders    []Reminder           `bson:"reminders,omitempty" json:"reminders,omitempty"`       // Sent as notifications
+	Delegation   *Delegation          `bson:"delegation,omitempty" json:"delegation,omitempty"`     // Offered to another user, pending until accepted
+	Subtasks     []Subtask            `bson:"subtasks,omitempty" json:"subtasks,omitempty"`         // Checklist, in display order
+	AutoComplete bool                 `bson:"autoComplete,omitempty" json:"autoComplete,omitempty"` // Completed once all its subtasks are
+	Recurrence   *Recurrence          `bson:"recurrence,omitempty" json:"recurrence,omitempty"`     // Repeating series the task is an occurrence of
+	Position     int                  `bson:"position" json:"position"`                             // Order within the board column
+	User         primitive.ObjectID   `bson:"user" json:"user"`
+	CreatedAt    time.Time            `bson:"createdAt" json:"createdAt"`
+	UpdatedAt    time.Time            `bson:"updatedAt" json:"updatedAt"`
+
+	// Counters of the subtasks, kept up to date by every subtask write so
+	// that lists can show progress without the checklist
+	CompletedSubtasks int `bson:"completedSubtasks,omitempty" json:"completedSubtasks"`
+	TotalSubtasks     int `bson:"totalSubtasks,omitempty" json:"totalSubtasks"`
 }
 
 // NewTask creates a new task with default values
@@ -31,3 +56,52 @@ func NewTask(title string, userID primitive.ObjectID) *Task {
 		UpdatedAt: now,
 	}
 }
+
+// Subtask is an item of the checklist of a task
+type Subtask struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Title       string             `bson:"title" json:"title"`
+	Completed   bool               `bson:"completed" json:"completed"`
+	CompletedAt *time.Time         `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+}
+
+// SubtasksCompleted reports whether the task has subtasks and all of them
+// are completed
+func (t *Task) SubtasksCompleted() bool {
+	if len(t.Subtasks) == 0 {
+		return false
+	}
+	for _, subtask := range t.Subtasks {
+		if !subtask.Completed {
+			return false
+		}
+	}
+	return true
+}
+
+// CountSubtasks sets the subtask counters from the subtasks of the task
+func (t *Task) CountSubtasks() {
+	t.CompletedSubtasks, t.TotalSubtasks = 0, len(t.Subtasks)
+	for _, subtask := range t.Subtasks {
+		if subtask.Completed {
+			t.CompletedSubtasks++
+		}
+	}
+}
+
+// Subtask returns the subtask with the given ID
+func (t *Task) Subtask(id primitive.ObjectID) (Subtask, bool) {
+	for _, subtask := range t.Subtasks {
+		if subtask.ID == id {
+			return subtask, true
+		}
+	}
+	return Subtask{}, false
+}
+
+// TaskCounts are the open and completed tasks of a goal, context, project
+// or tag, stored on it and recounted as its tasks are written
+type TaskCounts struct {
+	Open      int64 `bson:"open" json:"open"`
+	Completed int64 `bson:"completed" json:"completed"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskVersion is a snapshot of a task as it was before an edit
+type TaskVersion struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Task      primitive.ObjectID `bson:"task" json:"task"`
+	User      primitive.ObjectID `bson:"user" json:"user"`
+	Version   int                `bson:"version" json:"version"` // Increases with each edit of the task
+	Snapshot  Task               `bson:"snapshot" json:"snapshot"`
+	CreatedAt time.Time          `bson:"createdAt" json:"createdAt"` // When the snapshot was replaced
+}
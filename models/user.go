@@ -6,16 +6,62 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // User represents a user in the system
 type User struct {
-	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Username           string             `bson:"username" json:"username" binding:"required"`
-	Email              string             `bson:"email" json:"email" binding:"required,email"`
-	Password           string             `bson:"password" json:"-"`                     // Password is never returned in JSON
-	RefreshToken       string             `bson:"refreshToken,omitempty" json:"-"`       // Refresh token hash stored in DB
-	RefreshTokenExpire *time.Time         `bson:"refreshTokenExpire,omitempty" json:"-"` // When the refresh token expires
-	CreatedAt          time.Time          `bson:"createdAt" json:"createdAt"`
-	UpdatedAt          time.Time          `bson:"updatedAt" json:"updatedAt"`
+	ID                 primitive.ObjectID          `bson:"_id,omitempty" json:"id"`
+	Username           string                      `bson:"username" json:"username" binding:"required"`
+	Email              string                      `bson:"email" json:"email" binding:"required,email"`
+	Password           string                      `bson:"password" json:"-"`                                      // Password is never returned in JSON
+	Sessions           []Session                   `bson:"sessions,omitempty" json:"-"`                            // Logins kept alive by their refresh tokens, oldest first
+	RefreshToken       string                      `bson:"refreshToken,omitempty" json:"-"`                        // Single refresh token hash of older versions, moved into Sessions on its next refresh
+	RefreshTokenExpire *time.Time                  `bson:"refreshTokenExpire,omitempty" json:"-"`                  // When the single refresh token expires
+	PasswordReset      *PasswordReset              `bson:"passwordReset,omitempty" json:"-"`                       // Pending reset of a forgotten password
+	EmailVerification  *EmailVerification          `bson:"emailVerification,omitempty" json:"-"`                   // Set until the email address of a new account is verified
+	EmailChange        *EmailChange                `bson:"emailChange,omitempty" json:"-"`                         // New email address waiting for confirmation
+	Language           string                      `bson:"language,omitempty" json:"language,omitempty"`           // Language of the emails sent to the user, the default one when empty
+	NotificationPrefs  NotificationPreferences     `bson:"notificationPreferences,omitempty" json:"-"`             // Per event and channel opt-outs
+	Role               string                      `bson:"role,omitempty" json:"role"`                             // Empty means a regular user
+	TokenVersion       int                         `bson:"tokenVersion,omitempty" json:"-"`                        // Bumped to revoke all access tokens
+	DeactivatedAt      *time.Time                  `bson:"deactivatedAt,omitempty" json:"deactivatedAt,omitempty"` // Set while an admin has deactivated the account
+	LastLoginAt        *time.Time                  `bson:"lastLoginAt,omitempty" json:"lastLoginAt,omitempty"`
+	AcceptedPolicies   map[string]PolicyAcceptance `bson:"acceptedPolicies,omitempty" json:"-"` // Latest accepted version per policy type
+	Escalation         *EscalationSettings         `bson:"escalation,omitempty" json:"-"`       // Automatic priority escalation of overdue tasks
+	QuotaWarnings      map[string]time.Time        `bson:"quotaWarnings,omitempty" json:"-"`    // When the user was notified of each nearly used up quota
+	Holidays           *HolidaySettings            `bson:"holidays,omitempty" json:"-"`         // Days off that due dates can be moved away from
+	WorkSchedule       *WorkSchedule               `bson:"workSchedule,omitempty" json:"-"`     // Working days and hours, the default schedule when unset
+	CreatedAt          time.Time                   `bson:"createdAt" json:"createdAt"`
+	UpdatedAt          time.Time                   `bson:"updatedAt" json:"updatedAt"`
+}
+
+// PasswordReset is a pending reset of a forgotten password, whose token was
+// emailed to the user
+type PasswordReset struct {
+	TokenHash   string    `bson:"tokenHash"`
+	RequestedAt time.Time `bson:"requestedAt"`
+	ExpiresAt   time.Time `bson:"expiresAt"`
+}
+
+// EmailVerification is the pending verification of the email address a
+// user registered with, whose token was emailed to it
+type EmailVerification struct {
+	TokenHash   string    `bson:"tokenHash"`
+	RequestedAt time.Time `bson:"requestedAt"`
+	ExpiresAt   time.Time `bson:"expiresAt"`
+}
+
+// EmailChange is a new email address waiting to be confirmed with the token
+// emailed to it
+type EmailChange struct {
+	Email       string    `bson:"email"`
+	TokenHash   string    `bson:"tokenHash"`
+	RequestedAt time.Time `bson:"requestedAt"`
+	ExpiresAt   time.Time `bson:"expiresAt"`
 }
 
 // NewUser creates a new user with default values
@@ -30,21 +76,55 @@ func NewUser(username, email, hashedPassword string) *User {
 	}
 }
 
+// IsAdmin reports whether the user can access the admin endpoints
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// EmailVerified reports whether the user's email address is known to be
+// theirs. Accounts registered before verification existed count as verified.
+func (u *User) EmailVerified() bool {
+	return u.EmailVerification == nil
+}
+
+// IsActive reports whether the user can log in and use the API
+func (u *User) IsActive() bool {
+	return u.DeactivatedAt == nil
+}
+
 // UserResponse is the structure returned when a user is part of a response
 // It doesn't include sensitive data like password
 type UserResponse struct {
-	ID        primitive.ObjectID `json:"id"`
-	Username  string             `json:"username"`
-	Email     string             `json:"email"`
-	CreatedAt time.Time          `json:"createdAt"`
+	ID            primitive.ObjectID `json:"id"`
+	Username      string             `json:"username"`
+	Email         string             `json:"email"`
+	EmailVerified bool               `json:"emailVerified"`
+	Role          string             `json:"role"`
+	Language      string             `json:"language,omitempty"`
+	DeactivatedAt *time.Time         `json:"deactivatedAt,omitempty"`
+	LastLoginAt   *time.Time         `json:"lastLoginAt,omitempty"`
+	CreatedAt     time.Time          `json:"createdAt"`
 }
 
 // ToResponse converts a User to a UserResponse
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:        u.ID,
-		Username:  u.Username,
-		Email:     u.Email,
-		CreatedAt: u.CreatedAt,
+		ID:            u.ID,
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified(),
+		Role:          u.roleOrDefault(),
+		Language:      u.Language,
+		DeactivatedAt: u.DeactivatedAt,
+		LastLoginAt:   u.LastLoginAt,
+		CreatedAt:     u.CreatedAt,
+	}
+}
+
+// roleOrDefault returns the user's role, users without one are regular users
+func (u *User) roleOrDefault() string {
+	if u.Role == "" {
+		return RoleUser
 	}
+	return u.Role
 }
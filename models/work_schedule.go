@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// WorkSchedule holds the weekdays and hours a user works. Start and End are
+// local times formatted 15:04.
+type WorkSchedule struct {
+	Days  []time.Weekday `bson:"days" json:"days"` // 0 for Sunday
+	Start string         `bson:"start" json:"start"`
+	End   string         `bson:"end" json:"end"`
+}
+
+// DefaultWorkSchedule applies until a user sets their own: Monday to Friday,
+// 9:00 to 17:00
+var DefaultWorkSchedule = WorkSchedule{
+	Days:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	Start: "09:00",
+	End:   "17:00",
+}
+
+// Resolved returns the schedule, or the default one when none is set
+func (s *WorkSchedule) Resolved() WorkSchedule {
+	if s == nil {
+		return DefaultWorkSchedule
+	}
+	return *s
+}
+
+// IsWorkingDay reports whether the schedule includes a weekday
+func (s WorkSchedule) IsWorkingDay(weekday time.Weekday) bool {
+	for _, day := range s.Days {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// Minutes returns the length of a working day
+func (s WorkSchedule) Minutes() int {
+	return minuteOfDay(s.End) - minuteOfDay(s.Start)
+}
+
+// InHours reports whether t falls within the working hours of its weekday
+func (s WorkSchedule) InHours(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	return s.IsWorkingDay(t.Weekday()) && minute >= minuteOfDay(s.Start) && minute < minuteOfDay(s.End)
+}
+
+// minuteOfDay converts a 15:04 time to minutes after midnight, -1 when invalid
+func minuteOfDay(value string) int {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return -1
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+// ValidWorkTime reports whether a value is a 15:04 time of day
+func ValidWorkTime(value string) bool {
+	return minuteOfDay(value) >= 0
+}
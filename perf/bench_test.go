@@ -0,0 +1,155 @@
+// Package perf benchmarks the hot code paths of the API and checks them
+// against the performance budget in budget.json:
+//
+//	go test ./perf -bench . -benchmem
+//
+// TestBudget fails when a benchmark goes over its budget, so it can gate CI.
+// Timings depend on the machine, so it only runs with PERF_BUDGET=1:
+//
+//	PERF_BUDGET=1 go test ./perf -run TestBudget
+package perf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"testing"
+
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+	"gotodolist/testutil"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// budget is the maximum cost of one operation of a benchmark
+type budget struct {
+	NsPerOp     int64 `json:"nsPerOp"`
+	AllocsPerOp int64 `json:"allocsPerOp"`
+}
+
+// benchmarks are the hot paths, keyed by the name used in the budget file
+var benchmarks = map[string]func(b *testing.B){
+	"auth/stateless":   BenchmarkAuthStateless,
+	"auth/cached-user": BenchmarkAuthCachedUser,
+	"tasks/list-query": BenchmarkTaskListQuery,
+	"http/pagination":  BenchmarkPaginationLinks,
+}
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.ReleaseMode)
+	os.Exit(m.Run())
+}
+
+// TestBudget runs every benchmark and fails for those over their budget.
+// It is skipped unless PERF_BUDGET=1, timings being too noisy on shared
+// machines to fail a plain go test, and with -short.
+func TestBudget(t *testing.T) {
+	if os.Getenv("PERF_BUDGET") != "1" {
+		t.Skip("PERF_BUDGET=1 is not set")
+	}
+	if testing.Short() {
+		t.Skip("benchmarks are skipped in short mode")
+	}
+
+	data, err := os.ReadFile("budget.json")
+	if err != nil {
+		t.Fatalf("read the budget: %v", err)
+	}
+	budgets := map[string]budget{}
+	if err := json.Unmarshal(data, &budgets); err != nil {
+		t.Fatalf("parse the budget: %v", err)
+	}
+
+	names := make([]string, 0, len(benchmarks))
+	for name := range benchmarks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		limit, ok := budgets[name]
+		if !ok {
+			t.Errorf("%s: no budget", name)
+			continue
+		}
+		result := testing.Benchmark(benchmarks[name])
+		t.Logf("%-20s %10d ns/op (budget %d) %6d allocs/op (budget %d)", name, result.NsPerOp(), limit.NsPerOp, result.AllocsPerOp(), limit.AllocsPerOp)
+		if result.NsPerOp() > limit.NsPerOp {
+			t.Errorf("%s: %d ns/op, over the budget of %d", name, result.NsPerOp(), limit.NsPerOp)
+		}
+		if result.AllocsPerOp() > limit.AllocsPerOp {
+			t.Errorf("%s: %d allocs/op, over the budget of %d", name, result.AllocsPerOp(), limit.AllocsPerOp)
+		}
+	}
+}
+
+// BenchmarkAuthStateless measures Protect on a valid token, trusting its
+// claims
+func BenchmarkAuthStateless(b *testing.B) {
+	benchmarkAuth(b, true)
+}
+
+// BenchmarkAuthCachedUser measures Protect on a valid token, finding the
+// user in the cache
+func BenchmarkAuthCachedUser(b *testing.B) {
+	benchmarkAuth(b, false)
+}
+
+// benchmarkAuth measures Protect on a valid token in either mode
+func benchmarkAuth(b *testing.B, stateless bool) {
+	b.Setenv("AUTH_STATELESS", fmt.Sprint(stateless))
+
+	user := testutil.NewUser()
+	cache := middleware.NewUserCache()
+	cache.Set(user)
+
+	router := gin.New()
+	router.GET("/tasks/", middleware.NewAuthMiddleware(nil, cache, nil).Protect(), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	req := httptest.NewRequest(http.MethodGet, "/tasks/", nil)
+	req.Header.Set("Authorization", "Bearer "+testutil.Token(b, user))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			b.Fatalf("unexpected status %d", w.Code)
+		}
+	}
+}
+
+// BenchmarkTaskListQuery measures building the query of a filtered task list
+func BenchmarkTaskListQuery(b *testing.B) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/tasks/?completed=false&priority=high&goal=64f1a2b3c4d5e6f708091a2b&sort=dueDate&sortDir=desc&page=2&limit=20", nil)
+	userID := primitive.NewObjectID()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := controllers.ParseTaskListQuery(c, userID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkPaginationLinks measures the Link header of a paginated list
+func BenchmarkPaginationLinks(b *testing.B) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/tasks/?page=2&limit=20&priority=high", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		utils.SetPaginationLinks(c, 2, 20, 10)
+	}
+}
@@ -0,0 +1,54 @@
+// Authentication load scenario: logins and token refreshes at a constant
+// rate. Logins hash the password, so this mostly measures bcrypt cost.
+//
+//   k6 run -e BASE_URL=http://localhost:8080 perf/k6/auth.js
+import http from 'k6/http';
+import { check } from 'k6';
+
+const BASE_URL = __ENV.BASE_URL || 'http://localhost:8080';
+
+export const options = {
+  scenarios: {
+    auth: {
+      executor: 'constant-arrival-rate',
+      rate: 20,
+      timeUnit: '1s',
+      duration: '1m',
+      preAllocatedVUs: 20,
+    },
+  },
+  // Performance budget, the run fails when it is exceeded
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    'http_req_duration{name:login}': ['p(95)<400'],
+    'http_req_duration{name:refresh}': ['p(95)<100'],
+  },
+};
+
+const json = { headers: { 'Content-Type': 'application/json' } };
+
+// Every virtual user logs in with its own account, as a login replaces the
+// refresh token of the previous one
+let credentials;
+
+function register() {
+  const name = `k6-auth-${__VU}-${Date.now()}`;
+  const account = { email: `${name}@example.com`, password: 'password123' };
+  const res = http.post(`${BASE_URL}/auth/register`, JSON.stringify(Object.assign({ username: name }, account)), Object.assign({ tags: { name: 'register' } }, json));
+  check(res, { registered: (r) => r.status === 200 });
+  return account;
+}
+
+export default function () {
+  if (!credentials) {
+    credentials = register();
+  }
+
+  let res = http.post(`${BASE_URL}/auth/login`, JSON.stringify(credentials), Object.assign({ tags: { name: 'login' } }, json));
+  if (!check(res, { 'logged in': (r) => r.status === 200 })) {
+    return;
+  }
+
+  res = http.post(`${BASE_URL}/auth/refresh-token`, JSON.stringify({ refreshToken: res.json('refreshToken') }), Object.assign({ tags: { name: 'refresh' } }, json));
+  check(res, { refreshed: (r) => r.status === 200 });
+}
@@ -0,0 +1,81 @@
+// Task CRUD load scenario: every virtual user lists, creates, reads,
+// updates and deletes tasks with its own account.
+//
+//   k6 run -e BASE_URL=http://localhost:8080 perf/k6/tasks.js
+import http from 'k6/http';
+import { check, group } from 'k6';
+
+const BASE_URL = __ENV.BASE_URL || 'http://localhost:8080';
+
+export const options = {
+  scenarios: {
+    tasks: {
+      executor: 'ramping-vus',
+      startVUs: 1,
+      stages: [
+        { duration: '30s', target: 20 },
+        { duration: '1m', target: 20 },
+        { duration: '15s', target: 0 },
+      ],
+    },
+  },
+  // Performance budget, the run fails when it is exceeded
+  thresholds: {
+    http_req_failed: ['rate<0.01'],
+    'http_req_duration{name:list}': ['p(95)<150'],
+    'http_req_duration{name:create}': ['p(95)<200'],
+    'http_req_duration{name:get}': ['p(95)<100'],
+    'http_req_duration{name:update}': ['p(95)<200'],
+    'http_req_duration{name:delete}': ['p(95)<150'],
+  },
+};
+
+const json = { 'Content-Type': 'application/json' };
+
+// Each virtual user registers once and keeps its token
+let token;
+
+function login() {
+  const name = `k6-${__VU}-${Date.now()}`;
+  const res = http.post(`${BASE_URL}/auth/register`, JSON.stringify({
+    username: name,
+    email: `${name}@example.com`,
+    password: 'password123',
+  }), { headers: json, tags: { name: 'register' } });
+  check(res, { registered: (r) => r.status === 200 });
+  return res.json('token');
+}
+
+export default function () {
+  if (!token) {
+    token = login();
+  }
+  const params = (name) => ({
+    headers: Object.assign({ Authorization: `Bearer ${token}` }, json),
+    tags: { name },
+  });
+
+  group('tasks', () => {
+    let res = http.get(`${BASE_URL}/tasks/?limit=20&sort=dueDate`, params('list'));
+    check(res, { listed: (r) => r.status === 200 });
+
+    res = http.post(`${BASE_URL}/tasks/`, JSON.stringify({
+      title: 'Load test task',
+      priority: 'high',
+      dueDate: new Date(Date.now() + 86400000).toISOString(),
+    }), params('create'));
+    if (!check(res, { created: (r) => r.status === 201 })) {
+      return;
+    }
+    const id = res.json('data.id');
+
+    res = http.get(`${BASE_URL}/tasks/${id}`, params('get'));
+    check(res, { read: (r) => r.status === 200 });
+
+    res = http.put(`${BASE_URL}/tasks/${id}`, JSON.stringify({ completed: true }), params('update'));
+    check(res, { updated: (r) => r.status === 200 });
+
+    res = http.del(`${BASE_URL}/tasks/${id}`, null, params('delete'));
+    check(res, { deleted: (r) => r.status === 200 });
+  });
+}
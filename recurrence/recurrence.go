@@ -0,0 +1,332 @@
+// Package recurrence computes the occurrences of repeating tasks from a
+// recurrence rule, the subset of the iCalendar RRULE (RFC 5545) a todo
+// list needs: FREQ, INTERVAL, BYDAY, BYMONTHDAY, COUNT and UNTIL. The
+// shortcuts daily, weekly, monthly and yearly stand for the rules of the
+// same frequency.
+//
+// Occurrences are computed one after the other from the previous one, and
+// keep its time of day. Weeks start on Monday. Monthly and yearly rules
+// without BYMONTHDAY fall on the day of the series start, or the last day
+// of shorter months.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequencies of a rule
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+// maxInterval caps INTERVAL, a rule repeating less than every 100 periods
+// is a mistake rather than a plan
+const maxInterval = 99
+
+// weekdays are the BYDAY codes
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// Rule is a parsed recurrence rule
+type Rule struct {
+	Freq       string
+	Interval   int            // Periods between occurrences, 1 or more
+	ByDay      []time.Weekday // Weekdays of DAILY and WEEKLY rules
+	ByMonthDay []int          // Days of MONTHLY rules, -1 being the last day
+	Count      int            // Number of occurrences, 0 for no limit
+	Until      *time.Time     // Last possible occurrence
+}
+
+// Parse reads a rule such as "FREQ=WEEKLY;BYDAY=MO,WE" or a shortcut such
+// as "weekly". An optional "RRULE:" prefix is ignored.
+func Parse(text string) (Rule, error) {
+	text = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(text)), "RRULE:")
+	switch text {
+	case Daily, Weekly, Monthly, Yearly:
+		return Rule{Freq: text, Interval: 1}, nil
+	case "":
+		return Rule{}, errors.New("recurrence rule is empty")
+	}
+
+	rule := Rule{Interval: 1}
+	for _, part := range strings.Split(text, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return Rule{}, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+
+		var err error
+		switch name {
+		case "FREQ":
+			rule.Freq = value
+		case "INTERVAL":
+			rule.Interval, err = strconv.Atoi(value)
+			if err == nil && (rule.Interval < 1 || rule.Interval > maxInterval) {
+				err = fmt.Errorf("INTERVAL must be between 1 and %d", maxInterval)
+			}
+		case "BYDAY":
+			rule.ByDay, err = parseWeekdays(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseMonthDays(value)
+		case "COUNT":
+			rule.Count, err = strconv.Atoi(value)
+			if err == nil && rule.Count < 1 {
+				err = errors.New("COUNT must be positive")
+			}
+		case "UNTIL":
+			var until time.Time
+			until, err = parseUntil(value)
+			rule.Until = &until
+		case "WKST":
+			if value != "MO" {
+				err = errors.New("only WKST=MO is supported")
+			}
+		default:
+			err = fmt.Errorf("%s is not supported", name)
+		}
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid recurrence rule: %v", err)
+		}
+	}
+
+	switch {
+	case rule.Freq != Daily && rule.Freq != Weekly && rule.Freq != Monthly && rule.Freq != Yearly:
+		return Rule{}, errors.New("invalid recurrence rule: FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY")
+	case len(rule.ByDay) > 0 && rule.Freq != Daily && rule.Freq != Weekly:
+		return Rule{}, errors.New("invalid recurrence rule: BYDAY only applies to DAILY and WEEKLY")
+	case len(rule.ByDay) > 0 && rule.Freq == Daily && rule.Interval > 1:
+		return Rule{}, errors.New("invalid recurrence rule: a DAILY rule with BYDAY cannot have an INTERVAL")
+	case len(rule.ByMonthDay) > 0 && rule.Freq != Monthly:
+		return Rule{}, errors.New("invalid recurrence rule: BYMONTHDAY only applies to MONTHLY")
+	case rule.Count > 0 && rule.Until != nil:
+		return Rule{}, errors.New("invalid recurrence rule: COUNT and UNTIL cannot be combined")
+	}
+	return rule, nil
+}
+
+// parseWeekdays reads the days of BYDAY, such as MO,WE,FR
+func parseWeekdays(value string) ([]time.Weekday, error) {
+	seen := map[time.Weekday]bool{}
+	var days []time.Weekday
+	for _, code := range strings.Split(value, ",") {
+		day, ok := weekdays[code]
+		if !ok {
+			return nil, fmt.Errorf("unknown BYDAY day %q", code)
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	// In week order, Monday first
+	sort.Slice(days, func(i, j int) bool { return weekIndex(days[i]) < weekIndex(days[j]) })
+	return days, nil
+}
+
+// parseMonthDays reads the days of BYMONTHDAY, such as 1,15,-1
+func parseMonthDays(value string) ([]int, error) {
+	seen := map[int]bool{}
+	var days []int
+	for _, text := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(text)
+		if err != nil || day == 0 || day < -1 || day > 31 {
+			return nil, fmt.Errorf("BYMONTHDAY days must be 1 to 31, or -1 for the last day")
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+	return days, nil
+}
+
+// parseUntil reads UNTIL as a UTC date-time or a date, which includes the
+// whole day
+func parseUntil(value string) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return until, nil
+	}
+	if until, err := time.Parse("20060102", value); err == nil {
+		return until.Add(24*time.Hour - time.Second), nil
+	}
+	return time.Time{}, errors.New("UNTIL must be a date such as 20261231 or a UTC time such as 20261231T170000Z")
+}
+
+// String returns the rule in its canonical RRULE form, without shortcuts
+func (r Rule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		codes := make([]string, len(r.ByDay))
+		for i, day := range r.ByDay {
+			for code, weekday := range weekdays {
+				if weekday == day {
+					codes[i] = code
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(codes, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, day := range r.ByMonthDay {
+			days[i] = strconv.Itoa(day)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the occurrence following previous in the series starting
+// at start, the occurrence number of previous being given to apply COUNT.
+// It returns false once the rule has no more occurrences.
+func (r Rule) Next(start, previous time.Time, occurrence int) (time.Time, bool) {
+	if r.Count > 0 && occurrence >= r.Count {
+		return time.Time{}, false
+	}
+
+	var next time.Time
+	switch r.Freq {
+	case Daily:
+		next = r.nextDaily(previous)
+	case Weekly:
+		next = r.nextWeekly(previous)
+	case Monthly:
+		next = r.nextMonthly(start, previous)
+	default:
+		next = addMonths(previous, 12*r.Interval, start.Day())
+	}
+
+	if r.Until != nil && next.After(*r.Until) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// After returns the first occurrence following previous that is later
+// than now, skipping the missed ones, with the number of occurrences
+// advanced. It returns false once the rule has no more occurrences.
+func (r Rule) After(start, previous time.Time, occurrence int, now time.Time) (time.Time, int, bool) {
+	next, ok := r.Next(start, previous, occurrence)
+	occurrence++
+	// A year of daily occurrences bounds the catching up
+	for i := 0; ok && !next.After(now) && i < 366; i++ {
+		next, ok = r.Next(start, next, occurrence)
+		occurrence++
+	}
+	return next, occurrence, ok
+}
+
+// nextDaily returns the next day, or the next of the BYDAY weekdays
+func (r Rule) nextDaily(previous time.Time) time.Time {
+	if len(r.ByDay) == 0 {
+		return previous.AddDate(0, 0, r.Interval)
+	}
+	next := previous.AddDate(0, 0, 1)
+	for !r.onDay(next.Weekday()) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// nextWeekly returns the next BYDAY weekday of the week, or the first one
+// of the week INTERVAL weeks later. Without BYDAY it is the same weekday.
+func (r Rule) nextWeekly(previous time.Time) time.Time {
+	if len(r.ByDay) == 0 {
+		return previous.AddDate(0, 0, 7*r.Interval)
+	}
+	for _, day := range r.ByDay {
+		if weekIndex(day) > weekIndex(previous.Weekday()) {
+			return previous.AddDate(0, 0, weekIndex(day)-weekIndex(previous.Weekday()))
+		}
+	}
+	monday := previous.AddDate(0, 0, -weekIndex(previous.Weekday()))
+	return monday.AddDate(0, 0, 7*r.Interval+weekIndex(r.ByDay[0]))
+}
+
+// nextMonthly returns the next BYMONTHDAY day of the month, or the first
+// one of the month INTERVAL months later. Without BYMONTHDAY it is the
+// day of the month of start, the last day of shorter months.
+func (r Rule) nextMonthly(start, previous time.Time) time.Time {
+	if len(r.ByMonthDay) == 0 {
+		return addMonths(previous, r.Interval, start.Day())
+	}
+
+	// Days of the month of previous after it, then of the months after
+	for months := 0; ; months += r.Interval {
+		month := addMonths(previous, months, 1)
+		var best time.Time
+		for _, day := range r.ByMonthDay {
+			candidate, ok := monthDay(month, day)
+			if !ok || !candidate.After(previous) {
+				continue
+			}
+			if best.IsZero() || candidate.Before(best) {
+				best = candidate
+			}
+		}
+		if !best.IsZero() {
+			return best
+		}
+	}
+}
+
+// monthDay returns a day of the month of t, -1 being the last one, and
+// false when the month is too short
+func monthDay(t time.Time, day int) (time.Time, bool) {
+	length := daysIn(t.Year(), t.Month(), t.Location())
+	if day == -1 {
+		day = length
+	}
+	if day > length {
+		return time.Time{}, false
+	}
+	return time.Date(t.Year(), t.Month(), day, t.Hour(), t.Minute(), t.Second(), 0, t.Location()), true
+}
+
+// addMonths moves t by months, on day or the last day of shorter months
+func addMonths(t time.Time, months, day int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	if length := daysIn(first.Year(), first.Month(), t.Location()); day > length {
+		day = length
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// daysIn returns the number of days of a month
+func daysIn(year int, month time.Month, location *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, location).Day()
+}
+
+// onDay reports whether a weekday is one of BYDAY
+func (r Rule) onDay(day time.Weekday) bool {
+	for _, allowed := range r.ByDay {
+		if allowed == day {
+			return true
+		}
+	}
+	return false
+}
+
+// weekIndex numbers the weekdays from Monday
+func weekIndex(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"gotodolist/authz"
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminRoutes configures the admin routes
+func SetupAdminRoutes(router *gin.Engine, adminController *controllers.AdminController, authMiddleware *middleware.AuthMiddleware) {
+	admin := router.Group("/admin")
+
+	// Admin routes require an authenticated user with the admin:access permission
+	admin.Use(authMiddleware.Protect(), authMiddleware.Require(authz.AdminAccess))
+
+	{
+		admin.GET("/log-level", adminController.GetLogLevel)
+		admin.PUT("/log-level", adminController.UpdateLogLevel)
+		admin.GET("/slow-queries", adminController.GetSlowQueries)
+		admin.GET("/slow-requests", adminController.GetSlowRequests)
+		admin.GET("/jobs", adminController.GetJobs)
+		admin.GET("/stats", adminController.GetStats)
+		admin.GET("/users", adminController.ListUsers)
+		admin.POST("/users/:id/deactivate", adminController.DeactivateUser)
+		admin.POST("/users/:id/reactivate", adminController.ReactivateUser)
+	}
+}
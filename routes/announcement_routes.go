@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"gotodolist/authz"
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAnnouncementRoutes configures the public announcement feed and its admin management routes
+func SetupAnnouncementRoutes(router *gin.Engine, announcementController *controllers.AnnouncementController, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/announcements", announcementController.GetAnnouncements)
+
+	admin := router.Group("/admin/announcements")
+
+	// Managing announcements requires the announcements:manage permission
+	admin.Use(authMiddleware.Protect(), authMiddleware.Require(authz.AnnouncementManage))
+
+	{
+		admin.GET("/", announcementController.GetAllAnnouncements)
+		admin.POST("/", announcementController.CreateAnnouncement)
+		admin.DELETE("/:id", announcementController.DeleteAnnouncement)
+	}
+}
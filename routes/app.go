@@ -0,0 +1,211 @@
+package routes
+
+import (
+	"time"
+
+	"gotodolist/configs"
+	"gotodolist/controllers"
+	"gotodolist/jobs"
+	"gotodolist/middleware"
+	"gotodolist/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// App holds the controllers of the API wired over the collections of a
+// database, with the scheduler, mail queue and caches they share. main.go
+// serves it and runs its jobs, and the contract tests serve it over a test
+// database, so both use the same wiring.
+type App struct {
+	// Collections read at startup, to backfill counts and apply validators
+	TasksCollection    *mongo.Collection
+	UsersCollection    *mongo.Collection
+	GoalsCollection    *mongo.Collection
+	ContextsCollection *mongo.Collection
+	ProjectsCollection *mongo.Collection
+	TagsCollection     *mongo.Collection
+
+	// Background jobs, each one runs on a single instance at a time
+	Scheduler *jobs.Scheduler
+
+	// Account emails and alerts are sent through the MAIL_TRANSPORT, failed
+	// ones being kept in the outbox and retried
+	MailQueue *controllers.MailQueue
+
+	AuthMiddleware *middleware.AuthMiddleware
+
+	NotificationController *controllers.NotificationController
+	AutomationController   *controllers.AutomationController
+	HolidayController      *controllers.HolidayController
+	TaskController         *controllers.TaskController
+	AuthController         *controllers.AuthController
+	HabitController        *controllers.HabitController
+	GoalController         *controllers.GoalController
+	ContextController      *controllers.ContextController
+	ProjectController      *controllers.ProjectController
+	NoteController         *controllers.NoteController
+	BoardController        *controllers.BoardController
+	StatsController        *controllers.StatsController
+	DashboardController    *controllers.DashboardController
+	ReviewController       *controllers.ReviewController
+	AdminController        *controllers.AdminController
+	AnnouncementController *controllers.AnnouncementController
+	PolicyController       *controllers.PolicyController
+	DataExportController   *controllers.DataExportController
+	MaintenanceController  *controllers.MaintenanceController
+	HealthController       *controllers.HealthController
+	StatusController       *controllers.StatusController
+	DelegationController   *controllers.DelegationController
+	EscalationController   *controllers.EscalationController
+}
+
+// NewApp wires the controllers over the collections of a database
+func NewApp(client *mongo.Client, dbName string) *App {
+	tasksCollection := configs.GetCollection(client, "tasks", dbName)
+	usersCollection := configs.GetCollection(client, "users", dbName)
+	habitsCollection := configs.GetCollection(client, "habits", dbName)
+	checkInsCollection := configs.GetCollection(client, "habit_checkins", dbName)
+	goalsCollection := configs.GetCollection(client, "goals", dbName)
+	contextsCollection := configs.GetCollection(client, "contexts", dbName)
+	boardsCollection := configs.GetCollection(client, "boards", dbName)
+	announcementsCollection := configs.GetCollection(client, "announcements", dbName)
+	policiesCollection := configs.GetCollection(client, "policies", dbName)
+	dataExportsCollection := configs.GetCollection(client, "data_exports", dbName)
+	activityCollection := configs.GetCollection(client, "task_activity", dbName)
+	notesCollection := configs.GetCollection(client, "task_notes", dbName)
+	versionsCollection := configs.GetCollection(client, "task_versions", dbName)
+	notificationsCollection := configs.GetCollection(client, "notifications", dbName)
+	automationsCollection := configs.GetCollection(client, "automations", dbName)
+	automationRunsCollection := configs.GetCollection(client, "automation_runs", dbName)
+	projectsCollection := configs.GetCollection(client, "projects", dbName)
+	projectTemplatesCollection := configs.GetCollection(client, "project_templates", dbName)
+	tagsCollection := configs.GetCollection(client, "tags", dbName)
+
+	app := &App{
+		TasksCollection:    tasksCollection,
+		UsersCollection:    usersCollection,
+		GoalsCollection:    goalsCollection,
+		ContextsCollection: contextsCollection,
+		ProjectsCollection: projectsCollection,
+		TagsCollection:     tagsCollection,
+		Scheduler:          jobs.NewScheduler(configs.GetCollection(client, "job_leases", dbName)),
+		MailQueue:          controllers.NewMailQueue(configs.GetCollection(client, "email_outbox", dbName), utils.NewMailer()),
+	}
+
+	// Users are cached per instance to spare a lookup on every request
+	userCache := middleware.NewUserCache()
+
+	policyGate := middleware.NewPolicyGate(policiesCollection)
+
+	app.NotificationController = controllers.NewNotificationController(notificationsCollection, usersCollection, app.MailQueue)
+	app.AutomationController = controllers.NewAutomationController(automationsCollection, automationRunsCollection, tasksCollection, goalsCollection, projectsCollection, tagsCollection, activityCollection, app.NotificationController)
+	app.HolidayController = controllers.NewHolidayController(usersCollection, userCache)
+	app.TaskController = controllers.NewTaskController(tasksCollection, goalsCollection, contextsCollection, projectsCollection, tagsCollection, activityCollection, versionsCollection, app.AutomationController, app.NotificationController, app.HolidayController)
+	app.AuthController = controllers.NewAuthController(usersCollection, userCache, app.MailQueue)
+	app.HabitController = controllers.NewHabitController(habitsCollection, checkInsCollection)
+	app.GoalController = controllers.NewGoalController(goalsCollection, tasksCollection)
+	app.ContextController = controllers.NewContextController(contextsCollection, tasksCollection, app.NotificationController)
+	app.ProjectController = controllers.NewProjectController(projectsCollection, tasksCollection, projectTemplatesCollection, goalsCollection, contextsCollection, tagsCollection, usersCollection, app.NotificationController)
+	app.NoteController = controllers.NewNoteController(notesCollection, tasksCollection, app.NotificationController)
+	app.BoardController = controllers.NewBoardController(boardsCollection, tasksCollection)
+	app.StatsController = controllers.NewStatsController(tasksCollection, app.HolidayController)
+	app.DashboardController = controllers.NewDashboardController(tasksCollection, app.HabitController)
+	app.ReviewController = controllers.NewReviewController(tasksCollection)
+	app.AdminController = controllers.NewAdminController(usersCollection, tasksCollection, userCache, app.Scheduler)
+	app.AnnouncementController = controllers.NewAnnouncementController(announcementsCollection)
+	app.PolicyController = controllers.NewPolicyController(policiesCollection, usersCollection, userCache, policyGate)
+
+	// Collections holding documents owned by a user, exported with their
+	// data and cleaned up once the user is gone
+	userData := map[string]*mongo.Collection{
+		"tasks":             tasksCollection,
+		"habits":            habitsCollection,
+		"habit_checkins":    checkInsCollection,
+		"goals":             goalsCollection,
+		"contexts":          contextsCollection,
+		"projects":          projectsCollection,
+		"project_templates": projectTemplatesCollection,
+		"tags":              tagsCollection,
+		"boards":            boardsCollection,
+		"task_activity":     activityCollection,
+		"task_notes":        notesCollection,
+		"task_versions":     versionsCollection,
+		"notifications":     notificationsCollection,
+		"automations":       automationsCollection,
+	}
+	app.DataExportController = controllers.NewDataExportController(dataExportsCollection, usersCollection, userData)
+	app.MaintenanceController = controllers.NewMaintenanceController(usersCollection, tasksCollection, activityCollection, userData, map[string]*mongo.Collection{
+		"task_activity":   activityCollection,
+		"task_notes":      notesCollection,
+		"task_versions":   versionsCollection,
+		"automation_runs": automationRunsCollection,
+	})
+	app.HealthController = controllers.NewHealthController(configs.GetCollection(client, "health_snapshots", dbName), client, usersCollection, app.Scheduler, app.NotificationController)
+	app.StatusController = controllers.NewStatusController(configs.GetCollection(client, "incidents", dbName), app.HealthController)
+	app.DelegationController = controllers.NewDelegationController(tasksCollection, goalsCollection, contextsCollection, projectsCollection, tagsCollection, usersCollection, activityCollection, versionsCollection, app.NotificationController)
+	app.EscalationController = controllers.NewEscalationController(tasksCollection, usersCollection, activityCollection, app.NotificationController, userCache)
+
+	app.AuthMiddleware = middleware.NewAuthMiddleware(usersCollection, userCache, policyGate)
+	return app
+}
+
+// Register sets up the routes of the specification on a router. The
+// profiling and email preview routes, served on some instances only, and
+// the documentation are left to the caller.
+func (app *App) Register(router *gin.Engine) {
+	authMiddleware := app.AuthMiddleware
+
+	SetupTaskRoutes(router, app.TaskController, authMiddleware)
+	SetupInboxRoutes(router, app.TaskController, authMiddleware)
+	SetupDelegationRoutes(router, app.DelegationController, authMiddleware)
+	SetupNoteRoutes(router, app.NoteController, authMiddleware)
+	SetupAuthRoutes(router, app.AuthController, authMiddleware)
+	SetupHabitRoutes(router, app.HabitController, authMiddleware)
+	SetupGoalRoutes(router, app.GoalController, authMiddleware)
+	SetupContextRoutes(router, app.ContextController, authMiddleware)
+	SetupProjectRoutes(router, app.ProjectController, authMiddleware)
+	SetupBoardRoutes(router, app.BoardController, authMiddleware)
+	SetupStatsRoutes(router, app.StatsController, authMiddleware)
+	SetupDashboardRoutes(router, app.DashboardController, authMiddleware)
+	SetupReviewRoutes(router, app.ReviewController, authMiddleware)
+	SetupAdminRoutes(router, app.AdminController, authMiddleware)
+	SetupMaintenanceRoutes(router, app.MaintenanceController, authMiddleware)
+	SetupHealthRoutes(router, app.HealthController, authMiddleware)
+	SetupAnnouncementRoutes(router, app.AnnouncementController, authMiddleware)
+	SetupStatusRoutes(router, app.StatusController, authMiddleware)
+	SetupPolicyRoutes(router, app.PolicyController, authMiddleware)
+	SetupDataExportRoutes(router, app.DataExportController, authMiddleware)
+	SetupNotificationRoutes(router, app.NotificationController, authMiddleware)
+	SetupEscalationRoutes(router, app.EscalationController, authMiddleware)
+	SetupHolidayRoutes(router, app.HolidayController, authMiddleware)
+	SetupAutomationRoutes(router, app.AutomationController, authMiddleware)
+
+	// Define health check route
+	router.GET("/health", func(c *gin.Context) {
+		// Failing jobs are reported without taking the instance out of rotation
+		jobStatus := "ok"
+		if !app.Scheduler.Healthy() {
+			jobStatus = "degraded"
+		}
+		c.JSON(200, gin.H{
+			"status":    "up",
+			"jobs":      jobStatus,
+			"instance":  utils.InstanceID(),
+			"timestamp": time.Now(),
+		})
+	})
+
+	// Build and configuration of the instance, for deployments and bug reports
+	router.GET("/version", func(c *gin.Context) {
+		build := utils.GetBuildInfo()
+		c.JSON(200, gin.H{
+			"version":   build.Version,
+			"commit":    build.Commit,
+			"buildDate": build.BuildDate,
+			"goVersion": build.GoVersion,
+			"features":  utils.EnabledFeatures(),
+			"instance":  utils.InstanceID(),
+		})
+	})
+}
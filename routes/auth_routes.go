@@ -14,9 +14,19 @@ func SetupAuthRoutes(router *gin.Engine, authController *controllers.AuthControl
 		auth.POST("/register", authController.Register)
 		auth.POST("/login", authController.Login)
 		auth.POST("/refresh-token", authController.RefreshToken)
+		auth.POST("/forgot-password", authController.ForgotPassword)
+		auth.POST("/reset-password", authController.ResetPassword)
+		auth.POST("/verify-email", authController.VerifyEmail)
+		auth.GET("/verify-email/:token", authController.VerifyEmailLink)
 
 		// Protected routes
 		auth.POST("/logout", authMiddleware.Protect(), authController.Logout)
 		auth.GET("/me", authMiddleware.Protect(), authController.GetMe)
+		auth.PUT("/me/password", authMiddleware.Protect(), authController.ChangePassword)
+		auth.PUT("/me/email", authMiddleware.Protect(), authController.RequestEmailChange)
+		auth.POST("/me/verify-email", authMiddleware.Protect(), authController.ResendVerification)
+		auth.PUT("/me/language", authMiddleware.Protect(), authController.UpdateLanguage)
+		auth.GET("/me/notifications", authMiddleware.Protect(), authController.GetNotificationPreferences)
+		auth.PUT("/me/notifications", authMiddleware.Protect(), authController.UpdateNotificationPreferences)
 	}
 }
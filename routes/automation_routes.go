@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAutomationRoutes configures the automation management routes
+func SetupAutomationRoutes(router *gin.Engine, automationController *controllers.AutomationController, authMiddleware *middleware.AuthMiddleware) {
+	automations := router.Group("/automations")
+
+	// All automation routes require authentication
+	automations.Use(authMiddleware.Protect())
+
+	{
+		automations.GET("/", automationController.GetAutomations)
+		automations.POST("/", automationController.CreateAutomation)
+		automations.GET("/:id", automationController.GetAutomation)
+		automations.PUT("/:id", automationController.UpdateAutomation)
+		automations.DELETE("/:id", automationController.DeleteAutomation)
+		automations.GET("/:id/runs", automationController.GetAutomationRuns)
+	}
+}
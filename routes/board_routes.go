@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupBoardRoutes configures the kanban board routes
+func SetupBoardRoutes(router *gin.Engine, boardController *controllers.BoardController, authMiddleware *middleware.AuthMiddleware) {
+	board := router.Group("/board")
+
+	// Apply auth middleware to all board routes
+	board.Use(authMiddleware.Protect())
+
+	{
+		board.GET("/", boardController.GetBoard)
+		board.PUT("/columns", boardController.UpdateColumns)
+	}
+
+	// Moving a task between columns lives next to the other task routes
+	router.POST("/tasks/:id/move", authMiddleware.Protect(), boardController.MoveTask)
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupContextRoutes configures the context routes
+func SetupContextRoutes(router *gin.Engine, contextController *controllers.ContextController, authMiddleware *middleware.AuthMiddleware) {
+	contexts := router.Group("/contexts")
+
+	// Apply auth middleware to all context routes
+	contexts.Use(authMiddleware.Protect())
+
+	{
+		contexts.GET("/", contextController.GetContexts)
+		contexts.POST("/", contextController.CreateContext)
+		contexts.PUT("/:id", contextController.UpdateContext)
+		contexts.DELETE("/:id", contextController.DeleteContext)
+		contexts.PATCH("/:id/tasks", contextController.UpdateContextTasks)
+		contexts.POST("/:id/merge", contextController.MergeContext)
+	}
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupDashboardRoutes configures the dashboard routes
+func SetupDashboardRoutes(router *gin.Engine, dashboardController *controllers.DashboardController, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/dashboard", authMiddleware.Protect(), dashboardController.GetDashboard)
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupDataExportRoutes configures the routes to request and download personal data exports
+func SetupDataExportRoutes(router *gin.Engine, dataExportController *controllers.DataExportController, authMiddleware *middleware.AuthMiddleware) {
+	exports := router.Group("/auth/me/exports")
+
+	// All export routes require authentication
+	exports.Use(authMiddleware.Protect())
+
+	{
+		exports.GET("/", dataExportController.GetExports)
+		exports.POST("/", dataExportController.RequestExport)
+		exports.GET("/:id", dataExportController.GetExport)
+		exports.GET("/:id/download", dataExportController.DownloadExport)
+	}
+}
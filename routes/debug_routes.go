@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"net/http/pprof"
+
+	"gotodolist/authz"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupDebugRoutes exposes the runtime profiles of net/http/pprof under
+// /debug/pprof, restricted to the users with the debug:pprof permission
+func SetupDebugRoutes(router *gin.Engine, authMiddleware *middleware.AuthMiddleware) {
+	debug := router.Group("/debug/pprof")
+
+	// Profiles reveal internals of the process, only admins can collect them
+	debug.Use(authMiddleware.Protect(), authMiddleware.Require(authz.Profiling))
+
+	{
+		debug.GET("/", gin.WrapF(pprof.Index))
+		debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		debug.GET("/profile", gin.WrapF(pprof.Profile))
+		debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+		debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+		debug.GET("/trace", gin.WrapF(pprof.Trace))
+
+		// Named profiles such as heap, goroutine or allocs
+		debug.GET("/:name", func(c *gin.Context) {
+			pprof.Handler(c.Param("name")).ServeHTTP(c.Writer, c.Request)
+		})
+	}
+}
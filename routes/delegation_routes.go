@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupDelegationRoutes configures the routes offering tasks to other users
+// and answering the offers received
+func SetupDelegationRoutes(router *gin.Engine, delegationController *controllers.DelegationController, authMiddleware *middleware.AuthMiddleware) {
+	tasks := router.Group("/tasks")
+	tasks.Use(authMiddleware.Protect())
+
+	{
+		tasks.POST("/:id/delegation", delegationController.OfferTask)
+		tasks.DELETE("/:id/delegation", delegationController.WithdrawOffer)
+	}
+
+	delegations := router.Group("/delegations")
+	delegations.Use(authMiddleware.Protect())
+
+	{
+		delegations.GET("", delegationController.GetDelegations)
+		delegations.POST("/:id/accept", delegationController.AcceptDelegation)
+		delegations.POST("/:id/decline", delegationController.DeclineDelegation)
+	}
+}
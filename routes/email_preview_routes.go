@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupEmailPreviewRoutes serves previews of the emails under
+// /debug/emails. They need no authentication, so they are only set up in
+// debug mode.
+func SetupEmailPreviewRoutes(router *gin.Engine, emailPreviewController *controllers.EmailPreviewController) {
+	preview := router.Group("/debug/emails")
+	{
+		preview.GET("/", emailPreviewController.ListEmails)
+		preview.GET("/:name", emailPreviewController.PreviewEmail)
+	}
+}
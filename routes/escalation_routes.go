@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupEscalationRoutes configures the routes of the overdue task escalation settings
+func SetupEscalationRoutes(router *gin.Engine, escalationController *controllers.EscalationController, authMiddleware *middleware.AuthMiddleware) {
+	escalation := router.Group("/auth/me/escalation")
+
+	// All escalation routes require authentication
+	escalation.Use(authMiddleware.Protect())
+
+	{
+		escalation.GET("", escalationController.GetSettings)
+		escalation.PUT("", escalationController.UpdateSettings)
+	}
+}
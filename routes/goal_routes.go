@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupGoalRoutes configures the goal routes
+func SetupGoalRoutes(router *gin.Engine, goalController *controllers.GoalController, authMiddleware *middleware.AuthMiddleware) {
+	goals := router.Group("/goals")
+
+	// Apply auth middleware to all goal routes
+	goals.Use(authMiddleware.Protect())
+
+	{
+		goals.GET("/", goalController.GetGoals)
+		goals.GET("/:id", goalController.GetGoal)
+		goals.POST("/", goalController.CreateGoal)
+		goals.PUT("/:id", goalController.UpdateGoal)
+		goals.DELETE("/:id", goalController.DeleteGoal)
+	}
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupHabitRoutes configures the habit routes
+func SetupHabitRoutes(router *gin.Engine, habitController *controllers.HabitController, authMiddleware *middleware.AuthMiddleware) {
+	habits := router.Group("/habits")
+
+	// Apply auth middleware to all habit routes
+	habits.Use(authMiddleware.Protect())
+
+	{
+		habits.GET("/", habitController.GetHabits)
+		habits.GET("/stats", habitController.GetStats)
+		habits.GET("/:id", habitController.GetHabit)
+		habits.POST("/", habitController.CreateHabit)
+		habits.PUT("/:id", habitController.UpdateHabit)
+		habits.DELETE("/:id", habitController.DeleteHabit)
+		habits.GET("/:id/checkins", habitController.GetCheckIns)
+		habits.POST("/:id/checkins", habitController.CheckIn)
+		habits.DELETE("/:id/checkins/:date", habitController.UndoCheckIn)
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"gotodolist/authz"
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupHealthRoutes configures the admin health history routes
+func SetupHealthRoutes(router *gin.Engine, healthController *controllers.HealthController, authMiddleware *middleware.AuthMiddleware) {
+	health := router.Group("/admin/health")
+
+	// Health history routes require the health:history permission
+	health.Use(authMiddleware.Protect(), authMiddleware.Require(authz.HealthHistory))
+
+	{
+		health.GET("/history", healthController.GetHistory)
+	}
+}
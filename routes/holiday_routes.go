@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupHolidayRoutes configures the routes of the holiday calendar and work
+// schedule settings
+func SetupHolidayRoutes(router *gin.Engine, holidayController *controllers.HolidayController, authMiddleware *middleware.AuthMiddleware) {
+	holidays := router.Group("/auth/me/holidays")
+
+	// All holiday routes require authentication
+	holidays.Use(authMiddleware.Protect())
+
+	{
+		holidays.GET("", holidayController.GetSettings)
+		holidays.PUT("", holidayController.UpdateSettings)
+		holidays.GET("/calendar", holidayController.GetCalendar)
+	}
+
+	schedule := router.Group("/auth/me/work-schedule")
+	schedule.Use(authMiddleware.Protect())
+
+	{
+		schedule.GET("", holidayController.GetWorkSchedule)
+		schedule.PUT("", holidayController.UpdateWorkSchedule)
+	}
+}
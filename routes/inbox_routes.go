@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupInboxRoutes configures the inbox capture and triage routes
+func SetupInboxRoutes(router *gin.Engine, taskController *controllers.TaskController, authMiddleware *middleware.AuthMiddleware) {
+	inbox := router.Group("/inbox")
+
+	// Apply auth middleware to all inbox routes
+	inbox.Use(authMiddleware.Protect())
+
+	{
+		inbox.GET("/", taskController.GetInbox)
+		inbox.POST("/", taskController.CaptureTask)
+	}
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"gotodolist/authz"
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupMaintenanceRoutes configures the admin maintenance routes
+func SetupMaintenanceRoutes(router *gin.Engine, maintenanceController *controllers.MaintenanceController, authMiddleware *middleware.AuthMiddleware) {
+	maintenance := router.Group("/admin/maintenance")
+
+	// Maintenance routes require the maintenance:run permission
+	maintenance.Use(authMiddleware.Protect(), authMiddleware.Require(authz.MaintenanceRun))
+
+	{
+		maintenance.POST("/cleanup", maintenanceController.RunCleanup)
+		maintenance.POST("/compaction", maintenanceController.RunCompaction)
+		maintenance.GET("/collections", maintenanceController.GetCollectionSizes)
+		maintenance.GET("/consistency", maintenanceController.GetConsistency)
+		maintenance.POST("/consistency", maintenanceController.RepairConsistency)
+	}
+}
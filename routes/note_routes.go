@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupNoteRoutes configures the routes of the notes appended to tasks
+func SetupNoteRoutes(router *gin.Engine, noteController *controllers.NoteController, authMiddleware *middleware.AuthMiddleware) {
+	notes := router.Group("/tasks/:id/notes")
+
+	// Apply auth middleware to all note routes
+	notes.Use(authMiddleware.Protect())
+
+	{
+		notes.GET("", noteController.GetNotes)
+		notes.POST("", noteController.CreateNote)
+		notes.PUT("/:noteId", noteController.UpdateNote)
+		notes.DELETE("/:noteId", noteController.DeleteNote)
+	}
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupNotificationRoutes configures the in-app notification inbox routes
+func SetupNotificationRoutes(router *gin.Engine, notificationController *controllers.NotificationController, authMiddleware *middleware.AuthMiddleware) {
+	notifications := router.Group("/notifications")
+
+	// All notification routes require authentication
+	notifications.Use(authMiddleware.Protect())
+
+	{
+		notifications.GET("/", notificationController.GetNotifications)
+		notifications.POST("/read-all", notificationController.MarkAllRead)
+		notifications.POST("/:id/read", notificationController.MarkRead)
+	}
+}
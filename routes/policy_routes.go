@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"gotodolist/authz"
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupPolicyRoutes configures the policy document routes
+func SetupPolicyRoutes(router *gin.Engine, policyController *controllers.PolicyController, authMiddleware *middleware.AuthMiddleware) {
+	policies := router.Group("/policies")
+	{
+		policies.GET("/", policyController.GetPolicies)
+		policies.GET("/:type", policyController.GetPolicy)
+
+		// Protected routes
+		policies.POST("/:type/accept", authMiddleware.Protect(), policyController.AcceptPolicy)
+	}
+
+	// Publishing requires the policies:publish permission
+	router.POST("/admin/policies", authMiddleware.Protect(), authMiddleware.Require(authz.PolicyPublish), policyController.PublishPolicy)
+}
@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupProjectRoutes configures the project routes
+func SetupProjectRoutes(router *gin.Engine, projectController *controllers.ProjectController, authMiddleware *middleware.AuthMiddleware) {
+	projects := router.Group("/projects")
+
+	// Apply auth middleware to all project routes
+	projects.Use(authMiddleware.Protect())
+
+	{
+		projects.GET("/", projectController.GetProjects)
+		projects.POST("/", projectController.CreateProject)
+		projects.GET("/:id", projectController.GetProject)
+		projects.PUT("/:id", projectController.UpdateProject)
+		projects.DELETE("/:id", projectController.DeleteProject)
+		projects.PUT("/:id/defaults", projectController.SetDefaults)
+		projects.DELETE("/:id/defaults", projectController.DeleteDefaults)
+		projects.POST("/:id/template", projectController.SaveTemplate)
+		projects.GET("/:id/timeline", projectController.GetTimeline)
+		projects.GET("/:id/sections", projectController.GetSections)
+		projects.POST("/:id/sections", projectController.CreateSection)
+		projects.PUT("/:id/sections", projectController.ReorderSections)
+		projects.PUT("/:id/sections/:sectionId", projectController.UpdateSection)
+		projects.DELETE("/:id/sections/:sectionId", projectController.DeleteSection)
+	}
+	templates := router.Group("/project-templates")
+	templates.Use(authMiddleware.Protect())
+
+	{
+		templates.GET("/", projectController.GetTemplates)
+		templates.DELETE("/:id", projectController.DeleteTemplate)
+	}
+}
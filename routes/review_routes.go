@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupReviewRoutes configures the review routes
+func SetupReviewRoutes(router *gin.Engine, reviewController *controllers.ReviewController, authMiddleware *middleware.AuthMiddleware) {
+	review := router.Group("/review")
+
+	// Apply auth middleware to all review routes
+	review.Use(authMiddleware.Protect())
+
+	{
+		review.GET("/weekly", reviewController.GetWeeklyReview)
+	}
+}
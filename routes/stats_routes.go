@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupStatsRoutes configures the reporting routes
+func SetupStatsRoutes(router *gin.Engine, statsController *controllers.StatsController, authMiddleware *middleware.AuthMiddleware) {
+	stats := router.Group("/stats")
+
+	// Apply auth middleware to all stats routes
+	stats.Use(authMiddleware.Protect())
+
+	{
+		stats.GET("/workload", statsController.GetWorkload)
+		stats.GET("/gamification", statsController.GetGamification)
+	}
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"gotodolist/authz"
+	"gotodolist/controllers"
+	"gotodolist/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupStatusRoutes configures the public status page and the admin routes
+// posting its incidents
+func SetupStatusRoutes(router *gin.Engine, statusController *controllers.StatusController, authMiddleware *middleware.AuthMiddleware) {
+	router.GET("/status", statusController.GetStatus)
+
+	incidents := router.Group("/admin/incidents")
+
+	// Posting incidents requires the incidents:manage permission
+	incidents.Use(authMiddleware.Protect(), authMiddleware.Require(authz.IncidentManage))
+
+	{
+		incidents.GET("/", statusController.GetIncidents)
+		incidents.POST("/", statusController.CreateIncident)
+		incidents.PUT("/:id", statusController.UpdateIncident)
+		incidents.DELETE("/:id", statusController.DeleteIncident)
+	}
+}
@@ -16,7 +16,30 @@ func SetupTaskRoutes(router *gin.Engine, taskController *controllers.TaskControl
 
 	{
 		tasks.GET("/", taskController.GetTasks)
+		tasks.GET("/matrix", taskController.GetMatrix)
+		tasks.GET("/counts", taskController.GetTaskCounts)
+		tasks.GET("/tags", taskController.GetTaskTags)
+		tasks.PUT("/tags/:tag", taskController.UpdateTag)
+		tasks.PATCH("/tags/:tag", taskController.UpdateTagTasks)
+		tasks.POST("/tags/:tag/merge", taskController.MergeTag)
+		tasks.GET("/next", taskController.GetNextTasks)
+		tasks.GET("/export", taskController.ExportTasks)
 		tasks.GET("/:id", taskController.GetTask)
+		tasks.GET("/:id/activity", taskController.GetTaskActivity)
+		tasks.GET("/:id/versions", taskController.GetTaskVersions)
+		tasks.POST("/:id/versions/:v/restore", taskController.RestoreTaskVersion)
+		tasks.POST("/:id/snooze", taskController.SnoozeTask)
+		tasks.DELETE("/:id/snooze", taskController.UnsnoozeTask)
+		tasks.PUT("/:id/reminders", taskController.SetTaskReminders)
+		tasks.POST("/:id/recurrence/skip", taskController.SkipOccurrence)
+		tasks.POST("/:id/recurrence/exceptions", taskController.AddRecurrenceException)
+		tasks.POST("/:id/recurrence/pause", taskController.PauseRecurrence)
+		tasks.POST("/:id/recurrence/resume", taskController.ResumeRecurrence)
+		tasks.POST("/:id/recurrence/end", taskController.EndRecurrence)
+		tasks.POST("/:id/subtasks", taskController.CreateSubtask)
+		tasks.PUT("/:id/subtasks", taskController.ReorderSubtasks)
+		tasks.PUT("/:id/subtasks/:subtaskId", taskController.UpdateSubtask)
+		tasks.DELETE("/:id/subtasks/:subtaskId", taskController.DeleteSubtask)
 		tasks.POST("/", taskController.CreateTask)
 		tasks.PUT("/:id", taskController.UpdateTask)
 		tasks.DELETE("/:id", taskController.DeleteTask)
@@ -0,0 +1,112 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotodolist/models"
+	"gotodolist/utils"
+)
+
+// Token mints an access token for a user, signed with the current JWT key
+// like the tokens returned by login
+func Token(t testing.TB, user models.User) string {
+	t.Helper()
+
+	token, err := utils.GenerateAccessToken(utils.AccessClaims{
+		UserID:       user.ID.Hex(),
+		Username:     user.Username,
+		Email:        user.Email,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
+	})
+	if err != nil {
+		t.Fatalf("generate access token: %v", err)
+	}
+	return token
+}
+
+// Client sends requests to a handler, usually the router, authenticated
+// with Token when it is set
+type Client struct {
+	Handler http.Handler
+	Token   string
+}
+
+// NewClient creates a client sending requests as the given user
+func NewClient(t testing.TB, handler http.Handler, user models.User) *Client {
+	return &Client{Handler: handler, Token: Token(t, user)}
+}
+
+// Do sends a request and returns the recorded response. A body other than
+// nil is sent as JSON.
+func (c *Client) Do(t testing.TB, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encode request body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	w := httptest.NewRecorder()
+	c.Handler.ServeHTTP(w, req)
+	return w
+}
+
+// Data checks that a response has the expected status and decodes the
+// "data" of its body into v, which may be nil to only check the status
+func Data(t testing.TB, w *httptest.ResponseRecorder, status int, v interface{}) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("status %d, want %d: %s", w.Code, status, w.Body.String())
+	}
+	if v == nil {
+		return
+	}
+
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if err := json.Unmarshal(response.Data, v); err != nil {
+		t.Fatalf("decode response data: %v", err)
+	}
+}
+
+// Error checks that a response has the expected status and error message
+func Error(t testing.TB, w *httptest.ResponseRecorder, status int, message string) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("status %d, want %d: %s", w.Code, status, w.Body.String())
+	}
+
+	var response struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if response.Error != message {
+		t.Errorf("error %q, want %q", response.Error, message)
+	}
+}
@@ -0,0 +1,46 @@
+package testutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotodolist/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Database connects to the MongoDB of TEST_MONGO_URI and returns a database
+// of its own to the test, dropped when the test ends. Tests needing it are
+// skipped when TEST_MONGO_URI is not set, so `go test ./...` runs without
+// a database.
+func Database(t testing.TB) *mongo.Database {
+	t.Helper()
+
+	uri := utils.GetEnv("TEST_MONGO_URI", "")
+	if uri == "" {
+		t.Skip("TEST_MONGO_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect to MongoDB: %v", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		t.Fatalf("ping MongoDB: %v", err)
+	}
+
+	database := client.Database("gotodolist_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		database.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return database
+}
@@ -0,0 +1,116 @@
+// Package testutil holds the setup shared by handler and service tests:
+// factories building valid models, a throwaway MongoDB database and an HTTP
+// client sending requests as a user with a freshly minted token.
+package testutil
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotodolist/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password is the password of every user built by NewUser
+const Password = "password123"
+
+var (
+	sequence     atomic.Int64
+	passwordHash string
+	passwordOnce sync.Once
+)
+
+// next returns a number unique to this test binary, to build unique names
+func next() int64 {
+	return sequence.Add(1)
+}
+
+// NewUser builds a regular user with a unique username and email and
+// Password as password. changes are applied in order, before it is returned.
+func NewUser(changes ...func(*models.User)) models.User {
+	passwordOnce.Do(func() {
+		// The lowest cost keeps tests fast, login checks any cost
+		hash, _ := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.MinCost)
+		passwordHash = string(hash)
+	})
+
+	n := next()
+	user := *models.NewUser(fmt.Sprintf("user%d", n), fmt.Sprintf("user%d@example.com", n), passwordHash)
+	user.ID = primitive.NewObjectID()
+	for _, change := range changes {
+		change(&user)
+	}
+	return user
+}
+
+// NewAdmin builds an admin, see NewUser
+func NewAdmin(changes ...func(*models.User)) models.User {
+	return NewUser(append([]func(*models.User){func(user *models.User) { user.Role = models.RoleAdmin }}, changes...)...)
+}
+
+// Unverified is a NewUser change leaving the user's email address
+// unverified, as after registration
+func Unverified(user *models.User) {
+	now := time.Now()
+	user.EmailVerification = &models.EmailVerification{
+		TokenHash:   fmt.Sprintf("unverified%d", next()),
+		RequestedAt: now,
+		ExpiresAt:   now.Add(48 * time.Hour),
+	}
+}
+
+// NewTask builds an open task of a user with a unique title
+func NewTask(user models.User, changes ...func(*models.Task)) models.Task {
+	task := *models.NewTask(fmt.Sprintf("Task %d", next()), user.ID)
+	task.ID = primitive.NewObjectID()
+	for _, change := range changes {
+		change(&task)
+	}
+	return task
+}
+
+// NewGoal builds a goal of a user with a unique name
+func NewGoal(user models.User, changes ...func(*models.Goal)) models.Goal {
+	goal := *models.NewGoal(fmt.Sprintf("Goal %d", next()), user.ID)
+	goal.ID = primitive.NewObjectID()
+	for _, change := range changes {
+		change(&goal)
+	}
+	return goal
+}
+
+// NewProject builds a project of a user with a unique name
+func NewProject(user models.User, changes ...func(*models.Project)) models.Project {
+	project := *models.NewProject(fmt.Sprintf("Project %d", next()), user.ID)
+	project.ID = primitive.NewObjectID()
+	for _, change := range changes {
+		change(&project)
+	}
+	return project
+}
+
+// Completed is a NewTask change completing the task now
+func Completed(task *models.Task) {
+	now := time.Now()
+	task.Completed = true
+	task.CompletedAt = &now
+}
+
+// Insert stores documents built by the factories, failing the test when it
+// cannot
+func Insert(t testing.TB, collection *mongo.Collection, documents ...interface{}) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := collection.InsertMany(ctx, documents); err != nil {
+		t.Fatalf("insert into %s: %v", collection.Name(), err)
+	}
+}
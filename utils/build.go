@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information, injected at build time with
+// -ldflags "-X gotodolist/utils.Version=v1.2.0 -X gotodolist/utils.Commit=abc1234 -X gotodolist/utils.BuildDate=2026-01-02T15:04:05Z"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// BuildInfo identifies the build of the running binary
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	GoVersion string `json:"goVersion"`
+}
+
+// GetBuildInfo returns the build information. A commit or date missing from
+// the ldflags falls back to the VCS stamp of the Go toolchain, if any.
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.BuildDate == "":
+				info.BuildDate = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// EnabledFeatures reports which optional behaviours are switched on by the
+// environment of this instance
+func EnabledFeatures() map[string]bool {
+	return map[string]bool{
+		"openapiValidation":    GetEnv("OPENAPI_VALIDATION", "false") == "true",
+		"statelessAuth":        StatelessAuth(),
+		"hideForeignResources": GetEnv("HIDE_FOREIGN_RESOURCES", "false") == "true",
+		"corsReflectOrigin":    GetEnv("CORS_REFLECT_ORIGIN", "false") == "true",
+		"logPrivacy":           newLogAnonymizer() != nil,
+		"sentry":               GetEnv("SENTRY_DSN", "") != "",
+		"pprof":                PprofEnabled(),
+	}
+}
+
+// PprofEnabled reports whether the admin-only profiling endpoints are
+// served (PPROF_ENABLED=true)
+func PprofEnabled() bool {
+	return GetEnv("PPROF_ENABLED", "false") == "true"
+}
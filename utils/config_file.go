@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFiles are looked up in the working directory when neither
+// -config nor CONFIG_FILE names a file
+var defaultConfigFiles = []string{"config.yaml", "config.yml", "config.toml"}
+
+// fileConfig holds the settings of the config file, keyed by the name of
+// the environment variable they stand for
+var fileConfig struct {
+	sync.RWMutex
+	path   string
+	values map[string]string
+}
+
+// LoadConfigFile loads the settings of a YAML or TOML config file and
+// returns its path. The file is the one given, else CONFIG_FILE, else the
+// first of config.yaml, config.yml and config.toml that exists; it is not
+// an error for none of the defaults to exist, in which case "" is returned.
+//
+// Keys are the environment variable names, case insensitive, and nested
+// keys are joined with "_", so "mongo: {uri: ...}" sets MONGO_URI. Lists
+// become comma-separated values. The environment, including .env, takes
+// precedence over the file, which takes precedence over the defaults.
+func LoadConfigFile(path string) (string, error) {
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path == "" {
+		for _, name := range defaultConfigFiles {
+			if _, err := os.Stat(name); err == nil {
+				path = name
+				break
+			}
+		}
+	}
+	if path == "" {
+		return "", nil
+	}
+
+	values, err := readConfigFile(path)
+	if err != nil {
+		return path, err
+	}
+
+	fileConfig.Lock()
+	fileConfig.path = path
+	fileConfig.values = values
+	fileConfig.Unlock()
+	return path, nil
+}
+
+// ReloadConfigFile reads the config file and the secret files again and
+// applies the settings that can change at runtime: LOG_LEVEL is applied at
+// once, cached keys are fetched again, and settings read on each use pick
+// up their new value. It returns
+// the path of the config file, or "" when none is in use. On error the
+// previous config file settings are kept.
+func ReloadConfigFile() (string, error) {
+	fileConfig.RLock()
+	path := fileConfig.path
+	fileConfig.RUnlock()
+
+	if path != "" {
+		values, err := readConfigFile(path)
+		if err != nil {
+			return path, err
+		}
+		fileConfig.Lock()
+		fileConfig.values = values
+		fileConfig.Unlock()
+	}
+	if err := LoadSecretFiles(); err != nil {
+		return path, err
+	}
+
+	ResetKeyCache()
+	GetLogger().severity.Store(defaultLogLevel())
+	return path, nil
+}
+
+// ConfigFilePath returns the path of the config file in use, "" if none
+func ConfigFilePath() string {
+	fileConfig.RLock()
+	defer fileConfig.RUnlock()
+	return fileConfig.path
+}
+
+// fileSetting returns the value of a setting in the config file, "" if it
+// is not set there
+func fileSetting(key string) string {
+	fileConfig.RLock()
+	defer fileConfig.RUnlock()
+	return fileConfig.values[key]
+}
+
+// readConfigFile parses a config file into settings keyed by environment
+// variable name, choosing the format from the extension
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .toml", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flattenConfig("", raw, values)
+	return values, nil
+}
+
+// flattenConfig turns nested keys into environment variable names
+func flattenConfig(prefix string, raw map[string]interface{}, values map[string]string) {
+	for key, value := range raw {
+		name := strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+		if prefix != "" {
+			name = prefix + "_" + name
+		}
+
+		switch value := value.(type) {
+		case map[string]interface{}:
+			flattenConfig(name, value, values)
+		case []interface{}:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			values[name] = strings.Join(items, ",")
+		case nil:
+		default:
+			values[name] = fmt.Sprint(value)
+		}
+	}
+}
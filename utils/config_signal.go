@@ -0,0 +1,21 @@
+//go:build !windows
+
+package utils
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// WatchConfigReload reloads the config file on every SIGHUP, calling
+// onReload with the outcome
+func WatchConfigReload(onReload func(path string, err error)) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			onReload(ReloadConfigFile())
+		}
+	}()
+}
@@ -0,0 +1,6 @@
+//go:build windows
+
+package utils
+
+// WatchConfigReload does nothing on Windows, which has no SIGHUP
+func WatchConfigReload(onReload func(path string, err error)) {}
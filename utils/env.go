@@ -15,9 +15,16 @@ func LoadEnv() {
 	}
 }
 
-// GetEnv gets an environment variable or returns a default value
+// GetEnv gets an environment variable, else its secret file, else its
+// setting in the config file, or returns a default value
 func GetEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
+	if value == "" {
+		value = secretSetting(key)
+	}
+	if value == "" {
+		value = fileSetting(key)
+	}
 	if value == "" {
 		return defaultValue
 	}
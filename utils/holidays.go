@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"sort"
+	"time"
+)
+
+// Holiday is a public holiday on a day formatted with DayLayout
+type Holiday struct {
+	Date string `json:"date"`
+	Name string `json:"name"`
+}
+
+// holidayRule computes the day of a holiday in a given year
+type holidayRule struct {
+	name string
+	day  func(year int) time.Time
+}
+
+// fixed is a holiday on the same date every year
+func fixed(name string, month time.Month, day int) holidayRule {
+	return holidayRule{name, func(year int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	}}
+}
+
+// nthWeekday is a holiday on the nth weekday of a month, counted from the
+// end of the month when n is negative
+func nthWeekday(name string, month time.Month, weekday time.Weekday, n int) holidayRule {
+	return holidayRule{name, func(year int) time.Time {
+		if n < 0 {
+			last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.Local)
+			offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+			return last.AddDate(0, 0, -offset+7*(n+1))
+		}
+		first := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+		offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+		return first.AddDate(0, 0, offset+7*(n-1))
+	}}
+}
+
+// easter is a holiday the given number of days after Easter Sunday
+func easter(name string, days int) holidayRule {
+	return holidayRule{name, func(year int) time.Time {
+		return easterSunday(year).AddDate(0, 0, days)
+	}}
+}
+
+// holidayCalendars holds the national holidays of each supported locale.
+// Substitute days for holidays falling on a weekend are not included.
+var holidayCalendars = map[string][]holidayRule{
+	"US": {
+		fixed("New Year's Day", time.January, 1),
+		nthWeekday("Martin Luther King Jr. Day", time.January, time.Monday, 3),
+		nthWeekday("Presidents' Day", time.February, time.Monday, 3),
+		nthWeekday("Memorial Day", time.May, time.Monday, -1),
+		fixed("Juneteenth", time.June, 19),
+		fixed("Independence Day", time.July, 4),
+		nthWeekday("Labor Day", time.September, time.Monday, 1),
+		nthWeekday("Columbus Day", time.October, time.Monday, 2),
+		fixed("Veterans Day", time.November, 11),
+		nthWeekday("Thanksgiving Day", time.November, time.Thursday, 4),
+		fixed("Christmas Day", time.December, 25),
+	},
+	"GB": {
+		fixed("New Year's Day", time.January, 1),
+		easter("Good Friday", -2),
+		easter("Easter Monday", 1),
+		nthWeekday("Early May Bank Holiday", time.May, time.Monday, 1),
+		nthWeekday("Spring Bank Holiday", time.May, time.Monday, -1),
+		nthWeekday("Summer Bank Holiday", time.August, time.Monday, -1),
+		fixed("Christmas Day", time.December, 25),
+		fixed("Boxing Day", time.December, 26),
+	},
+	"DE": {
+		fixed("New Year's Day", time.January, 1),
+		easter("Good Friday", -2),
+		easter("Easter Monday", 1),
+		fixed("Labour Day", time.May, 1),
+		easter("Ascension Day", 39),
+		easter("Whit Monday", 50),
+		fixed("German Unity Day", time.October, 3),
+		fixed("Christmas Day", time.December, 25),
+		fixed("Second Day of Christmas", time.December, 26),
+	},
+	"FR": {
+		fixed("New Year's Day", time.January, 1),
+		easter("Easter Monday", 1),
+		fixed("Labour Day", time.May, 1),
+		fixed("Victory in Europe Day", time.May, 8),
+		easter("Ascension Day", 39),
+		easter("Whit Monday", 50),
+		fixed("Bastille Day", time.July, 14),
+		fixed("Assumption of Mary", time.August, 15),
+		fixed("All Saints' Day", time.November, 1),
+		fixed("Armistice Day", time.November, 11),
+		fixed("Christmas Day", time.December, 25),
+	},
+}
+
+// HolidayLocales returns the locales with a built-in holiday calendar
+func HolidayLocales() []string {
+	locales := make([]string, 0, len(holidayCalendars))
+	for locale := range holidayCalendars {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// HolidaysOf returns the holidays of a locale in a year sorted by date, and
+// false when the locale has no built-in calendar
+func HolidaysOf(locale string, year int) ([]Holiday, bool) {
+	rules, ok := holidayCalendars[locale]
+	if !ok {
+		return nil, false
+	}
+
+	holidays := make([]Holiday, 0, len(rules))
+	for _, rule := range rules {
+		holidays = append(holidays, Holiday{Date: rule.day(year).Format(DayLayout), Name: rule.name})
+	}
+	sort.SliceStable(holidays, func(i, j int) bool { return holidays[i].Date < holidays[j].Date })
+	return holidays, true
+}
+
+// easterSunday returns Easter Sunday of a year in the Gregorian calendar
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+}
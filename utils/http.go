@@ -1,6 +1,11 @@
 package utils
 
 import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,3 +17,61 @@ func GetQueryDefault(c *gin.Context, key, defaultValue string) string {
 	}
 	return value
 }
+
+// RequestURL returns the absolute URL of the current request
+func RequestURL(c *gin.Context) *url.URL {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = strings.TrimSpace(strings.Split(proto, ",")[0])
+	}
+
+	u := *c.Request.URL
+	u.Scheme = scheme
+	u.Host = c.Request.Host
+	return &u
+}
+
+// SetPaginationLinks sets an RFC 5988 Link header with the first, prev, next
+// and last pages of a paginated list, and returns the same absolute URLs
+// keyed by relation for the pagination block of the response
+func SetPaginationLinks(c *gin.Context, page, limit, totalPages int) gin.H {
+	base := RequestURL(c)
+	pageURL := func(p int) string {
+		u := *base
+		query := u.Query()
+		query.Set("page", strconv.Itoa(p))
+		query.Set("limit", strconv.Itoa(limit))
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+
+	lastPage := totalPages
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	links := gin.H{
+		"self":  pageURL(page),
+		"first": pageURL(1),
+		"last":  pageURL(lastPage),
+	}
+	if page > 1 {
+		links["prev"] = pageURL(page - 1)
+	}
+	if page < lastPage {
+		links["next"] = pageURL(page + 1)
+	}
+
+	var header []string
+	for _, rel := range []string{"first", "prev", "next", "last"} {
+		if link, ok := links[rel]; ok {
+			header = append(header, fmt.Sprintf("<%s>; rel=\"%s\"", link, rel))
+		}
+	}
+	c.Header("Link", strings.Join(header, ", "))
+
+	return links
+}
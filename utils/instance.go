@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"sync"
+)
+
+var (
+	instanceID   string
+	instanceOnce sync.Once
+)
+
+// InstanceID returns an identifier for this API instance, used to tell
+// replicas apart in logs and when coordinating shared work. It is read from
+// INSTANCE_ID, or derived from the hostname plus a random suffix.
+func InstanceID() string {
+	instanceOnce.Do(func() {
+		instanceID = GetEnv("INSTANCE_ID", "")
+		if instanceID != "" {
+			return
+		}
+
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			hostname = "instance"
+		}
+
+		b := make([]byte, 3)
+		rand.Read(b)
+		instanceID = hostname + "-" + hex.EncodeToString(b)
+	})
+	return instanceID
+}
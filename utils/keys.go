@@ -0,0 +1,208 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JWTKey is the name of the key signing access tokens
+const JWTKey = "JWT_SECRET"
+
+// keyRetryInterval is how long cached keys are used after the provider
+// failed before it is asked again
+const keyRetryInterval = 30 * time.Second
+
+// Key is a version of a secret key
+type Key struct {
+	ID    string // Identifies the version, sent as the "kid" of tokens
+	Value []byte
+}
+
+// KeyProvider supplies secret keys by name, such as JWT_SECRET
+type KeyProvider interface {
+	// Keys returns the versions of a key in use: the current one first,
+	// then the previous one while a rotation is in progress
+	Keys(ctx context.Context, name string) ([]Key, error)
+}
+
+var (
+	keysOnce     sync.Once
+	keysProvider *cachedKeyProvider
+)
+
+// Keys returns the key provider chosen with KEY_PROVIDER: "env" (the
+// default) reads the settings, "file" reads the files of KEYS_DIR and
+// "vault" reads HashiCorp Vault. Keys are cached for KEY_CACHE_TTL (5m by
+// default), and the cached ones keep being used while the provider fails.
+func Keys() KeyProvider {
+	keysOnce.Do(func() {
+		var provider KeyProvider
+		switch name := GetEnv("KEY_PROVIDER", "env"); name {
+		case "file":
+			provider = fileKeyProvider{dir: GetEnv("KEYS_DIR", "/run/secrets")}
+		case "vault":
+			provider = newVaultKeyProvider()
+		default:
+			if name != "env" {
+				GetLogger().Named("keys").Warning("Unknown KEY_PROVIDER, using env: " + name)
+			}
+			provider = envKeyProvider{}
+		}
+
+		ttl, err := time.ParseDuration(GetEnv("KEY_CACHE_TTL", "5m"))
+		if err != nil || ttl < 0 {
+			ttl = 5 * time.Minute
+		}
+		keysProvider = &cachedKeyProvider{
+			provider: provider,
+			ttl:      ttl,
+			entries:  map[string]cachedKeys{},
+		}
+	})
+	return keysProvider
+}
+
+// CurrentKey returns the current version of a key
+func CurrentKey(ctx context.Context, name string) (Key, error) {
+	keys, err := Keys().Keys(ctx, name)
+	if err != nil {
+		return Key{}, err
+	}
+	return keys[0], nil
+}
+
+// ResetKeyCache drops the cached keys, so that a rotation is picked up at
+// once rather than after KEY_CACHE_TTL
+func ResetKeyCache() {
+	Keys()
+	keysProvider.reset()
+}
+
+// keyID derives the ID of a key version from its value, for providers
+// without versions
+func keyID(value []byte) string {
+	hash := sha256.Sum256(value)
+	return hex.EncodeToString(hash[:4])
+}
+
+// envKeyProvider reads a key from its setting, secret files included, and
+// its previous version from the setting with a _PREVIOUS suffix. JWT_SECRET
+// falls back to the development default.
+type envKeyProvider struct{}
+
+// Keys implements KeyProvider
+func (envKeyProvider) Keys(ctx context.Context, name string) ([]Key, error) {
+	fallback := ""
+	if name == JWTKey {
+		fallback = "your-secret-key"
+	}
+	current := GetEnv(name, fallback)
+	if current == "" {
+		return nil, fmt.Errorf("key %s is not set", name)
+	}
+
+	keys := []Key{{ID: keyID([]byte(current)), Value: []byte(current)}}
+	if previous := GetEnv(name+"_PREVIOUS", ""); previous != "" && previous != current {
+		keys = append(keys, Key{ID: keyID([]byte(previous)), Value: []byte(previous)})
+	}
+	return keys, nil
+}
+
+// fileKeyProvider reads a key from the file named after it in lower case,
+// such as jwt_secret, and its previous version from jwt_secret.previous
+type fileKeyProvider struct {
+	dir string
+}
+
+// Keys implements KeyProvider
+func (p fileKeyProvider) Keys(ctx context.Context, name string) ([]Key, error) {
+	path := filepath.Join(p.dir, strings.ToLower(name))
+	current, err := readKeyFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, fmt.Errorf("key file %s is empty", path)
+	}
+
+	keys := []Key{{ID: keyID(current), Value: current}}
+	previous, err := readKeyFile(path + ".previous")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if previous != nil && string(previous) != string(current) {
+		keys = append(keys, Key{ID: keyID(previous), Value: previous})
+	}
+	return keys, nil
+}
+
+// readKeyFile reads a key file without its trailing newlines, nil when empty
+func readKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return nil, nil
+	}
+	return []byte(value), nil
+}
+
+// cachedKeys are the keys of a name as last fetched
+type cachedKeys struct {
+	keys    []Key
+	expires time.Time
+}
+
+// cachedKeyProvider caches the keys of another provider
+type cachedKeyProvider struct {
+	provider KeyProvider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedKeys
+}
+
+// Keys implements KeyProvider. When the provider fails, the keys fetched
+// last are returned until it recovers.
+func (p *cachedKeyProvider) Keys(ctx context.Context, name string) ([]Key, error) {
+	p.mu.Lock()
+	entry, ok := p.entries[name]
+	p.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.keys, nil
+	}
+
+	keys, err := p.provider.Keys(ctx, name)
+	if err != nil {
+		if !ok {
+			return nil, err
+		}
+		GetLogger().Named("keys").Warning("Failed to refresh key " + name + ", using the cached one: " + err.Error())
+		keys = entry.keys
+		p.mu.Lock()
+		p.entries[name] = cachedKeys{keys: keys, expires: time.Now().Add(keyRetryInterval)}
+		p.mu.Unlock()
+		return keys, nil
+	}
+
+	p.mu.Lock()
+	p.entries[name] = cachedKeys{keys: keys, expires: time.Now().Add(p.ttl)}
+	p.mu.Unlock()
+	return keys, nil
+}
+
+// reset drops every cached key
+func (p *cachedKeyProvider) reset() {
+	p.mu.Lock()
+	p.entries = map[string]cachedKeys{}
+	p.mu.Unlock()
+}
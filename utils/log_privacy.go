@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Log privacy modes, set with LOG_PRIVACY
+const (
+	LogPrivacyOff      = "off"
+	LogPrivacyHash     = "hash"
+	LogPrivacyTruncate = "truncate"
+)
+
+var (
+	emailPattern    = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+	objectIDPattern = regexp.MustCompile(`\b[0-9a-fA-F]{24}\b`)
+	ipv4Pattern     = regexp.MustCompile(`\b\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}\b`)
+	ipv6Pattern     = regexp.MustCompile(`[0-9A-Fa-f]*:[0-9A-Fa-f:]*:[0-9A-Fa-f]*`)
+)
+
+// logAnonymizer masks emails, IP addresses and object IDs such as user IDs
+// in log lines. Hashing keeps equal values correlatable across lines,
+// truncation keeps a readable prefix.
+type logAnonymizer struct {
+	mode string
+	salt string
+}
+
+// newLogAnonymizer reads LOG_PRIVACY and LOG_PRIVACY_SALT, it returns nil
+// when privacy mode is off. Unknown modes hash, so a typo never leaks data.
+func newLogAnonymizer() *logAnonymizer {
+	mode := strings.ToLower(strings.TrimSpace(GetEnv("LOG_PRIVACY", LogPrivacyOff)))
+	if mode == LogPrivacyOff || mode == "" {
+		return nil
+	}
+	if mode != LogPrivacyTruncate {
+		mode = LogPrivacyHash
+	}
+	return &logAnonymizer{mode: mode, salt: GetEnv("LOG_PRIVACY_SALT", "")}
+}
+
+// anonymize masks every email, IP address and object ID of a line
+func (a *logAnonymizer) anonymize(line string) string {
+	line = emailPattern.ReplaceAllStringFunc(line, a.email)
+	line = objectIDPattern.ReplaceAllStringFunc(line, a.objectID)
+	line = ipv4Pattern.ReplaceAllStringFunc(line, a.ip)
+	return ipv6Pattern.ReplaceAllStringFunc(line, a.ip)
+}
+
+// hash returns a short salted digest of a value
+func (a *logAnonymizer) hash(kind, value string) string {
+	sum := sha256.Sum256([]byte(a.salt + strings.ToLower(value)))
+	return kind + ":" + hex.EncodeToString(sum[:6])
+}
+
+// email masks an address, truncation keeps its first letter and domain
+func (a *logAnonymizer) email(email string) string {
+	if a.mode == LogPrivacyHash {
+		return a.hash("email", email)
+	}
+	at := strings.LastIndex(email, "@")
+	return email[:1] + "***" + email[at:]
+}
+
+// objectID masks an ID, truncation keeps its timestamp part
+func (a *logAnonymizer) objectID(id string) string {
+	if a.mode == LogPrivacyHash {
+		return a.hash("id", id)
+	}
+	return id[:8] + "****"
+}
+
+// ip masks an address, truncation keeps its /24 or /48 network. Matches
+// that are not addresses, such as times, are left untouched.
+func (a *logAnonymizer) ip(value string) string {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return value
+	}
+	if a.mode == LogPrivacyHash {
+		return a.hash("ip", ip.String())
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(48, 128)).String()
+}
+
+// LogPseudonym returns how a value such as an email or user ID appears in
+// the logs when they are hashed. It reports false in the other modes,
+// where the logged form is either the value itself or not unique.
+func LogPseudonym(value string) (string, bool) {
+	anonymizer := GetLogger().anonymizer
+	if anonymizer == nil || anonymizer.mode != LogPrivacyHash {
+		return "", false
+	}
+	return anonymizer.anonymize(value), true
+}
@@ -6,7 +6,9 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -24,10 +26,61 @@ const (
 	LogSuccess LogLevel = "SUCCESS"
 )
 
+// logSeverity orders the levels, messages below the logger's level are dropped
+var logSeverity = map[LogLevel]int32{
+	LogDebug:   0,
+	LogInfo:    1,
+	LogSuccess: 1,
+	LogWarning: 2,
+	LogError:   3,
+}
+
+// ParseLogLevel parses a level name such as "debug" or "WARNING"
+func ParseLogLevel(name string) (LogLevel, error) {
+	level := LogLevel(strings.ToUpper(strings.TrimSpace(name)))
+	if level == "WARN" {
+		level = LogWarning
+	}
+	if _, ok := logSeverity[level]; !ok || level == LogSuccess {
+		return "", fmt.Errorf("unknown log level %q, use debug, info, warning or error", name)
+	}
+	return level, nil
+}
+
+// defaultLogLevel reads LOG_LEVEL, defaulting to debug in debug mode and info otherwise
+func defaultLogLevel() int32 {
+	fallback := "info"
+	if gin.Mode() == gin.DebugMode {
+		fallback = "debug"
+	}
+	level, err := ParseLogLevel(GetEnv("LOG_LEVEL", fallback))
+	if err != nil {
+		level, _ = ParseLogLevel(fallback)
+	}
+	return logSeverity[level]
+}
+
 // Logger is the main struct for logging operations
 type Logger struct {
-	file   *os.File
-	writer io.Writer
+	file      *os.File
+	writer    io.Writer
+	severity  *atomic.Int32 // Minimum severity that is written, shared with child loggers
+	component string        // Subsystem name of a child logger
+	fields    string        // Rendered key=value context of a child logger
+
+	anonymizer *logAnonymizer // Masks personal data when LOG_PRIVACY is enabled
+}
+
+// newLogger creates a root logger writing to the given writer
+func newLogger(file *os.File, writer io.Writer) *Logger {
+	logger := &Logger{
+		file:       file,
+		writer:     writer,
+		severity:   &atomic.Int32{},
+		anonymizer: newLogAnonymizer(),
+	}
+	logger.severity.Store(defaultLogLevel())
+	return logger
 }
 
 var logInstance *Logger
@@ -59,10 +112,7 @@ func InitLogger(logFilePath string) (*Logger, error) {
 		writer = file
 	}
 
-	logInstance = &Logger{
-		file:   file,
-		writer: writer,
-	}
+	logInstance = newLogger(file, writer)
 
 	return logInstance, nil
 }
@@ -74,13 +124,42 @@ func GetLogger() *Logger {
 		logger, err := InitLogger("logs/app.log")
 		if err != nil {
 			// Fall back to stdout if file logging fails
-			return &Logger{writer: os.Stdout}
+			return newLogger(nil, os.Stdout)
 		}
 		return logger
 	}
 	return logInstance
 }
 
+// Named returns a child logger for a subsystem such as "auth" or "jobs".
+// Its lines are tagged with the name instead of the caller's file, and it
+// shares the output and level of its parent.
+func (l *Logger) Named(component string) *Logger {
+	child := *l
+	if child.component != "" {
+		component = child.component + "." + component
+	}
+	child.component = component
+	return &child
+}
+
+// With returns a child logger adding key/value context to every line, such
+// as With("job", name). Keys and values alternate.
+func (l *Logger) With(keyValues ...interface{}) *Logger {
+	child := *l
+	var fields strings.Builder
+	fields.WriteString(child.fields)
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		value := fmt.Sprint(keyValues[i+1])
+		if strings.ContainsAny(value, " \t\"=") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&fields, " %v=%s", keyValues[i], value)
+	}
+	child.fields = fields.String()
+	return &child
+}
+
 // Close closes the log file
 func (l *Logger) Close() error {
 	if l.file != nil {
@@ -89,15 +168,19 @@ func (l *Logger) Close() error {
 	return nil
 }
 
-// formatMessage formats a log message with timestamp, level, and caller info
+// formatMessage formats a log message with timestamp, level, and the
+// component of a named logger or the caller info
 func (l *Logger) formatMessage(level LogLevel, message string) string {
-	// Get caller information
-	_, file, line, ok := runtime.Caller(2)
-	callerInfo := "unknown"
-	if ok {
-		parts := strings.Split(file, "/")
-		if len(parts) >= 2 {
-			callerInfo = fmt.Sprintf("%s:%d", parts[len(parts)-1], line)
+	callerInfo := l.component
+	if callerInfo == "" {
+		// Skip formatMessage, output and the exported logging method
+		_, file, line, ok := runtime.Caller(3)
+		callerInfo = "unknown"
+		if ok {
+			parts := strings.Split(file, "/")
+			if len(parts) >= 2 {
+				callerInfo = fmt.Sprintf("%s:%d", parts[len(parts)-1], line)
+			}
 		}
 	}
 
@@ -105,40 +188,65 @@ func (l *Logger) formatMessage(level LogLevel, message string) string {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 
 	// Return formatted log message
-	return fmt.Sprintf("[%s] [%s] [%s] %s\n", timestamp, level, callerInfo, message)
+	return fmt.Sprintf("[%s] [%s] [%s] %s%s\n", timestamp, level, callerInfo, message, l.fields)
+}
+
+// Level returns the minimum level that is written
+func (l *Logger) Level() LogLevel {
+	severity := l.severity.Load()
+	for _, level := range []LogLevel{LogDebug, LogInfo, LogWarning, LogError} {
+		if logSeverity[level] == severity {
+			return level
+		}
+	}
+	return LogInfo
+}
+
+// SetLevel changes the minimum level that is written, at runtime
+func (l *Logger) SetLevel(level LogLevel) {
+	l.severity.Store(logSeverity[level])
 }
 
 // Log logs a message with the specified level
 func (l *Logger) Log(level LogLevel, message string) {
+	l.output(level, message)
+}
+
+// output writes a message if its level is enabled
+func (l *Logger) output(level LogLevel, message string) {
+	if logSeverity[level] < l.severity.Load() {
+		return
+	}
 	formattedMessage := l.formatMessage(level, message)
+	if l.anonymizer != nil {
+		formattedMessage = l.anonymizer.anonymize(formattedMessage)
+	}
 	fmt.Fprint(l.writer, formattedMessage)
 }
 
 // Debug logs a debug message
 func (l *Logger) Debug(message string) {
-	if gin.Mode() == gin.DebugMode {
-		l.Log(LogDebug, message)
-	}
+	l.output(LogDebug, message)
 }
 
 // Info logs an info message
 func (l *Logger) Info(message string) {
-	l.Log(LogInfo, message)
+	l.output(LogInfo, message)
 }
 
 // Warning logs a warning message
 func (l *Logger) Warning(message string) {
-	l.Log(LogWarning, message)
+	l.output(LogWarning, message)
 }
 
 // Error logs an error message
 func (l *Logger) Error(message string) {
-	l.Log(LogError, message)
+	l.output(LogError, message)
 }
 
 // Success logs a success message
 func (l *Logger) Success(message string) {
-	l.Log(LogSuccess, message)
+	l.output(LogSuccess, message)
 }
 
 // LogRequest logs HTTP request information
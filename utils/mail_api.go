@@ -0,0 +1,141 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"gotodolist/emails"
+)
+
+// sendGridMailer sends emails through the v3 Mail Send API of SendGrid,
+// authenticated with SENDGRID_API_KEY
+type sendGridMailer struct {
+	apiKey string
+	from   *mail.Address
+	client *http.Client
+}
+
+// sendGridAddress is an address in a SendGrid request
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+// newSendGridMailer creates a SendGrid transport from the environment
+func newSendGridMailer(from *mail.Address) *sendGridMailer {
+	return &sendGridMailer{
+		apiKey: GetEnv("SENDGRID_API_KEY", ""),
+		from:   from,
+		client: &http.Client{Timeout: mailTimeout},
+	}
+}
+
+// Send implements Mailer
+func (m *sendGridMailer) Send(ctx context.Context, to string, email emails.Message) error {
+	recipient, err := parseRecipient(to, email)
+	if err != nil {
+		return err
+	}
+
+	// The plain text version has to come first
+	content := []map[string]string{{"type": "text/plain", "value": email.Text}}
+	if email.HTML != "" {
+		content = append(content, map[string]string{"type": "text/html", "value": email.HTML})
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"personalizations": []map[string]interface{}{
+			{"to": []sendGridAddress{{Email: recipient.Address, Name: recipient.Name}}},
+		},
+		"from":    sendGridAddress{Email: m.from.Address, Name: m.from.Name},
+		"subject": email.Subject,
+		"content": content,
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.sendgrid.com/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+m.apiKey)
+	request.Header.Set("Content-Type", "application/json")
+	return sendMailRequest(m.client, request, "sendgrid")
+}
+
+// mailgunMailer sends emails through the messages API of Mailgun for
+// MAILGUN_DOMAIN, authenticated with MAILGUN_API_KEY. MAILGUN_API_BASE
+// selects the region, https://api.eu.mailgun.net for domains in the EU.
+type mailgunMailer struct {
+	apiBase string
+	domain  string
+	apiKey  string
+	from    *mail.Address
+	client  *http.Client
+}
+
+// newMailgunMailer creates a Mailgun transport from the environment
+func newMailgunMailer(from *mail.Address) *mailgunMailer {
+	return &mailgunMailer{
+		apiBase: strings.TrimRight(GetEnv("MAILGUN_API_BASE", "https://api.mailgun.net"), "/"),
+		domain:  GetEnv("MAILGUN_DOMAIN", ""),
+		apiKey:  GetEnv("MAILGUN_API_KEY", ""),
+		from:    from,
+		client:  &http.Client{Timeout: mailTimeout},
+	}
+}
+
+// Send implements Mailer
+func (m *mailgunMailer) Send(ctx context.Context, to string, email emails.Message) error {
+	recipient, err := parseRecipient(to, email)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{
+		"from":    {m.from.String()},
+		"to":      {recipient.String()},
+		"subject": {email.Subject},
+		"text":    {email.Text},
+	}
+	if email.HTML != "" {
+		form.Set("html", email.HTML)
+	}
+
+	endpoint := m.apiBase + "/v3/" + url.PathEscape(m.domain) + "/messages"
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.SetBasicAuth("api", m.apiKey)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return sendMailRequest(m.client, request, "mailgun")
+}
+
+// sendMailRequest sends a request to the API of a mail service. An email
+// the service found invalid is rejected, other failures can be retried.
+func sendMailRequest(client *http.Client, request *http.Request, service string) error {
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return nil
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	err = fmt.Errorf("%s answered %d: %s", service, response.StatusCode, strings.TrimSpace(string(detail)))
+	switch response.StatusCode {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return fmt.Errorf("%w: %v", ErrMailRejected, err)
+	}
+	return err
+}
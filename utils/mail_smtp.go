@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"gotodolist/emails"
+)
+
+// mailTimeout bounds the whole conversation with the SMTP server
+const mailTimeout = 30 * time.Second
+
+// smtpMailer sends emails through the SMTP server at SMTP_HOST and
+// SMTP_PORT (587 by default). It logs in with SMTP_USERNAME and
+// SMTP_PASSWORD when they are set, once the connection is upgraded with
+// STARTTLS, which is required unless SMTP_STARTTLS is false.
+type smtpMailer struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     *mail.Address
+	startTLS bool
+}
+
+// newSMTPMailer creates an SMTP transport from the environment
+func newSMTPMailer(from *mail.Address) *smtpMailer {
+	return &smtpMailer{
+		host:     GetEnv("SMTP_HOST", ""),
+		port:     GetEnv("SMTP_PORT", "587"),
+		username: GetEnv("SMTP_USERNAME", ""),
+		password: GetEnv("SMTP_PASSWORD", ""),
+		from:     from,
+		startTLS: GetEnv("SMTP_STARTTLS", "true") != "false",
+	}
+}
+
+// Send implements Mailer, sending an email as plain text, or with its
+// text and HTML versions as alternatives when it has an HTML version
+func (m *smtpMailer) Send(ctx context.Context, to string, email emails.Message) error {
+	recipient, err := parseRecipient(to, email)
+	if err != nil {
+		return err
+	}
+	message, err := m.message(recipient, email)
+	if err != nil {
+		return err
+	}
+	err = m.deliver(ctx, recipient.Address, message)
+
+	// Permanent failures are answered with a 5xx code
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return fmt.Errorf("%w: %v", ErrMailRejected, err)
+	}
+	return err
+}
+
+// message builds the email with its headers, lines ending with CRLF
+func (m *smtpMailer) message(to *mail.Address, email emails.Message) ([]byte, error) {
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", m.from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", email.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", NewRequestID(), m.host)},
+		{"MIME-Version", "1.0"},
+	}
+	for _, header := range headers {
+		message.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+
+	if email.HTML == "" {
+		message.WriteString("Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&message, email.Text); err != nil {
+			return nil, err
+		}
+		return message.Bytes(), nil
+	}
+
+	// Clients show the last alternative they support, so HTML comes last
+	parts := multipart.NewWriter(&message)
+	message.WriteString("Content-Type: multipart/alternative; boundary=" + parts.Boundary() + "\r\n\r\n")
+	for _, part := range [][2]string{{"text/plain", email.Text}, {"text/html", email.HTML}} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part[0] + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(writer, part[1]); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return message.Bytes(), nil
+}
+
+// writeQuotedPrintable writes a body in the quoted-printable encoding,
+// with CRLF line endings, so that no line exceeds the SMTP limit
+func writeQuotedPrintable(w io.Writer, body string) error {
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	encoder := quotedprintable.NewWriter(w)
+	if _, err := encoder.Write([]byte(body)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// deliver sends a message over a new SMTP connection
+func (m *smtpMailer) deliver(ctx context.Context, to string, message []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, mailTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(m.host, m.port))
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return err
+		}
+	} else if m.startTLS {
+		return errors.New("the SMTP server does not support STARTTLS, set SMTP_STARTTLS=false to send without it")
+	}
+	if m.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"gotodolist/emails"
+)
+
+// ErrMailRejected wraps the errors of emails the transport refused for
+// good, such as an invalid recipient, which are not worth retrying
+var ErrMailRejected = errors.New("email rejected")
+
+// Mailer delivers emails through a mail transport
+type Mailer interface {
+	// Send sends an email to a single recipient
+	Send(ctx context.Context, to string, email emails.Message) error
+}
+
+// NewMailer returns the mail transport chosen with MAIL_TRANSPORT: "smtp"
+// sends through SMTP_HOST, "sendgrid" and "mailgun" through the HTTP API
+// of those services and "log" only logs emails, so development works
+// without a mail server. It defaults to smtp when SMTP_HOST is set and
+// log otherwise. A transport missing its settings falls back to log.
+func NewMailer() Mailer {
+	logger := GetLogger().Named("mailer")
+	from := mailFrom(logger)
+
+	name := GetEnv("MAIL_TRANSPORT", DefaultMailTransport())
+	switch name {
+	case "smtp":
+		if GetEnv("SMTP_HOST", "") != "" {
+			return newSMTPMailer(from)
+		}
+	case "sendgrid":
+		if GetEnv("SENDGRID_API_KEY", "") != "" {
+			return newSendGridMailer(from)
+		}
+	case "mailgun":
+		if GetEnv("MAILGUN_API_KEY", "") != "" && GetEnv("MAILGUN_DOMAIN", "") != "" {
+			return newMailgunMailer(from)
+		}
+	case "log":
+		return logMailer{logger: logger}
+	default:
+		logger.Warning("Unknown MAIL_TRANSPORT, emails are logged instead of sent: " + name)
+		return logMailer{logger: logger}
+	}
+	logger.Warning("MAIL_TRANSPORT " + name + " is missing its settings, emails are logged instead of sent")
+	return logMailer{logger: logger}
+}
+
+// DefaultMailTransport returns the transport used when MAIL_TRANSPORT is
+// not set: smtp with an SMTP_HOST, log without one
+func DefaultMailTransport() string {
+	if GetEnv("SMTP_HOST", "") != "" {
+		return "smtp"
+	}
+	return "log"
+}
+
+// mailFrom reads the sender of emails from MAIL_FROM, or SMTP_FROM which
+// it replaces, an invalid address falling back to the default
+func mailFrom(logger *Logger) *mail.Address {
+	value := GetEnv("MAIL_FROM", GetEnv("SMTP_FROM", ""))
+	if value != "" {
+		from, err := mail.ParseAddress(value)
+		if err == nil {
+			return from
+		}
+		logger.Warning("Invalid MAIL_FROM, using no-reply@localhost: " + err.Error())
+	}
+	return &mail.Address{Name: "gotodolist", Address: "no-reply@localhost"}
+}
+
+// parseRecipient checks an email before it is handed to a transport
+func parseRecipient(to string, email emails.Message) (*mail.Address, error) {
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid recipient: %v", ErrMailRejected, err)
+	}
+	if strings.ContainsAny(email.Subject, "\r\n") {
+		return nil, fmt.Errorf("%w: invalid subject: contains a line break", ErrMailRejected)
+	}
+	return recipient, nil
+}
+
+// logMailer logs emails instead of sending them, with their body at the
+// debug level only
+type logMailer struct {
+	logger *Logger
+}
+
+// Send implements Mailer
+func (m logMailer) Send(ctx context.Context, to string, email emails.Message) error {
+	recipient, err := parseRecipient(to, email)
+	if err != nil {
+		return err
+	}
+	m.logger.Info("Email not sent, MAIL_TRANSPORT is log: \"" + email.Subject + "\" to " + recipient.Address)
+	m.logger.Debug("Email body:\n" + email.Text)
+	return nil
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// secretSettings are the sensitive settings that can be read from a file,
+// named by the setting with a _FILE suffix, such as a Docker or Kubernetes
+// secret mounted at /run/secrets/jwt_secret
+var secretSettings = []string{"JWT_SECRET", "JWT_SECRET_PREVIOUS", "MONGO_URI", "SENTRY_DSN", "LOG_PRIVACY_SALT", "VAULT_TOKEN", "SMTP_PASSWORD", "SENDGRID_API_KEY", "MAILGUN_API_KEY"}
+
+// secretFiles holds the values read from secret files, keyed by setting
+var secretFiles struct {
+	sync.RWMutex
+	values map[string]string
+}
+
+// LoadSecretFiles reads the secret files named by the _FILE variant of the
+// secret settings, such as JWT_SECRET_FILE, from the environment or the
+// config file. Trailing newlines are dropped. A value set directly takes
+// precedence over its file, and a file that cannot be read is an error
+// rather than a silent fallback to the default.
+func LoadSecretFiles() error {
+	values := map[string]string{}
+	for _, key := range secretSettings {
+		path := os.Getenv(key + "_FILE")
+		if path == "" {
+			path = fileSetting(key + "_FILE")
+		}
+		if path == "" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s_FILE: %w", key, err)
+		}
+		value := strings.TrimRight(string(data), "\r\n")
+		if value == "" {
+			return fmt.Errorf("%s_FILE: %s is empty", key, path)
+		}
+		values[key] = value
+	}
+
+	secretFiles.Lock()
+	secretFiles.values = values
+	secretFiles.Unlock()
+	return nil
+}
+
+// secretSetting returns the value of a setting read from its secret file,
+// "" if it has none
+func secretSetting(key string) string {
+	secretFiles.RLock()
+	defer secretFiles.RUnlock()
+	return secretFiles.values[key]
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SentryReporter sends error events to Sentry through its store endpoint
+type SentryReporter struct {
+	endpoint string
+	auth     string
+	client   *http.Client
+}
+
+// NewSentryReporter creates a reporter from a Sentry DSN such as
+// https://<key>@o0.ingest.sentry.io/<project>
+func NewSentryReporter(dsn string) (*SentryReporter, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil || parsed.User == nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN")
+	}
+
+	path := strings.Trim(parsed.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	prefix, project := "", path
+	if slash >= 0 {
+		prefix, project = "/"+path[:slash], path[slash+1:]
+	}
+	if project == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN: missing project ID")
+	}
+
+	return &SentryReporter{
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/store/", parsed.Scheme, parsed.Host, prefix, project),
+		auth:     fmt.Sprintf("Sentry sentry_version=7, sentry_client=gotodolist/1.0, sentry_key=%s", parsed.User.Username()),
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}, nil
+}
+
+// Report sends an error event in the background; delivery failures are logged
+func (r *SentryReporter) Report(message string, stack []byte, tags map[string]string) {
+	eventID := make([]byte, 16)
+	rand.Read(eventID)
+
+	event := map[string]interface{}{
+		"event_id":    hex.EncodeToString(eventID),
+		"timestamp":   time.Now().UTC().Format(time.RFC3339),
+		"level":       "error",
+		"platform":    "go",
+		"logger":      "gotodolist",
+		"server_name": InstanceID(),
+		"environment": GetEnv("GIN_MODE", "debug"),
+		"message":     message,
+		"tags":        tags,
+		"extra": map[string]string{
+			"stack": string(stack),
+		},
+	}
+
+	go func() {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+
+		req, err := http.NewRequest(http.MethodPost, r.endpoint, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Sentry-Auth", r.auth)
+
+		resp, err := r.client.Do(req)
+		if err != nil {
+			GetLogger().Warning("Failed to report error to Sentry: " + err.Error())
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			GetLogger().Warning(fmt.Sprintf("Sentry rejected error report with status %d", resp.StatusCode))
+		}
+	}()
+}
@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"sort"
+	"time"
+)
+
+// DayLayout is the format used for day keys (e.g. check-in dates)
+const DayLayout = "2006-01-02"
+
+// StartOfDay returns midnight of the day containing t
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// PeriodStart returns the start of the day, or of the week (Monday) when weekly is set, containing t
+func PeriodStart(t time.Time, weekly bool) time.Time {
+	start := StartOfDay(t)
+	if weekly {
+		offset := (int(start.Weekday()) + 6) % 7
+		start = start.AddDate(0, 0, -offset)
+	}
+	return start
+}
+
+// CalculateStreaks returns the current and longest run of consecutive periods
+// whose count reaches target. counts is keyed by the period start formatted
+// with DayLayout. The period containing now does not break the current streak
+// while it is still in progress.
+func CalculateStreaks(counts map[string]int, target int, weekly bool, now time.Time) (current int, longest int) {
+	step := 1
+	if weekly {
+		step = 7
+	}
+
+	met := func(t time.Time) bool {
+		return counts[t.Format(DayLayout)] >= target
+	}
+
+	// Current streak: walk backwards from the current period
+	period := PeriodStart(now, weekly)
+	if !met(period) {
+		period = period.AddDate(0, 0, -step)
+	}
+	for met(period) {
+		current++
+		period = period.AddDate(0, 0, -step)
+	}
+
+	// Longest streak: scan all periods that reached the target in order
+	var periods []time.Time
+	for key, count := range counts {
+		if count < target {
+			continue
+		}
+		t, err := time.ParseInLocation(DayLayout, key, now.Location())
+		if err != nil {
+			continue
+		}
+		periods = append(periods, t)
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].Before(periods[j]) })
+
+	run := 0
+	for i, t := range periods {
+		if i > 0 && periods[i-1].AddDate(0, 0, step).Equal(t) {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+
+	return current, longest
+}
@@ -1,31 +1,58 @@
 package utils
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// GenerateAccessToken creates a new JWT access token for a user
-func GenerateAccessToken(userID string) (string, error) {
+// AccessClaims are the user details embedded in an access token
+type AccessClaims struct {
+	UserID       string
+	Username     string
+	Email        string
+	Role         string
+	TokenVersion int    // Must match the user's, bumping it revokes every token
+	SessionID    string // Session the token belongs to, ending it revokes the token
+}
+
+// GenerateAccessToken creates a new JWT access token for a user. The
+// username, email and role are embedded so that the stateless auth mode can
+// trust the token without looking the user up.
+func GenerateAccessToken(user AccessClaims) (string, error) {
 	// Define token expiration
 	expireTime := GetTokenExpiration()
 
 	// Create claims
 	claims := jwt.MapClaims{
-		"id":  userID,
-		"exp": expireTime.Unix(),
-		"iat": time.Now().Unix(),
+		"id":       user.UserID,
+		"username": user.Username,
+		"email":    user.Email,
+		"role":     user.Role,
+		"ver":      user.TokenVersion,
+		"exp":      expireTime.Unix(),
+		"iat":      time.Now().Unix(),
+	}
+	if user.SessionID != "" {
+		claims["sid"] = user.SessionID
 	}
 
 	// Create token with claims
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	// Sign the token with the secret key
-	tokenString, err := token.SignedString([]byte(GetEnv("JWT_SECRET", "your-secret-key")))
+	// Sign the token with the current key, named in the header so that it
+	// can still be verified once the key is rotated
+	key, err := CurrentKey(context.Background(), JWTKey)
+	if err != nil {
+		return "", err
+	}
+	token.Header["kid"] = key.ID
+	tokenString, err := token.SignedString(key.Value)
 	if err != nil {
 		return "", err
 	}
@@ -33,6 +60,27 @@ func GenerateAccessToken(userID string) (string, error) {
 	return tokenString, nil
 }
 
+// TokenKey returns the key verifying an access token: the version named by
+// its "kid", or the current key for tokens issued without one. Tokens
+// signed with a key that is neither current nor previous are rejected.
+func TokenKey(ctx context.Context, token *jwt.Token) ([]byte, error) {
+	keys, err := Keys().Keys(ctx, JWTKey)
+	if err != nil {
+		return nil, err
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return keys[0].Value, nil
+	}
+	for _, key := range keys {
+		if key.ID == kid {
+			return key.Value, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
 // GenerateRefreshToken creates a new refresh token
 func GenerateRefreshToken() (string, string, time.Time) {
 	// Generate random token
@@ -50,6 +98,54 @@ func GenerateRefreshToken() (string, string, time.Time) {
 	return refreshToken, hashedToken, expireTime
 }
 
+// GeneratePasswordResetToken creates a password reset token, returning it
+// with its hash for storage and the time it expires, PASSWORD_RESET_TTL
+// (1h by default) from now
+func GeneratePasswordResetToken() (string, string, time.Time) {
+	return generateEmailedToken("PASSWORD_RESET_TTL", time.Hour)
+}
+
+// GenerateEmailVerificationToken creates a token verifying the email
+// address of a new account, returning it with its hash for storage and the
+// time it expires, EMAIL_VERIFICATION_TTL (48h by default) from now
+func GenerateEmailVerificationToken() (string, string, time.Time) {
+	return generateEmailedToken("EMAIL_VERIFICATION_TTL", 48*time.Hour)
+}
+
+// GenerateEmailChangeToken creates a token confirming a new email address,
+// returning it with its hash for storage and the time it expires,
+// EMAIL_CHANGE_TTL (24h by default) from now
+func GenerateEmailChangeToken() (string, string, time.Time) {
+	return generateEmailedToken("EMAIL_CHANGE_TTL", 24*time.Hour)
+}
+
+// generateEmailedToken creates a single use token sent by email, valid for
+// the duration of a setting
+func generateEmailedToken(ttlSetting string, defaultTTL time.Duration) (string, string, time.Time) {
+	b := make([]byte, 32)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	ttl, err := time.ParseDuration(GetEnv(ttlSetting, defaultTTL.String()))
+	if err != nil || ttl <= 0 {
+		ttl = defaultTTL
+	}
+
+	return token, HashString(token), time.Now().Add(ttl)
+}
+
+// StatelessAuth reports whether protected routes trust the token claims
+// instead of loading the user on every request (AUTH_STATELESS=true)
+func StatelessAuth() bool {
+	return GetEnv("AUTH_STATELESS", "false") == "true"
+}
+
+// RequireVerifiedEmail reports whether users must verify their email
+// address before using the API (REQUIRE_VERIFIED_EMAIL=true)
+func RequireVerifiedEmail() bool {
+	return GetEnv("REQUIRE_VERIFIED_EMAIL", "false") == "true"
+}
+
 // GetTokenExpiration returns the expiration time for access tokens
 func GetTokenExpiration() time.Time {
 	// Parse the JWT_EXPIRE environment variable with a default of 24 hours
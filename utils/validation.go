@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// MaxIconLength is the maximum number of characters of an icon name or emoji
+const MaxIconLength = 32
+
+// IsValidColor checks that a color is a hex color such as #f80 or #ff8800
+func IsValidColor(color string) bool {
+	return hexColorPattern.MatchString(color)
+}
+
+// IsValidIcon checks that an icon is an emoji or a short icon name
+func IsValidIcon(icon string) bool {
+	length := utf8.RuneCountInString(icon)
+	return length > 0 && length <= MaxIconLength
+}
+
+var tagPattern = regexp.MustCompile(`^[\p{L}\p{N}_-]{1,32}$`)
+
+// MaxTags is the maximum number of tags of a task
+const MaxTags = 20
+
+// NormalizeTag returns a tag such as " #Work" in its stored "work" form, and
+// whether it is a valid tag
+func NormalizeTag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	return tag, tagPattern.MatchString(tag)
+}
+
+// NormalizeTags normalizes a list of tags, dropping duplicates, and reports
+// whether every tag is valid and there are at most MaxTags of them
+func NormalizeTags(tags []string) ([]string, bool) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag, ok := NormalizeTag(tag)
+		if !ok {
+			return nil, false
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, len(normalized) <= MaxTags
+}
+
+var contextNamePattern = regexp.MustCompile(`^@[\p{L}\p{N}_-]{1,31}$`)
+
+// NormalizeContext returns a GTD context such as "home" or "@home" in its
+// stored "@home" form, and whether it is a valid context name
+func NormalizeContext(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	if !strings.HasPrefix(name, "@") {
+		name = "@" + name
+	}
+	return name, contextNamePattern.MatchString(name)
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// vaultKeyProvider reads keys from the KV version 2 secrets engine of
+// HashiCorp Vault. A key is the "value" field of the secret named after it
+// in lower case, and its previous version is the secret's previous version.
+type vaultKeyProvider struct {
+	addr   string // VAULT_ADDR, e.g. https://vault.example.com:8200
+	token  string // VAULT_TOKEN, or VAULT_TOKEN_FILE
+	mount  string // VAULT_KV_MOUNT, "secret" by default
+	path   string // VAULT_KEYS_PATH, "gotodolist" by default
+	client *http.Client
+}
+
+// vaultSecret is the part of a KV version 2 read that is used
+type vaultSecret struct {
+	Data struct {
+		Data     map[string]interface{} `json:"data"`
+		Metadata struct {
+			Version int `json:"version"`
+		} `json:"metadata"`
+	} `json:"data"`
+}
+
+// newVaultKeyProvider creates a Vault provider from the environment
+func newVaultKeyProvider() *vaultKeyProvider {
+	return &vaultKeyProvider{
+		addr:   strings.TrimRight(GetEnv("VAULT_ADDR", "http://127.0.0.1:8200"), "/"),
+		token:  GetEnv("VAULT_TOKEN", ""),
+		mount:  strings.Trim(GetEnv("VAULT_KV_MOUNT", "secret"), "/"),
+		path:   strings.Trim(GetEnv("VAULT_KEYS_PATH", "gotodolist"), "/"),
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Keys implements KeyProvider
+func (p *vaultKeyProvider) Keys(ctx context.Context, name string) ([]Key, error) {
+	current, version, err := p.read(ctx, name, 0)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, fmt.Errorf("vault secret %s has no value", name)
+	}
+	keys := []Key{{ID: "v" + strconv.Itoa(version), Value: current}}
+
+	// A deleted or destroyed previous version is no longer accepted
+	if version > 1 {
+		previous, _, err := p.read(ctx, name, version-1)
+		if err != nil {
+			return nil, err
+		}
+		if previous != nil {
+			keys = append(keys, Key{ID: "v" + strconv.Itoa(version-1), Value: previous})
+		}
+	}
+	return keys, nil
+}
+
+// read returns the value of a version of a secret, the latest for version
+// 0, with its version number. The value is nil when the version has been
+// deleted.
+func (p *vaultKeyProvider) read(ctx context.Context, name string, version int) ([]byte, int, error) {
+	endpoint := fmt.Sprintf("%s/v1/%s/data/%s/%s", p.addr, p.mount, p.path, url.PathEscape(strings.ToLower(name)))
+	if version > 0 {
+		endpoint += "?version=" + strconv.Itoa(version)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	request.Header.Set("X-Vault-Token", p.token)
+
+	response, err := p.client.Do(request)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer response.Body.Close()
+
+	// Vault answers 404 with the metadata of a deleted version
+	if response.StatusCode != http.StatusOK && !(response.StatusCode == http.StatusNotFound && version > 0) {
+		return nil, 0, fmt.Errorf("vault answered %d reading %s", response.StatusCode, name)
+	}
+	var secret vaultSecret
+	if err := json.NewDecoder(response.Body).Decode(&secret); err != nil && response.StatusCode == http.StatusOK {
+		return nil, 0, fmt.Errorf("invalid vault response reading %s: %w", name, err)
+	}
+
+	value, _ := secret.Data.Data["value"].(string)
+	if value == "" {
+		return nil, secret.Data.Metadata.Version, nil
+	}
+	return []byte(value), secret.Data.Metadata.Version, nil
+}